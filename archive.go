@@ -0,0 +1,123 @@
+package bloom
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"io"
+	"time"
+)
+
+// The archive form of a filter consists of the magic number "BLMA", a version byte of value 1,
+// the length of the metadata as a big-endian uint32, the metadata as a JSON object,
+// and the filter in the binary form produced by MarshalBinary.
+// Besides the fields of Metadata, the JSON object records the filter's size and number of hash values,
+// so that the archive can be audited without decoding the filter.
+const (
+	archiveMagic   = "BLMA"
+	archiveVersion = 1
+
+	// maxMetadataSize bounds the length of the metadata to limit allocation when reading malformed data.
+	maxMetadataSize = 1 << 20
+)
+
+// Metadata describes the provenance of an archived filter.
+type Metadata struct {
+	// Created is the time at which the filter was built.
+	Created time.Time
+
+	// Source describes the data from which the filter was built.
+	Source string
+
+	// Items is the estimated number of distinct items inserted into the filter.
+	Items uint64
+
+	// FalsePositiveRate is the false positive rate for which the filter was sized.
+	FalsePositiveRate float64
+
+	// Hash is the filter's hash algorithm. WriteArchive records the algorithm of the filter it writes
+	// regardless of the value of Hash.
+	Hash Hash
+}
+
+// archiveMetadata is the JSON form of an archive's metadata.
+type archiveMetadata struct {
+	Created           time.Time `json:"created"`
+	Source            string    `json:"source"`
+	Items             uint64    `json:"items"`
+	FalsePositiveRate float64   `json:"fpr"`
+	Hash              string    `json:"hash"`
+	M                 int       `json:"m"`
+	K                 int       `json:"k"`
+}
+
+// WriteArchive writes f and md to w in an archive form that bundles the filter with its metadata,
+// so that data pipelines can audit where a persisted filter came from and whether it is stale.
+func (f *Filter) WriteArchive(w io.Writer, md Metadata) error {
+	js, err := json.Marshal(archiveMetadata{
+		Created:           md.Created,
+		Source:            md.Source,
+		Items:             md.Items,
+		FalsePositiveRate: md.FalsePositiveRate,
+		Hash:              f.hash.String(),
+		M:                 f.m,
+		K:                 f.k,
+	})
+	if err != nil {
+		return err
+	}
+	if len(js) > maxMetadataSize {
+		return errors.New("metadata too large")
+	}
+	b := append([]byte(archiveMagic), archiveVersion)
+	b = binary.BigEndian.AppendUint32(b, uint32(len(js)))
+	if _, err := w.Write(append(b, js...)); err != nil {
+		return err
+	}
+	_, err = f.WriteTo(w)
+	return err
+}
+
+// ReadArchive reads a filter and its metadata in the archive form written by WriteArchive from r,
+// stores the filter in f, and returns the metadata. It reads no further than the end of the filter's data.
+// It returns an error if the data is malformed or the metadata's size, number of hash values,
+// or hash algorithm does not match the filter's. If it returns an error, it does not modify the contents of f.
+func (f *Filter) ReadArchive(r io.Reader) (Metadata, error) {
+	p := make([]byte, len(archiveMagic)+5)
+	if _, err := io.ReadFull(r, p); err != nil {
+		return Metadata{}, eofError(err)
+	}
+	if string(p[:len(archiveMagic)]) != archiveMagic {
+		return Metadata{}, newError(ErrCorrupt, "missing magic number")
+	}
+	if p[len(archiveMagic)] != archiveVersion {
+		return Metadata{}, newError(ErrUnsupported, "unsupported format version")
+	}
+	n := binary.BigEndian.Uint32(p[len(archiveMagic)+1:])
+	if n > maxMetadataSize {
+		return Metadata{}, newError(ErrCorrupt, "metadata too large")
+	}
+	js := make([]byte, n)
+	if _, err := io.ReadFull(r, js); err != nil {
+		return Metadata{}, eofError(err)
+	}
+	var am archiveMetadata
+	if err := json.Unmarshal(js, &am); err != nil {
+		return Metadata{}, err
+	}
+	g := new(Filter)
+	if _, err := g.ReadFrom(r); err != nil {
+		return Metadata{}, err
+	}
+	if am.Hash != g.hash.String() || am.M != g.m || am.K != g.k {
+		return Metadata{}, newError(ErrCorrupt, "metadata does not match filter")
+	}
+	*f = *g
+	return Metadata{
+		Created:           am.Created,
+		Source:            am.Source,
+		Items:             am.Items,
+		FalsePositiveRate: am.FalsePositiveRate,
+		Hash:              g.hash,
+	}, nil
+}
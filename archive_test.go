@@ -0,0 +1,72 @@
+package bloom
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestArchive(t *testing.T) {
+	md := Metadata{
+		Created:           time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC),
+		Source:            "users table",
+		Items:             1000,
+		FalsePositiveRate: 0.01,
+	}
+	for _, f := range []*Filter{New(16, 3), NewWithHash(1000, 7, BitsAndBlooms)} {
+		f.Insert([]byte("a"))
+		var buf bytes.Buffer
+		if err := f.WriteArchive(&buf, md); err != nil {
+			t.Fatalf("TestArchive(%v): %v", f.hash, err)
+		}
+		want := `{"created":"2024-03-01T12:30:00Z","source":"users table","items":1000,"fpr":0.01,"hash":"` + f.hash.String() + `"`
+		if !bytes.Contains(buf.Bytes(), []byte(want)) {
+			t.Errorf("TestArchive(%v): got %q, want metadata %s", f.hash, buf.Bytes(), want)
+		}
+		buf.WriteString("trailing")
+
+		g := new(Filter)
+		got, err := g.ReadArchive(&buf)
+		if err != nil {
+			t.Errorf("TestArchive(%v): %v", f.hash, err)
+		}
+		md.Hash = f.hash
+		if !reflect.DeepEqual(got, md) {
+			t.Errorf("TestArchive(%v): got %+v, want %+v", f.hash, got, md)
+		}
+		if !reflect.DeepEqual(g, f) {
+			t.Errorf("TestArchive(%v): got %v, want %v", f.hash, g, f)
+		}
+		if buf.String() != "trailing" {
+			t.Errorf("TestArchive(%v): read past the end of the archive", f.hash)
+		}
+	}
+}
+
+func TestReadArchiveError(t *testing.T) {
+	var buf bytes.Buffer
+	New(1, 1).WriteArchive(&buf, Metadata{Source: "s"})
+	data := buf.Bytes()
+	metadataEnd := len(data) - len(binaryOf(1, 0))
+	for _, data := range [][]byte{
+		nil,
+		data[:8],                           // truncated header
+		data[:20],                          // truncated metadata
+		data[:len(data)-1],                 // truncated filter
+		withByte(data, 0, 'X'),             // bad magic number
+		withByte(data, 4, 2),               // unsupported version
+		withByte(data, 5, 0x10),            // metadata too large
+		withByte(data, 9, '['),             // malformed metadata
+		withByte(data, metadataEnd-2, '2'), // metadata does not match filter
+		withByte(data, len(data)-1, 0),     // corrupted filter
+	} {
+		f := New(1, 1)
+		if _, err := f.ReadArchive(bytes.NewReader(data)); err == nil {
+			t.Errorf("TestReadArchiveError(%q): got nil error", data)
+		}
+		if !reflect.DeepEqual(f, New(1, 1)) {
+			t.Errorf("TestReadArchiveError(%q): modified filter: %v", data, f)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package bloom
+
+// asyncBatchSize is the greatest number of items that an AsyncInserter applies at once.
+const asyncBatchSize = 256
+
+// An AsyncInserter inserts items into a Filter on a background goroutine,
+// so that callers of its Insert method need not wait for the items' bit positions to be derived and set.
+// Items are queued on a buffered channel and applied in batches of those that are waiting.
+// An AsyncInserter's methods may be called concurrently from multiple goroutines,
+// and the Filter may be queried concurrently with the insertions.
+type AsyncInserter struct {
+	f    *Filter
+	ops  chan asyncOp
+	done chan struct{}
+}
+
+// An asyncOp is an item to insert or, if flushed is not nil, a request to be notified when preceding items are applied.
+type asyncOp struct {
+	item    []byte
+	flushed chan struct{}
+}
+
+// NewAsyncInserter returns an AsyncInserter that inserts items into f and can queue up to buffer items
+// before Insert blocks. It starts a goroutine that runs until Close is called.
+// It panics if buffer is negative.
+func NewAsyncInserter(f *Filter, buffer int) *AsyncInserter {
+	if buffer < 0 {
+		panic("bloom: negative buffer size")
+	}
+	a := &AsyncInserter{f: f, ops: make(chan asyncOp, buffer), done: make(chan struct{})}
+	go a.run()
+	return a
+}
+
+// run applies queued items to the filter until the queue is closed.
+func (a *AsyncInserter) run() {
+	defer close(a.done)
+	batch := make([][]byte, 0, asyncBatchSize)
+	apply := func() {
+		for _, item := range batch {
+			a.f.Insert(item)
+		}
+		clear(batch)
+		batch = batch[:0]
+	}
+	for op := range a.ops {
+		// Gather the items that are waiting, up to the batch size.
+	gather:
+		for {
+			if op.flushed != nil {
+				apply()
+				close(op.flushed)
+			} else if batch = append(batch, op.item); len(batch) == asyncBatchSize {
+				apply()
+			}
+			select {
+			case next, ok := <-a.ops:
+				if !ok {
+					break gather
+				}
+				op = next
+			default:
+				break gather
+			}
+		}
+		apply()
+	}
+}
+
+// Insert queues item for insertion into the filter, blocking only if the queue is full.
+// The caller must not modify item until it has been inserted, as indicated by the return of Flush or Close.
+// Insert must not be called after Close.
+func (a *AsyncInserter) Insert(item []byte) {
+	a.ops <- asyncOp{item: item}
+}
+
+// Flush waits until every item queued by a call to Insert that returned before Flush was called has been inserted.
+func (a *AsyncInserter) Flush() {
+	flushed := make(chan struct{})
+	a.ops <- asyncOp{flushed: flushed}
+	<-flushed
+}
+
+// Close inserts every queued item and stops the background goroutine.
+// It must not be called more than once, or concurrently with Insert or Flush.
+func (a *AsyncInserter) Close() {
+	close(a.ops)
+	<-a.done
+}
@@ -0,0 +1,49 @@
+package bloom
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestAsyncInserter(t *testing.T) {
+	for _, buffer := range []int{0, 1, 1000} {
+		f := NewWithHash(1<<14, 5, BitsAndBlooms)
+		a := NewAsyncInserter(f, buffer)
+		var wg sync.WaitGroup
+		for g := range 4 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := range 500 {
+					a.Insert([]byte(fmt.Sprint(g, i)))
+				}
+				a.Flush()
+				for i := range 500 {
+					if !f.MaybeContains([]byte(fmt.Sprint(g, i))) {
+						t.Errorf("TestAsyncInserter(%v): item %v, %v not found after Flush", buffer, g, i)
+					}
+				}
+			}()
+		}
+		wg.Wait()
+
+		a.Insert([]byte("last"))
+		a.Close()
+		if !f.MaybeContains([]byte("last")) {
+			t.Errorf("TestAsyncInserter(%v): item not found after Close", buffer)
+		}
+
+		want := NewWithHash(1<<14, 5, BitsAndBlooms)
+		for g := range 4 {
+			for i := range 500 {
+				want.Insert([]byte(fmt.Sprint(g, i)))
+			}
+		}
+		want.Insert([]byte("last"))
+		if !reflect.DeepEqual(f, want) {
+			t.Errorf("TestAsyncInserter(%v): got %v, want %v", buffer, f, want)
+		}
+	}
+}
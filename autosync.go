@@ -0,0 +1,70 @@
+package bloom
+
+import (
+	"math/rand/v2"
+	"time"
+)
+
+// WithAutoSync enables automatic synchronization: a background goroutine calls Sync every interval,
+// varied at random by up to a tenth so that many filters opened together do not write at once,
+// and after every inserts insertions. Either trigger is disabled if its value is 0.
+// If onError is not nil, it is called with each error returned by an automatic Sync, from the background goroutine.
+// Close stops the goroutine. WithAutoSync panics if interval or inserts is negative.
+func WithAutoSync(interval time.Duration, inserts int, onError func(error)) FileOption {
+	if interval < 0 || inserts < 0 {
+		panic("bloom: negative automatic synchronization interval or number of insertions")
+	}
+	return func(o *fileOptions) {
+		o.syncInterval = interval
+		o.syncInserts = inserts
+		o.onSyncError = onError
+	}
+}
+
+// startAutoSync starts the automatic Sync goroutine if the options enable it.
+func (s *FileFilter) startAutoSync(o *fileOptions) {
+	if o.syncInterval == 0 && o.syncInserts == 0 {
+		return
+	}
+	if o.syncInserts > 0 {
+		s.syncInserts = int64(o.syncInserts)
+		s.kick = make(chan struct{}, 1)
+	}
+	s.stop = make(chan struct{})
+	s.done = make(chan struct{})
+	go s.autoSync(o.syncInterval, o.onSyncError)
+}
+
+// autoSync calls Sync every interval, if it is positive, and when signaled by Insert, until s.stop is closed.
+func (s *FileFilter) autoSync(interval time.Duration, onError func(error)) {
+	defer close(s.done)
+	var (
+		t    *time.Timer
+		tick <-chan time.Time
+	)
+	if interval > 0 {
+		t = time.NewTimer(jitter(interval))
+		defer t.Stop()
+		tick = t.C
+	}
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-tick:
+		case <-s.kick:
+		}
+		s.inserts.Store(0)
+		if err := s.Sync(); err != nil && onError != nil {
+			onError(err)
+		}
+		if t != nil {
+			t.Reset(jitter(interval))
+		}
+	}
+}
+
+// jitter returns d varied at random by up to a tenth.
+func jitter(d time.Duration) time.Duration {
+	return d - d/10 + rand.N(d/5+1)
+}
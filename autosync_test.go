@@ -0,0 +1,81 @@
+package bloom
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// waitForFile waits until the filter stored in the file at path contains item, or fails after a timeout.
+func waitForFile(t *testing.T, path string, item []byte) {
+	for deadline := time.Now().Add(10 * time.Second); !fileContents(t, path).MaybeContains(item); {
+		if time.Now().After(deadline) {
+			t.Fatalf("waitForFile(%v, %q): timed out", path, item)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestAutoSync(t *testing.T) {
+	for _, test := range []struct {
+		interval time.Duration
+		inserts  int
+	}{
+		{time.Millisecond, 0},
+		{0, 10},
+		{time.Hour, 10},
+	} {
+		path := filepath.Join(t.TempDir(), "filter")
+		s, err := CreateFile(path, NewWithHash(1<<12, 4, BitsAndBlooms), WithAutoSync(test.interval, test.inserts, nil))
+		if err != nil {
+			t.Fatalf("TestAutoSync(%v, %v): %v", test.interval, test.inserts, err)
+		}
+		for i := range 10 {
+			s.Insert([]byte(fmt.Sprint(i)))
+		}
+		waitForFile(t, path, []byte("9"))
+		if err := s.Close(); err != nil {
+			t.Errorf("TestAutoSync(%v, %v): %v", test.interval, test.inserts, err)
+		}
+	}
+}
+
+func TestAutoSyncError(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "filter")
+	errs := make(chan error, 1)
+	s, err := CreateFile(path, NewWithHash(1<<12, 4, BitsAndBlooms), WithAutoSync(0, 1, func(err error) {
+		select {
+		case errs <- err:
+		default:
+		}
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.file.Close()
+	s.Insert([]byte("x"))
+	if err := <-errs; err == nil {
+		t.Errorf("TestAutoSyncError: got nil error")
+	}
+	if err := s.Close(); err == nil {
+		t.Errorf("TestAutoSyncError: Close: got nil error")
+	}
+
+	for _, test := range []struct {
+		interval time.Duration
+		inserts  int
+	}{
+		{-1, 0},
+		{0, -1},
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("TestAutoSyncError(%v, %v): did not panic", test.interval, test.inserts)
+				}
+			}()
+			WithAutoSync(test.interval, test.inserts, nil)
+		}()
+	}
+}
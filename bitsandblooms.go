@@ -0,0 +1,145 @@
+package bloom
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+)
+
+// The binary form of a filter written by the WriteTo method of github.com/bits-and-blooms/bloom's BloomFilter
+// consists of the following fields, with integers in big-endian byte order:
+//
+//	m      uint64    size of the filter in bits
+//	k      uint64    number of hash values
+//	length uint64    size of the filter's bitset in bits, equal to m
+//	words  [(length+63)/64]uint64
+//
+// Bit n of the filter is bit n%64 of word n/64, counting from the least significant bit.
+// The package's JSON form is an object with members "m" and "k"
+// and a member "b" whose value is the base64 URL encoding of the bitset's length and words.
+
+// WriteBitsAndBlooms writes f to w in the binary form used by github.com/bits-and-blooms/bloom,
+// and returns the number of bytes written and any error encountered.
+// It returns an error if f does not use the BitsAndBlooms hash algorithm.
+func (f *Filter) WriteBitsAndBlooms(w io.Writer) (int64, error) {
+	if f.hash != BitsAndBlooms {
+		return 0, newError(ErrUnsupported, "filter does not use the BitsAndBlooms hash algorithm")
+	}
+	b := make([]byte, 0, 24+(f.m+63)/64*8)
+	b = binary.BigEndian.AppendUint64(b, uint64(f.m))
+	b = binary.BigEndian.AppendUint64(b, uint64(f.k))
+	b = binary.BigEndian.AppendUint64(b, uint64(f.m))
+	n, err := w.Write(appendWords(b, f.bytes()))
+	return int64(n), err
+}
+
+// ReadBitsAndBlooms reads a filter in the binary form used by github.com/bits-and-blooms/bloom from r
+// and stores it in f as a filter that uses the BitsAndBlooms hash algorithm.
+// It reads no further than the end of the filter's data, and returns the number of bytes read and any error encountered.
+// If it returns an error, it does not modify the contents of f.
+func (f *Filter) ReadBitsAndBlooms(r io.Reader) (int64, error) {
+	cr := &countReader{r: r}
+	p := make([]byte, 24)
+	if _, err := io.ReadFull(cr, p); err != nil {
+		return cr.n, eofError(err)
+	}
+	h := header{hashAlgorithm: byte(BitsAndBlooms), m: binary.BigEndian.Uint64(p)}
+	k, length := binary.BigEndian.Uint64(p[8:]), binary.BigEndian.Uint64(p[16:])
+	if err := checkParams(BitsAndBlooms, h.m, k); err != nil {
+		return cr.n, err
+	}
+	h.k = byte(k)
+	if length != h.m {
+		return cr.n, newError(ErrCorrupt, "bitset length does not match filter size")
+	}
+	b, err := readWords(cr, h.m)
+	if err != nil {
+		return cr.n, err
+	}
+	f.load(h, b)
+	return cr.n, nil
+}
+
+// appendWords appends to dst the filter bits b as a sequence of big-endian 64-bit words,
+// in which bit n of the filter is bit n%64 of word n/64. The final word is padded with zeros.
+func appendWords(dst, b []byte) []byte {
+	var word [8]byte
+	for i := 0; i < len(b); i += 8 {
+		clear(word[:])
+		copy(word[:], b[i:])
+		dst = binary.BigEndian.AppendUint64(dst, binary.LittleEndian.Uint64(word[:]))
+	}
+	return dst
+}
+
+// readWords reads from r the bits of a filter of m bits as a sequence of big-endian 64-bit words
+// in the form written by appendWords.
+func readWords(r io.Reader, m uint64) ([]byte, error) {
+	b, err := readDense(r, nil, (m+63)/64*8)
+	if err != nil {
+		return nil, eofError(err)
+	}
+	for i := 0; i < len(b); i += 8 {
+		binary.LittleEndian.PutUint64(b[i:], binary.BigEndian.Uint64(b[i:]))
+	}
+	for _, c := range b[(m+7)/8:] {
+		if c != 0 {
+			return nil, newError(ErrCorrupt, "bits set beyond filter size")
+		}
+	}
+	b = b[:(m+7)/8]
+	if err := checkPadding(b, m); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// bitsAndBloomsJSON is the JSON form of a filter used by github.com/bits-and-blooms/bloom.
+type bitsAndBloomsJSON struct {
+	M uint64 `json:"m"`
+	K uint64 `json:"k"`
+	B string `json:"b"`
+}
+
+// MarshalBitsAndBloomsJSON marshals f into the JSON form used by github.com/bits-and-blooms/bloom.
+// It returns an error if f does not use the BitsAndBlooms hash algorithm.
+func (f *Filter) MarshalBitsAndBloomsJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	if _, err := f.WriteBitsAndBlooms(&buf); err != nil {
+		return nil, err
+	}
+	return json.Marshal(bitsAndBloomsJSON{
+		M: uint64(f.m),
+		K: uint64(f.k),
+		B: base64.URLEncoding.EncodeToString(buf.Bytes()[16:]),
+	})
+}
+
+// UnmarshalBitsAndBloomsJSON unmarshals a filter in the JSON form used by github.com/bits-and-blooms/bloom
+// and stores it in f as a filter that uses the BitsAndBlooms hash algorithm.
+// If it returns an error, it does not modify the contents of f.
+func (f *Filter) UnmarshalBitsAndBloomsJSON(data []byte) error {
+	var j bitsAndBloomsJSON
+	if err := json.Unmarshal(data, &j); err != nil {
+		return err
+	}
+	bitset, err := base64.URLEncoding.DecodeString(j.B)
+	if err != nil {
+		return err
+	}
+	p := make([]byte, 0, 16+len(bitset))
+	p = binary.BigEndian.AppendUint64(p, j.M)
+	p = binary.BigEndian.AppendUint64(p, j.K)
+	r := bytes.NewReader(append(p, bitset...))
+	var g Filter
+	if _, err := g.ReadBitsAndBlooms(r); err != nil {
+		return err
+	}
+	if r.Len() != 0 {
+		return newError(ErrCorrupt, "bitset length does not match data length")
+	}
+	*f = g
+	return nil
+}
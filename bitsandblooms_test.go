@@ -0,0 +1,121 @@
+package bloom
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestBitsAndBloomsLocations(t *testing.T) {
+	// Locations computed by github.com/bits-and-blooms/bloom for a filter of 1000 bits using 6 hash values
+	for _, test := range []struct {
+		s    string
+		locs []int
+	}{
+		{"", []int{0x0, 0x1ed, 0x3da, 0x3ca, 0x340, 0xe9}},
+		{"abc", []int{0x23f, 0x306, 0x347, 0x70, 0x217, 0x396}},
+		{"0123456789abcde", []int{0x379, 0x36d, 0x8b, 0xd1, 0x25, 0x179}},
+	} {
+		f := NewWithHash(1000, 6, BitsAndBlooms)
+		d := f.hash.digest([]byte(test.s))
+		for i, want := range test.locs {
+			if got := f.location(&d, i); got != want {
+				t.Errorf("TestBitsAndBloomsLocations(%q, %v): got %v, want %v", test.s, i, got, want)
+			}
+		}
+	}
+}
+
+// bitsAndBloomsData is the binary form of a filter of 100 bits using 3 hash values into which
+// github.com/bits-and-blooms/bloom has inserted "a" and "b", and bitsAndBloomsJSON is its JSON form.
+var (
+	bitsAndBloomsData = []byte{
+		0, 0, 0, 0, 0, 0, 0, 0x64, 0, 0, 0, 0, 0, 0, 0, 0x3, 0, 0, 0, 0, 0, 0, 0, 0x64,
+		0, 0, 0x4, 0, 0, 0x2, 0, 0x22, 0, 0, 0, 0, 0x4, 0, 0, 0x40,
+	}
+	bitsAndBloomsJSONData = []byte(`{"m":100,"k":3,"b":"AAAAAAAAAGQAAAQAAAIAIgAAAAAEAABA"}`)
+)
+
+func TestBitsAndBlooms(t *testing.T) {
+	f := NewWithHash(100, 3, BitsAndBlooms)
+	f.Insert([]byte("a"))
+	f.Insert([]byte("b"))
+
+	var buf bytes.Buffer
+	if _, err := f.WriteBitsAndBlooms(&buf); err != nil {
+		t.Errorf("TestBitsAndBlooms: %v", err)
+	}
+	if !bytes.Equal(buf.Bytes(), bitsAndBloomsData) {
+		t.Errorf("TestBitsAndBlooms: got %v, want %v", buf.Bytes(), bitsAndBloomsData)
+	}
+	g := new(Filter)
+	if n, err := g.ReadBitsAndBlooms(bytes.NewReader(bitsAndBloomsData)); err != nil || n != int64(len(bitsAndBloomsData)) {
+		t.Errorf("TestBitsAndBlooms: read %v bytes, %v", n, err)
+	}
+	if !reflect.DeepEqual(g, f) {
+		t.Errorf("TestBitsAndBlooms: got %v, want %v", g, f)
+	}
+
+	data, err := f.MarshalBitsAndBloomsJSON()
+	if err != nil {
+		t.Errorf("TestBitsAndBlooms: %v", err)
+	}
+	if !bytes.Equal(data, bitsAndBloomsJSONData) {
+		t.Errorf("TestBitsAndBlooms: got %s, want %s", data, bitsAndBloomsJSONData)
+	}
+	g = new(Filter)
+	if err := g.UnmarshalBitsAndBloomsJSON(bitsAndBloomsJSONData); err != nil {
+		t.Errorf("TestBitsAndBlooms: %v", err)
+	}
+	if !reflect.DeepEqual(g, f) {
+		t.Errorf("TestBitsAndBlooms: got %v, want %v", g, f)
+	}
+
+	// The native binary form records the hash algorithm.
+	data, _ = f.MarshalBinary()
+	g = new(Filter)
+	if err := g.UnmarshalBinary(data); err != nil {
+		t.Errorf("TestBitsAndBlooms: %v", err)
+	}
+	if !reflect.DeepEqual(g, f) {
+		t.Errorf("TestBitsAndBlooms: got %v, want %v", g, f)
+	}
+}
+
+func TestBitsAndBloomsErrors(t *testing.T) {
+	if _, err := New(1, 1).WriteBitsAndBlooms(new(bytes.Buffer)); err == nil {
+		t.Errorf("TestBitsAndBloomsErrors: wrote filter that uses SHA256")
+	}
+	for _, data := range [][]byte{
+		nil,
+		bitsAndBloomsData[:20],
+		bitsAndBloomsData[:len(bitsAndBloomsData)-1],
+		withByte(bitsAndBloomsData, 7, 0),   // no bits
+		withByte(bitsAndBloomsData, 15, 0),  // no hash values
+		withByte(bitsAndBloomsData, 14, 1),  // too many hash values
+		withByte(bitsAndBloomsData, 23, 99), // bitset length does not match
+		withByte(bitsAndBloomsData, 32, 16), // bit set beyond filter size
+	} {
+		f := New(1, 1)
+		if _, err := f.ReadBitsAndBlooms(bytes.NewReader(data)); err == nil {
+			t.Errorf("TestBitsAndBloomsErrors(%v): got nil error", data)
+		}
+		if !reflect.DeepEqual(f, New(1, 1)) {
+			t.Errorf("TestBitsAndBloomsErrors(%v): modified filter to %v", data, f)
+		}
+	}
+	for _, data := range []string{
+		``,
+		`{"m":100,"k":3,"b":"AAAAAAAAAGQAAAQAAAIAIgAAAAAEAAB"}`,
+		`{"m":100,"k":3,"b":"AAAAAAAAAGQAAAQAAAIAIgAAAAAEAABAAAAAAAAAAAA="}`,
+		`{"m":101,"k":3,"b":"AAAAAAAAAGQAAAQAAAIAIgAAAAAEAABA"}`,
+	} {
+		f := New(1, 1)
+		if err := f.UnmarshalBitsAndBloomsJSON([]byte(data)); err == nil {
+			t.Errorf("TestBitsAndBloomsErrors(%s): got nil error", data)
+		}
+		if !reflect.DeepEqual(f, New(1, 1)) {
+			t.Errorf("TestBitsAndBloomsErrors(%s): modified filter to %v", data, f)
+		}
+	}
+}
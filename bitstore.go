@@ -0,0 +1,104 @@
+package bloom
+
+import (
+	"sync/atomic"
+)
+
+// A Bitstore holds the bits of a StoreFilter, so that the filter can be kept in memory other than a Filter's,
+// such as a memory-mapped region, a shared memory segment, or a remote or page-cached store.
+// Bit n is bit n%64 of word n/64, counting from the least significant bit.
+// A Bitstore must be safe for concurrent use if its StoreFilter is used concurrently.
+type Bitstore interface {
+	// Get reports whether bit n is set.
+	Get(n uint64) bool
+
+	// Set sets bit n.
+	Set(n uint64)
+
+	// OrWord sets the bits of word i that are set in w.
+	OrWord(i uint64, w uint64)
+
+	// Len returns the number of bits in the store.
+	Len() uint64
+}
+
+// A SliceStore is a Bitstore held in a slice of words, which it reads and writes with atomic operations.
+type SliceStore struct {
+	w []uint64
+	m uint64 // the number of bits, which the words may exceed by up to 63
+}
+
+// NewSliceStore returns a SliceStore of m bits.
+func NewSliceStore(m uint64) SliceStore {
+	return SliceStore{w: make([]uint64, (m+63)/64), m: m}
+}
+
+// Words returns the words that hold s's bits. The bits of the last word beyond s's length are never set.
+func (s SliceStore) Words() []uint64 { return s.w }
+
+func (s SliceStore) Get(n uint64) bool { return atomic.LoadUint64(&s.w[n/64])>>(n%64)&1 != 0 }
+func (s SliceStore) Set(n uint64)      { atomic.OrUint64(&s.w[n/64], 1<<(n%64)) }
+func (s SliceStore) Len() uint64       { return s.m }
+
+func (s SliceStore) OrWord(i uint64, w uint64) {
+	if i == s.m/64 {
+		w &= 1<<(s.m%64) - 1 // the bits beyond s's length
+	}
+	atomic.OrUint64(&s.w[i], w)
+}
+
+// A StoreFilter is a Bloom filter whose bits are held in a Bitstore.
+// It derives bit positions in the same manner as a Filter of the same size, number of hash values, and hash algorithm.
+type StoreFilter struct {
+	s      Bitstore
+	params Filter // the size, number of hash values, and hash algorithm, without bits
+}
+
+// NewStoreFilter returns a StoreFilter whose bits are held in s, using k hash values derived by the hash algorithm h.
+// The filter's size is s.Len() bits. It panics if h does not support a filter of that size or k hash values.
+func NewStoreFilter(s Bitstore, k int, h Hash) *StoreFilter {
+	m := s.Len()
+	if k < 0 {
+		panic("bloom: negative number of hash values")
+	}
+	if err := checkParams(h, m, uint64(k)); err != nil {
+		panic("bloom: " + err.Error())
+	}
+	return &StoreFilter{s: s, params: Filter{k: k, m: int(m), hash: h}}
+}
+
+// Insert inserts item into f's set.
+func (f *StoreFilter) Insert(item []byte) {
+	d := f.params.hash.digest(item)
+	for i := 0; i < f.params.k; i++ {
+		f.s.Set(uint64(f.params.location(&d, i)))
+	}
+}
+
+// MaybeContains reports whether item is probably in f's set, in the manner of Filter.MaybeContains.
+func (f *StoreFilter) MaybeContains(item []byte) bool {
+	d := f.params.hash.digest(item)
+	for i := 0; i < f.params.k; i++ {
+		if !f.s.Get(uint64(f.params.location(&d, i))) {
+			return false
+		}
+	}
+	return true
+}
+
+// Union inserts into f every item in g's set, a word at a time.
+// It returns an error without modifying f if g differs from f in size, number of hash values, or hash algorithm.
+func (f *StoreFilter) Union(g *Filter) error {
+	if !f.params.compatible(g) {
+		return newError(ErrIncompatible, "incompatible filters")
+	}
+	for i := range g.pages {
+		p := g.page(i)
+		for j := range p.w {
+			if v := atomic.LoadUint64(&p.w[j]); v != 0 {
+				f.s.OrWord(uint64(i*pageWords+j), v)
+			}
+		}
+	}
+	return nil
+}
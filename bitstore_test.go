@@ -0,0 +1,114 @@
+package bloom
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestStoreFilter(t *testing.T) {
+	for h := range Hash(len(hashNames)) {
+		k := 4
+		if h == ParquetSBBF {
+			k = sbbfWords
+		}
+		f := NewWithHash(1<<12, k, h)
+		s := NewSliceStore(1 << 12)
+		sf := NewStoreFilter(s, k, h)
+		for i := 0; i < 200; i += 2 {
+			item := []byte(fmt.Sprint(i))
+			f.Insert(item)
+			sf.Insert(item)
+		}
+		if got, want := newFilterFrom(s, f), f; !equal(got, want) {
+			t.Errorf("TestStoreFilter(%v): got %v, want %v", h, got, want)
+		}
+		for i := range 200 {
+			item := []byte(fmt.Sprint(i))
+			if got, want := sf.MaybeContains(item), f.MaybeContains(item); got != want {
+				t.Errorf("TestStoreFilter(%v, %v): got %v, want %v", h, i, got, want)
+			}
+		}
+
+		g := NewWithHash(1<<12, k, h)
+		g.Insert([]byte("union"))
+		if err := sf.Union(g); err != nil {
+			t.Errorf("TestStoreFilter(%v): %v", h, err)
+		}
+		if !sf.MaybeContains([]byte("union")) {
+			t.Errorf("TestStoreFilter(%v): union does not contain item", h)
+		}
+		if err := sf.Union(NewWithHash(1<<13, k, h)); err == nil {
+			t.Errorf("TestStoreFilter(%v): union with incompatible filter: got nil error", h)
+		}
+	}
+}
+
+// newFilterFrom returns a Filter with the parameters of f and the bits of s.
+func newFilterFrom(s SliceStore, f *Filter) *Filter {
+	return &Filter{pages: newPages(s.Words()), k: f.k, m: f.m, hash: f.hash}
+}
+
+func TestSliceStoreLen(t *testing.T) {
+	s := NewSliceStore(1000)
+	if got := s.Len(); got != 1000 {
+		t.Errorf("TestSliceStoreLen: got %v, want 1000", got)
+	}
+	f := NewWithHash(1000, 4, BitsAndBlooms)
+	f.Insert([]byte("x"))
+	sf := NewStoreFilter(s, 4, BitsAndBlooms)
+	if err := sf.Union(f); err != nil {
+		t.Errorf("TestSliceStoreLen: %v", err)
+	}
+	if !sf.MaybeContains([]byte("x")) {
+		t.Errorf("TestSliceStoreLen: union does not contain item")
+	}
+	s.OrWord(15, ^uint64(0))
+	if got, want := s.Words()[15], uint64(1)<<(1000%64)-1; got != want {
+		t.Errorf("TestSliceStoreLen: got last word %#x, want %#x", got, want)
+	}
+}
+
+func TestNewStoreFilterPanics(t *testing.T) {
+	for _, test := range []struct {
+		m uint64
+		k int
+		h Hash
+	}{
+		{1 << 17, 3, SHA256},
+		{1 << 12, -1, BitsAndBlooms},
+		{1 << 12, 3, ParquetSBBF},
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("TestNewStoreFilterPanics(%v, %v, %v): did not panic", test.m, test.k, test.h)
+				}
+			}()
+			NewStoreFilter(NewSliceStore(test.m), test.k, test.h)
+		}()
+	}
+}
+
+func TestLocations(t *testing.T) {
+	for h := range Hash(len(hashNames)) {
+		k := 4
+		if h == ParquetSBBF {
+			k = sbbfWords
+		}
+		f := NewWithHash(1<<12, k, h)
+		f.Insert([]byte("x"))
+		g := NewWithHash(1<<12, k, h)
+		for _, n := range h.Locations([]byte("x"), 1<<12, k) {
+			g.setBit(n)
+		}
+		if !equal(g, f) {
+			t.Errorf("TestLocations(%v): got %v, want %v", h, g, f)
+		}
+	}
+	defer func() {
+		if recover() == nil {
+			t.Errorf("TestLocations: did not panic")
+		}
+	}()
+	SHA256.Locations(nil, 100, 4)
+}
@@ -2,10 +2,16 @@
 package bloom
 
 import (
-	"crypto/sha256"
+	"bytes"
 	"encoding/binary"
-	"errors"
+	"hash/crc32"
+	"io"
+	"iter"
 	"math/bits"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"unsafe"
 )
 
 const (
@@ -16,26 +22,135 @@ const (
 )
 
 // Filter is a Bloom filter, which represents a set of items and provides a probabilistic test for membership.
-// Filter satisfies the encoding.BinaryMarshaler and BinaryUnmarshaler interfaces.
-// The zero value represents an empty filter of size 0 that uses 0 hash values.
+// Filter satisfies the encoding.BinaryMarshaler and BinaryUnmarshaler interfaces
+// as well as the gob.GobEncoder and GobDecoder interfaces.
+// The zero value represents an empty filter of size 0 that uses 0 hash values: it reports that it contains no item,
+// and panics if an item is inserted into it, so that a Filter embedded in a struct but never created
+// by New, NewWithHash, or UnmarshalBinary is detected at the first insertion rather than silently discarding items.
+//
+// Insert, MaybeContains, Union, Intersect, ApplyDelta, and the methods that marshal or export a Filter
+// may be called concurrently from multiple goroutines: bits are set with atomic OR operations and read with atomic loads.
+// Methods that replace a Filter's contents, such as UnmarshalBinary, must not be called concurrently with any other method,
+// and Snapshot must not be called concurrently with methods that modify the Filter.
 type Filter struct {
-	f []byte
-	k int
+	pages []*page // bit n is bit n%64 of word n/64, which is word n/64%pageWords of page n/64/pageWords
+	k     int
+	m     int // size in bits
+	hash  Hash
+}
+
+// pageWords is the number of words in each page of a Filter's bits, the unit in which Snapshot shares them.
+const pageWords = 512
+
+// A page holds a contiguous range of a Filter's words.
+// A shared page may be referenced by more than one Filter, and is copied before it is modified.
+type page struct {
+	w      []uint64
+	shared atomic.Bool
+}
+
+// newPages returns pages holding the words w, which they alias.
+func newPages(w []uint64) []*page {
+	p := make([]*page, (len(w)+pageWords-1)/pageWords)
+	ps := make([]page, len(p))
+	for i := range p {
+		ps[i].w = w[i*pageWords : min((i+1)*pageWords, len(w))]
+		p[i] = &ps[i]
+	}
+	return p
+}
+
+// smallWords is the greatest number of words of a Filter that newFilter allocates together with the Filter.
+const smallWords = 8
+
+// A smallFilter holds a Filter of at most smallWords words together with its storage,
+// so that the many small filters of some applications each occupy a single allocation.
+type smallFilter struct {
+	f     Filter
+	pages [1]*page
+	p     page
+	w     [smallWords]uint64
+}
+
+// newFilter returns an empty Filter of size m bits that uses k hash values derived by h,
+// without validating its parameters.
+func newFilter(m, k int, h Hash) *Filter {
+	n := (m + 63) / 64
+	if n > smallWords {
+		return &Filter{pages: newPages(make([]uint64, n)), k: k, m: m, hash: h}
+	}
+	s := new(smallFilter)
+	s.p.w = s.w[:n:n]
+	s.pages[0] = &s.p
+	s.f = Filter{pages: s.pages[:min(n, 1)], k: k, m: m, hash: h}
+	return &s.f
+}
+
+// page returns the filter's ith page.
+func (f *Filter) page(i int) *page {
+	return (*page)(atomic.LoadPointer((*unsafe.Pointer)(unsafe.Pointer(&f.pages[i]))))
+}
+
+// writablePage returns the filter's ith page, first replacing it with a copy if it is shared.
+func (f *Filter) writablePage(i int) *page {
+	ptr := (*unsafe.Pointer)(unsafe.Pointer(&f.pages[i]))
+	for {
+		p := (*page)(atomic.LoadPointer(ptr))
+		if !p.shared.Load() {
+			return p
+		}
+		q := &page{w: make([]uint64, len(p.w))}
+		copy(q.w, p.w) // a shared page is never modified
+		if atomic.CompareAndSwapPointer(ptr, unsafe.Pointer(p), unsafe.Pointer(q)) {
+			return q
+		}
+	}
 }
 
 // bit returns the filter's nth bit.
 func (f *Filter) bit(n int) int {
-	b, i := n/8, n%8
-	return int(f.f[b]) >> uint(i) & 1
+	w, i := n/64, n%64
+	p := f.page(w / pageWords)
+	return int(atomic.LoadUint64(&p.w[w%pageWords]) >> uint(i) & 1)
 }
 
 // setBit sets the filter's nth bit to 1.
+// If the bit is already set, it does not write to memory, so that repeated insertions
+// neither dirty cache lines shared with other goroutines nor copy shared pages.
 func (f *Filter) setBit(n int) {
-	b, i := n/8, n%8
-	f.f[b] |= 1 << uint(i)
+	w, b := n/64, uint64(1)<<uint(n%64)
+	p := f.page(w / pageWords)
+	if atomic.LoadUint64(&p.w[w%pageWords])&b != 0 {
+		return
+	}
+	p = f.writablePage(w / pageWords)
+	atomic.OrUint64(&p.w[w%pageWords], b)
+}
+
+// bytes returns a copy of the filter's bits in the order of the binary form: bit n is bit n%8 of byte n/8.
+func (f *Filter) bytes() []byte {
+	b := make([]byte, 0, (f.m+63)/64*8)
+	for i := range f.pages {
+		p := f.page(i)
+		for j := range p.w {
+			b = binary.LittleEndian.AppendUint64(b, atomic.LoadUint64(&p.w[j]))
+		}
+	}
+	return b[:(f.m+7)/8]
 }
 
-// New returns a Filter of size b bytes that uses k hash values.
+// words returns the bits of b, in the order of the binary form, as the words of a Filter.
+func words(b []byte) []uint64 {
+	w := make([]uint64, (len(b)+7)/8)
+	for i := range w {
+		var buf [8]byte
+		copy(buf[:], b[8*i:])
+		w[i] = binary.LittleEndian.Uint64(buf[:])
+	}
+	return w
+}
+
+// New returns a Filter of size b bytes that uses k hash values derived by the SHA256 hash algorithm.
 // It panics if b is not a power of 2 in the range [1, 8192] or k is not in the range [1, 16].
 func New(b, k int) *Filter {
 	if b <= 0 || b > maxFilterSize {
@@ -47,76 +162,852 @@ func New(b, k int) *Filter {
 	if k <= 0 || k > maxHashValues {
 		panic("bloom: number of hash values out of range")
 	}
-	return &Filter{make([]byte, b), k}
+	return newFilter(b*8, k, SHA256)
+}
+
+// NewWithHash returns a Filter of size m bits that uses k hash values derived by the hash algorithm h.
+// It panics if h does not support a filter of size m bits or k hash values.
+func NewWithHash(m, k int, h Hash) *Filter {
+	if m < 0 || k < 0 {
+		panic("bloom: negative filter size or number of hash values")
+	}
+	if err := checkParams(h, uint64(m), uint64(k)); err != nil {
+		panic("bloom: " + err.Error())
+	}
+	return newFilter(m, k, h)
 }
 
 // Insert inserts item into f's set.
 func (f *Filter) Insert(item []byte) {
-	h := hashBits(item)
+	d := f.hash.digest(item)
+	f.insertDigest(&d)
+}
+
+// insertDigest sets the bits of an item with digest d. It panics if f is a zero Filter.
+func (f *Filter) insertDigest(d *digest) {
+	f.checkInsert()
+	for i := 0; i < f.k; i++ {
+		f.setBit(f.location(d, i))
+	}
+}
+
+// checkInsert panics if f is a zero Filter, which has no bits in which to insert an item.
+func (f *Filter) checkInsert() {
+	if f.k == 0 {
+		panic("bloom: insertion into zero Filter")
+	}
+}
+
+// testAndInsert sets the bits of an item with digest d and reports whether they were all set beforehand.
+// It panics if f is a zero Filter.
+func (f *Filter) testAndInsert(d *digest) bool {
+	return f.insertNovel(d) == 0
+}
+
+// insertNovel sets the bits of an item with digest d and returns the number of them that were not set beforehand.
+// It panics if f is a zero Filter.
+func (f *Filter) insertNovel(d *digest) int {
+	f.checkInsert()
+	var novel int
 	for i := 0; i < f.k; i++ {
-		in := h[i] & (len(f.f)*8 - 1)
-		f.setBit(in)
+		n := f.location(d, i)
+		w, b := n/64, uint64(1)<<uint(n%64)
+		p := f.writablePage(w / pageWords)
+		if atomic.OrUint64(&p.w[w%pageWords], b)&b == 0 {
+			novel++
+		}
 	}
+	return novel
 }
 
 // MaybeContains reports whether item is probably in f's set.
 // If MaybeContains returns true, a false positive is possible,
 // but if MaybeContains returns false, item is definitely not in the set.
+// MaybeContains does not allocate memory.
 func (f *Filter) MaybeContains(item []byte) bool {
-	h := hashBits(item)
+	if f.k == 0 {
+		return false
+	}
+	d := f.hash.digest(item)
 	for i := 0; i < f.k; i++ {
-		in := h[i] & (len(f.f)*8 - 1)
-		if f.bit(in) == 0 {
+		if f.bit(f.location(&d, i)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Positions returns the positions of the bits that f sets for item, in the order in which they are derived,
+// as Hash.Locations does for f's hash algorithm and parameters. MaybeContains reports that item is probably
+// in f's set exactly when all of these bits are set, so Positions can explain a false positive
+// or check another implementation of f's hash algorithm. A zero Filter has no positions for any item.
+func (f *Filter) Positions(item []byte) []int {
+	d := f.hash.digest(item)
+	pos := make([]int, f.k)
+	for i := range pos {
+		pos[i] = f.location(&d, i)
+	}
+	return pos
+}
+
+// InsertString inserts the bytes of s into f's set, in the manner of Insert but without copying s.
+func (f *Filter) InsertString(s string) {
+	f.Insert(unsafe.Slice(unsafe.StringData(s), len(s)))
+}
+
+// MaybeContainsString reports whether the bytes of s are probably in f's set,
+// in the manner of MaybeContains but without copying s.
+func (f *Filter) MaybeContainsString(s string) bool {
+	return f.MaybeContains(unsafe.Slice(unsafe.StringData(s), len(s)))
+}
+
+// InsertUint64 inserts v into f's set as the item of its 8-byte little-endian encoding, without allocating memory.
+// This is the item that EncodeInt returns for v, and that Guava's Funnels.longFunnel() writes.
+func (f *Filter) InsertUint64(v uint64) {
+	var b [8]byte
+	binary.LittleEndian.PutUint64(b[:], v)
+	f.Insert(b[:])
+}
+
+// MaybeContainsUint64 reports whether v is probably in f's set, in the manner of InsertUint64.
+func (f *Filter) MaybeContainsUint64(v uint64) bool {
+	var b [8]byte
+	binary.LittleEndian.PutUint64(b[:], v)
+	return f.MaybeContains(b[:])
+}
+
+// InsertInt64 inserts v into f's set as the item of its 8-byte little-endian two's complement encoding,
+// in the manner of InsertUint64, so that a non-negative value is the same item as the equal uint64.
+func (f *Filter) InsertInt64(v int64) {
+	f.InsertUint64(uint64(v))
+}
+
+// MaybeContainsInt64 reports whether v is probably in f's set, in the manner of InsertInt64.
+func (f *Filter) MaybeContainsInt64(v int64) bool {
+	return f.MaybeContainsUint64(uint64(v))
+}
+
+// InsertBatch inserts each of items into f's set.
+func (f *Filter) InsertBatch(items [][]byte) {
+	if len(items) > 0 {
+		f.checkInsert()
+	}
+	for _, item := range items {
+		d := f.hash.digest(item)
+		for i := 0; i < f.k; i++ {
+			f.setBit(f.location(&d, i))
+		}
+	}
+}
+
+// InsertMany inserts each of its arguments into f's set, in the manner of InsertBatch,
+// so that a few related items, such as the forms of a name, can be inserted in one statement.
+func (f *Filter) InsertMany(items ...[]byte) {
+	f.InsertBatch(items)
+}
+
+// InsertSeq inserts into f's set every item yielded by seq, in the manner of Insert, so that an iterator
+// over the rows of a database cursor or the lines of a file can feed f directly.
+// The items are inserted on the calling goroutine as they are yielded, and are not retained,
+// so seq may reuse the memory of an item once it has been yielded. LoadFrom divides the insertions
+// of a long sequence among several goroutines.
+func (f *Filter) InsertSeq(seq iter.Seq[[]byte]) {
+	for item := range seq {
+		f.Insert(item)
+	}
+}
+
+// InsertStrings inserts the bytes of each of items into f's set, in the manner of InsertString.
+func (f *Filter) InsertStrings(items []string) {
+	for _, s := range items {
+		f.InsertString(s)
+	}
+}
+
+// ContainsBatch reports, for each of items, whether it is probably in f's set, in the manner of MaybeContains.
+func (f *Filter) ContainsBatch(items [][]byte) []bool {
+	res := make([]bool, len(items))
+	if f.k == 0 {
+		return res
+	}
+	for j, item := range items {
+		d := f.hash.digest(item)
+		res[j] = true
+		for i := 0; i < f.k; i++ {
+			if f.bit(f.location(&d, i)) == 0 {
+				res[j] = false
+				break
+			}
+		}
+	}
+	return res
+}
+
+// Union inserts into f every item in g's set, so that f represents the union of the two sets.
+// It returns an error without modifying f if g differs from f in size, number of hash values, or hash algorithm.
+func (f *Filter) Union(g *Filter) error {
+	if !f.compatible(g) {
+		return newError(ErrIncompatible, "incompatible filters")
+	}
+	for i := range g.pages {
+		q := g.page(i)
+		var p *page
+		for j := range q.w {
+			v := atomic.LoadUint64(&q.w[j])
+			if v == 0 {
+				continue
+			}
+			if p == nil {
+				p = f.writablePage(i)
+			}
+			atomic.OrUint64(&p.w[j], v)
+		}
+	}
+	return nil
+}
+
+// Intersect clears the bits of f that are not set in g, so that f represents a set that contains
+// every item in both sets, though it may report more false positives than a filter of that set alone.
+// It returns an error without modifying f if g differs from f in size, number of hash values, or hash algorithm.
+// Words are cleared with atomic AND operations, so Intersect may be called concurrently with Insert;
+// since it can clear bits, a concurrent MaybeContains may report either result for an item being removed.
+func (f *Filter) Intersect(g *Filter) error {
+	if !f.compatible(g) {
+		return newError(ErrIncompatible, "incompatible filters")
+	}
+	for i := range f.pages {
+		q := g.page(i)
+		p := f.page(i)
+		for j := range q.w {
+			v := atomic.LoadUint64(&q.w[j])
+			if atomic.LoadUint64(&p.w[j])&^v == 0 {
+				continue
+			}
+			p = f.writablePage(i)
+			atomic.AndUint64(&p.w[j], v)
+		}
+	}
+	return nil
+}
+
+// The binary form of a Filter begins with a header of the following fields, with integers in big-endian byte order:
+//
+//	magic          [4]byte  "BLMF"
+//	version        uint8    format version
+//	hash algorithm uint8    algorithm used to derive hash values from items
+//	flags          uint8    bitwise OR of format flags
+//	k              uint8    number of hash values
+//	seed           uint64   seed of the hash algorithm
+//	m              uint64   size of the filter in bits
+//
+// followed by the filter's bits. Bit n of the filter is bit n%8 of byte n/8, counting from the least significant bit.
+// If the flags include flagSparse, the bits are instead encoded as described by appendSparse.
+// If the flags include flagChecksum, the bits are followed by the CRC-32C checksum of all preceding bytes.
+const (
+	magic         = "BLMF"
+	formatVersion = 1
+	headerSize    = 24
+)
+
+// Format flags
+const (
+	// The data ends with a checksum.
+	flagChecksum = 1 << iota
+
+	// The data is in the chunked form written by WriteChunks.
+	flagChunked
+
+	// The filter's bits are in sparse encoding.
+	flagSparse
+
+	// The data is a delta written by MarshalDelta.
+	flagDelta
+)
+
+// castagnoli is the CRC-32C table used to compute checksums.
+var castagnoli = crc32.MakeTable(crc32.Castagnoli)
+
+// header holds the fields of the binary form's header that follow the magic number and version.
+type header struct {
+	hashAlgorithm byte
+	flags         byte
+	k             byte
+	seed          uint64
+	m             uint64
+}
+
+// header returns f's header.
+func (f *Filter) header() header {
+	return header{hashAlgorithm: byte(f.hash), flags: flagChecksum, k: byte(f.k), m: uint64(f.m)}
+}
+
+// appendHeader appends the magic number, version, and h to b.
+func appendHeader(b []byte, h header) []byte {
+	b = append(b, magic...)
+	b = append(b, formatVersion, h.hashAlgorithm, h.flags, h.k)
+	b = binary.BigEndian.AppendUint64(b, h.seed)
+	return binary.BigEndian.AppendUint64(b, h.m)
+}
+
+// readHeader reads a header from the front of data and returns it and the remainder of data.
+// It returns an error if the header does not describe a Filter that this package supports.
+func readHeader(data []byte) (header, []byte, error) {
+	if len(data) < headerSize {
+		return header{}, nil, newError(ErrTruncated, "data too short for header")
+	}
+	if string(data[:len(magic)]) != magic {
+		return header{}, nil, newError(ErrCorrupt, "missing magic number")
+	}
+	if data[4] != formatVersion {
+		return header{}, nil, newError(ErrUnsupported, "unsupported format version")
+	}
+	h := header{
+		hashAlgorithm: data[5],
+		flags:         data[6],
+		k:             data[7],
+		seed:          binary.BigEndian.Uint64(data[8:]),
+		m:             binary.BigEndian.Uint64(data[16:]),
+	}
+	if h.flags&^(flagChecksum|flagChunked|flagSparse|flagDelta) != 0 ||
+		h.flags&flagChunked != 0 && h.flags&(flagSparse|flagDelta) != 0 {
+		return header{}, nil, newError(ErrUnsupported, "unsupported format flags")
+	}
+	if h.seed != 0 {
+		return header{}, nil, newError(ErrUnsupported, "unsupported seed")
+	}
+	if err := checkParams(Hash(h.hashAlgorithm), h.m, uint64(h.k)); err != nil {
+		return header{}, nil, err
+	}
+	return h, data[headerSize:], nil
+}
+
+// load stores in f the filter described by h with a copy of bits b, in the order of the binary form.
+// If f already holds a filter with the same number of words in pages that are not shared, it reuses them.
+func (f *Filter) load(h header, b []byte) {
+	if f.reusable(int(h.m)) {
+		for _, p := range f.pages {
+			for j := range p.w {
+				var buf [8]byte
+				b = b[copy(buf[:], b):]
+				p.w[j] = binary.LittleEndian.Uint64(buf[:])
+			}
+		}
+	} else {
+		f.pages = newPages(words(b))
+	}
+	f.k = int(h.k)
+	f.m = int(h.m)
+	f.hash = Hash(h.hashAlgorithm)
+}
+
+// reusable reports whether f's storage can hold a filter of m bits.
+func (f *Filter) reusable(m int) bool {
+	if (f.m+63)/64 != (m+63)/64 {
+		return false
+	}
+	for _, p := range f.pages {
+		if p.shared.Load() {
 			return false
 		}
 	}
 	return true
 }
 
-// hashBits returns a slice of ints consisting of pairs of bytes from the SHA-256 hash of item.
-func hashBits(item []byte) []int {
-	hash := sha256.Sum256(item)
-	// SHA-256 hashes are 32 bytes long, so constructing i from a pair of bytes yields a maximum of 16 hash values,
-	// each indexing a filter of size at most 65536 bits.
-	b := make([]int, maxHashValues)
-	for i := 0; i < len(b); i++ {
-		b[i] = int(binary.BigEndian.Uint16(hash[2*i:]))
+// bitsBuffers holds buffers into which UnmarshalBinary and ReadFrom decode the bits of a Filter before loading them,
+// so that reloading a filter repeatedly does not allocate. decode replaces a buffer only with one holding
+// the bits of a valid filter, so that data that claims a large size and fails validation leaves no large buffer behind.
+var bitsBuffers = sync.Pool{New: func() any { return new([]byte) }}
+
+// checkPadding reports whether any bits of b beyond the first m are set.
+func checkPadding(b []byte, m uint64) error {
+	if m%8 != 0 && b[len(b)-1]>>(m%8) != 0 {
+		return newError(ErrCorrupt, "bits set beyond filter size")
+	}
+	return nil
+}
+
+// loadChecked validates the parameters in h, with k in place of h.k, and the bits b of a filter decoded
+// from a form other than the binary form, and stores the filter in f.
+func (f *Filter) loadChecked(h header, k uint64, b []byte) error {
+	if err := checkParams(Hash(h.hashAlgorithm), h.m, k); err != nil {
+		return err
+	}
+	if uint64(len(b)) != (h.m+7)/8 {
+		return newError(ErrCorrupt, "filter size does not match data length")
 	}
-	return b
+	if err := checkPadding(b, h.m); err != nil {
+		return err
+	}
+	f.load(h, b)
+	return nil
 }
 
-// MarshalBinary marshals f into a binary form. It satisfies the encoding.BinaryMarshaler interface.
+// MarshalBinary marshals f into a binary form consisting of a header that identifies the format version
+// and f's parameters, followed by f's bits and a checksum.
+// The bits are stored as a list of the positions of set bits if that is smaller than storing them directly.
+// It satisfies the encoding.BinaryMarshaler interface.
 func (f *Filter) MarshalBinary() ([]byte, error) {
-	// The filter, followed by the number of hash values expressed as a single byte
-	b := make([]byte, len(f.f)+1)
-	copy(b, f.f)
-	b[len(f.f)] = byte(f.k)
-	return b, nil
+	var b bytes.Buffer
+	b.Grow(headerSize + (f.m+7)/8 + crc32.Size)
+	f.WriteTo(&b)
+	return b.Bytes(), nil
 }
 
 // UnmarshalBinary unmarshals a binary representation of a Filter and stores the representation in f.
-// If the size of the unmarshaled Filter in bytes is not a power of 2 in the range [1, 8192]
-// or the unmarshaled number of hash values is not in the range [1, 16],
-// UnmarshalBinary returns an error without modifying the contents of f.
+// It accepts the form produced by MarshalBinary as well as the legacy form produced by earlier versions of this package,
+// which consists of the filter followed by the number of hash values expressed as a single byte.
+// If the data is malformed, fails checksum verification, specifies a format version, hash algorithm, or seed that is not supported,
+// or specifies a size or number of hash values that its hash algorithm does not support,
+// UnmarshalBinary returns an error without modifying the contents of f; the error wraps ErrEmptyData, ErrTruncated,
+// ErrCorrupt, ErrUnsupported, ErrBadSize, or ErrBadHashCount according to its cause.
+// It also returns an error wrapping ErrBadSize for a filter in sparse encoding of more than DefaultSparseLimit bits.
 // Otherwise, it overwrites any existing data in f and returns nil.
 // UnmarshalBinary satisfies the encoding.BinaryUnmarshaler interface.
 func (f *Filter) UnmarshalBinary(data []byte) error {
+	return f.unmarshalBinary(data, 0)
+}
+
+// UnmarshalBinaryLimit unmarshals data in the manner of UnmarshalBinary, but returns an error wrapping ErrBadSize
+// without allocating the filter if data describes a filter of more than maxSize bits,
+// in place of DefaultSparseLimit, which it does not apply.
+// Because the bits of a filter with few bits set occupy only a few bytes in sparse encoding, however large the filter,
+// data from an untrusted source should be unmarshaled with a limit, lest a short input demand a vast allocation.
+func (f *Filter) UnmarshalBinaryLimit(data []byte, maxSize int) error {
+	return f.unmarshalBinary(data, max(maxSize, 1))
+}
+
+// unmarshalBinary implements UnmarshalBinary, limiting the size of the filter to maxSize bits if it is positive.
+func (f *Filter) unmarshalBinary(data []byte, maxSize int) error {
+	if len(data) < len(magic) || string(data[:len(magic)]) != magic {
+		return f.unmarshalLegacy(data, maxSize)
+	}
+	bp := bitsBuffers.Get().(*[]byte)
+	defer bitsBuffers.Put(bp)
+	r := bytes.NewReader(data)
+	b, h, _, err := decode(r, false, bp, maxSize)
+	if err != nil {
+		return err
+	}
+	if r.Len() != 0 {
+		return newError(ErrCorrupt, "filter size does not match data length")
+	}
+	f.load(h, b)
+	return nil
+}
+
+// WriteTo writes f to w in the binary form produced by MarshalBinary, without first copying it to a separate buffer:
+// its bits are written a page at a time, or, if sparse encoding is smaller, encoded directly from its pages.
+// It returns the number of bytes written and any error encountered.
+// WriteTo satisfies the io.WriterTo interface.
+func (f *Filter) WriteTo(w io.Writer) (int64, error) {
+	h := f.header()
+	l := (f.m + 7) / 8
+	var (
+		count int
+		pos   []byte
+	)
+	// Sparse encoding takes at least a byte for each set bit, so it is attempted only if it might be smaller.
+	if f.BitCount() < l {
+		var ok bool
+		if count, pos, ok = f.sparsePositions(l); ok {
+			h.flags |= flagSparse
+		}
+	}
+	crc := crc32.New(castagnoli)
+	cw := &countWriter{w: io.MultiWriter(w, crc)}
+	cw.Write(appendHeader(make([]byte, 0, headerSize), h))
+	if h.flags&flagSparse != 0 {
+		cw.Write(binary.AppendUvarint(nil, uint64(count)))
+		cw.Write(pos)
+	} else {
+		buf := make([]byte, 0, min(l, pageWords*8))
+		for off := 0; off < l && cw.err == nil; off += pageWords * 8 {
+			buf = f.appendBits(buf[:0], off, min(off+pageWords*8, l))
+			cw.Write(buf)
+		}
+	}
+	cw.Write(binary.BigEndian.AppendUint32(nil, crc.Sum32()))
+	return cw.n, cw.err
+}
+
+// appendBits appends to dst the bytes of f's bits from byte offset off to byte offset end,
+// in the order of the binary form, reading them from f's pages a word at a time.
+func (f *Filter) appendBits(dst []byte, off, end int) []byte {
+	for off < end {
+		w := off / 8
+		var buf [8]byte
+		binary.LittleEndian.PutUint64(buf[:], atomic.LoadUint64(&f.page(w / pageWords).w[w%pageWords]))
+		n := min(8, end-w*8)
+		dst = append(dst, buf[off%8:n]...)
+		off = w*8 + n
+	}
+	return dst
+}
+
+// sparsePositions returns the number of f's bits that are set and the positions of those bits in the form
+// of the sparse encoding written by appendSparse, reading them from f's pages.
+// It reports whether the encoding, including the count, is shorter than limit bytes.
+// The count is that of the positions returned, even if bits are set concurrently.
+func (f *Filter) sparsePositions(limit int) (int, []byte, bool) {
+	var (
+		count int
+		pos   []byte
+		prev  int
+	)
+	for i := range f.pages {
+		p := f.page(i)
+		for j := range p.w {
+			for x := atomic.LoadUint64(&p.w[j]); x != 0; x &= x - 1 {
+				n := (i*pageWords+j)*64 + bits.TrailingZeros64(x)
+				pos = binary.AppendUvarint(pos, uint64(n-prev))
+				prev = n
+				count++
+				if len(pos) >= limit {
+					return 0, nil, false
+				}
+			}
+		}
+	}
+	if uvarintLen(count)+len(pos) >= limit {
+		return 0, nil, false
+	}
+	return count, pos, true
+}
+
+// uvarintLen returns the length of the unsigned varint encoding of n.
+func uvarintLen(n int) int {
+	return len(binary.AppendUvarint(make([]byte, 0, binary.MaxVarintLen64), uint64(n)))
+}
+
+// encode writes to w the header h and the filter bits b, in sparse encoding if that is smaller, followed by a checksum.
+func encode(w io.Writer, h header, b []byte) (int64, error) {
+	if s := appendSparse(nil, b, len(b)); s != nil {
+		h.flags |= flagSparse
+		b = s
+	}
+	crc := crc32.New(castagnoli)
+	cw := &countWriter{w: io.MultiWriter(w, crc)}
+	cw.Write(appendHeader(make([]byte, 0, headerSize), h))
+	cw.Write(b)
+	cw.Write(binary.BigEndian.AppendUint32(nil, crc.Sum32()))
+	return cw.n, cw.err
+}
+
+// ReadFrom reads a Filter in the binary form produced by MarshalBinary from r and stores it in f.
+// It reads no further than the end of the Filter's data, and returns the number of bytes read and any error encountered.
+// It validates the data in the same manner as UnmarshalBinary, but does not accept the legacy form.
+// If it returns an error, it does not modify the contents of f.
+// ReadFrom satisfies the io.ReaderFrom interface.
+func (f *Filter) ReadFrom(r io.Reader) (int64, error) {
+	return f.readFrom(r, 0)
+}
+
+// ReadFromLimit reads a Filter from r in the manner of ReadFrom, but returns an error wrapping ErrBadSize
+// without allocating the filter if its header describes a filter of more than maxSize bits,
+// as UnmarshalBinaryLimit does.
+func (f *Filter) ReadFromLimit(r io.Reader, maxSize int) (int64, error) {
+	return f.readFrom(r, max(maxSize, 1))
+}
+
+// readFrom implements ReadFrom, limiting the size of the filter to maxSize bits if it is positive.
+func (f *Filter) readFrom(r io.Reader, maxSize int) (int64, error) {
+	bp := bitsBuffers.Get().(*[]byte)
+	defer bitsBuffers.Put(bp)
+	b, h, n, err := decode(r, false, bp, maxSize)
+	if err != nil {
+		return n, err
+	}
+	f.load(h, b)
+	return n, nil
+}
+
+// decode reads the binary form of a Filter from r, reading no further than the end of its data.
+// It returns the filter's bits, its header, and the number of bytes read.
+// If delta is true, the data must be a delta written by MarshalDelta; otherwise it must not be.
+// If bp is not nil, decode reads the bits into *bp, replacing it with a larger buffer if necessary,
+// and the bits alias *bp. If maxSize is positive, decode rejects a filter of more than maxSize bits;
+// otherwise it rejects a filter in sparse encoding of more than DefaultSparseLimit bits.
+func decode(r io.Reader, delta bool, bp *[]byte, maxSize int) (b []byte, h header, n int64, err error) {
+	crc := crc32.New(castagnoli)
+	cr := &countReader{r: io.TeeReader(r, crc)}
+	hb := make([]byte, headerSize)
+	if _, err := io.ReadFull(cr, hb); err != nil {
+		return nil, header{}, cr.n, eofError(err)
+	}
+	h, _, err = readHeader(hb)
+	if err != nil {
+		return nil, header{}, cr.n, err
+	}
+	if err := checkLimit(h.m, maxSize); err != nil {
+		return nil, header{}, cr.n, err
+	}
+	if maxSize == 0 && h.flags&flagSparse != 0 && h.m > DefaultSparseLimit {
+		return nil, header{}, cr.n, newError(ErrBadSize, "sparse filter size exceeds default limit")
+	}
+	if h.flags&flagChunked != 0 {
+		return nil, header{}, cr.n, newError(ErrUnsupported, "data is in chunked form")
+	}
+	switch isDelta := h.flags&flagDelta != 0; {
+	case isDelta && !delta:
+		return nil, header{}, cr.n, newError(ErrUnsupported, "data is a delta")
+	case !isDelta && delta:
+		return nil, header{}, cr.n, newError(ErrUnsupported, "data is not a delta")
+	}
+	// The header's size is not trusted until the data is read: the bits are read into a buffer
+	// that grows only as the data arrives, so that a header claiming a large size in data that ends early,
+	// or is corrupt, does not allocate the memory it claims.
+	if bp != nil {
+		b = (*bp)[:0]
+	}
+	l := (h.m + 7) / 8
+	if h.flags&flagSparse != 0 {
+		b, err = readSparse(cr, b, h.m)
+	} else if b, err = readDense(cr, b, l); err == nil {
+		err = checkPadding(b, h.m)
+	}
+	if err != nil {
+		return nil, header{}, cr.n, eofError(err)
+	}
+	if h.flags&flagChecksum != 0 {
+		sum := crc.Sum32()
+		cb := make([]byte, crc32.Size)
+		if _, err := io.ReadFull(cr, cb); err != nil {
+			return nil, header{}, cr.n, eofError(err)
+		}
+		if binary.BigEndian.Uint32(cb) != sum {
+			return nil, header{}, cr.n, newError(ErrCorrupt, "checksum mismatch")
+		}
+	}
+	// Sparse bits end with the last byte that has a bit set.
+	if n := len(b); uint64(n) < l {
+		b = slices.Grow(b, int(l)-n)[:l]
+		clear(b[n:])
+	}
+	if bp != nil {
+		*bp = b
+	}
+	return b, h, cr.n, nil
+}
+
+// readChunkSize is the amount by which readDense grows its buffer at least.
+const readChunkSize = 1 << 20
+
+// readDense reads l bytes from r, appending them to b[:0] and returning the result.
+// It grows the buffer as the data arrives, at most doubling it at each step,
+// so that it allocates memory in proportion to the data actually read rather than to l.
+func readDense(r io.Reader, b []byte, l uint64) ([]byte, error) {
+	b = b[:0]
+	for uint64(len(b)) < l {
+		if len(b) == cap(b) {
+			b = slices.Grow(b, int(min(l-uint64(len(b)), max(uint64(len(b)), readChunkSize))))
+		}
+		n := int(min(l, uint64(cap(b))))
+		if _, err := io.ReadFull(r, b[len(b):n]); err != nil {
+			return nil, err
+		}
+		b = b[:n]
+	}
+	return b, nil
+}
+
+// countWriter is an io.Writer that counts the bytes written to w.
+// After the first error, it discards subsequent writes and retains the error.
+type countWriter struct {
+	w   io.Writer
+	n   int64
+	err error
+}
+
+func (cw *countWriter) Write(p []byte) (int, error) {
+	if cw.err != nil {
+		return 0, cw.err
+	}
+	n, err := cw.w.Write(p)
+	cw.n += int64(n)
+	cw.err = err
+	return n, err
+}
+
+// countReader is an io.Reader that counts the bytes read from r.
+type countReader struct {
+	r io.Reader
+	n int64
+}
+
+func (cr *countReader) Read(p []byte) (int, error) {
+	n, err := cr.r.Read(p)
+	cr.n += int64(n)
+	return n, err
+}
+
+// ReadByte reads a single byte from r, without reading ahead.
+func (cr *countReader) ReadByte() (byte, error) {
+	var b [1]byte
+	if _, err := io.ReadFull(cr, b[:]); err != nil {
+		return 0, err
+	}
+	return b[0], nil
+}
+
+// DefaultSparseLimit is the largest size, in bits, of a filter in sparse encoding that UnmarshalBinary, ReadFrom,
+// NewFromBytesNoCopy, and the methods that decode a Filter by them accept. The bits of a filter in dense encoding
+// occupy its data, so that decoding them allocates memory in proportion to the data's length, but a few bytes
+// of sparse encoding can describe a filter of any size: the limit bounds the memory that a short input can demand.
+// UnmarshalBinaryLimit and ReadFromLimit accept a sparse filter of any size up to their own limit.
+const DefaultSparseLimit = 1 << 30 // 128 MiB
+
+// checkLimit reports whether a filter of m bits exceeds a limit of maxSize bits, if maxSize is positive.
+func checkLimit(m uint64, maxSize int) error {
+	if maxSize > 0 && m > uint64(maxSize) {
+		return newError(ErrBadSize, "filter size exceeds limit")
+	}
+	return nil
+}
+
+// unmarshalLegacy unmarshals the legacy binary form of a Filter and stores it in f,
+// limiting its size to maxSize bits if maxSize is positive.
+func (f *Filter) unmarshalLegacy(data []byte, maxSize int) error {
+	h, b, err := readLegacy(data)
+	if err != nil {
+		return err
+	}
+	if err := checkLimit(h.m, maxSize); err != nil {
+		return err
+	}
+	f.load(h, b)
+	return nil
+}
+
+// readLegacy returns the header and bits of the Filter in data in the legacy binary form.
+// The bits alias data.
+func readLegacy(data []byte) (header, []byte, error) {
 	l := len(data)
 	if l == 0 {
-		return errors.New("empty data slice")
+		return header{}, nil, newError(ErrEmptyData, "empty data slice")
 	}
 	if l-1 <= 0 || l-1 > maxFilterSize {
-		return errors.New("filter size out of range")
+		return header{}, nil, newError(ErrBadSize, "filter size out of range")
 	}
 	if bits.OnesCount(uint(l-1)) != 1 {
-		return errors.New("filter size not a power of 2")
+		return header{}, nil, newError(ErrBadSize, "filter size not a power of 2")
 	}
 	k := int(data[l-1])
 	if k <= 0 || k > maxHashValues {
-		panic("number of hash values out of range")
+		return header{}, nil, newError(ErrBadHashCount, "number of hash values out of range")
 	}
-	f.f = make([]byte, l-1)
-	copy(f.f, data[:l-1])
-	f.k = k
-	return nil
+	return header{hashAlgorithm: byte(SHA256), k: byte(k), m: uint64(l-1) * 8}, data[: l-1 : l-1], nil
+}
+
+// NewFromBytesNoCopy returns the Filter whose binary form, as produced by MarshalBinary, is data,
+// validating it in the same manner as UnmarshalBinary. The Filter aliases data rather than copying it,
+// which suits read-only filters loaded from memory-mapped files or embedded assets,
+// provided that the bits are not in sparse encoding, that they occupy a multiple of 8 bytes
+// starting at an address that is a multiple of 8, and that the machine is little-endian;
+// otherwise the Filter holds a copy. The caller must not modify data while the Filter is in use.
+// The Filter never modifies data: Insert and other methods that set bits first copy the affected pages.
+func NewFromBytesNoCopy(data []byte) (*Filter, error) {
+	var (
+		h   header
+		b   []byte
+		err error
+	)
+	if len(data) < len(magic) || string(data[:len(magic)]) != magic {
+		h, b, err = readLegacy(data)
+	} else {
+		h, b, err = readAliased(data)
+	}
+	if err != nil {
+		return nil, err
+	}
+	pages := newPages(wordsNoCopy(b))
+	for _, p := range pages {
+		p.shared.Store(true) // written only after being copied
+	}
+	return &Filter{pages: pages, k: int(h.k), m: int(h.m), hash: Hash(h.hashAlgorithm)}, nil
+}
+
+// MustUnmarshal returns the Filter whose binary form, as produced by MarshalBinary or in the legacy form, is data.
+// It panics if UnmarshalBinary would return an error, and so suits the initialization of package-level variables
+// from filters built in advance and embedded in a program, where invalid data can only mean a mistake in the build.
+func MustUnmarshal(data []byte) *Filter {
+	f := new(Filter)
+	if err := f.UnmarshalBinary(data); err != nil {
+		panic("bloom: " + err.Error())
+	}
+	return f
+}
+
+// Must returns f if err is nil and panics otherwise. It wraps a call to a function that returns a Filter and an error,
+// such as NewFromBytesNoCopy or LoadFS, in the initialization of a package-level variable:
+//
+//	var blocklist = bloom.Must(bloom.LoadFS(assets, "blocklist.bloom"))
+//
+// New and NewWithHash need no such wrapper, since they panic on invalid parameters.
+func Must(f *Filter, err error) *Filter {
+	if err != nil {
+		panic("bloom: " + err.Error())
+	}
+	return f
+}
+
+// nativeLittleEndian reports whether the machine stores words in little-endian byte order,
+// which is the byte order of a Filter's words in its binary form.
+var nativeLittleEndian = binary.NativeEndian.Uint16([]byte{1, 0}) == 1
+
+// wordsNoCopy returns the bits of b as the words of a Filter, aliasing b if the machine's byte order
+// and b's alignment and length permit, and copying it otherwise.
+func wordsNoCopy(b []byte) []uint64 {
+	if len(b) == 0 || len(b)%8 != 0 || !nativeLittleEndian || uintptr(unsafe.Pointer(&b[0]))%8 != 0 {
+		return words(b)
+	}
+	return unsafe.Slice((*uint64)(unsafe.Pointer(&b[0])), len(b)/8)
+}
+
+// readAliased returns the header and bits of the Filter in data in the binary form produced by MarshalBinary.
+// Unless the bits are in sparse encoding, they alias data.
+func readAliased(data []byte) (header, []byte, error) {
+	h, rest, err := readHeader(data)
+	if err != nil {
+		return header{}, nil, err
+	}
+	if h.flags&(flagChunked|flagSparse|flagDelta) != 0 {
+		r := bytes.NewReader(data)
+		b, h, _, err := decode(r, false, nil, 0)
+		if err != nil {
+			return header{}, nil, err
+		}
+		if r.Len() != 0 {
+			return header{}, nil, newError(ErrCorrupt, "filter size does not match data length")
+		}
+		return h, b, nil
+	}
+	n := (h.m + 7) / 8
+	if h.flags&flagChecksum != 0 {
+		n += crc32.Size
+	}
+	if uint64(len(rest)) != n {
+		return header{}, nil, newError(ErrCorrupt, "filter size does not match data length")
+	}
+	b := rest[: (h.m+7)/8 : (h.m+7)/8]
+	if err := checkPadding(b, h.m); err != nil {
+		return header{}, nil, err
+	}
+	if h.flags&flagChecksum != 0 {
+		end := len(data) - crc32.Size
+		if binary.BigEndian.Uint32(data[end:]) != crc32.Checksum(data[:end], castagnoli) {
+			return header{}, nil, newError(ErrCorrupt, "checksum mismatch")
+		}
+	}
+	return h, b, nil
+}
+
+// GobEncode encodes f in the same binary form as MarshalBinary. It satisfies the gob.GobEncoder interface.
+func (f *Filter) GobEncode() ([]byte, error) {
+	return f.MarshalBinary()
+}
+
+// GobDecode decodes a Filter encoded by GobEncode and stores it in f.
+// It validates data in the same manner as UnmarshalBinary and satisfies the gob.GobDecoder interface.
+func (f *Filter) GobDecode(data []byte) error {
+	return f.UnmarshalBinary(data)
 }
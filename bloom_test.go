@@ -1,27 +1,43 @@
 package bloom
 
 import (
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"math"
 	"reflect"
+	"runtime"
+	"slices"
+	"sync"
 	"testing"
+	"unsafe"
 )
 
+// filter returns a Filter with the given bits that uses k hash values derived by SHA256.
+func filter(k int, bits ...byte) *Filter {
+	return &Filter{pages: newPages(words(bits)), k: k, m: len(bits) * 8}
+}
+
 var bitTests = []struct {
 	f   *Filter
 	ins []int
 }{
-	{&Filter{f: []byte{0}}, make([]int, 0)},
-	{&Filter{f: []byte{1}}, []int{0}},
-	{&Filter{f: []byte{2}}, []int{1}},
-	{&Filter{f: []byte{3}}, []int{0, 1}},
-	{&Filter{f: []byte{0, 0}}, make([]int, 0)},
-	{&Filter{f: []byte{1, 0}}, []int{0}},
-	{&Filter{f: []byte{2, 0}}, []int{1}},
-	{&Filter{f: []byte{3, 0}}, []int{0, 1}},
-	{&Filter{f: []byte{0, 1}}, []int{8}},
-	{&Filter{f: []byte{0, 2}}, []int{9}},
-	{&Filter{f: []byte{0, 3}}, []int{8, 9}},
-	{&Filter{f: []byte{255}}, []int{0, 1, 2, 3, 4, 5, 6, 7}},
-	{&Filter{f: []byte{72, 97, 80, 130, 1, 8, 0, 4}}, []int{3, 6, 8, 13, 14, 20, 22, 25, 31, 32, 43, 58}},
+	{filter(0, 0), make([]int, 0)},
+	{filter(0, 1), []int{0}},
+	{filter(0, 2), []int{1}},
+	{filter(0, 3), []int{0, 1}},
+	{filter(0, 0, 0), make([]int, 0)},
+	{filter(0, 1, 0), []int{0}},
+	{filter(0, 2, 0), []int{1}},
+	{filter(0, 3, 0), []int{0, 1}},
+	{filter(0, 0, 1), []int{8}},
+	{filter(0, 0, 2), []int{9}},
+	{filter(0, 0, 3), []int{8, 9}},
+	{filter(0, 255), []int{0, 1, 2, 3, 4, 5, 6, 7}},
+	{filter(0, 72, 97, 80, 130, 1, 8, 0, 4), []int{3, 6, 8, 13, 14, 20, 22, 25, 31, 32, 43, 58}},
 }
 
 func TestBit(t *testing.T) {
@@ -31,9 +47,9 @@ func TestBit(t *testing.T) {
 		for _, n := range test.ins {
 			m[n] = 1
 		}
-		for n := 0; n < len(test.f.f)*8; n++ {
+		for n := 0; n < test.f.m; n++ {
 			if got, want := test.f.bit(n), m[n]; got != want {
-				t.Errorf("TestBit(%v, %v, %v): got %v, want %v", test.f.f, test.ins, n, got, want)
+				t.Errorf("TestBit(%v, %v, %v): got %v, want %v", test.f.bytes(), test.ins, n, got, want)
 			}
 		}
 	}
@@ -41,13 +57,13 @@ func TestBit(t *testing.T) {
 
 func TestSetBit(t *testing.T) {
 	for _, test := range bitTests {
-		f := New(len(test.f.f), 1)
+		f := New(test.f.m/8, 1)
 		for _, i := range test.ins {
 			f.setBit(i)
 		}
-		for n := 0; n < len(f.f)*8; n++ {
+		for n := 0; n < f.m; n++ {
 			if got, want := f.bit(n), test.f.bit(n); got != want {
-				t.Errorf("TestSetBit(%v, %v, %v): got %v, want %v", test.f.f, test.ins, n, got, want)
+				t.Errorf("TestSetBit(%v, %v, %v): got %v, want %v", test.f.bytes(), test.ins, n, got, want)
 			}
 		}
 	}
@@ -83,15 +99,15 @@ func TestInsert(t *testing.T) {
 			// Construct a map of precisely the bits that should be set
 			m := make(map[int]int)
 			for i := 0; i < f.k; i++ {
-				n := int(test.h[i]) & (len(f.f)*8 - 1)
+				n := int(test.h[i]) & (f.m - 1)
 				m[n] = 1
 			}
 
 			f.Insert([]byte(test.s))
 
-			for n := 0; n < len(f.f)*8; n++ {
+			for n := 0; n < f.m; n++ {
 				if got, want := f.bit(n), m[n]; got != want {
-					t.Errorf("TestInsert(%v, k=%v, \"%v\", bit %x): got %v, want %v", len(f.f), f.k, test.s, n, got, want)
+					t.Errorf("TestInsert(%v, k=%v, \"%v\", bit %x): got %v, want %v", f.m/8, f.k, test.s, n, got, want)
 				}
 			}
 		}
@@ -110,7 +126,7 @@ func TestMaybeContains(t *testing.T) {
 		for n := 0; n <= len(s); n++ {
 			for i := range s {
 				if got := f.MaybeContains([]byte(s[i])); got != (i < n) {
-					t.Errorf("TestMaybeContains(%v, %v: %v, %v); got %v, want %v", len(f.f), f.k, n, i, got, i < n)
+					t.Errorf("TestMaybeContains(%v, %v: %v, %v); got %v, want %v", f.m/8, f.k, n, i, got, i < n)
 				}
 			}
 			if n < len(s) {
@@ -120,16 +136,411 @@ func TestMaybeContains(t *testing.T) {
 	}
 }
 
+func TestZeroFilter(t *testing.T) {
+	var f Filter
+	if f.MaybeContains([]byte("a")) || f.MaybeContainsString("") || f.ContainsBatch([][]byte{nil})[0] {
+		t.Errorf("TestZeroFilter: zero Filter contains an item")
+	}
+	f.InsertBatch(nil)
+	for name, insert := range map[string]func(){
+		"Insert":      func() { f.Insert([]byte("a")) },
+		"InsertBatch": func() { f.InsertBatch([][]byte{[]byte("a")}) },
+		"InsertMany":  func() { f.InsertMany([]byte("a")) },
+		"SyncFilter":  func() { NewSyncFilter(&f).TestAndInsert([]byte("a")) },
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("TestZeroFilter(%v): did not panic", name)
+				}
+			}()
+			insert()
+		}()
+	}
+	// A Filter populated by UnmarshalBinary may be used.
+	if err := f.UnmarshalBinary(mustMarshal(New(8, 2))); err != nil {
+		t.Fatal(err)
+	}
+	f.Insert([]byte("a"))
+	if !f.MaybeContains([]byte("a")) {
+		t.Errorf("TestZeroFilter: unmarshaled Filter does not contain inserted item")
+	}
+}
+
+func TestPositions(t *testing.T) {
+	for _, f := range []*Filter{
+		New(16, 3),
+		NewWithHash(1000, 7, BitsAndBlooms),
+		NewWithHash(640, 5, GuavaMitz64),
+		NewWithHash(512, 8, ParquetSBBF),
+	} {
+		f.Insert([]byte("a"))
+		for _, s := range []string{"a", "b", "c"} {
+			pos := f.Positions([]byte(s))
+			if want := f.hash.Locations([]byte(s), f.m, f.k); !reflect.DeepEqual(pos, want) {
+				t.Errorf("TestPositions(%v, %q): got %v, want %v", f.hash, s, pos, want)
+			}
+			all := true
+			for _, n := range pos {
+				all = all && f.bit(n) == 1
+			}
+			if got := f.MaybeContains([]byte(s)); got != all {
+				t.Errorf("TestPositions(%v, %q): MaybeContains got %v, want %v", f.hash, s, got, all)
+			}
+		}
+	}
+	var f Filter
+	if pos := f.Positions([]byte("a")); len(pos) != 0 {
+		t.Errorf("TestPositions: zero Filter got %v, want no positions", pos)
+	}
+}
+
+func TestString(t *testing.T) {
+	f, want := New(128, 4), New(128, 4)
+	for _, s := range []string{"", "a", "hello, world"} {
+		f.InsertString(s)
+		want.Insert([]byte(s))
+		if !f.MaybeContainsString(s) {
+			t.Errorf("TestString(%q): inserted item not found", s)
+		}
+	}
+	if !reflect.DeepEqual(f, want) {
+		t.Errorf("TestString: got %v, want %v", f, want)
+	}
+	if f.MaybeContainsString("not inserted") != f.MaybeContains([]byte("not inserted")) {
+		t.Errorf("TestString: MaybeContainsString differs from MaybeContains")
+	}
+	s := "item"
+	if n := testing.AllocsPerRun(10, func() {
+		f.InsertString(s)
+		f.MaybeContainsString(s)
+	}); n != 0 {
+		t.Errorf("TestString: got %v allocations, want 0", n)
+	}
+}
+
+func TestInt(t *testing.T) {
+	f, want := New(128, 4), New(128, 4)
+	for _, v := range []uint64{0, 1, 1 << 40, math.MaxUint64} {
+		f.InsertUint64(v)
+		want.Insert(EncodeInt(v))
+		if !f.MaybeContainsUint64(v) || !f.MaybeContainsInt64(int64(v)) {
+			t.Errorf("TestInt(%v): inserted item not found", v)
+		}
+	}
+	f.InsertInt64(-5)
+	want.Insert(EncodeInt(-5))
+	if !reflect.DeepEqual(f, want) {
+		t.Errorf("TestInt: got %v, want %v", f, want)
+	}
+	if !f.MaybeContainsInt64(-5) || f.MaybeContainsInt64(-6) || f.MaybeContainsUint64(2) {
+		t.Errorf("TestInt: got %v, %v, %v, want true, false, false", f.MaybeContainsInt64(-5), f.MaybeContainsInt64(-6), f.MaybeContainsUint64(2))
+	}
+	if n := testing.AllocsPerRun(10, func() {
+		f.InsertUint64(7)
+		f.MaybeContainsUint64(7)
+		f.InsertInt64(-7)
+		f.MaybeContainsInt64(-7)
+	}); n != 0 {
+		t.Errorf("TestInt: got %v allocations, want 0", n)
+	}
+}
+
+func TestBatch(t *testing.T) {
+	items := make([][]byte, 100)
+	for i := range items {
+		items[i] = []byte(fmt.Sprint(i))
+	}
+	f, want := New(1024, 4), New(1024, 4)
+	for _, item := range items[:50] {
+		want.Insert(item)
+	}
+	f.InsertBatch(items[:50])
+	if !reflect.DeepEqual(f, want) {
+		t.Errorf("TestBatch: got %v, want %v", f, want)
+	}
+	got := f.ContainsBatch(items)
+	if len(got) != len(items) {
+		t.Fatalf("TestBatch: got %v results, want %v", len(got), len(items))
+	}
+	for i, item := range items {
+		if want := f.MaybeContains(item); got[i] != want {
+			t.Errorf("TestBatch(%s): got %v, want %v", item, got[i], want)
+		}
+	}
+	if got := f.ContainsBatch(nil); len(got) != 0 {
+		t.Errorf("TestBatch: got %v, want no results", got)
+	}
+
+	f.InsertMany(items[50], items[51], items[52])
+	f.InsertMany()
+	for _, item := range items[50:53] {
+		want.Insert(item)
+	}
+	if !reflect.DeepEqual(f, want) {
+		t.Errorf("TestBatch: InsertMany got %v, want %v", f, want)
+	}
+}
+
+func TestInsertSeq(t *testing.T) {
+	items := []string{"a", "b", "hello, world"}
+	f, g, want := New(128, 4), New(128, 4), New(128, 4)
+	for _, s := range items {
+		want.InsertString(s)
+	}
+	buf := make([]byte, 0, 16)
+	f.InsertSeq(func(yield func([]byte) bool) {
+		for _, s := range items {
+			// The memory of each item is reused.
+			buf = append(buf[:0], s...)
+			if !yield(buf) {
+				return
+			}
+		}
+	})
+	if !reflect.DeepEqual(f, want) {
+		t.Errorf("TestInsertSeq: got %v, want %v", f, want)
+	}
+	g.InsertStrings(items)
+	if !reflect.DeepEqual(g, want) {
+		t.Errorf("TestInsertSeq: InsertStrings got %v, want %v", g, want)
+	}
+	f.InsertSeq(slices.Values([][]byte{}))
+	g.InsertStrings(nil)
+	if !reflect.DeepEqual(f, want) || !reflect.DeepEqual(g, want) {
+		t.Errorf("TestInsertSeq: empty input modified filter")
+	}
+}
+
+func TestNewAllocs(t *testing.T) {
+	for _, test := range []struct {
+		m      int
+		allocs float64
+	}{
+		{64, 1},
+		{smallWords * 64, 1},
+		{smallWords*64 + 64, 4},
+		{4 * pageWords * 64, 4},
+	} {
+		if n := testing.AllocsPerRun(10, func() { NewWithHash(test.m, 3, BitsAndBlooms) }); n != test.allocs {
+			t.Errorf("TestNewAllocs(%v): got %v allocations, want %v", test.m, n, test.allocs)
+		}
+	}
+}
+
+func TestInsertAllocs(t *testing.T) {
+	item := []byte("item")
+	for h := range Hash(len(hashNames)) {
+		m, k := 1<<12, 4
+		if h == ParquetSBBF {
+			k = sbbfWords
+		}
+		f := NewWithHash(m, k, h)
+		if n := testing.AllocsPerRun(10, func() {
+			f.Insert(item)
+			f.MaybeContains(item)
+		}); n != 0 {
+			t.Errorf("TestInsertAllocs(%v): got %v allocations, want 0", h, n)
+		}
+	}
+}
+
+func TestMaybeContainsAllocs(t *testing.T) {
+	item := []byte("item")
+	sf := NewSyncFilter(New(128, 4))
+	sharded := NewShardedFilter(4, 1024, 4, BitsAndBlooms)
+	counting := NewCountingFilter(1024, 4, BitsAndBlooms)
+	for name, contains := range map[string]func([]byte) bool{
+		"SyncFilter":     sf.MaybeContains,
+		"ShardedFilter":  sharded.MaybeContains,
+		"CountingFilter": counting.MaybeContains,
+	} {
+		if n := testing.AllocsPerRun(10, func() { contains(item) }); n != 0 {
+			t.Errorf("TestMaybeContainsAllocs(%v): got %v allocations, want 0", name, n)
+		}
+	}
+}
+
+func BenchmarkMaybeContains(b *testing.B) {
+	item := []byte("item")
+	for h := range Hash(len(hashNames)) {
+		k := 4
+		if h == ParquetSBBF {
+			k = sbbfWords
+		}
+		f := NewWithHash(1<<16, k, h)
+		f.Insert(item)
+		b.Run(h.String(), func(b *testing.B) {
+			b.ReportAllocs()
+			for b.Loop() {
+				f.MaybeContains(item)
+			}
+		})
+	}
+}
+
+func TestConcurrentInsert(t *testing.T) {
+	f, want := New(1024, 4), New(1024, 4)
+	var wg sync.WaitGroup
+	for g := range 8 {
+		for i := range 100 {
+			want.Insert([]byte(fmt.Sprint(g, i)))
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range 100 {
+				item := []byte(fmt.Sprint(g, i))
+				f.Insert(item)
+				if !f.MaybeContains(item) {
+					t.Errorf("TestConcurrentInsert(%v, %v): inserted item not found", g, i)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if !reflect.DeepEqual(f, want) {
+		t.Errorf("TestConcurrentInsert: got %v, want %v", f, want)
+	}
+}
+
+func TestUnion(t *testing.T) {
+	for _, test := range []struct {
+		f, g, want *Filter
+	}{
+		{filter(1, 0), filter(1, 0), filter(1, 0)},
+		{filter(2, 0x0f, 0x01), filter(2, 0x30, 0x01), filter(2, 0x3f, 0x01)},
+		{filter(3, bitsAt(8, 0, 63)...), filter(3, bitsAt(8, 5)...), filter(3, bitsAt(8, 0, 5, 63)...)},
+	} {
+		if err := test.f.Union(test.g); err != nil {
+			t.Errorf("TestUnion: %v", err)
+		}
+		if !reflect.DeepEqual(test.f, test.want) {
+			t.Errorf("TestUnion: got %v, want %v", test.f, test.want)
+		}
+	}
+
+	for _, g := range []*Filter{New(2, 1), New(1, 2), NewWithHash(8, 1, BitsAndBlooms)} {
+		f := New(1, 1)
+		if err := f.Union(g); err == nil {
+			t.Errorf("TestUnion(%v): got nil error", g)
+		}
+	}
+
+	// The union contains the items of both filters.
+	f, g := New(128, 4), New(128, 4)
+	f.Insert([]byte("a"))
+	g.Insert([]byte("b"))
+	f.Union(g)
+	if !f.MaybeContains([]byte("a")) || !f.MaybeContains([]byte("b")) {
+		t.Errorf("TestUnion: union does not contain inserted items")
+	}
+}
+
+func TestIntersect(t *testing.T) {
+	for _, test := range []struct {
+		f, g, want *Filter
+	}{
+		{filter(1, 0), filter(1, 0xff), filter(1, 0)},
+		{filter(2, 0x0f, 0x01), filter(2, 0x3c, 0x01), filter(2, 0x0c, 0x01)},
+		{filter(3, bitsAt(8, 0, 5, 63)...), filter(3, bitsAt(8, 5, 63)...), filter(3, bitsAt(8, 5, 63)...)},
+	} {
+		if err := test.f.Intersect(test.g); err != nil {
+			t.Errorf("TestIntersect: %v", err)
+		}
+		if !reflect.DeepEqual(test.f, test.want) {
+			t.Errorf("TestIntersect: got %v, want %v", test.f, test.want)
+		}
+	}
+
+	for _, g := range []*Filter{New(2, 1), New(1, 2), NewWithHash(8, 1, BitsAndBlooms)} {
+		f := New(1, 1)
+		f.Insert([]byte("a"))
+		want := New(1, 1)
+		want.Insert([]byte("a"))
+		if err := f.Intersect(g); err == nil {
+			t.Errorf("TestIntersect(%v): got nil error", g)
+		}
+		if !reflect.DeepEqual(f, want) {
+			t.Errorf("TestIntersect(%v): got %v, want %v", g, f, want)
+		}
+	}
+
+	// The intersection contains the items common to both filters, and does not modify a snapshot.
+	f, g := New(128, 4), New(128, 4)
+	f.Insert([]byte("a"))
+	f.Insert([]byte("c"))
+	g.Insert([]byte("b"))
+	g.Insert([]byte("c"))
+	s := f.Snapshot()
+	f.Intersect(g)
+	if f.MaybeContains([]byte("a")) || f.MaybeContains([]byte("b")) || !f.MaybeContains([]byte("c")) {
+		t.Errorf("TestIntersect: intersection does not contain exactly the common items")
+	}
+	if !s.MaybeContains([]byte("a")) {
+		t.Errorf("TestIntersect: snapshot modified")
+	}
+}
+
+// binaryOf returns the binary form of a filter with the given bits using k hash values.
+func binaryOf(k byte, bits ...byte) []byte {
+	return checked(unchecked(k, bits...))
+}
+
+// unchecked returns the binary form of a filter with the given bits using k hash values, without a checksum.
+func unchecked(k byte, bits ...byte) []byte {
+	return append(binaryHeader(k, 0, len(bits)*8), bits...)
+}
+
+// sparse returns the binary form of a filter of m bits using k hash values with the given sparse encoding.
+func sparse(k byte, m int, enc ...byte) []byte {
+	return checked(append(binaryHeader(k, 4, m), enc...))
+}
+
+// binaryHeader returns the header of the binary form of a filter of m bits using k hash values.
+func binaryHeader(k, flags byte, m int) []byte {
+	return []byte{
+		'B', 'L', 'M', 'F', 1, 0, flags, k,
+		0, 0, 0, 0, 0, 0, 0, 0,
+		0, 0, 0, 0, byte(m >> 24), byte(m >> 16), byte(m >> 8), byte(m),
+	}
+}
+
+// checked sets the checksum flag in the header of the binary form b and appends its checksum.
+func checked(b []byte) []byte {
+	b[6] |= 1
+	return binary.BigEndian.AppendUint32(b, crc32.Checksum(b, crc32.MakeTable(crc32.Castagnoli)))
+}
+
+// bitsAt returns a filter of b bytes with the bits at positions ns set.
+func bitsAt(b int, ns ...int) []byte {
+	f := make([]byte, b)
+	for _, n := range ns {
+		f[n/8] |= 1 << (n % 8)
+	}
+	return f
+}
+
+// withByte returns a copy of b with the byte at index i replaced by c.
+func withByte(b []byte, i int, c byte) []byte {
+	b = append([]byte(nil), b...)
+	b[i] = c
+	return b
+}
+
 var marshalTests = []struct {
-	f    *Filter
-	data []byte
+	f      *Filter
+	data   []byte
+	legacy []byte
 }{
-	{New(1, 1), []byte{0, 1}},
-	{New(4, 1), []byte{0, 0, 0, 0, 1}},
-	{New(4, 3), []byte{0, 0, 0, 0, 3}},
-	{&Filter{f: []byte{255}, k: 4}, []byte{255, 4}},
-	{&Filter{f: []byte{15, 23}, k: 4}, []byte{15, 23, 4}},
-	{&Filter{f: []byte{1, 0, 1, 1, 2, 3, 5, 8}, k: 13}, []byte{1, 0, 1, 1, 2, 3, 5, 8, 13}},
+	{New(1, 1), binaryOf(1, 0), []byte{0, 1}},
+	{New(4, 1), sparse(1, 32, 0), []byte{0, 0, 0, 0, 1}},
+	{New(4, 3), sparse(3, 32, 0), []byte{0, 0, 0, 0, 3}},
+	{filter(4, 255), binaryOf(4, 255), []byte{255, 4}},
+	{filter(4, 15, 23), binaryOf(4, 15, 23), []byte{15, 23, 4}},
+	{filter(13, 1, 0, 1, 1, 2, 3, 5, 8), binaryOf(13, 1, 0, 1, 1, 2, 3, 5, 8), []byte{1, 0, 1, 1, 2, 3, 5, 8, 13}},
+	{New(8192, 16), sparse(16, 65536, 0), append(make([]byte, 8192), 16)},
+	{filter(2, bitsAt(64, 3, 300)...), sparse(2, 512, 2, 3, 0xa9, 0x02), append(bitsAt(64, 3, 300), 2)},
 }
 
 func TestMarshalBinary(t *testing.T) {
@@ -144,14 +555,345 @@ func TestMarshalBinary(t *testing.T) {
 	}
 }
 
+// invalidBinary holds malformed binary forms of filters.
+var invalidBinary = [][]byte{
+	nil,
+	{'B', 'L', 'M', 'F'},
+	binaryOf(1)[:23],
+	binaryOf(1),                          // no bits
+	binaryOf(1, 0, 0, 0),                 // size not a power of 2
+	binaryOf(0, 0),                       // no hash values
+	binaryOf(17, 0),                      // too many hash values
+	unchecked(1, 0)[:24],                 // truncated bits
+	binaryOf(1, 0)[:27],                  // truncated checksum
+	append(unchecked(1, 0), 0),           // trailing data
+	withByte(binaryOf(1, 0), 4, 2),       // unsupported version
+	withByte(binaryOf(1, 0), 5, 1),       // unsupported hash algorithm
+	withByte(binaryOf(1, 0), 6, 3),       // unsupported flags
+	withByte(binaryOf(1, 0), 6, 0),       // missing checksum flag
+	withByte(binaryOf(1, 0), 15, 1),      // nonzero seed
+	withByte(unchecked(1, 0), 16, 1),     // size does not match data
+	withByte(binaryOf(1, 0), 24, 1),      // corrupted bits
+	withByte(binaryOf(1, 0, 0), 26, 255), // corrupted checksum
+	sparse(1, 32, 1, 32),                 // sparse bit position out of range
+	sparse(1, 32, 2, 3, 0),               // sparse bit positions not increasing
+	sparse(1, 32, 33),                    // sparse bit count out of range
+	sparse(1, 32, 2, 3),                  // truncated sparse encoding
+	withByte(sparse(1, 32, 0), 6, 7),     // sparse and chunked
+	{0, 0, 0, 1},                         // legacy size not a power of 2
+	{0, 0},                               // legacy with no hash values
+	{0, 17},                              // legacy with too many hash values
+}
+
 func TestUnmarshalBinary(t *testing.T) {
 	for _, test := range marshalTests {
+		plain := unchecked(byte(test.f.k), test.f.bytes()...)
+		for _, data := range [][]byte{test.data, plain, test.legacy} {
+			f := new(Filter)
+			if err := f.UnmarshalBinary(data); err != nil {
+				t.Errorf("TestUnmarshalBinary: %v", err)
+			}
+			if !reflect.DeepEqual(f, test.f) {
+				t.Errorf("TestUnmarshalBinary: got %v, want %v", f, test.f)
+			}
+		}
+	}
+
+	for _, data := range invalidBinary {
+		f := New(1, 1)
+		if err := f.UnmarshalBinary(data); err == nil {
+			t.Errorf("TestUnmarshalBinary(%v): got nil error", data)
+		}
+		if !reflect.DeepEqual(f, New(1, 1)) {
+			t.Errorf("TestUnmarshalBinary(%v): modified filter to %v", data, f)
+		}
+	}
+}
+
+func TestUnmarshalBinaryReuse(t *testing.T) {
+	g := NewWithHash(2*pageWords*64, 3, BitsAndBlooms)
+	g.Insert([]byte("a"))
+	data, _ := g.MarshalBinary()
+
+	// Storage of the same size is reused, and reloading does not allocate.
+	f := NewWithHash(2*pageWords*64, 3, BitsAndBlooms)
+	w := f.pages[0].w
+	if err := f.UnmarshalBinary(data); err != nil {
+		t.Fatalf("TestUnmarshalBinaryReuse: %v", err)
+	}
+	if &f.pages[0].w[0] != &w[0] || !reflect.DeepEqual(f, g) {
+		t.Errorf("TestUnmarshalBinaryReuse: storage not reused")
+	}
+	// The buffer pool may drop buffers, so measure the average over several reloads.
+	const reloads = 10
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	for range reloads {
+		f.UnmarshalBinary(data)
+	}
+	runtime.ReadMemStats(&after)
+	if n := (after.TotalAlloc - before.TotalAlloc) / reloads; n >= uint64(f.m/8) {
+		t.Errorf("TestUnmarshalBinaryReuse: allocated %v bytes per reload of a filter of %v bytes", n, f.m/8)
+	}
+
+	// Shared storage is not reused.
+	s := f.Snapshot()
+	if err := f.UnmarshalBinary(mustMarshal(NewWithHash(2*pageWords*64, 3, BitsAndBlooms))); err != nil {
+		t.Fatalf("TestUnmarshalBinaryReuse: %v", err)
+	}
+	if !s.MaybeContains([]byte("a")) || f.MaybeContains([]byte("a")) {
+		t.Errorf("TestUnmarshalBinaryReuse: snapshot storage reused")
+	}
+	aligned := make([]uint64, (len(data)+7)/8)
+	nc := unsafe.Slice((*byte)(unsafe.Pointer(&aligned[0])), len(data))
+	copy(nc, data)
+	h, err := NewFromBytesNoCopy(nc)
+	if err != nil {
+		t.Fatalf("TestUnmarshalBinaryReuse: %v", err)
+	}
+	h.UnmarshalBinary(mustMarshal(NewWithHash(2*pageWords*64, 3, BitsAndBlooms)))
+	h.Insert([]byte("b"))
+	if !bytes.Equal(nc, data) {
+		t.Errorf("TestUnmarshalBinaryReuse: aliased data modified")
+	}
+}
+
+func FuzzUnmarshalBinary(f *testing.F) {
+	for _, test := range marshalTests {
+		f.Add(test.data)
+		f.Add(test.legacy)
+	}
+	for _, data := range invalidBinary {
+		f.Add(data)
+	}
+	f.Add(sparseBomb())
+	f.Fuzz(func(t *testing.T, data []byte) {
+		g := new(Filter)
+		err := g.UnmarshalBinary(data)
+		h, errNoCopy := NewFromBytesNoCopy(data)
+		if (err == nil) != (errNoCopy == nil) {
+			t.Fatalf("FuzzUnmarshalBinary(%v): UnmarshalBinary error %v, NewFromBytesNoCopy error %v", data, err, errNoCopy)
+		}
+		if err != nil {
+			return
+		}
+		if !equal(g, h) {
+			t.Errorf("FuzzUnmarshalBinary(%v): UnmarshalBinary got %v, NewFromBytesNoCopy got %v", data, g, h)
+		}
+		if err := g.Validate(); err != nil {
+			t.Errorf("FuzzUnmarshalBinary(%v): UnmarshalBinary got invalid filter: %v", data, err)
+		}
+		if err := h.Validate(); err != nil {
+			t.Errorf("FuzzUnmarshalBinary(%v): NewFromBytesNoCopy got invalid filter: %v", data, err)
+		}
+		r := new(Filter)
+		if err := r.UnmarshalBinary(mustMarshal(g)); err != nil || !equal(r, g) {
+			t.Errorf("FuzzUnmarshalBinary(%v): round trip got %v, %v, want %v", data, r, err, g)
+		}
+	})
+}
+
+// mustMarshal returns the binary form of f.
+func mustMarshal(f *Filter) []byte {
+	data, err := f.MarshalBinary()
+	if err != nil {
+		panic(err)
+	}
+	return data
+}
+
+func TestNewFromBytesNoCopy(t *testing.T) {
+	for _, test := range marshalTests {
+		plain := unchecked(byte(test.f.k), test.f.bytes()...)
+		for _, data := range [][]byte{test.data, plain, test.legacy} {
+			f, err := NewFromBytesNoCopy(data)
+			if err != nil {
+				t.Errorf("TestNewFromBytesNoCopy(%v): %v", data, err)
+				continue
+			}
+			if !equal(f, test.f) {
+				t.Errorf("TestNewFromBytesNoCopy(%v): got %v, want %v", data, f, test.f)
+			}
+			// The test data is allocated on 8-byte boundaries.
+			isSparse := bytes.HasPrefix(data, []byte("BLMF")) && data[6]&4 != 0
+			want := !isSparse && test.f.m%64 == 0 && nativeLittleEndian
+			if got := aliases(f.pages[0].w, data); got != want {
+				t.Errorf("TestNewFromBytesNoCopy(%v): got aliasing %v, want %v", data, got, want)
+			}
+		}
+	}
+
+	for _, data := range invalidBinary {
+		if f, err := NewFromBytesNoCopy(data); err == nil {
+			t.Errorf("TestNewFromBytesNoCopy(%v): got %v, nil error", data, f)
+		}
+	}
+}
+
+func TestMust(t *testing.T) {
+	for _, test := range marshalTests {
+		if f := MustUnmarshal(test.data); !equal(f, test.f) {
+			t.Errorf("TestMust: MustUnmarshal got %v, want %v", f, test.f)
+		}
+		if f := Must(NewFromBytesNoCopy(test.legacy)); !equal(f, test.f) {
+			t.Errorf("TestMust: Must got %v, want %v", f, test.f)
+		}
+	}
+	for name, fn := range map[string]func(){
+		"MustUnmarshal": func() { MustUnmarshal(binaryOf(0, 0)) },
+		"Must":          func() { Must(NewFromBytesNoCopy(binaryOf(0, 0))) },
+	} {
+		func() {
+			defer func() {
+				if r := recover(); r != "bloom: number of hash values out of range" {
+					t.Errorf("TestMust(%v): got panic %v, want %q", name, r, "bloom: number of hash values out of range")
+				}
+			}()
+			fn()
+		}()
+	}
+}
+
+// aliases reports whether w lies within data.
+func aliases(w []uint64, data []byte) bool {
+	p, start := uintptr(unsafe.Pointer(&w[0])), uintptr(unsafe.Pointer(&data[0]))
+	return p >= start && p+uintptr(len(w))*8 <= start+uintptr(len(data))
+}
+
+func TestWriteTo(t *testing.T) {
+	for _, test := range marshalTests {
+		var buf bytes.Buffer
+		n, err := test.f.WriteTo(&buf)
+		if err != nil {
+			t.Errorf("TestWriteTo: %v", err)
+		}
+		if n != int64(len(test.data)) {
+			t.Errorf("TestWriteTo: wrote %v bytes, want %v", n, len(test.data))
+		}
+		if !reflect.DeepEqual(buf.Bytes(), test.data) {
+			t.Errorf("TestWriteTo: got %v, want %v", buf.Bytes(), test.data)
+		}
+	}
+}
+
+func TestReadFrom(t *testing.T) {
+	// Consecutive filters can be read from the same stream.
+	var buf bytes.Buffer
+	for _, test := range marshalTests {
+		buf.Write(test.data)
+	}
+	for _, test := range marshalTests {
+		f := new(Filter)
+		n, err := f.ReadFrom(&buf)
+		if err != nil {
+			t.Errorf("TestReadFrom: %v", err)
+		}
+		if n != int64(len(test.data)) {
+			t.Errorf("TestReadFrom: read %v bytes, want %v", n, len(test.data))
+		}
+		if !reflect.DeepEqual(f, test.f) {
+			t.Errorf("TestReadFrom: got %v, want %v", f, test.f)
+		}
+	}
+
+	for _, data := range [][]byte{
+		nil,
+		binaryOf(1, 0)[:20],             // truncated header
+		binaryOf(1, 0)[:24],             // truncated bits
+		binaryOf(1, 0)[:27],             // truncated checksum
+		withByte(binaryOf(1, 0), 24, 1), // corrupted bits
+		[]byte{0, 1},                    // legacy form
+	} {
+		f := New(1, 1)
+		if _, err := f.ReadFrom(bytes.NewReader(data)); err == nil {
+			t.Errorf("TestReadFrom(%v): got nil error", data)
+		}
+		if !reflect.DeepEqual(f, New(1, 1)) {
+			t.Errorf("TestReadFrom(%v): modified filter to %v", data, f)
+		}
+	}
+}
+
+func TestGob(t *testing.T) {
+	for _, test := range marshalTests {
+		var buf bytes.Buffer
+		if err := gob.NewEncoder(&buf).Encode(test.f); err != nil {
+			t.Errorf("TestGob: %v", err)
+			continue
+		}
 		f := new(Filter)
-		if err := f.UnmarshalBinary(test.data); err != nil {
-			t.Errorf("TestUnmarshalBinary: %v", err)
+		if err := gob.NewDecoder(&buf).Decode(f); err != nil {
+			t.Errorf("TestGob: %v", err)
+			continue
 		}
 		if !reflect.DeepEqual(f, test.f) {
-			t.Errorf("TestUnmarshalBinary: got %v, want %v", f, test.f)
+			t.Errorf("TestGob: got %v, want %v", f, test.f)
 		}
 	}
 }
+
+// sparseBomb returns a valid checksummed filter of 2^33 bits in sparse encoding whose only set bit is its last,
+// which would demand a gigabyte of memory from a few bytes of data if DefaultSparseLimit did not reject it.
+func sparseBomb() []byte {
+	data := binaryHeader(1, flagSparse|flagChecksum, 0)
+	data[5] = byte(BitsAndBlooms)
+	binary.BigEndian.PutUint64(data[16:], 1<<33)
+	data = binary.AppendUvarint(data, 1)
+	data = binary.AppendUvarint(data, 1<<33-1)
+	return binary.BigEndian.AppendUint32(data, crc32.Checksum(data, castagnoli))
+}
+
+func TestUnmarshalBinaryHugeSize(t *testing.T) {
+	// header returns the header of a filter of m bits using BitsAndBlooms, with the given flags.
+	header := func(flags byte, m uint64) []byte {
+		h := binaryHeader(1, flags, 0)
+		h[5] = byte(BitsAndBlooms)
+		binary.BigEndian.PutUint64(h[16:], m)
+		return h
+	}
+	for _, test := range []struct {
+		name   string
+		data   []byte
+		want   error
+		noCopy error // the error of NewFromBytesNoCopy, which checks the length of dense data first
+	}{
+		{"sparse", append(header(flagSparse|flagChecksum, 1<<33), 5, 1, 2, 3), ErrBadSize, ErrBadSize},
+		{"sparse at cap", append(header(flagSparse, 1<<40), 1), ErrBadSize, ErrBadSize},
+		{"sparse bomb", sparseBomb(), ErrBadSize, ErrBadSize},
+		{"sparse within default limit", append(header(flagSparse, DefaultSparseLimit), 5, 1, 2, 3), ErrTruncated, ErrTruncated},
+		{"dense at cap", append(header(0, 1<<40), 1, 2, 3), ErrTruncated, ErrCorrupt},
+		{"dense checked", append(header(flagChecksum, 1<<36), make([]byte, 1000)...), ErrTruncated, ErrCorrupt},
+	} {
+		var before, after runtime.MemStats
+		runtime.ReadMemStats(&before)
+		err := new(Filter).UnmarshalBinary(test.data)
+		_, rerr := new(Filter).ReadFrom(bytes.NewReader(test.data))
+		_, nerr := NewFromBytesNoCopy(test.data)
+		runtime.ReadMemStats(&after)
+		if !errors.Is(err, test.want) || !errors.Is(rerr, test.want) || !errors.Is(nerr, test.noCopy) {
+			t.Errorf("TestUnmarshalBinaryHugeSize(%v): got %v, %v, and %v, want %v, %v, and %v",
+				test.name, err, rerr, nerr, test.want, test.want, test.noCopy)
+		}
+		if n := after.TotalAlloc - before.TotalAlloc; n > 8<<20 {
+			t.Errorf("TestUnmarshalBinaryHugeSize(%v): allocated %v bytes", test.name, n)
+		}
+	}
+
+	// A valid empty filter in sparse encoding occupies a few bytes, however large.
+	data := append(header(flagSparse, 1<<40), 0)
+	if err := new(Filter).UnmarshalBinaryLimit(data, 1<<30); !errors.Is(err, ErrBadSize) {
+		t.Errorf("TestUnmarshalBinaryHugeSize(limit): got %v, want %v", err, ErrBadSize)
+	}
+	if _, err := new(Filter).ReadFromLimit(bytes.NewReader(data), 1<<30); !errors.Is(err, ErrBadSize) {
+		t.Errorf("TestUnmarshalBinaryHugeSize(ReadFromLimit): got %v, want %v", err, ErrBadSize)
+	}
+	data = append(header(flagSparse, 1<<20), 0)
+	if err := new(Filter).UnmarshalBinaryLimit(data, 1<<20); err != nil {
+		t.Errorf("TestUnmarshalBinaryHugeSize(within limit): %v", err)
+	}
+	// A caller may opt in to a sparse filter larger than the default limit.
+	data = append(header(flagSparse, DefaultSparseLimit*2), 5, 1, 2, 3)
+	if err := new(Filter).UnmarshalBinaryLimit(data, DefaultSparseLimit*2); !errors.Is(err, ErrTruncated) {
+		t.Errorf("TestUnmarshalBinaryHugeSize(above default limit): got %v, want %v", err, ErrTruncated)
+	}
+}
@@ -0,0 +1,155 @@
+package bloomgrpc
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/dkmccandless/bloom"
+)
+
+// maxResponseSize is the size of the largest response message the Client accepts.
+const maxResponseSize = 64 << 20
+
+// A Client calls the methods of a FilterService. It is safe for concurrent use by multiple goroutines.
+type Client struct {
+	url    string
+	client *http.Client
+}
+
+// NewClient returns a Client for the FilterService served at baseURL, such as "https://host:port",
+// that makes requests with client. The client must negotiate HTTP/2 with the server.
+func NewClient(baseURL string, client *http.Client) *Client {
+	return &Client{url: strings.TrimSuffix(baseURL, "/"), client: client}
+}
+
+// Insert inserts items into the filter's set.
+func (c *Client) Insert(ctx context.Context, items ...[]byte) error {
+	var req []byte
+	for _, item := range items {
+		req = appendProtoLen(req, 1, item)
+	}
+	return c.call(ctx, "Insert", req, func([]byte) error { return nil })
+}
+
+// MaybeContains reports whether item is probably in the filter's set, in the manner of bloom.Filter.MaybeContains.
+func (c *Client) MaybeContains(ctx context.Context, item []byte) (bool, error) {
+	var ok bool
+	err := c.call(ctx, "Query", appendProtoLen(nil, 1, item), func(resp []byte) error {
+		res, err := readBools(resp)
+		ok = len(res) > 0 && res[len(res)-1]
+		return err
+	})
+	return ok, err
+}
+
+// ContainsBatch reports, for each item of items, whether it is probably in the filter's set.
+func (c *Client) ContainsBatch(ctx context.Context, items [][]byte) ([]bool, error) {
+	var req []byte
+	for _, item := range items {
+		req = appendProtoLen(req, 1, item)
+	}
+	var res []bool
+	err := c.call(ctx, "BatchQuery", req, func(resp []byte) error {
+		var err error
+		res, err = readBools(resp)
+		if err == nil && len(res) != len(items) {
+			err = errors.New("number of results does not match number of items")
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// Merge inserts into the filter every item in f's set, in the manner of bloom.Filter.Union.
+func (c *Client) Merge(ctx context.Context, f *bloom.Filter) error {
+	fp, err := f.MarshalProto()
+	if err != nil {
+		return err
+	}
+	return c.call(ctx, "Merge", appendProtoLen(nil, 1, fp), func([]byte) error { return nil })
+}
+
+// Snapshot downloads a copy of the filter.
+func (c *Client) Snapshot(ctx context.Context) (*bloom.Filter, error) {
+	var data []byte
+	err := c.call(ctx, "Snapshot", nil, func(resp []byte) error {
+		return readProtoFields(resp, func(field int, typ uint64, v uint64, p []byte) error {
+			if field == 1 && typ == protoLen {
+				data = append(data, p...)
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	f := new(bloom.Filter)
+	if err := f.UnmarshalBinary(data); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// call calls the named method with the request message req, and calls fn with each response message.
+// It returns an *Error if the call's status is not OK.
+func (c *Client) call(ctx context.Context, method string, req []byte, fn func(resp []byte) error) error {
+	var body bytes.Buffer
+	writeMessage(&body, req)
+	hr, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url+"/"+serviceName+"/"+method, &body)
+	if err != nil {
+		return err
+	}
+	hr.Header.Set("Content-Type", "application/grpc")
+	hr.Header.Set("TE", "trailers")
+	resp, err := c.client.Do(hr)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return &Error{codeUnknown, "HTTP status " + resp.Status}
+	}
+	for {
+		msg, err := readMessage(resp.Body, maxResponseSize)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if err := fn(msg); err != nil {
+			return err
+		}
+	}
+	return status(resp)
+}
+
+// status returns the error reported by the status of a response, whose body has been read, or nil if it is OK.
+// A response without a message reports its status in its headers rather than its trailers.
+func status(resp *http.Response) error {
+	h := resp.Trailer
+	if h.Get("Grpc-Status") == "" {
+		h = resp.Header
+	}
+	code, err := strconv.Atoi(h.Get("Grpc-Status"))
+	if err != nil {
+		return &Error{codeUnknown, "missing or malformed grpc-status"}
+	}
+	if code == codeOK {
+		return nil
+	}
+	msg := h.Get("Grpc-Message")
+	if m, err := url.PathUnescape(msg); err == nil {
+		msg = m
+	}
+	return &Error{code, msg}
+}
@@ -0,0 +1,137 @@
+package bloomgrpc
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+// Protocol buffer wire types
+const (
+	protoVarint = 0
+	protoI64    = 1
+	protoLen    = 2
+	protoI32    = 5
+)
+
+// appendProtoLen appends to b a length-delimited field with the given field number and value.
+func appendProtoLen(b []byte, field int, v []byte) []byte {
+	b = binary.AppendUvarint(b, uint64(field)<<3|protoLen)
+	b = binary.AppendUvarint(b, uint64(len(v)))
+	return append(b, v...)
+}
+
+// appendProtoBools appends to b a packed repeated bool field with the given field number and values.
+func appendProtoBools(b []byte, field int, v []bool) []byte {
+	p := make([]byte, len(v))
+	for i, ok := range v {
+		if ok {
+			p[i] = 1
+		}
+	}
+	return appendProtoLen(b, field, p)
+}
+
+// readProtoFields calls fn with the field number, wire type, and value of each field of the message in data,
+// in order. The value of a varint field is v; that of any other field is p, which holds the bytes of a fixed-size field.
+func readProtoFields(data []byte, fn func(field int, typ uint64, v uint64, p []byte) error) error {
+	for len(data) > 0 {
+		tag, n := binary.Uvarint(data)
+		if n <= 0 {
+			return errors.New("malformed protobuf field tag")
+		}
+		data = data[n:]
+		var (
+			v   uint64
+			p   []byte
+			err error
+		)
+		switch typ := tag & 7; typ {
+		case protoVarint:
+			v, data, err = readProtoVarint(data)
+		case protoLen:
+			p, data, err = readProtoLen(data)
+		case protoI64, protoI32:
+			n := 8
+			if typ == protoI32 {
+				n = 4
+			}
+			if len(data) < n {
+				return errors.New("unexpected end of protobuf data")
+			}
+			p, data = data[:n], data[n:]
+		default:
+			return errors.New("unsupported protobuf wire type")
+		}
+		if err != nil {
+			return err
+		}
+		if err := fn(int(tag>>3), tag&7, v, p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readProtoVarint reads a varint from the front of data and returns its value and the remainder of data.
+func readProtoVarint(data []byte) (v uint64, rest []byte, err error) {
+	v, n := binary.Uvarint(data)
+	if n <= 0 {
+		return 0, nil, errors.New("malformed protobuf varint")
+	}
+	return v, data[n:], nil
+}
+
+// readProtoLen reads a length-delimited value from the front of data and returns it and the remainder of data.
+func readProtoLen(data []byte) (v, rest []byte, err error) {
+	l, data, err := readProtoVarint(data)
+	if err != nil {
+		return nil, nil, err
+	}
+	if l > uint64(len(data)) {
+		return nil, nil, errors.New("unexpected end of protobuf data")
+	}
+	return data[:l], data[l:], nil
+}
+
+// readItems returns the values of the repeated bytes field numbered 1 of the message in data.
+func readItems(data []byte) ([][]byte, error) {
+	var items [][]byte
+	err := readProtoFields(data, func(field int, typ uint64, v uint64, p []byte) error {
+		if field != 1 {
+			return nil
+		}
+		if typ != protoLen {
+			return errors.New("unexpected protobuf wire type")
+		}
+		items = append(items, p)
+		return nil
+	})
+	return items, err
+}
+
+// readBools returns the values of the repeated bool field numbered 1 of the message in data,
+// which may be packed or not.
+func readBools(data []byte) ([]bool, error) {
+	var res []bool
+	err := readProtoFields(data, func(field int, typ uint64, v uint64, p []byte) error {
+		if field != 1 {
+			return nil
+		}
+		switch typ {
+		case protoVarint:
+			res = append(res, v != 0)
+		case protoLen:
+			for len(p) > 0 {
+				var err error
+				if v, p, err = readProtoVarint(p); err != nil {
+					return err
+				}
+				res = append(res, v != 0)
+			}
+		default:
+			return errors.New("unexpected protobuf wire type")
+		}
+		return nil
+	})
+	return res, err
+}
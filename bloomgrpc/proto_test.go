@@ -0,0 +1,48 @@
+package bloomgrpc
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestReadItems(t *testing.T) {
+	for _, test := range []struct {
+		data  []byte
+		items [][]byte
+		ok    bool
+	}{
+		{nil, nil, true},
+		{appendProtoLen(appendProtoLen(nil, 1, []byte("a")), 1, nil), [][]byte{[]byte("a"), {}}, true},
+		// Unknown fields of each wire type are skipped.
+		{[]byte{0x10, 1, 0x19, 0, 0, 0, 0, 0, 0, 0, 0, 0x22, 1, 'x', 0x2d, 0, 0, 0, 0, 0x0a, 1, 'b'}, [][]byte{[]byte("b")}, true},
+		{[]byte{0x08, 1}, nil, false},
+		{[]byte{0x0a, 2, 'a'}, nil, false},
+		{[]byte{0x80}, nil, false},
+		{[]byte{0x19, 0}, nil, false},
+		{[]byte{0x0b}, nil, false},
+	} {
+		items, err := readItems(test.data)
+		if (err == nil) != test.ok || test.ok && !reflect.DeepEqual(items, test.items) {
+			t.Errorf("TestReadItems(%x): got %q, %v", test.data, items, err)
+		}
+	}
+}
+
+func TestReadBools(t *testing.T) {
+	for _, test := range []struct {
+		data []byte
+		res  []bool
+		ok   bool
+	}{
+		{nil, nil, true},
+		{appendProtoBools(nil, 1, []bool{true, false, true}), []bool{true, false, true}, true},
+		{[]byte{0x08, 1, 0x08, 0, 0x0a, 1, 1}, []bool{true, false, true}, true},
+		{[]byte{0x0a, 1, 0x80}, nil, false},
+		{[]byte{0x0d, 0, 0, 0, 0}, nil, false},
+	} {
+		res, err := readBools(test.data)
+		if (err == nil) != test.ok || test.ok && !reflect.DeepEqual(res, test.res) {
+			t.Errorf("TestReadBools(%x): got %v, %v", test.data, res, err)
+		}
+	}
+}
@@ -0,0 +1,231 @@
+// Package bloomgrpc implements the FilterService gRPC service defined in bloom.proto,
+// serving a Bloom filter as a membership service to gRPC clients in any language.
+//
+// The Server and Client speak the gRPC protocol over HTTP/2 with package net/http,
+// without depending on the gRPC and protocol buffer modules or on generated code.
+// Messages are not compressed.
+package bloomgrpc
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/dkmccandless/bloom"
+)
+
+// serviceName is the full name of the service, which prefixes the path of each method.
+const serviceName = "bloom.FilterService"
+
+const (
+	// maxMessageSize is the size of the largest request message the Server accepts.
+	maxMessageSize = 64 << 20
+
+	// snapshotChunkSize is the size of the chunks of data in which Snapshot streams a filter.
+	snapshotChunkSize = 1 << 20
+)
+
+// gRPC status codes
+const (
+	codeOK                = 0
+	codeUnknown           = 2
+	codeInvalidArgument   = 3
+	codeResourceExhausted = 8
+	codeUnimplemented     = 12
+	codeInternal          = 13
+)
+
+// An Error is a gRPC status other than OK returned by a call.
+type Error struct {
+	Code    int // the gRPC status code
+	Message string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("grpc status %d: %s", e.Code, e.Message)
+}
+
+// A Server is an http.Handler that serves the FilterService over HTTP/2 for a SyncFilter.
+// It must be served by an http.Server that negotiates HTTP/2, over TLS or, for unencrypted connections,
+// with http.Protocols.SetUnencryptedHTTP2 enabled.
+type Server struct {
+	f *bloom.SyncFilter
+}
+
+// NewServer returns a Server that serves f.
+func NewServer(f *bloom.SyncFilter) *Server {
+	return &Server{f: f}
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost || !strings.HasPrefix(r.Header.Get("Content-Type"), "application/grpc") {
+		http.Error(w, "not a gRPC request", http.StatusUnsupportedMediaType)
+		return
+	}
+	w.Header().Set("Content-Type", "application/grpc")
+	w.Header().Set("Trailer", "Grpc-Status, Grpc-Message")
+	req, err := readMessage(r.Body, maxMessageSize)
+	if err == io.EOF {
+		err = invalid(errors.New("missing request message"))
+	}
+	if err != nil {
+		finish(w, err)
+		return
+	}
+	method, ok := strings.CutPrefix(r.URL.Path, "/"+serviceName+"/")
+	if !ok {
+		method = ""
+	}
+	switch method {
+	case "Insert":
+		err = s.insert(w, req)
+	case "Query":
+		err = s.query(w, req)
+	case "BatchQuery":
+		err = s.batchQuery(w, req)
+	case "Merge":
+		err = s.merge(w, req)
+	case "Snapshot":
+		err = s.snapshot(w)
+	default:
+		err = &Error{codeUnimplemented, "unknown method " + r.URL.Path}
+	}
+	finish(w, err)
+}
+
+func (s *Server) insert(w io.Writer, req []byte) error {
+	items, err := readItems(req)
+	if err != nil {
+		return invalid(err)
+	}
+	for _, item := range items {
+		s.f.Insert(item)
+	}
+	return writeMessage(w, nil)
+}
+
+func (s *Server) query(w io.Writer, req []byte) error {
+	items, err := readItems(req)
+	if err != nil {
+		return invalid(err)
+	}
+	var item []byte
+	if len(items) > 0 {
+		item = items[len(items)-1] // the last value of a singular field wins
+	}
+	var resp []byte
+	if s.f.MaybeContains(item) {
+		resp = []byte{1<<3 | protoVarint, 1}
+	}
+	return writeMessage(w, resp)
+}
+
+func (s *Server) batchQuery(w io.Writer, req []byte) error {
+	items, err := readItems(req)
+	if err != nil {
+		return invalid(err)
+	}
+	res := make([]bool, len(items))
+	for i, item := range items {
+		res[i] = s.f.MaybeContains(item)
+	}
+	return writeMessage(w, appendProtoBools(nil, 1, res))
+}
+
+func (s *Server) merge(w io.Writer, req []byte) error {
+	var fp []byte
+	if err := readProtoFields(req, func(field int, typ uint64, v uint64, p []byte) error {
+		if field == 1 {
+			if typ != protoLen {
+				return errors.New("unexpected protobuf wire type")
+			}
+			fp = p
+		}
+		return nil
+	}); err != nil {
+		return invalid(err)
+	}
+	g := new(bloom.Filter)
+	if err := g.UnmarshalProto(fp); err != nil {
+		return invalid(err)
+	}
+	if err := s.f.Union(g); err != nil {
+		return invalid(err)
+	}
+	return writeMessage(w, nil)
+}
+
+func (s *Server) snapshot(w io.Writer) error {
+	data, err := s.f.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	for len(data) > 0 {
+		n := min(len(data), snapshotChunkSize)
+		if err := writeMessage(w, appendProtoLen(nil, 1, data[:n])); err != nil {
+			return err
+		}
+		data = data[n:]
+	}
+	return nil
+}
+
+// invalid returns an Error with code InvalidArgument that reports err.
+func invalid(err error) error {
+	return &Error{codeInvalidArgument, err.Error()}
+}
+
+// finish sets the trailers of a response to report err, which is nil if the call succeeded.
+func finish(w http.ResponseWriter, err error) {
+	code, msg := codeOK, ""
+	if err != nil {
+		var e *Error
+		if !errors.As(err, &e) {
+			e = &Error{codeInternal, err.Error()}
+		}
+		code, msg = e.Code, e.Message
+	}
+	w.Header().Set("Grpc-Status", fmt.Sprint(code))
+	if msg != "" {
+		w.Header().Set("Grpc-Message", url.PathEscape(msg))
+	}
+}
+
+// writeMessage writes to w the message msg, prefixed in the manner of gRPC by its compression flag and length.
+func writeMessage(w io.Writer, msg []byte) error {
+	var p [5]byte
+	binary.BigEndian.PutUint32(p[1:], uint32(len(msg)))
+	if _, err := w.Write(p[:]); err != nil {
+		return err
+	}
+	_, err := w.Write(msg)
+	return err
+}
+
+// readMessage reads from r a message written by writeMessage of at most max bytes.
+// It returns io.EOF if r holds no further messages.
+func readMessage(r io.Reader, max int) ([]byte, error) {
+	var p [5]byte
+	if _, err := io.ReadFull(r, p[:]); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			return nil, invalid(errors.New("truncated message prefix"))
+		}
+		return nil, err
+	}
+	if p[0] != 0 {
+		return nil, &Error{codeUnimplemented, "compressed messages are not supported"}
+	}
+	n := binary.BigEndian.Uint32(p[1:])
+	if uint64(n) > uint64(max) {
+		return nil, &Error{codeResourceExhausted, "message too large"}
+	}
+	msg := make([]byte, n)
+	if _, err := io.ReadFull(r, msg); err != nil {
+		return nil, invalid(errors.New("truncated message"))
+	}
+	return msg, nil
+}
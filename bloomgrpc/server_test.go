@@ -0,0 +1,135 @@
+package bloomgrpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/dkmccandless/bloom"
+)
+
+// newTestServer returns a started HTTP/2 server of a Server for a new filter, and a Client for it.
+func newTestServer(t *testing.T) (*httptest.Server, *Client) {
+	srv := httptest.NewUnstartedServer(NewServer(bloom.NewSyncFilter(bloom.NewWithHash(1<<14, 4, bloom.BitsAndBlooms))))
+	srv.EnableHTTP2 = true
+	srv.StartTLS()
+	t.Cleanup(srv.Close)
+	return srv, NewClient(srv.URL, srv.Client())
+}
+
+func TestServer(t *testing.T) {
+	_, c := newTestServer(t)
+	ctx := context.Background()
+
+	if err := c.Insert(ctx, []byte("x"), []byte("y")); err != nil {
+		t.Fatalf("TestServer: %v", err)
+	}
+	for item, want := range map[string]bool{"x": true, "y": true, "z": false, "": false} {
+		if got, err := c.MaybeContains(ctx, []byte(item)); err != nil || got != want {
+			t.Errorf("TestServer(%q): got %v, %v, want %v, nil", item, got, err, want)
+		}
+	}
+
+	g := bloom.NewWithHash(1<<14, 4, bloom.BitsAndBlooms)
+	var items [][]byte
+	for i := range 100 {
+		items = append(items, []byte(fmt.Sprint(i)))
+		g.Insert(items[i])
+	}
+	if err := c.Merge(ctx, g); err != nil {
+		t.Errorf("TestServer: %v", err)
+	}
+	got, err := c.ContainsBatch(ctx, append(items, []byte("x"), []byte("absent")))
+	if err != nil {
+		t.Errorf("TestServer: %v", err)
+	}
+	if want := append(slices.Repeat([]bool{true}, 101), false); !slices.Equal(got, want) {
+		t.Errorf("TestServer: ContainsBatch: got %v, want %v", got, want)
+	}
+	if got, err := c.ContainsBatch(ctx, nil); err != nil || len(got) != 0 {
+		t.Errorf("TestServer: ContainsBatch(nil): got %v, %v", got, err)
+	}
+
+	f, err := c.Snapshot(ctx)
+	if err != nil {
+		t.Fatalf("TestServer: %v", err)
+	}
+	for _, item := range append(items, []byte("x"), []byte("y")) {
+		if !f.MaybeContains(item) {
+			t.Errorf("TestServer: Snapshot: item %s not found", item)
+		}
+	}
+
+	// A filter of a different size cannot be merged.
+	var e *Error
+	if err := c.Merge(ctx, bloom.NewWithHash(1<<12, 4, bloom.BitsAndBlooms)); !errors.As(err, &e) || e.Code != codeInvalidArgument {
+		t.Errorf("TestServer: incompatible Merge: got %v, want code %v", err, codeInvalidArgument)
+	}
+}
+
+func TestServerSnapshotChunks(t *testing.T) {
+	srv := httptest.NewUnstartedServer(NewServer(bloom.NewSyncFilter(bloom.NewWithHash(3*snapshotChunkSize*8, 4, bloom.BitsAndBlooms))))
+	srv.EnableHTTP2 = true
+	srv.StartTLS()
+	defer srv.Close()
+	c := NewClient(srv.URL, srv.Client())
+	if err := c.Insert(context.Background(), []byte("10")); err != nil {
+		t.Fatal(err)
+	}
+	f, err := c.Snapshot(context.Background())
+	if err != nil {
+		t.Fatalf("TestServerSnapshotChunks: %v", err)
+	}
+	if f.Size() != 3*snapshotChunkSize*8 || !f.MaybeContains([]byte("10")) {
+		t.Errorf("TestServerSnapshotChunks: got filter of size %v", f.Size())
+	}
+}
+
+func TestServerErrors(t *testing.T) {
+	srv, c := newTestServer(t)
+	ctx := context.Background()
+
+	var e *Error
+	if err := c.call(ctx, "Missing", nil, func([]byte) error { return nil }); !errors.As(err, &e) || e.Code != codeUnimplemented {
+		t.Errorf("TestServerErrors: unknown method: got %v, want code %v", err, codeUnimplemented)
+	}
+
+	// Malformed request messages.
+	for _, req := range [][]byte{{0x0a, 5, 'x'}, {0x08}, {0x0f}} {
+		if err := c.call(ctx, "Insert", req, func([]byte) error { return nil }); !errors.As(err, &e) || e.Code != codeInvalidArgument {
+			t.Errorf("TestServerErrors(%x): got %v, want code %v", req, err, codeInvalidArgument)
+		}
+	}
+
+	// Requests that are not gRPC requests, or lack a message, or whose message is compressed.
+	for _, test := range []struct {
+		contentType, body string
+		status, code      int
+	}{
+		{"application/json", "", http.StatusUnsupportedMediaType, 0},
+		{"application/grpc", "", http.StatusOK, codeInvalidArgument},
+		{"application/grpc", "\x00\x00\x00", http.StatusOK, codeInvalidArgument},
+		{"application/grpc", "\x01\x00\x00\x00\x00", http.StatusOK, codeUnimplemented},
+		{"application/grpc+proto", "\x00\x00\x00\x00\x05", http.StatusOK, codeInvalidArgument},
+	} {
+		resp, err := srv.Client().Post(srv.URL+"/"+serviceName+"/Insert", test.contentType, strings.NewReader(test.body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != test.status {
+			t.Errorf("TestServerErrors(%q, %q): got status %v, want %v", test.contentType, test.body, resp.StatusCode, test.status)
+			continue
+		}
+		if test.status == http.StatusOK {
+			if err := status(resp); !errors.As(err, &e) || e.Code != test.code {
+				t.Errorf("TestServerErrors(%q, %q): got %v, want code %v", test.contentType, test.body, err, test.code)
+			}
+		}
+	}
+}
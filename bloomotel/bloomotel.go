@@ -0,0 +1,64 @@
+// Package bloomotel reports the metrics of Bloom filters in the form of OpenTelemetry metric instruments.
+//
+// It does not depend on the OpenTelemetry modules. Instead, Instruments describes an observable instrument
+// for each metric, and Observe reports the current value of each for a filter, so that a callback registered
+// with an OpenTelemetry Meter records them alongside a service's other metrics:
+//
+//	obs := make(map[string]metric.Float64Observable)
+//	var observables []metric.Observable
+//	for _, in := range bloomotel.Instruments {
+//		opts := []metric.Float64ObservableOption{metric.WithDescription(in.Description), metric.WithUnit(in.Unit)}
+//		if in.Counter {
+//			obs[in.Name], _ = meter.Float64ObservableCounter(in.Name, opts...)
+//		} else {
+//			obs[in.Name], _ = meter.Float64ObservableGauge(in.Name, opts...)
+//		}
+//		observables = append(observables, obs[in.Name])
+//	}
+//	meter.RegisterCallback(func(ctx context.Context, o metric.Observer) error {
+//		bloomotel.Observe(filter, func(in bloomotel.Instrument, v float64) {
+//			o.ObserveFloat64(obs[in.Name], v, metric.WithAttributes(attribute.String("filter", "users")))
+//		})
+//		return nil
+//	}, observables...)
+//
+// The counters report the cumulative numbers of insertions and lookups counted by a SyncFilter
+// created with bloom.WithCounts, and are not observed for other filters.
+package bloomotel
+
+import "github.com/dkmccandless/bloom"
+
+// An Instrument describes an observable metric instrument.
+type Instrument struct {
+	Name        string
+	Description string
+	Unit        string // in the Unified Code for Units of Measure, as OpenTelemetry specifies
+	Counter     bool   // whether the instrument is a monotonic cumulative counter rather than a gauge
+}
+
+// Instruments holds the instruments whose values Observe reports.
+var Instruments = []Instrument{
+	{"bloom.filter.size", "The number of bits in the filter.", "{bit}", false},
+	{"bloom.filter.hash_values", "The number of hash values derived for each item.", "{hash}", false},
+	{"bloom.filter.fill_ratio", "The fraction of the filter's bits that are set.", "1", false},
+	{"bloom.filter.estimated_items", "The estimated number of distinct items inserted.", "{item}", false},
+	{"bloom.filter.false_positive_rate", "The estimated false positive rate.", "1", false},
+	{"bloom.filter.inserts", "The number of insertions.", "{insert}", true},
+	{"bloom.filter.queries", "The number of lookups.", "{query}", true},
+}
+
+// Observe calls observe with each instrument in Instruments and its current value for s,
+// omitting the counters if s does not count insertions and lookups.
+// The estimated number of items is +Inf if every bit of s is set.
+func Observe(s *bloom.SyncFilter, observe func(in Instrument, v float64)) {
+	st := s.Stats()
+	for i, v := range []float64{
+		float64(st.Size), float64(st.HashValues), st.FillRatio, st.EstimatedItems, st.FalsePositiveRate,
+	} {
+		observe(Instruments[i], v)
+	}
+	if inserts, queries, ok := s.Counts(); ok {
+		observe(Instruments[5], float64(inserts))
+		observe(Instruments[6], float64(queries))
+	}
+}
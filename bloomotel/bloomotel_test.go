@@ -0,0 +1,60 @@
+package bloomotel
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/dkmccandless/bloom"
+)
+
+// observed returns the values that Observe reports for s, by instrument name.
+func observed(s *bloom.SyncFilter) map[string]float64 {
+	m := make(map[string]float64)
+	Observe(s, func(in Instrument, v float64) { m[in.Name] = v })
+	return m
+}
+
+func TestObserve(t *testing.T) {
+	s := bloom.NewSyncFilter(bloom.NewWithHash(1024, 3, bloom.BitsAndBlooms), bloom.WithCounts())
+	for _, item := range []string{"a", "b", "c"} {
+		s.Insert([]byte(item))
+	}
+	s.MaybeContains([]byte("a"))
+	st := s.Stats()
+	want := map[string]float64{
+		"bloom.filter.size":                1024,
+		"bloom.filter.hash_values":         3,
+		"bloom.filter.fill_ratio":          st.FillRatio,
+		"bloom.filter.estimated_items":     st.EstimatedItems,
+		"bloom.filter.false_positive_rate": st.FalsePositiveRate,
+		"bloom.filter.inserts":             3,
+		"bloom.filter.queries":             1,
+	}
+	if got := observed(s); !reflect.DeepEqual(got, want) {
+		t.Errorf("TestObserve: got %v, want %v", got, want)
+	}
+
+	// Counters are omitted for a filter that does not count.
+	got := observed(bloom.NewSyncFilter(bloom.New(8, 2)))
+	if len(got) != len(Instruments)-2 || got["bloom.filter.size"] != 64 {
+		t.Errorf("TestObserve(not counting): got %v", got)
+	}
+	if _, ok := got["bloom.filter.inserts"]; ok {
+		t.Errorf("TestObserve(not counting): inserts observed")
+	}
+}
+
+func TestInstruments(t *testing.T) {
+	names := make(map[string]bool)
+	for _, in := range Instruments {
+		if names[in.Name] || in.Description == "" || in.Unit == "" {
+			t.Errorf("TestInstruments: invalid or duplicate instrument %+v", in)
+		}
+		names[in.Name] = true
+	}
+	for _, in := range Instruments[5:] {
+		if !in.Counter {
+			t.Errorf("TestInstruments(%v): not a counter", in.Name)
+		}
+	}
+}
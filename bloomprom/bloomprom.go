@@ -0,0 +1,106 @@
+// Package bloomprom exports the metrics of Bloom filters to Prometheus.
+//
+// A Collector is a prometheus.Collector that reports the metrics of the filters registered with it,
+// so that registering it with a prometheus.Registerer puts the filters on dashboards without further code:
+//
+//	c := bloomprom.New()
+//	c.Register("users", users)
+//	prometheus.MustRegister(c)
+//	http.Handle("/metrics", promhttp.Handler())
+//
+// Each metric carries a label filter="name" giving the name with which its filter was registered:
+//
+//	bloom_filter_size_bits                      the number of bits
+//	bloom_filter_hash_values                    the number of hash values derived for each item
+//	bloom_filter_fill_ratio                     the fraction of bits that are set
+//	bloom_filter_estimated_items                the estimated number of distinct items inserted
+//	bloom_filter_estimated_false_positive_rate  the estimated false positive rate
+//	bloom_filter_inserts_total                  the number of insertions, if counted
+//	bloom_filter_queries_total                  the number of lookups, if counted
+//
+// Insertions and lookups are counted by a SyncFilter created with bloom.WithCounts;
+// the counters of other filters are omitted.
+package bloomprom
+
+import (
+	"sync"
+
+	"github.com/dkmccandless/bloom"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	sizeDesc = prometheus.NewDesc("bloom_filter_size_bits",
+		"The number of bits in the filter.", []string{"filter"}, nil)
+	hashValuesDesc = prometheus.NewDesc("bloom_filter_hash_values",
+		"The number of hash values derived for each item.", []string{"filter"}, nil)
+	fillRatioDesc = prometheus.NewDesc("bloom_filter_fill_ratio",
+		"The fraction of the filter's bits that are set.", []string{"filter"}, nil)
+	estimatedItemsDesc = prometheus.NewDesc("bloom_filter_estimated_items",
+		"The estimated number of distinct items inserted.", []string{"filter"}, nil)
+	falsePositiveRateDesc = prometheus.NewDesc("bloom_filter_estimated_false_positive_rate",
+		"The estimated false positive rate.", []string{"filter"}, nil)
+	insertsDesc = prometheus.NewDesc("bloom_filter_inserts_total",
+		"The number of insertions.", []string{"filter"}, nil)
+	queriesDesc = prometheus.NewDesc("bloom_filter_queries_total",
+		"The number of lookups.", []string{"filter"}, nil)
+)
+
+// A Collector collects the metrics of a set of filters. It implements prometheus.Collector.
+// Its methods may be called concurrently.
+type Collector struct {
+	mu      sync.Mutex
+	filters map[string]*bloom.SyncFilter
+}
+
+// New returns a Collector with no filters registered.
+func New() *Collector {
+	return &Collector{filters: make(map[string]*bloom.SyncFilter)}
+}
+
+// Register adds s to the filters whose metrics c collects, under name.
+// It replaces any filter previously registered under the same name.
+func (c *Collector) Register(name string, s *bloom.SyncFilter) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.filters[name] = s
+}
+
+// Unregister removes the filter registered under name, if any.
+func (c *Collector) Unregister(name string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.filters, name)
+}
+
+// Describe sends the descriptors of the metrics that c collects to ch.
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	for _, d := range []*prometheus.Desc{
+		sizeDesc, hashValuesDesc, fillRatioDesc, estimatedItemsDesc, falsePositiveRateDesc, insertsDesc, queriesDesc,
+	} {
+		ch <- d
+	}
+}
+
+// Collect sends the current metrics of c's filters to ch.
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	c.mu.Lock()
+	filters := make(map[string]*bloom.SyncFilter, len(c.filters))
+	for name, s := range c.filters {
+		filters[name] = s
+	}
+	c.mu.Unlock()
+
+	for name, s := range filters {
+		st := s.Stats()
+		ch <- prometheus.MustNewConstMetric(sizeDesc, prometheus.GaugeValue, float64(st.Size), name)
+		ch <- prometheus.MustNewConstMetric(hashValuesDesc, prometheus.GaugeValue, float64(st.HashValues), name)
+		ch <- prometheus.MustNewConstMetric(fillRatioDesc, prometheus.GaugeValue, st.FillRatio, name)
+		ch <- prometheus.MustNewConstMetric(estimatedItemsDesc, prometheus.GaugeValue, st.EstimatedItems, name)
+		ch <- prometheus.MustNewConstMetric(falsePositiveRateDesc, prometheus.GaugeValue, st.FalsePositiveRate, name)
+		if inserts, queries, ok := s.Counts(); ok {
+			ch <- prometheus.MustNewConstMetric(insertsDesc, prometheus.CounterValue, float64(inserts), name)
+			ch <- prometheus.MustNewConstMetric(queriesDesc, prometheus.CounterValue, float64(queries), name)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package bloomprom
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/dkmccandless/bloom"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestCollector(t *testing.T) {
+	c := New()
+	if n := testutil.CollectAndCount(c); n != 0 {
+		t.Errorf("TestCollector(empty): got %v metrics, want 0", n)
+	}
+
+	counted := bloom.NewSyncFilter(bloom.NewWithHash(64, 1, bloom.BitsAndBlooms), bloom.WithCounts())
+	for _, item := range []string{"a", "b", "a"} {
+		counted.Insert([]byte(item))
+	}
+	counted.MaybeContains([]byte("c"))
+	c.Register(`q"\`, counted)
+	c.Register("empty", bloom.NewSyncFilter(bloom.New(8, 2)))
+	c.Register("removed", bloom.NewSyncFilter(bloom.New(8, 2)))
+	c.Unregister("removed")
+
+	st := counted.Stats()
+	want := `# HELP bloom_filter_size_bits The number of bits in the filter.
+# TYPE bloom_filter_size_bits gauge
+bloom_filter_size_bits{filter="empty"} 64
+bloom_filter_size_bits{filter="q\"\\"} 64
+# HELP bloom_filter_hash_values The number of hash values derived for each item.
+# TYPE bloom_filter_hash_values gauge
+bloom_filter_hash_values{filter="empty"} 2
+bloom_filter_hash_values{filter="q\"\\"} 1
+# HELP bloom_filter_fill_ratio The fraction of the filter's bits that are set.
+# TYPE bloom_filter_fill_ratio gauge
+bloom_filter_fill_ratio{filter="empty"} 0
+bloom_filter_fill_ratio{filter="q\"\\"} ` + formatFloat(st.FillRatio) + `
+# HELP bloom_filter_estimated_items The estimated number of distinct items inserted.
+# TYPE bloom_filter_estimated_items gauge
+bloom_filter_estimated_items{filter="empty"} 0
+bloom_filter_estimated_items{filter="q\"\\"} ` + formatFloat(st.EstimatedItems) + `
+# HELP bloom_filter_estimated_false_positive_rate The estimated false positive rate.
+# TYPE bloom_filter_estimated_false_positive_rate gauge
+bloom_filter_estimated_false_positive_rate{filter="empty"} 0
+bloom_filter_estimated_false_positive_rate{filter="q\"\\"} ` + formatFloat(st.FalsePositiveRate) + `
+# HELP bloom_filter_inserts_total The number of insertions.
+# TYPE bloom_filter_inserts_total counter
+bloom_filter_inserts_total{filter="q\"\\"} 3
+# HELP bloom_filter_queries_total The number of lookups.
+# TYPE bloom_filter_queries_total counter
+bloom_filter_queries_total{filter="q\"\\"} 1
+`
+	if err := testutil.CollectAndCompare(c, strings.NewReader(want)); err != nil {
+		t.Errorf("TestCollector: %v", err)
+	}
+	if err := prometheus.NewPedanticRegistry().Register(c); err != nil {
+		t.Errorf("TestCollector: Register: %v", err)
+	}
+}
+
+// formatFloat formats v as a sample value in the text exposition format.
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
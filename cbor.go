@@ -0,0 +1,140 @@
+package bloom
+
+import (
+	"encoding/binary"
+)
+
+// CBOR major types (RFC 8949, section 3.1), shifted into the high-order bits of an initial byte
+const (
+	cborUint  = 0 << 5
+	cborBytes = 2 << 5
+	cborArray = 4 << 5
+	cborTag   = 6 << 5
+)
+
+// cborFilterTag is the tag of the CBOR form of a Filter: the magic number "BLMF" as a big-endian integer,
+// in the range of tags allocated first come, first served.
+const cborFilterTag = 0x424c4d46
+
+// MarshalCBOR marshals f into a CBOR data item: an array of four elements, the number of hash values
+// as an unsigned integer, the filter's bits as a byte string, the hash algorithm as an unsigned integer,
+// and the filter's size in bits as an unsigned integer, tagged with the tag number 0x424c4d46 ("BLMF").
+// It satisfies the Marshaler interfaces of common CBOR packages.
+func (f *Filter) MarshalCBOR() ([]byte, error) {
+	l := (f.m + 7) / 8
+	b := make([]byte, 0, 5+1+9+9+l+9+9)
+	b = appendCBORHead(b, cborTag, cborFilterTag)
+	b = append(b, cborArray|4)
+	b = appendCBORHead(b, cborUint, uint64(f.k))
+	b = appendCBORHead(b, cborBytes, uint64(l))
+	b = f.appendBits(b, 0, l)
+	b = appendCBORHead(b, cborUint, uint64(f.hash))
+	b = appendCBORHead(b, cborUint, uint64(f.m))
+	return b, nil
+}
+
+// UnmarshalCBOR unmarshals a CBOR data item produced by MarshalCBOR and stores the result in f.
+// It also accepts the untagged array of two elements, the number of hash values and the bits,
+// written by earlier versions of this package for filters that use SHA256.
+// It validates the filter size and number of hash values in the same manner as UnmarshalBinary,
+// and returns an error without modifying the contents of f if data is not a well-formed encoding of a Filter.
+// It satisfies the Unmarshaler interfaces of common CBOR packages.
+func (f *Filter) UnmarshalCBOR(data []byte) error {
+	major, n, data, err := readCBORHead(data)
+	if err != nil {
+		return err
+	}
+	tagged := major == cborTag
+	if tagged {
+		if n != cborFilterTag {
+			return newError(ErrUnsupported, "unsupported CBOR tag")
+		}
+		if major, n, data, err = readCBORHead(data); err != nil {
+			return err
+		}
+	}
+	if major != cborArray || n != 4 && (tagged || n != 2) {
+		return newError(ErrCorrupt, "CBOR data is not an array of four elements")
+	}
+	fields := n
+	major, k, data, err := readCBORHead(data)
+	if err != nil {
+		return err
+	}
+	if major != cborUint {
+		return newError(ErrCorrupt, "CBOR number of hash values is not an unsigned integer")
+	}
+	major, n, data, err = readCBORHead(data)
+	if err != nil {
+		return err
+	}
+	if major != cborBytes {
+		return newError(ErrCorrupt, "CBOR filter is not a byte string")
+	}
+	if n > uint64(len(data)) {
+		return newError(ErrTruncated, "unexpected end of CBOR data")
+	}
+	b, data := data[:n], data[n:]
+	h := header{hashAlgorithm: byte(SHA256), k: byte(k), m: n * 8}
+	if fields == 4 {
+		var hash uint64
+		for _, v := range []*uint64{&hash, &h.m} {
+			if major, *v, data, err = readCBORHead(data); err != nil {
+				return err
+			}
+			if major != cborUint {
+				return newError(ErrCorrupt, "CBOR hash algorithm or size is not an unsigned integer")
+			}
+		}
+		if hash >= uint64(len(hashNames)) {
+			return newError(ErrUnsupported, "unsupported hash algorithm")
+		}
+		h.hashAlgorithm = byte(hash)
+	}
+	if len(data) != 0 {
+		return newError(ErrCorrupt, "unexpected data after CBOR item")
+	}
+	return f.loadChecked(h, uint64(k), b)
+}
+
+// appendCBORHead appends to b the initial byte and argument of a data item of the given major type,
+// using the shortest encoding of n.
+func appendCBORHead(b []byte, major byte, n uint64) []byte {
+	switch {
+	case n < 24:
+		return append(b, major|byte(n))
+	case n <= 0xff:
+		return append(b, major|24, byte(n))
+	case n <= 0xffff:
+		return binary.BigEndian.AppendUint16(append(b, major|25), uint16(n))
+	case n <= 0xffffffff:
+		return binary.BigEndian.AppendUint32(append(b, major|26), uint32(n))
+	default:
+		return binary.BigEndian.AppendUint64(append(b, major|27), n)
+	}
+}
+
+// readCBORHead reads the initial byte and argument of a data item from the front of data.
+// It returns the major type, the argument, and the remainder of data.
+// Indefinite-length items are not supported.
+func readCBORHead(data []byte) (major byte, n uint64, rest []byte, err error) {
+	if len(data) == 0 {
+		return 0, 0, nil, newError(ErrTruncated, "unexpected end of CBOR data")
+	}
+	major, info := data[0]&0xe0, data[0]&0x1f
+	data = data[1:]
+	if info < 24 {
+		return major, uint64(info), data, nil
+	}
+	if info > 27 {
+		return 0, 0, nil, newError(ErrUnsupported, "unsupported CBOR argument encoding")
+	}
+	l := 1 << (info - 24)
+	if len(data) < l {
+		return 0, 0, nil, newError(ErrTruncated, "unexpected end of CBOR data")
+	}
+	for _, c := range data[:l] {
+		n = n<<8 | uint64(c)
+	}
+	return major, n, data[l:], nil
+}
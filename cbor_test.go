@@ -0,0 +1,82 @@
+package bloom
+
+import (
+	"reflect"
+	"testing"
+)
+
+// cborTagHead is the encoding of the tag of the CBOR form of a Filter.
+var cborTagHead = []byte{0xda, 'B', 'L', 'M', 'F'}
+
+var cborTests = []struct {
+	f    *Filter
+	data []byte
+}{
+	{New(1, 1), append(cborTagHead, 0x84, 0x01, 0x41, 0, 0x00, 0x08)},
+	{New(4, 3), append(cborTagHead, 0x84, 0x03, 0x44, 0, 0, 0, 0, 0x00, 0x18, 0x20)},
+	{filter(16, 15, 23), append(cborTagHead, 0x84, 0x10, 0x42, 15, 23, 0x00, 0x10)},
+	{New(32, 8), append(append(append(cborTagHead, 0x84, 0x08, 0x58, 0x20), make([]byte, 32)...), 0x00, 0x19, 0x01, 0x00)},
+	{New(256, 8), append(append(append(cborTagHead, 0x84, 0x08, 0x59, 0x01, 0x00), make([]byte, 256)...), 0x00, 0x19, 0x08, 0x00)},
+	{NewWithHash(100, 3, BitsAndBlooms), append(append(append(cborTagHead, 0x84, 0x03, 0x4d), make([]byte, 13)...), 0x01, 0x18, 0x64)},
+}
+
+func TestMarshalCBOR(t *testing.T) {
+	for _, test := range cborTests {
+		data, err := test.f.MarshalCBOR()
+		if err != nil {
+			t.Errorf("TestMarshalCBOR: %v", err)
+		}
+		if !reflect.DeepEqual(data, test.data) {
+			t.Errorf("TestMarshalCBOR: got %v, want %v", data, test.data)
+		}
+	}
+}
+
+func TestUnmarshalCBOR(t *testing.T) {
+	for _, test := range append(cborTests, []struct {
+		f    *Filter
+		data []byte
+	}{
+		// The untagged form of two elements written by earlier versions.
+		{New(1, 1), []byte{0x82, 0x01, 0x41, 0}},
+		{filter(16, 15, 23), []byte{0x82, 0x10, 0x42, 15, 23}},
+		// An untagged form of four elements.
+		{New(1, 1), []byte{0x84, 0x01, 0x41, 0, 0x00, 0x08}},
+	}...) {
+		f := new(Filter)
+		if err := f.UnmarshalCBOR(test.data); err != nil {
+			t.Errorf("TestUnmarshalCBOR(%v): %v", test.data, err)
+		}
+		if !reflect.DeepEqual(f, test.f) {
+			t.Errorf("TestUnmarshalCBOR(%v): got %v, want %v", test.data, f, test.f)
+		}
+	}
+	for _, data := range [][]byte{
+		nil,
+		{0x82},
+		{0x83, 0x01, 0x41, 0},                    // three-element array
+		{0x82, 0x41, 0, 0x01},                    // elements out of order
+		{0x82, 0x01, 0x42, 0},                    // truncated byte string
+		{0x82, 0x01, 0x43, 0, 0, 0},              // size not a power of 2
+		{0x82, 0x00, 0x41, 0},                    // no hash values
+		{0x82, 0x11, 0x41, 0},                    // too many hash values
+		{0x82, 0x01, 0x5f, 0x41, 0},              // indefinite-length byte string
+		{0x82, 0x01, 0x41, 0, 0xff},              // trailing data
+		append(cborTagHead, 0x82, 0x01, 0x41, 0), // tagged two-element array
+		{0xda, 'B', 'L', 'M', 'G', 0x84, 0x01, 0x41, 0, 0x00, 0x08},      // another tag
+		append(cborTagHead, 0x84, 0x01, 0x41, 0, 0x09, 0x08),             // unsupported hash algorithm
+		append(cborTagHead, 0x84, 0x01, 0x41, 0, 0x19, 0x01, 0x00, 0x08), // hash algorithm out of range
+		append(cborTagHead, 0x84, 0x01, 0x41, 0, 0x00, 0x10),             // size does not match bits
+		append(cborTagHead, 0x84, 0x01, 0x41, 0x80, 0x01, 0x07),          // bit set beyond size
+		append(cborTagHead, 0x84, 0x01, 0x41, 0, 0x00, 0x41, 0),          // size not an integer
+		append(cborTagHead, 0x84, 0x01, 0x41, 0, 0x00),                   // missing size
+	} {
+		f := New(1, 1)
+		if err := f.UnmarshalCBOR(data); err == nil {
+			t.Errorf("TestUnmarshalCBOR(%v): got nil error", data)
+		}
+		if !reflect.DeepEqual(f, New(1, 1)) {
+			t.Errorf("TestUnmarshalCBOR(%v): modified filter to %v", data, f)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package bloom
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// probeAlgorithms describes in C terms how each hash algorithm derives the position of probe i, counting from 0, of an item.
+var probeAlgorithms = map[Hash]string{
+	SHA256: `d is the SHA-256 hash of the item.
+   The position of probe i is ((d[2*i] << 8) | d[2*i+1]) & (BITS - 1).`,
+	BitsAndBlooms: `h0 and h1 are the first and second little-endian uint64_t halves of the 128-bit x64 MurmurHash3,
+   with seed 0, of the item, and h2 and h3 are those of the item followed by a byte of value 1.
+   With h[] = {h0, h1, h2, h3}, the position of probe i is
+   (h[i % 2] + i * h[2 + (i + i % 2) % 4 / 2]) % BITS in uint64_t arithmetic.`,
+	GuavaMitz32: `h is the first little-endian uint64_t half of the 128-bit x64 MurmurHash3, with seed 0, of the item.
+   With a = (int32_t)h and b = (int32_t)(h >> 32), let c = a + (i + 1) * b in wrapping int32_t arithmetic,
+   and c = ~c if c < 0. The position of probe i is c % BITS.`,
+	GuavaMitz64: `h0 and h1 are the little-endian uint64_t halves of the 128-bit x64 MurmurHash3, with seed 0, of the item.
+   The position of probe i is ((h0 + i * h1) & INT64_MAX) % BITS in uint64_t arithmetic.`,
+	RedisBloom: `a is the MurmurHash64A of the item with seed 0xc6a4a7935bd1e995, and b is that with seed a.
+   The position of probe i is (a + i * b) % BITS in uint64_t arithmetic.`,
+	ParquetSBBF: `h is the xxHash64, with seed 0, of the item. With block = ((h >> 32) * (BITS / 256)) >> 32,
+   key = (uint32_t)h, and salt[] = {0x47b6137b, 0x44974d91, 0x8824ad5b, 0xa2b7289d,
+   0x705495c7, 0x2df1424b, 0x9efc4947, 0x5c6bfb31}, the position of probe i is
+   block * 256 + i * 32 + (uint32_t)(key * salt[i]) >> 27.`,
+}
+
+// WriteCHeader writes f to w as a C header file that defines the filter's bits as an array of bytes
+// and its parameters as macros, so that programs written in C, such as firmware for embedded targets,
+// can query filters built in Go. Identifiers in the header begin with name, which must be a valid C identifier.
+// A comment in the header documents how to derive the positions of an item's bits.
+func (f *Filter) WriteCHeader(w io.Writer, name string) error {
+	if !isCIdentifier(name) {
+		return errors.New("invalid C identifier")
+	}
+	upper := strings.ToUpper(name)
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "/* Code generated by github.com/dkmccandless/bloom. DO NOT EDIT. */\n\n")
+	fmt.Fprintf(bw, "#ifndef %s_BLOOM_H\n#define %s_BLOOM_H\n\n#include <stdint.h>\n\n", upper, upper)
+	fmt.Fprintf(bw, "/* %s is a Bloom filter of %s_BITS bits using %s_HASHES hash values.\n", name, upper, upper)
+	fmt.Fprintf(bw, "   An item may be in the filter if the bits at each of its positions are set,\n")
+	fmt.Fprintf(bw, "   where bit n is (%s[n / 8] >> (n %% 8)) & 1.\n", name)
+	fmt.Fprintf(bw, "   %s */\n", strings.ReplaceAll(probeAlgorithms[f.hash], "BITS", upper+"_BITS"))
+	fmt.Fprintf(bw, "#define %s_BITS %dULL\n", upper, f.m)
+	fmt.Fprintf(bw, "#define %s_HASHES %d\n\n", upper, f.k)
+	b := f.bytes()
+	fmt.Fprintf(bw, "static const uint8_t %s[%d] = {", name, len(b))
+	for i, c := range b {
+		if i%12 == 0 {
+			bw.WriteString("\n\t")
+		} else {
+			bw.WriteByte(' ')
+		}
+		fmt.Fprintf(bw, "0x%02x,", c)
+	}
+	fmt.Fprintf(bw, "\n};\n\n#endif /* %s_BLOOM_H */\n", upper)
+	return bw.Flush()
+}
+
+// isCIdentifier reports whether s is a valid C identifier.
+func isCIdentifier(s string) bool {
+	if s == "" || s[0] >= '0' && s[0] <= '9' {
+		return false
+	}
+	for _, c := range s {
+		if !(c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,52 @@
+package bloom
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestWriteCHeader(t *testing.T) {
+	f := New(16, 3)
+	f.Insert([]byte("abc"))
+	var buf bytes.Buffer
+	if err := f.WriteCHeader(&buf, "allow"); err != nil {
+		t.Fatalf("TestWriteCHeader: %v", err)
+	}
+	want := `/* Code generated by github.com/dkmccandless/bloom. DO NOT EDIT. */
+
+#ifndef ALLOW_BLOOM_H
+#define ALLOW_BLOOM_H
+
+#include <stdint.h>
+
+/* allow is a Bloom filter of ALLOW_BITS bits using ALLOW_HASHES hash values.
+   An item may be in the filter if the bits at each of its positions are set,
+   where bit n is (allow[n / 8] >> (n % 8)) & 1.
+   d is the SHA-256 hash of the item.
+   The position of probe i is ((d[2*i] << 8) | d[2*i+1]) & (ALLOW_BITS - 1). */
+#define ALLOW_BITS 128ULL
+#define ALLOW_HASHES 3
+
+static const uint8_t allow[16] = {
+	0x02, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x80, 0x00, 0x00, 0x00, 0x00,
+	0x00, 0x00, 0x00, 0x01,
+};
+
+#endif /* ALLOW_BLOOM_H */
+`
+	if got := buf.String(); got != want {
+		t.Errorf("TestWriteCHeader: got\n%s\nwant\n%s", got, want)
+	}
+
+	for _, name := range []string{"", "1st", "a-b", "é"} {
+		if err := f.WriteCHeader(new(bytes.Buffer), name); err == nil {
+			t.Errorf("TestWriteCHeader(%q): got nil error", name)
+		}
+	}
+
+	for h := SHA256; checkParams(h, 64, 1) == nil; h++ {
+		if probeAlgorithms[h] == "" {
+			t.Errorf("TestWriteCHeader: no probe algorithm for %v", h)
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package bloom
+
+import (
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"io"
+)
+
+// The chunked form of a Filter allows large filters to be transferred incrementally with bounded buffering.
+// It begins with the same header as the binary form produced by MarshalBinary, with flagChunked set in its flags,
+// followed by the CRC-32C checksum of the header.
+// Each subsequent chunk consists of the following fields, with integers in big-endian byte order:
+//
+//	offset uint64    offset in bytes of the chunk's data within the filter's bits
+//	length uint32    length of the chunk's data in bytes
+//	data   [length]byte
+//	crc    uint32    CRC-32C checksum of offset, length, and data
+//
+// Chunks are written in order of increasing offset, and the chunked form ends with the chunk that contains the filter's last byte.
+// Because each chunk records its offset, a transfer that is interrupted can be resumed
+// by writing the chunked form again from the offset at which the reader stopped.
+const chunkHeaderSize = 8 + 4
+
+// WriteChunks writes to w the chunked form of f, beginning with the chunk at byte offset off of f's bits
+// and dividing the remainder into chunks of at most size bytes.
+// Pass an offset of 0 to write the entire filter, or the value reported by a ChunkReader's Offset method
+// to resume an interrupted transfer.
+// It returns the number of bytes written and any error encountered.
+func (f *Filter) WriteChunks(w io.Writer, off int64, size int) (int64, error) {
+	l := int64((f.m + 7) / 8)
+	if off < 0 || off > l {
+		return 0, errors.New("chunk offset out of range")
+	}
+	if size <= 0 || int64(size) > 1<<32-1 {
+		return 0, errors.New("chunk size out of range")
+	}
+	h := f.header()
+	h.flags |= flagChunked
+	b := appendHeader(make([]byte, 0, headerSize+crc32.Size), h)
+	cw := &countWriter{w: w}
+	cw.Write(binary.BigEndian.AppendUint32(b, crc32.Checksum(b, castagnoli)))
+	ch := make([]byte, chunkHeaderSize)
+	buf := make([]byte, 0, min(int64(size), l, pageWords*8))
+	for off < l && cw.err == nil {
+		end := min(off+int64(size), l)
+		binary.BigEndian.PutUint64(ch, uint64(off))
+		binary.BigEndian.PutUint32(ch[8:], uint32(end-off))
+		crc := crc32.Checksum(ch, castagnoli)
+		cw.Write(ch)
+		// The chunk's data is read from f's pages and written a page at a time, however large the chunk.
+		for ; off < end && cw.err == nil; off += int64(len(buf)) {
+			buf = f.appendBits(buf[:0], int(off), int(min(off+pageWords*8, end)))
+			crc = crc32.Update(crc, castagnoli, buf)
+			cw.Write(buf)
+		}
+		cw.Write(binary.BigEndian.AppendUint32(nil, crc))
+	}
+	return cw.n, cw.err
+}
+
+// A ChunkReader assembles a Filter from its chunked form, which may be read from one or more streams.
+// The zero value is ready to use.
+type ChunkReader struct {
+	h    header
+	size int64  // the size of the filter's bits in bytes, or 0 if no header has been read
+	b    []byte // the bits read so far, which grow as chunks are read, so that a header's size is not trusted
+	off  int64
+}
+
+// ReadFrom reads the chunked form of a Filter from r until it has read the filter's last chunk,
+// and returns the number of bytes read and any error encountered.
+// If an error interrupts the transfer, ReadFrom may be called again with a stream that resumes from the offset
+// reported by Offset or earlier; its header must describe the same filter.
+// ReadFrom satisfies the io.ReaderFrom interface.
+func (c *ChunkReader) ReadFrom(r io.Reader) (int64, error) {
+	cr := &countReader{r: r}
+	hb := make([]byte, headerSize+crc32.Size)
+	if _, err := io.ReadFull(cr, hb); err != nil {
+		return cr.n, eofError(err)
+	}
+	if crc32.Checksum(hb[:headerSize], castagnoli) != binary.BigEndian.Uint32(hb[headerSize:]) {
+		return cr.n, newError(ErrCorrupt, "header checksum mismatch")
+	}
+	h, _, err := readHeader(hb)
+	if err != nil {
+		return cr.n, err
+	}
+	if h.flags&flagChunked == 0 {
+		return cr.n, newError(ErrUnsupported, "data is not in chunked form")
+	}
+	switch {
+	case c.size == 0:
+		c.h, c.size = h, int64((h.m+7)/8)
+	case h != c.h:
+		return cr.n, newError(ErrIncompatible, "header does not match previously read header")
+	}
+	ch := make([]byte, chunkHeaderSize)
+	cb := make([]byte, crc32.Size)
+	var data []byte
+	for c.off < c.size {
+		if _, err := io.ReadFull(cr, ch); err != nil {
+			return cr.n, eofError(err)
+		}
+		off, l := binary.BigEndian.Uint64(ch), binary.BigEndian.Uint32(ch[8:])
+		if off > uint64(c.off) || l == 0 || uint64(l) > uint64(c.size)-off {
+			return cr.n, newError(ErrCorrupt, "chunk out of range")
+		}
+		// Buffer the data until it is verified, so that a corrupted chunk cannot overwrite verified data.
+		var err error
+		if data, err = readDense(cr, data, uint64(l)); err != nil {
+			return cr.n, eofError(err)
+		}
+		if _, err := io.ReadFull(cr, cb); err != nil {
+			return cr.n, eofError(err)
+		}
+		if crc32.Update(crc32.Checksum(ch, castagnoli), castagnoli, data) != binary.BigEndian.Uint32(cb) {
+			return cr.n, newError(ErrCorrupt, "chunk checksum mismatch")
+		}
+		// Chunks begin at or before c.off, which is the length of c.b.
+		n := copy(c.b[off:], data)
+		c.b = append(c.b, data[n:]...)
+		c.off = int64(len(c.b))
+	}
+	return cr.n, nil
+}
+
+// Offset returns the number of bytes of the filter's bits that have been read and verified.
+func (c *ChunkReader) Offset() int64 { return c.off }
+
+// Size returns the size of the filter in bytes, or 0 if no header has been read.
+func (c *ChunkReader) Size() int64 { return c.size }
+
+// Filter returns the assembled Filter.
+// It returns an error if the filter's last chunk has not been read.
+func (c *ChunkReader) Filter() (*Filter, error) {
+	if c.size == 0 || c.off < c.size {
+		return nil, newError(ErrTruncated, "incomplete chunked data")
+	}
+	if err := checkPadding(c.b, c.h.m); err != nil {
+		return nil, err
+	}
+	f := new(Filter)
+	f.load(c.h, c.b)
+	return f, nil
+}
@@ -0,0 +1,149 @@
+package bloom
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"io"
+	"reflect"
+	"runtime"
+	"testing"
+)
+
+func TestChunks(t *testing.T) {
+	f := New(8192, 8)
+	for i := 0; i < 1000; i++ {
+		f.Insert([]byte{byte(i), byte(i >> 8)})
+	}
+	for _, size := range []int{1, 100, 1000, 4096, 8192, 10000} {
+		var buf bytes.Buffer
+		n, err := f.WriteChunks(&buf, 0, size)
+		if err != nil {
+			t.Errorf("TestChunks(%v): %v", size, err)
+		}
+		if n != int64(buf.Len()) {
+			t.Errorf("TestChunks(%v): wrote %v bytes, reported %v", size, buf.Len(), n)
+		}
+		var c ChunkReader
+		if _, err := c.ReadFrom(&buf); err != nil {
+			t.Errorf("TestChunks(%v): %v", size, err)
+		}
+		if c.Offset() != 8192 || c.Size() != 8192 {
+			t.Errorf("TestChunks(%v): got offset %v, size %v, want 8192, 8192", size, c.Offset(), c.Size())
+		}
+		g, err := c.Filter()
+		if err != nil {
+			t.Errorf("TestChunks(%v): %v", size, err)
+		}
+		if !reflect.DeepEqual(g, f) {
+			t.Errorf("TestChunks(%v): got %v, want %v", size, g, f)
+		}
+	}
+}
+
+// failWriter writes to w until n bytes have been written, and then fails.
+type failWriter struct {
+	w io.Writer
+	n int
+}
+
+func (fw *failWriter) Write(p []byte) (int, error) {
+	if len(p) > fw.n {
+		n, _ := fw.w.Write(p[:fw.n])
+		fw.n = 0
+		return n, errors.New("connection lost")
+	}
+	fw.n -= len(p)
+	return fw.w.Write(p)
+}
+
+func TestChunksResume(t *testing.T) {
+	f := New(8192, 8)
+	for i := 0; i < 1000; i++ {
+		f.Insert([]byte{byte(i), byte(i >> 8)})
+	}
+	var c ChunkReader
+	for _, cut := range []int{100, 3000, 5000, 1 << 20} {
+		var buf bytes.Buffer
+		f.WriteChunks(&failWriter{&buf, cut}, c.Offset(), 1000)
+		c.ReadFrom(&buf)
+	}
+	g, err := c.Filter()
+	if err != nil {
+		t.Fatalf("TestChunksResume: %v", err)
+	}
+	if !reflect.DeepEqual(g, f) {
+		t.Errorf("TestChunksResume: got %v, want %v", g, f)
+	}
+}
+
+func TestChunkReaderErrors(t *testing.T) {
+	chunked := func(f *Filter) []byte {
+		var buf bytes.Buffer
+		f.WriteChunks(&buf, 0, 2)
+		return buf.Bytes()
+	}
+	data := chunked(New(4, 1))
+	for _, test := range []struct {
+		data []byte
+		off  int64
+	}{
+		{nil, 0},
+		{binaryOf(1, 0, 0, 0, 0), 0},  // not chunked
+		{withByte(data, 7, 2), 0},     // corrupted header
+		{withByte(data, 40, 0xff), 0}, // corrupted first chunk
+		{withByte(data, 58, 0xff), 2}, // corrupted second chunk
+		{data[:50], 2},                // truncated second chunk
+		{withByte(data, 39, 5), 0},    // chunk past end of filter
+		{withByte(data, 53, 4), 2},    // chunk beyond what has been read
+	} {
+		var c ChunkReader
+		if _, err := c.ReadFrom(bytes.NewReader(test.data)); err == nil {
+			t.Errorf("TestChunkReaderErrors(%v): got nil error", test.data)
+		}
+		if c.Offset() != test.off {
+			t.Errorf("TestChunkReaderErrors(%v): got offset %v, want %v", test.data, c.Offset(), test.off)
+		}
+		if _, err := c.Filter(); err == nil {
+			t.Errorf("TestChunkReaderErrors(%v): got nil error from incomplete filter", test.data)
+		}
+	}
+
+	// A resumed transfer must describe the same filter.
+	var c ChunkReader
+	c.ReadFrom(bytes.NewReader(data[:50]))
+	if _, err := c.ReadFrom(bytes.NewReader(chunked(New(4, 2)))); err == nil {
+		t.Errorf("TestChunkReaderErrors: got nil error from mismatched header")
+	}
+
+	f := New(1, 1)
+	if err := f.UnmarshalBinary(data); err == nil {
+		t.Errorf("TestChunkReaderErrors: UnmarshalBinary accepted chunked data")
+	}
+}
+
+func TestChunkReaderHugeSize(t *testing.T) {
+	h := binaryHeader(1, flagChunked, 0)
+	h[5] = byte(BitsAndBlooms)
+	binary.BigEndian.PutUint64(h[16:], 1<<40)
+	data := binary.BigEndian.AppendUint32(h, crc32.Checksum(h, castagnoli))
+	data = binary.BigEndian.AppendUint64(data, 0)
+	data = binary.BigEndian.AppendUint32(data, 1<<32-1)
+	data = append(data, 1, 2, 3)
+
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	var c ChunkReader
+	_, err := c.ReadFrom(bytes.NewReader(data))
+	runtime.ReadMemStats(&after)
+	if !errors.Is(err, ErrTruncated) {
+		t.Errorf("TestChunkReaderHugeSize: got %v, want %v", err, ErrTruncated)
+	}
+	if n := after.TotalAlloc - before.TotalAlloc; n > 8<<20 {
+		t.Errorf("TestChunkReaderHugeSize: allocated %v bytes", n)
+	}
+	if c.Size() != 1<<37 || c.Offset() != 0 {
+		t.Errorf("TestChunkReaderHugeSize: got size %v, offset %v", c.Size(), c.Offset())
+	}
+}
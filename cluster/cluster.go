@@ -0,0 +1,267 @@
+// Package cluster provides a client that partitions a Bloom filter's items among several remote filter servers
+// by consistent hashing, so that the capacity of the filter as a whole can exceed the memory of any one server.
+//
+// Each server, or node, owns the items that hash to the arcs of a ring that precede its points on the ring.
+// When a node is added or removed, only the items of the arcs that change owner are routed to a different node.
+// Because a Bloom filter cannot enumerate its items, those items are not moved; instead, the Client retains
+// the ring as it was before each change and consults the items' previous owners as well, until Settle is called.
+// Settle should be called once the items inserted before the changes have expired or been inserted again.
+package cluster
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"slices"
+	"strconv"
+	"sync"
+)
+
+// A Node is a remote filter server. *httpclient.Client satisfies it.
+type Node interface {
+	// InsertBatch inserts every item of items into the node's set.
+	InsertBatch(ctx context.Context, items [][]byte) error
+
+	// ContainsBatch reports, for each item of items, whether it is probably in the node's set.
+	ContainsBatch(ctx context.Context, items [][]byte) ([]bool, error)
+}
+
+// A Client routes items among the nodes of a cluster. It is safe for concurrent use by multiple goroutines.
+type Client struct {
+	vnodes int
+
+	mu  sync.RWMutex
+	cur *ring
+	old []*ring // the rings before each change since the last Settle, most recent first
+}
+
+// A ring is an immutable assignment of the ring's points to nodes.
+type ring struct {
+	points []point // in increasing order of hash
+	nodes  map[string]Node
+}
+
+// A point is a position on the ring, owned by a node.
+type point struct {
+	hash uint64
+	name string
+}
+
+// New returns a Client for a cluster with no nodes, that places each node at vnodes points on the ring.
+// More points divide items among nodes more evenly; a few hundred suffice for a spread within a few percent.
+// It panics if vnodes is not positive.
+func New(vnodes int) *Client {
+	if vnodes <= 0 {
+		panic("cluster: number of points per node out of range")
+	}
+	return &Client{vnodes: vnodes, cur: &ring{nodes: map[string]Node{}}}
+}
+
+// Add adds the node n to the cluster under name, replacing any node of the same name.
+// Items that were routed to other nodes before the change continue to be found there until Settle is called.
+func (c *Client) Add(name string, n Node) {
+	c.change(func(nodes map[string]Node) { nodes[name] = n })
+}
+
+// Remove removes the node of the given name from the cluster, if present.
+// Its items continue to be found on it until Settle is called.
+func (c *Client) Remove(name string) {
+	c.change(func(nodes map[string]Node) { delete(nodes, name) })
+}
+
+// change replaces c's ring with one whose nodes are modified by fn, and retains the previous ring.
+func (c *Client) change(fn func(map[string]Node)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	r := &ring{nodes: make(map[string]Node, len(c.cur.nodes)+1)}
+	for name, n := range c.cur.nodes {
+		r.nodes[name] = n
+	}
+	fn(r.nodes)
+	for name := range r.nodes {
+		for i := range c.vnodes {
+			r.points = append(r.points, point{hashBytes([]byte(name + "#" + strconv.Itoa(i))), name})
+		}
+	}
+	slices.SortFunc(r.points, func(a, b point) int {
+		if a.hash != b.hash {
+			return cmp.Compare(a.hash, b.hash)
+		}
+		return cmp.Compare(a.name, b.name)
+	})
+	if len(c.cur.nodes) > 0 {
+		c.old = append([]*ring{c.cur}, c.old...)
+	}
+	c.cur = r
+}
+
+// Settle discards the rings retained from before the changes to the cluster,
+// so that each item is sought only on the node to which it is now routed.
+func (c *Client) Settle() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.old = nil
+}
+
+// Nodes returns the names of the cluster's nodes in increasing order.
+func (c *Client) Nodes() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	names := make([]string, 0, len(c.cur.nodes))
+	for name := range c.cur.nodes {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// Owner returns the name of the node to which item is routed, or false if the cluster has no nodes.
+func (c *Client) Owner(item []byte) (string, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if len(c.cur.points) == 0 {
+		return "", false
+	}
+	return c.cur.owner(hashBytes(item)), true
+}
+
+// owner returns the name of the node that owns the ring position h.
+func (r *ring) owner(h uint64) string {
+	i, _ := slices.BinarySearchFunc(r.points, h, func(p point, h uint64) int { return cmp.Compare(p.hash, h) })
+	if i == len(r.points) {
+		i = 0
+	}
+	return r.points[i].name
+}
+
+// rings returns c's current ring followed by its retained rings.
+func (c *Client) rings() []*ring {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return append([]*ring{c.cur}, c.old...)
+}
+
+// Insert inserts item into the set of the node to which it is routed.
+func (c *Client) Insert(ctx context.Context, item []byte) error {
+	return c.InsertBatch(ctx, [][]byte{item})
+}
+
+// MaybeContains reports whether item is probably in the cluster's set.
+func (c *Client) MaybeContains(ctx context.Context, item []byte) (bool, error) {
+	res, err := c.ContainsBatch(ctx, [][]byte{item})
+	if err != nil {
+		return false, err
+	}
+	return res[0], nil
+}
+
+// InsertBatch inserts every item of items into the set of the node to which it is routed,
+// sending each node's items in a single call, concurrently with the other nodes.
+// It returns an error if the cluster has no nodes, or the errors of the nodes that failed.
+func (c *Client) InsertBatch(ctx context.Context, items [][]byte) error {
+	r := c.rings()[0]
+	if len(r.points) == 0 {
+		return errors.New("cluster has no nodes")
+	}
+	batches := make(map[string][]int)
+	for i, item := range items {
+		name := r.owner(hashBytes(item))
+		batches[name] = append(batches[name], i)
+	}
+	return r.each(items, batches, func(n Node, items [][]byte, _ []int) error {
+		return n.InsertBatch(ctx, items)
+	})
+}
+
+// ContainsBatch reports, for each item of items, whether it is probably in the cluster's set.
+// It asks the node to which each item is routed and, for the items not found there,
+// the node to which the item was routed before each change to the cluster since the last call to Settle.
+// It returns an error if the cluster has no nodes, or the errors of the nodes that failed.
+func (c *Client) ContainsBatch(ctx context.Context, items [][]byte) ([]bool, error) {
+	rings := c.rings()
+	if len(rings[0].points) == 0 {
+		return nil, errors.New("cluster has no nodes")
+	}
+	res := make([]bool, len(items))
+	hashes := make([]uint64, len(items))
+	for i, item := range items {
+		hashes[i] = hashBytes(item)
+	}
+	asked := make([][]string, len(items)) // the nodes that have been asked about each item
+	for _, r := range rings {
+		batches := make(map[string][]int)
+		for i, h := range hashes {
+			if res[i] {
+				continue
+			}
+			name := r.owner(h)
+			if slices.Contains(asked[i], name) {
+				continue
+			}
+			asked[i] = append(asked[i], name)
+			batches[name] = append(batches[name], i)
+		}
+		err := r.each(items, batches, func(n Node, items [][]byte, indexes []int) error {
+			found, err := n.ContainsBatch(ctx, items)
+			if err != nil {
+				return err
+			}
+			if len(found) != len(items) {
+				return errors.New("node returned wrong number of results")
+			}
+			for j, i := range indexes {
+				res[i] = found[j]
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
+
+// each calls fn concurrently for each node of batches with the node's items, which are those of items at its indexes,
+// and returns the errors it returns, annotated with the nodes' names.
+func (r *ring) each(items [][]byte, batches map[string][]int, fn func(n Node, items [][]byte, indexes []int) error) error {
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs []error
+	)
+	for name, indexes := range batches {
+		batch := make([][]byte, len(indexes))
+		for j, i := range indexes {
+			batch[j] = items[i]
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := fn(r.nodes[name], batch, indexes); err != nil {
+				mu.Lock()
+				errs = append(errs, fmt.Errorf("node %s: %w", name, err))
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+// hashBytes returns the position of b on the ring.
+func hashBytes(b []byte) uint64 {
+	h := fnv.New64a()
+	h.Write(b)
+	return mix(h.Sum64())
+}
+
+// mix is MurmurHash3's 64-bit finalizer, which spreads the similar FNV hashes of similar inputs over the ring.
+func mix(h uint64) uint64 {
+	h ^= h >> 33
+	h *= 0xff51afd7ed558ccd
+	h ^= h >> 33
+	h *= 0xc4ceb9fe1a85ec53
+	return h ^ h>>33
+}
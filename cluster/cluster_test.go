@@ -0,0 +1,232 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/dkmccandless/bloom"
+	"github.com/dkmccandless/bloom/httpclient"
+	"github.com/dkmccandless/bloom/httpserver"
+)
+
+var _ Node = (*httpclient.Client)(nil)
+
+// memNode is a Node that holds its filter in memory and counts the items it receives.
+type memNode struct {
+	s     *bloom.SyncFilter
+	items int
+	fail  bool
+}
+
+func newMemNode() *memNode {
+	return &memNode{s: bloom.NewSyncFilter(bloom.NewWithHash(1<<16, 4, bloom.BitsAndBlooms))}
+}
+
+func (n *memNode) InsertBatch(ctx context.Context, items [][]byte) error {
+	if n.fail {
+		return errors.New("node down")
+	}
+	for _, item := range items {
+		n.s.Insert(item)
+	}
+	n.items += len(items)
+	return nil
+}
+
+func (n *memNode) ContainsBatch(ctx context.Context, items [][]byte) ([]bool, error) {
+	if n.fail {
+		return nil, errors.New("node down")
+	}
+	res := make([]bool, len(items))
+	for i, item := range items {
+		res[i] = n.s.MaybeContains(item)
+	}
+	return res, nil
+}
+
+// keys returns n distinct items with the given prefix.
+func keys(prefix string, n int) [][]byte {
+	items := make([][]byte, n)
+	for i := range items {
+		items[i] = []byte(fmt.Sprint(prefix, i))
+	}
+	return items
+}
+
+func TestClient(t *testing.T) {
+	ctx := context.Background()
+	c := New(200)
+	if err := c.Insert(ctx, []byte("x")); err == nil {
+		t.Errorf("TestClient: Insert with no nodes got nil error")
+	}
+	if _, err := c.MaybeContains(ctx, []byte("x")); err == nil {
+		t.Errorf("TestClient: MaybeContains with no nodes got nil error")
+	}
+	if _, ok := c.Owner([]byte("x")); ok {
+		t.Errorf("TestClient: Owner with no nodes got ok")
+	}
+
+	nodes := make(map[string]*memNode)
+	for _, name := range []string{"a", "b", "c", "d"} {
+		nodes[name] = newMemNode()
+		c.Add(name, nodes[name])
+	}
+	c.Settle()
+	if got, want := c.Nodes(), []string{"a", "b", "c", "d"}; !slices.Equal(got, want) {
+		t.Errorf("TestClient: got nodes %v, want %v", got, want)
+	}
+
+	const n = 20000
+	items := keys("item", n)
+	if err := c.InsertBatch(ctx, items); err != nil {
+		t.Fatal(err)
+	}
+	for name, node := range nodes {
+		if d := math.Abs(float64(node.items)/n - 0.25); d > 0.05 {
+			t.Errorf("TestClient: node %v got %v of %v items", name, node.items, n)
+		}
+	}
+	for _, item := range items[:100] {
+		name, _ := c.Owner(item)
+		if !nodes[name].s.MaybeContains(item) {
+			t.Errorf("TestClient(%s): not inserted in owner %v", item, name)
+		}
+	}
+	found, err := c.ContainsBatch(ctx, items)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if i := slices.Index(found, false); i >= 0 {
+		t.Errorf("TestClient(%s): not found", items[i])
+	}
+	if ok, err := c.MaybeContains(ctx, items[0]); !ok || err != nil {
+		t.Errorf("TestClient(%s): got %v, %v, want true, nil", items[0], ok, err)
+	}
+}
+
+func TestClientChange(t *testing.T) {
+	ctx := context.Background()
+	c := New(200)
+	nodes := map[string]*memNode{"a": newMemNode(), "b": newMemNode(), "c": newMemNode()}
+	for name, node := range nodes {
+		c.Add(name, node)
+	}
+	const n = 10000
+	items := keys("item", n)
+	if err := c.InsertBatch(ctx, items); err != nil {
+		t.Fatal(err)
+	}
+	owners := make([]string, n)
+	for i, item := range items {
+		owners[i], _ = c.Owner(item)
+	}
+
+	// Adding a node moves about a quarter of the items, all of them to the new node.
+	nodes["d"] = newMemNode()
+	c.Add("d", nodes["d"])
+	var moved int
+	for i, item := range items {
+		if name, _ := c.Owner(item); name != owners[i] {
+			moved++
+			if name != "d" {
+				t.Errorf("TestClientChange(%s): moved from %v to %v", item, owners[i], name)
+			}
+		}
+	}
+	if d := math.Abs(float64(moved)/n - 0.25); d > 0.05 {
+		t.Errorf("TestClientChange: adding a node moved %v of %v items", moved, n)
+	}
+
+	// Removing a node moves only its items.
+	c.Remove("a")
+	for i, item := range items {
+		if name, _ := c.Owner(item); owners[i] != "a" && name != owners[i] && name != "d" {
+			t.Errorf("TestClientChange(%s): moved from %v to %v", item, owners[i], name)
+		}
+	}
+
+	// Until Settle, items are found on their previous owners.
+	found, err := c.ContainsBatch(ctx, items)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if i := slices.Index(found, false); i >= 0 {
+		t.Errorf("TestClientChange(%s): not found before Settle", items[i])
+	}
+	c.Settle()
+	found, err = c.ContainsBatch(ctx, items)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var lost int
+	for _, ok := range found {
+		if !ok {
+			lost++
+		}
+	}
+	if lost < n/3 {
+		t.Errorf("TestClientChange: %v of %v items not found after Settle, want about half", lost, n)
+	}
+
+	// New insertions go to the current owners.
+	before := nodes["a"].items
+	if err := c.InsertBatch(ctx, keys("more", 1000)); err != nil {
+		t.Fatal(err)
+	}
+	if nodes["a"].items != before {
+		t.Errorf("TestClientChange: removed node got %v items", nodes["a"].items-before)
+	}
+}
+
+func TestClientErrors(t *testing.T) {
+	ctx := context.Background()
+	c := New(100)
+	good, bad := newMemNode(), newMemNode()
+	c.Add("good", good)
+	c.Add("bad", bad)
+	bad.fail = true
+	if err := c.InsertBatch(ctx, keys("item", 100)); err == nil {
+		t.Errorf("TestClientErrors: InsertBatch got nil error")
+	}
+	if good.items == 0 {
+		t.Errorf("TestClientErrors: InsertBatch did not insert into working node")
+	}
+	if _, err := c.ContainsBatch(ctx, keys("item", 100)); err == nil {
+		t.Errorf("TestClientErrors: ContainsBatch got nil error")
+	}
+}
+
+func TestClientHTTP(t *testing.T) {
+	ctx := context.Background()
+	c := New(100)
+	for _, name := range []string{"a", "b"} {
+		ts := httptest.NewServer(httpserver.New(bloom.NewSyncFilter(bloom.NewWithHash(1<<16, 4, bloom.BitsAndBlooms))))
+		defer ts.Close()
+		c.Add(name, httpclient.New(ts.URL))
+	}
+	items := keys("item", 100)
+	if err := c.InsertBatch(ctx, items); err != nil {
+		t.Fatal(err)
+	}
+	found, err := c.ContainsBatch(ctx, append(items, []byte("absent")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if i := slices.Index(found[:len(items)], false); i >= 0 || found[len(items)] {
+		t.Errorf("TestClientHTTP: got %v", found)
+	}
+}
+
+func TestNewPanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("TestNewPanics: did not panic")
+		}
+	}()
+	New(0)
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/dkmccandless/bloom"
+	"github.com/dkmccandless/bloom/internal/cli"
+)
+
+// add implements the add command.
+func add(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("add", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() < 1 {
+		fmt.Fprint(stderr, "usage: bloom add file [input ...]\n")
+		return 2
+	}
+	path := fs.Arg(0)
+	f, err := load(path)
+	if err != nil {
+		fmt.Fprintf(stderr, "bloom add: %v\n", err)
+		return 1
+	}
+	if fs.NArg() == 1 {
+		if err := cli.ReadLines(stdin, f.Insert); err != nil {
+			fmt.Fprintf(stderr, "bloom add: %v\n", err)
+			return 1
+		}
+	}
+	for _, name := range fs.Args()[1:] {
+		if err := addFile(f, name); err != nil {
+			fmt.Fprintf(stderr, "bloom add: %v\n", err)
+			return 1
+		}
+	}
+	if err := f.SaveFile(path); err != nil {
+		fmt.Fprintf(stderr, "bloom add: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// addFile inserts each line of the file name into f.
+func addFile(f *bloom.Filter, name string) error {
+	file, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return cli.ReadLines(file, f.Insert)
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAdd(t *testing.T) {
+	path := tempFilter(t, 10000, 4)
+	if _, stderr, status := runCmd("apple\nbanana\r\n", "add", path); status != 0 {
+		t.Fatalf("TestAdd: got status %v, stderr %q", status, stderr)
+	}
+	input := filepath.Join(t.TempDir(), "items.txt")
+	if err := os.WriteFile(input, []byte("cherry\ndate"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, stderr, status := runCmd("ignored\n", "add", path, input); status != 0 {
+		t.Fatalf("TestAdd: got status %v, stderr %q", status, stderr)
+	}
+	f, err := load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, item := range []string{"apple", "banana", "cherry", "date"} {
+		if !f.MaybeContains([]byte(item)) {
+			t.Errorf("TestAdd: %q not in filter", item)
+		}
+	}
+	for _, item := range []string{"ignored", "banana\r", ""} {
+		if f.MaybeContains([]byte(item)) {
+			t.Errorf("TestAdd: %q in filter", item)
+		}
+	}
+
+	for _, args := range [][]string{
+		{},
+		{filepath.Join(t.TempDir(), "missing.bf")},
+		{path, filepath.Join(t.TempDir(), "missing.txt")},
+		{input},
+	} {
+		if _, stderr, status := runCmd("", append([]string{"add"}, args...)...); status == 0 || stderr == "" {
+			t.Errorf("TestAdd(%q): got status %v, stderr %q", args, status, stderr)
+		}
+	}
+}
+
+func TestAddMany(t *testing.T) {
+	path := tempFilter(t, 100000, 7)
+	var b strings.Builder
+	for i := range 5000 {
+		fmt.Fprintln(&b, i)
+	}
+	if _, stderr, status := runCmd(b.String(), "add", path); status != 0 {
+		t.Fatalf("TestAddMany: got status %v, stderr %q", status, stderr)
+	}
+	f, err := load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range 5000 {
+		if !f.MaybeContains([]byte(fmt.Sprint(i))) {
+			t.Errorf("TestAddMany: %v not in filter", i)
+		}
+	}
+}
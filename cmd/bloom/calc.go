@@ -0,0 +1,67 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"text/tabwriter"
+
+	"github.com/dkmccandless/bloom/internal/cli"
+)
+
+// calc implements the calc command.
+func calc(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("calc", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	n := fs.Float64("n", 0, "the expected number of `items`")
+	p := fs.Float64("p", 0, "the desired false positive `rate`")
+	m := fs.Int("m", 0, "the filter size in `bits`")
+	k := fs.Int("k", 0, "the number of `hashes` (default optimal for m and n)")
+	hashName := fs.String("hash", "", "round the parameters to those supported by the hash `algorithm`")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 0 || *n == 0 || (*p == 0) == (*m == 0) || *k != 0 && *m == 0 {
+		fmt.Fprint(stderr, "usage: bloom calc -n items (-p rate | -m bits [-k hashes]) [-hash algorithm]\n")
+		return 2
+	}
+	if !(*n >= 1) || math.IsInf(*n, 1) {
+		fmt.Fprint(stderr, "bloom calc: number of items must be at least 1\n")
+		return 2
+	}
+	var err error
+	if *p != 0 {
+		*m, *k, err = cli.Optimal(*n, *p)
+		if err != nil {
+			fmt.Fprintf(stderr, "bloom calc: %v\n", err)
+			return 2
+		}
+	} else if *m < 0 || *k < 0 {
+		fmt.Fprint(stderr, "bloom calc: negative filter size or number of hash values\n")
+		return 2
+	} else if *k == 0 {
+		*k = max(1, int(math.Round(float64(*m)/(*n)*math.Ln2)))
+	}
+	if *hashName != "" {
+		h, err := cli.ParseHash(*hashName)
+		if err != nil {
+			fmt.Fprintf(stderr, "bloom calc: %v\n", err)
+			return 2
+		}
+		if *m, *k, err = cli.Fit(h, *m, *k); err != nil {
+			fmt.Fprintf(stderr, "bloom calc: %v\n", err)
+			return 2
+		}
+	}
+
+	tw := tabwriter.NewWriter(stdout, 0, 0, 1, ' ', 0)
+	fmt.Fprintf(tw, "items:\t%.0f\n", *n)
+	fmt.Fprintf(tw, "size:\t%d bits\n", *m)
+	fmt.Fprintf(tw, "hashes:\t%d\n", *k)
+	fmt.Fprintf(tw, "memory:\t%s\n", byteSize((*m+7)/8))
+	fmt.Fprintf(tw, "bits per item:\t%.2f\n", float64(*m)/(*n))
+	fmt.Fprintf(tw, "false positive rate:\t%.4g\n", cli.FalsePositiveRate(*m, *k, *n))
+	tw.Flush()
+	return 0
+}
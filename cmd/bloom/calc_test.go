@@ -0,0 +1,59 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCalc(t *testing.T) {
+	for _, test := range []struct {
+		args []string
+		want []string
+	}{
+		{
+			[]string{"-n", "10e6", "-p", "0.001"},
+			[]string{"items:               10000000\n", "size:                143775876 bits\n", "hashes:              10\n",
+				"memory:              17.1 MiB\n", "bits per item:       14.38\n", "false positive rate: 0.001\n"},
+		},
+		{
+			[]string{"-n", "1000", "-m", "9586"},
+			[]string{"hashes:              7\n", "false positive rate: 0.01003\n"},
+		},
+		{
+			[]string{"-n", "1000", "-m", "9586", "-k", "1"},
+			[]string{"hashes:              1\n", "false positive rate: 0.09906\n"},
+		},
+		{
+			[]string{"-n", "1000", "-p", "0.01", "-hash", "sha256"},
+			[]string{"size:                16384 bits\n", "memory:              2.0 KiB\n", "false positive rate: 0.000"},
+		},
+	} {
+		stdout, stderr, status := runCmd("", append([]string{"calc"}, test.args...)...)
+		if status != 0 || stderr != "" {
+			t.Errorf("TestCalc(%q): got status %v, stderr %q", test.args, status, stderr)
+		}
+		for _, want := range test.want {
+			if !strings.Contains(stdout, want) {
+				t.Errorf("TestCalc(%q): output %q does not contain %q", test.args, stdout, want)
+			}
+		}
+	}
+
+	for _, args := range [][]string{
+		{},
+		{"-n", "1000"},
+		{"-p", "0.01"},
+		{"-n", "1000", "-p", "0.01", "-m", "1000"},
+		{"-n", "1000", "-p", "0.01", "-k", "3"},
+		{"-n", "0.5", "-p", "0.01"},
+		{"-n", "1000", "-p", "2"},
+		{"-n", "1000", "-m", "-5"},
+		{"-n", "1000", "-p", "0.01", "-hash", "md5"},
+		{"-n", "1e6", "-p", "0.01", "-hash", "sha256"},
+		{"-n", "1000", "-p", "0.01", "extra"},
+	} {
+		if _, stderr, status := runCmd("", append([]string{"calc"}, args...)...); status != 2 || stderr == "" {
+			t.Errorf("TestCalc(%q): got status %v, stderr %q", args, status, stderr)
+		}
+	}
+}
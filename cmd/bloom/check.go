@@ -0,0 +1,52 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/dkmccandless/bloom/internal/cli"
+)
+
+// check implements the check command.
+func check(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("check", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	invert := fs.Bool("v", false, "print the queries that are not in the set")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() < 1 {
+		fmt.Fprint(stderr, "usage: bloom check [-v] file [query ...]\n")
+		return 2
+	}
+	f, err := load(fs.Arg(0))
+	if err != nil {
+		fmt.Fprintf(stderr, "bloom check: %v\n", err)
+		return 2
+	}
+	w := bufio.NewWriter(stdout)
+	status := 1
+	query := func(q []byte) {
+		if f.MaybeContains(q) != *invert {
+			w.Write(q)
+			w.WriteByte('\n')
+			status = 0
+		}
+	}
+	if fs.NArg() == 1 {
+		err = cli.ReadLines(stdin, query)
+	}
+	for _, q := range fs.Args()[1:] {
+		query([]byte(q))
+	}
+	if ferr := w.Flush(); err == nil {
+		err = ferr
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "bloom check: %v\n", err)
+		return 2
+	}
+	return status
+}
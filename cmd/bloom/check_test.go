@@ -0,0 +1,34 @@
+package main
+
+import "testing"
+
+func TestCheck(t *testing.T) {
+	path := tempFilter(t, 10000, 4)
+	if _, stderr, status := runCmd("apple\nbanana\n", "add", path); status != 0 {
+		t.Fatalf("TestCheck: got status %v, stderr %q", status, stderr)
+	}
+	for _, test := range []struct {
+		stdin  string
+		args   []string
+		stdout string
+		status int
+	}{
+		{"apple\ncherry\nbanana\n", []string{path}, "apple\nbanana\n", 0},
+		{"apple\r\ncherry", []string{"-v", path}, "cherry\n", 0},
+		{"cherry\n", []string{path}, "", 1},
+		{"apple\n", []string{"-v", path}, "", 1},
+		{"ignored\n", []string{path, "banana", "date"}, "banana\n", 0},
+		{"", []string{path}, "", 1},
+	} {
+		stdout, stderr, status := runCmd(test.stdin, append([]string{"check"}, test.args...)...)
+		if stdout != test.stdout || status != test.status || stderr != "" {
+			t.Errorf("TestCheck(%q, %q): got %q, status %v, stderr %q, want %q, status %v",
+				test.stdin, test.args, stdout, status, stderr, test.stdout, test.status)
+		}
+	}
+	for _, args := range [][]string{{}, {"missing.bf"}, {"-x", path}} {
+		if _, stderr, status := runCmd("", append([]string{"check"}, args...)...); status != 2 || stderr == "" {
+			t.Errorf("TestCheck(%q): got status %v, stderr %q", args, status, stderr)
+		}
+	}
+}
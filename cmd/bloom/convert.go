@@ -0,0 +1,147 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/dkmccandless/bloom"
+)
+
+// A format is a serialized form of a filter that convert can read, write, or both.
+type format struct {
+	read  func(f *bloom.Filter, data []byte) error // nil if the format cannot be read
+	write func(f *bloom.Filter, w io.Writer) error // nil if the format cannot be written
+	doc   string                                   // a description for the usage message
+}
+
+var formats = map[string]format{
+	"native":               {(*bloom.Filter).UnmarshalBinary, writeTo((*bloom.Filter).WriteTo), "the binary form of this package"},
+	"guava":                {readFrom((*bloom.Filter).ReadGuava), writeTo((*bloom.Filter).WriteGuava), "Guava's BloomFilter.writeTo"},
+	"parquet":              {readFrom((*bloom.Filter).ReadParquet), writeTo((*bloom.Filter).WriteParquet), "a Parquet split block Bloom filter with its header"},
+	"bits-and-blooms":      {readFrom((*bloom.Filter).ReadBitsAndBlooms), writeTo((*bloom.Filter).WriteBitsAndBlooms), "github.com/bits-and-blooms/bloom's WriteTo"},
+	"bits-and-blooms-json": {(*bloom.Filter).UnmarshalBitsAndBloomsJSON, marshal((*bloom.Filter).MarshalBitsAndBloomsJSON), "github.com/bits-and-blooms/bloom's MarshalJSON"},
+	"proto":                {(*bloom.Filter).UnmarshalProto, marshal((*bloom.Filter).MarshalProto), "the bloom.Filter protocol buffer message"},
+	"cbor":                 {(*bloom.Filter).UnmarshalCBOR, marshal((*bloom.Filter).MarshalCBOR), "CBOR"},
+	"msgpack":              {(*bloom.Filter).UnmarshalMsgpack, marshal((*bloom.Filter).MarshalMsgpack), "MessagePack"},
+	"text":                 {(*bloom.Filter).UnmarshalText, marshal((*bloom.Filter).MarshalText), "the human-readable text form of this package"},
+	"compressed":           {(*bloom.Filter).UnmarshalCompressed, marshal((*bloom.Filter).MarshalCompressed), "the binary form of this package, compressed with gzip"},
+	"c":                    {nil, nil, "a C header file defining the filter as an array, with identifiers beginning with -name (write only)"},
+}
+
+// readFrom adapts a method that reads a filter from an io.Reader to read the whole of data.
+func readFrom(read func(*bloom.Filter, io.Reader) (int64, error)) func(*bloom.Filter, []byte) error {
+	return func(f *bloom.Filter, data []byte) error {
+		r := bytes.NewReader(data)
+		if _, err := read(f, r); err != nil {
+			return err
+		}
+		if r.Len() != 0 {
+			return errors.New("unexpected data following filter")
+		}
+		return nil
+	}
+}
+
+// writeTo adapts a method that writes a filter to an io.Writer.
+func writeTo(write func(*bloom.Filter, io.Writer) (int64, error)) func(*bloom.Filter, io.Writer) error {
+	return func(f *bloom.Filter, w io.Writer) error {
+		_, err := write(f, w)
+		return err
+	}
+}
+
+// marshal adapts a method that marshals a filter to write the result to an io.Writer.
+func marshal(m func(*bloom.Filter) ([]byte, error)) func(*bloom.Filter, io.Writer) error {
+	return func(f *bloom.Filter, w io.Writer) error {
+		data, err := m(f)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(data)
+		return err
+	}
+}
+
+// formatNames returns a description of each format, one per line.
+func formatNames() string {
+	var b strings.Builder
+	for _, name := range slices.Sorted(maps.Keys(formats)) {
+		fmt.Fprintf(&b, "\t%s: %s\n", name, formats[name].doc)
+	}
+	return b.String()
+}
+
+// convert implements the convert command.
+func convert(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("convert", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	from := fs.String("from", "native", "the `format` of the input")
+	to := fs.String("to", "native", "the `format` of the output")
+	name := fs.String("name", "bloom_filter", "the `prefix` of the identifiers of a C header")
+	fs.Usage = func() {
+		fmt.Fprint(stderr, "usage: bloom convert [-from format] [-to format] [-name prefix] in out\n")
+		fs.PrintDefaults()
+		fmt.Fprintf(stderr, "formats:\n%s", formatNames())
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 2 {
+		fs.Usage()
+		return 2
+	}
+	in, out := fs.Arg(0), fs.Arg(1)
+
+	r, ok := formats[*from]
+	if !ok || r.read == nil {
+		fmt.Fprintf(stderr, "bloom convert: cannot read format %q\n", *from)
+		return 2
+	}
+	w, ok := formats[*to]
+	if *to == "c" {
+		w.write = func(f *bloom.Filter, wr io.Writer) error { return f.WriteCHeader(wr, *name) }
+	}
+	if !ok || w.write == nil {
+		fmt.Fprintf(stderr, "bloom convert: cannot write format %q\n", *to)
+		return 2
+	}
+
+	var data []byte
+	var err error
+	if in == "-" {
+		data, err = io.ReadAll(stdin)
+	} else {
+		data, err = os.ReadFile(in)
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "bloom convert: %v\n", err)
+		return 1
+	}
+	f := new(bloom.Filter)
+	if err := r.read(f, data); err != nil {
+		fmt.Fprintf(stderr, "bloom convert: %s: %v\n", in, err)
+		return 1
+	}
+	var buf bytes.Buffer
+	if err := w.write(f, &buf); err != nil {
+		fmt.Fprintf(stderr, "bloom convert: %v\n", err)
+		return 1
+	}
+	if out == "-" {
+		_, err = stdout.Write(buf.Bytes())
+	} else {
+		err = os.WriteFile(out, buf.Bytes(), 0o644)
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "bloom convert: %v\n", err)
+		return 1
+	}
+	return 0
+}
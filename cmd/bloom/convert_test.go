@@ -0,0 +1,99 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dkmccandless/bloom"
+)
+
+func TestConvert(t *testing.T) {
+	dir := t.TempDir()
+	for _, test := range []struct {
+		h       bloom.Hash
+		formats []string
+	}{
+		{bloom.GuavaMitz64, []string{"native", "guava", "proto", "cbor", "msgpack", "text", "compressed"}},
+		{bloom.SHA256, []string{"native", "cbor", "msgpack", "proto"}},
+		{bloom.ParquetSBBF, []string{"parquet", "native"}},
+		{bloom.BitsAndBlooms, []string{"bits-and-blooms", "bits-and-blooms-json", "native"}},
+	} {
+		k := 4
+		if test.h == bloom.ParquetSBBF {
+			k = 8
+		}
+		want := bloom.NewWithHash(1024, k, test.h)
+		for _, item := range []string{"apple", "banana", "cherry"} {
+			want.InsertString(item)
+		}
+		in := filepath.Join(dir, "in")
+		if err := want.SaveFile(in); err != nil {
+			t.Fatal(err)
+		}
+		// Convert through each format in turn and back to the native form.
+		from := "native"
+		for _, to := range append(test.formats[1:], "native") {
+			out := filepath.Join(dir, to)
+			if _, stderr, status := runCmd("", "convert", "-from", from, "--to", to, in, out); status != 0 {
+				t.Fatalf("TestConvert(%v, %v to %v): got status %v, stderr %q", test.h, from, to, status, stderr)
+			}
+			in, from = out, to
+		}
+		got, err := load(in)
+		if err != nil {
+			t.Fatalf("TestConvert(%v): %v", test.h, err)
+		}
+		wantData, _ := want.MarshalBinary()
+		gotData, _ := got.MarshalBinary()
+		if string(gotData) != string(wantData) {
+			t.Errorf("TestConvert(%v): got %v, want %v", test.h, got, want)
+		}
+	}
+}
+
+func TestConvertStdio(t *testing.T) {
+	f := bloom.NewWithHash(64, 2, bloom.GuavaMitz32)
+	f.InsertString("x")
+	text, _ := f.MarshalText()
+	stdout, stderr, status := runCmd(string(text), "convert", "-from", "text", "-to", "c", "-name", "blocked", "-", "-")
+	if status != 0 || !strings.Contains(stdout, "BLOCKED_") {
+		t.Errorf("TestConvertStdio: got status %v, stdout %q, stderr %q", status, stdout, stderr)
+	}
+}
+
+func TestConvertErrors(t *testing.T) {
+	dir := t.TempDir()
+	in := filepath.Join(dir, "in")
+	if err := bloom.NewWithHash(1024, 4, bloom.BitsAndBlooms).SaveFile(in); err != nil {
+		t.Fatal(err)
+	}
+	garbage := filepath.Join(dir, "garbage")
+	if err := os.WriteFile(garbage, []byte("garbage"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	out := filepath.Join(dir, "out")
+	for _, test := range []struct {
+		args   []string
+		status int
+	}{
+		{[]string{in}, 2},
+		{[]string{"-from", "xml", in, out}, 2},
+		{[]string{"-from", "c", in, out}, 2},
+		{[]string{"-to", "xml", in, out}, 2},
+		{[]string{"-to", "guava", in, out}, 1},
+		{[]string{"-to", "c", "-name", "1x", in, out}, 1},
+		{[]string{filepath.Join(dir, "missing"), out}, 1},
+		{[]string{"-from", "guava", in, out}, 1},
+		{[]string{garbage, out}, 1},
+		{[]string{in, filepath.Join(dir, "missing", "out")}, 1},
+	} {
+		if _, stderr, status := runCmd("", append([]string{"convert"}, test.args...)...); status != test.status || stderr == "" {
+			t.Errorf("TestConvertErrors(%q): got status %v, stderr %q, want status %v", test.args, status, stderr, test.status)
+		}
+	}
+	if _, stderr, _ := runCmd("", "convert", "-h"); !strings.Contains(stderr, "bits-and-blooms-json: ") {
+		t.Errorf("TestConvertErrors: usage %q does not list formats", stderr)
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/dkmccandless/bloom"
+	"github.com/dkmccandless/bloom/internal/cli"
+)
+
+// create implements the create command.
+func create(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("create", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	n := fs.Float64("n", 0, "the expected number of `items`")
+	p := fs.Float64("p", 0, "the false positive `rate` after n insertions")
+	m := fs.Int("m", 0, "the filter size in `bits`")
+	k := fs.Int("k", 0, "the number of `hashes`")
+	hashName := fs.String("hash", bloom.BitsAndBlooms.String(), "the hash `algorithm`")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 1 {
+		fmt.Fprint(stderr, "usage: bloom create [-n items -p rate | -m bits -k hashes] [-hash algorithm] file\n")
+		return 2
+	}
+	path := fs.Arg(0)
+
+	h, err := cli.ParseHash(*hashName)
+	if err != nil {
+		fmt.Fprintf(stderr, "bloom create: %v\n", err)
+		return 2
+	}
+	switch {
+	case *n != 0 || *p != 0:
+		if *m != 0 || *k != 0 {
+			fmt.Fprint(stderr, "bloom create: -n and -p cannot be used with -m and -k\n")
+			return 2
+		}
+		*m, *k, err = cli.Optimal(*n, *p)
+		if err == nil {
+			*m, *k, err = cli.Fit(h, *m, *k)
+		}
+		if err != nil {
+			fmt.Fprintf(stderr, "bloom create: %v\n", err)
+			return 2
+		}
+	case *m == 0 || *k == 0:
+		fmt.Fprint(stderr, "bloom create: either -n and -p or -m and -k are required\n")
+		return 2
+	}
+	f, err := cli.NewFilter(*m, *k, h)
+	if err != nil {
+		fmt.Fprintf(stderr, "bloom create: %v\n", err)
+		return 2
+	}
+	if err := f.SaveFile(path); err != nil {
+		fmt.Fprintf(stderr, "bloom create: %v\n", err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/dkmccandless/bloom"
+)
+
+func TestCreate(t *testing.T) {
+	dir := t.TempDir()
+	for _, test := range []struct {
+		args []string
+		m, k int
+		h    bloom.Hash
+	}{
+		{[]string{"-m", "1000", "-k", "3"}, 1000, 3, bloom.BitsAndBlooms},
+		{[]string{"-n", "1000", "-p", "0.01"}, 9586, 7, bloom.BitsAndBlooms},
+		{[]string{"-n", "1000", "-p", "0.01", "-hash", "sha256"}, 16384, 7, bloom.SHA256},
+		{[]string{"-n", "1000", "-p", "0.01", "-hash", "parquet-sbbf"}, 9728, 8, bloom.ParquetSBBF},
+		{[]string{"-m", "128", "-k", "2", "-hash", "guava-mitz64"}, 128, 2, bloom.GuavaMitz64},
+	} {
+		path := filepath.Join(dir, "filter.bf")
+		if _, stderr, status := runCmd("", append(append([]string{"create"}, test.args...), path)...); status != 0 {
+			t.Errorf("TestCreate(%q): got status %v, stderr %q", test.args, status, stderr)
+			continue
+		}
+		f, err := load(path)
+		if err != nil {
+			t.Errorf("TestCreate(%q): %v", test.args, err)
+			continue
+		}
+		if f.Size() != test.m || f.HashValues() != test.k || f.HashAlgorithm() != test.h || f.BitCount() != 0 {
+			t.Errorf("TestCreate(%q): got %v, want m=%v k=%v %v", test.args, f, test.m, test.k, test.h)
+		}
+	}
+
+	for _, args := range [][]string{
+		{},
+		{"x", "y"},
+		{"-m", "1000"},
+		{"-n", "1000"},
+		{"-n", "1000", "-p", "1.5"},
+		{"-n", "1000", "-p", "0.01", "-m", "1000"},
+		{"-m", "1000", "-k", "3", "-hash", "md5"},
+		{"-m", "1000", "-k", "3", "-hash", "sha256"},
+		{"-m", "-1", "-k", "3"},
+		{"-bogus"},
+	} {
+		path := filepath.Join(dir, "invalid.bf")
+		if len(args) == 0 || args[0] != "x" {
+			args = append(args, path)
+		}
+		if _, stderr, status := runCmd("", append([]string{"create"}, args...)...); status != 2 || stderr == "" {
+			t.Errorf("TestCreate(%q): got status %v, stderr %q", args, status, stderr)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"text/tabwriter"
+)
+
+// inspect implements the inspect command.
+func inspect(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("inspect", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() < 1 {
+		fmt.Fprint(stderr, "usage: bloom inspect file ...\n")
+		return 2
+	}
+	status := 0
+	for i, path := range fs.Args() {
+		if err := inspectFile(stdout, path, fs.NArg() > 1); err != nil {
+			fmt.Fprintf(stderr, "bloom inspect: %v\n", err)
+			status = 1
+		}
+		if fs.NArg() > 1 && i < fs.NArg()-1 {
+			fmt.Fprintln(stdout)
+		}
+	}
+	return status
+}
+
+// inspectFile writes to w a description of the filter in the file at path, preceded by path if named is true.
+func inspectFile(w io.Writer, path string, named bool) error {
+	f, err := load(path)
+	if err != nil {
+		return err
+	}
+	fi, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	st := f.Stats()
+	tw := tabwriter.NewWriter(w, 0, 0, 1, ' ', 0)
+	if named {
+		fmt.Fprintf(tw, "file:\t%s\n", path)
+	}
+	fmt.Fprintf(tw, "file size:\t%d bytes\n", fi.Size())
+	fmt.Fprintf(tw, "hash:\t%v\n", st.HashAlgorithm)
+	fmt.Fprintf(tw, "size:\t%d bits (%s)\n", st.Size, byteSize((st.Size+7)/8))
+	fmt.Fprintf(tw, "hashes:\t%d\n", st.HashValues)
+	fmt.Fprintf(tw, "bits set:\t%d\n", st.BitCount)
+	fmt.Fprintf(tw, "fill ratio:\t%.4f\n", st.FillRatio)
+	if math.IsInf(st.EstimatedItems, 1) {
+		fmt.Fprintf(tw, "estimated items:\tunknown (saturated)\n")
+	} else {
+		fmt.Fprintf(tw, "estimated items:\t%.0f\n", st.EstimatedItems)
+	}
+	fmt.Fprintf(tw, "estimated false positive rate:\t%.4g\n", st.FalsePositiveRate)
+	return tw.Flush()
+}
+
+// byteSize formats n bytes in the largest binary unit in which it is at least 1.
+func byteSize(n int) string {
+	const units = "KMGT"
+	if n < 1024 {
+		return fmt.Sprintf("%d B", n)
+	}
+	v, i := float64(n)/1024, 0
+	for ; v >= 1024 && i < len(units)-1; i++ {
+		v /= 1024
+	}
+	return fmt.Sprintf("%.1f %ciB", v, units[i])
+}
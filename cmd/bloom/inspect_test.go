@@ -0,0 +1,70 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestInspect(t *testing.T) {
+	path := tempFilter(t, 8192, 4)
+	var b strings.Builder
+	for i := range 500 {
+		fmt.Fprintln(&b, i)
+	}
+	if _, stderr, status := runCmd(b.String(), "add", path); status != 0 {
+		t.Fatalf("TestInspect: got status %v, stderr %q", status, stderr)
+	}
+	stdout, stderr, status := runCmd("", "inspect", path)
+	if status != 0 || stderr != "" {
+		t.Fatalf("TestInspect: got status %v, stderr %q", status, stderr)
+	}
+	for _, want := range []string{
+		"hash:                          bits-and-blooms\n",
+		"size:                          8192 bits (1.0 KiB)\n",
+		"hashes:                        4\n",
+		"fill ratio:                    0.2",
+		"estimated false positive rate: 0.00",
+	} {
+		if !strings.Contains(stdout, want) {
+			t.Errorf("TestInspect: output %q does not contain %q", stdout, want)
+		}
+	}
+	var n float64
+	if _, rest, ok := strings.Cut(stdout, "estimated items:"); !ok {
+		t.Errorf("TestInspect: output %q has no estimated items", stdout)
+	} else if fmt.Sscan(rest, &n); n < 480 || n > 520 {
+		t.Errorf("TestInspect: estimated %v items, want about 500", n)
+	}
+	if strings.Contains(stdout, "file:") {
+		t.Errorf("TestInspect: output %q names the file", stdout)
+	}
+
+	stdout, stderr, status = runCmd("", "inspect", path, filepath.Join(t.TempDir(), "missing.bf"), path)
+	if status != 1 || stderr == "" || strings.Count(stdout, "file: ") != 2 {
+		t.Errorf("TestInspect: got status %v, stdout %q, stderr %q", status, stdout, stderr)
+	}
+	if _, stderr, status := runCmd("", "inspect"); status != 2 || stderr == "" {
+		t.Errorf("TestInspect(): got status %v, stderr %q", status, stderr)
+	}
+}
+
+func TestByteSize(t *testing.T) {
+	for _, test := range []struct {
+		n    int
+		want string
+	}{
+		{0, "0 B"},
+		{1023, "1023 B"},
+		{1024, "1.0 KiB"},
+		{1536, "1.5 KiB"},
+		{3 << 20, "3.0 MiB"},
+		{5 << 40, "5.0 TiB"},
+		{2048 << 40, "2048.0 TiB"},
+	} {
+		if got := byteSize(test.n); got != test.want {
+			t.Errorf("TestByteSize(%v): got %q, want %q", test.n, got, test.want)
+		}
+	}
+}
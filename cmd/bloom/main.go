@@ -0,0 +1,105 @@
+// Command bloom creates, populates, and queries Bloom filters stored in files
+// in the binary form of package github.com/dkmccandless/bloom.
+//
+// Usage:
+//
+//	bloom create [-n items -p rate | -m bits -k hashes] [-hash algorithm] file
+//	bloom add file [input ...]
+//	bloom check [-v] file [query ...]
+//	bloom inspect file ...
+//	bloom calc -n items (-p rate | -m bits [-k hashes]) [-hash algorithm]
+//	bloom simulate -n items [-probes number] (-p rate | -m bits -k hashes) [-hash algorithm] [-seed seed]
+//	bloom convert [-from format] [-to format] [-name prefix] in out
+//
+// Create writes an empty filter to file. Its size and number of hash values are given directly by -m and -k,
+// or chosen by -n and -p for a false positive rate of p after n insertions. The default hash algorithm is bits-and-blooms.
+//
+// Add inserts each line of the named input files, or of the standard input if there are none, into the filter in file.
+// A line's item is its bytes without the line ending.
+//
+// Check prints each query that is probably in the filter's set, or with -v, each that is not.
+// The queries are the arguments following file or, if there are none, the lines of the standard input.
+// Its exit status is 0 if it printed any queries, 1 if it printed none, and 2 if an error occurred.
+//
+// Inspect prints the parameters and contents of each filter: its hash algorithm, size, number of hash values,
+// number of bits set, and fill ratio, and estimates of the number of items inserted into it
+// and of its current false positive rate.
+//
+// Calc prints the parameters of a filter for n items: given a false positive rate p, the smallest size and
+// the number of hash values that achieve it; or given a size m, and optionally a number of hash values k,
+// the false positive rate after n insertions. With -hash, the size is rounded up to one the algorithm supports.
+// It also prints the filter's memory use and the number of bits per item.
+//
+// Simulate measures a filter's false positive rate empirically: it inserts n synthetic items into a new filter
+// of the given parameters, queries a number of synthetic items that were not inserted, and prints the observed
+// false positive rate with its 95% confidence interval beside the expected rate, and the time taken per operation.
+// The items are derived from a seed, given by -seed to repeat a simulation, or chosen at random and printed.
+//
+// Convert reads the filter in the file in, in the format given by -from, and writes it to the file out
+// in the format given by -to. Either file may be "-" for the standard input or output.
+// The formats are those that package bloom reads and writes, including those of Guava, Parquet,
+// and github.com/bits-and-blooms/bloom; "bloom convert -h" lists them. Because a filter's bits depend on
+// its hash algorithm, a filter can be converted only to a format that supports its algorithm.
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/dkmccandless/bloom"
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// A command is a subcommand of bloom. It returns the exit status.
+type command func(args []string, stdin io.Reader, stdout, stderr io.Writer) int
+
+var commands = map[string]command{
+	"create":   create,
+	"add":      add,
+	"check":    check,
+	"inspect":  inspect,
+	"calc":     calc,
+	"simulate": simulate,
+	"convert":  convert,
+}
+
+const usage = `usage:
+	bloom create [-n items -p rate | -m bits -k hashes] [-hash algorithm] file
+	bloom add file [input ...]
+	bloom check [-v] file [query ...]
+	bloom inspect file ...
+	bloom calc -n items (-p rate | -m bits [-k hashes]) [-hash algorithm]
+	bloom simulate -n items [-probes number] (-p rate | -m bits -k hashes) [-hash algorithm] [-seed seed]
+	bloom convert [-from format] [-to format] [-name prefix] in out
+`
+
+// run runs the bloom command with arguments args and returns its exit status.
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, usage)
+		return 2
+	}
+	cmd, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(stderr, "bloom: unknown command %q\n%s", args[0], usage)
+		return 2
+	}
+	return cmd(args[1:], stdin, stdout, stderr)
+}
+
+// load reads the filter in the file at path.
+func load(path string) (*bloom.Filter, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	f := new(bloom.Filter)
+	if err := f.UnmarshalBinary(data); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return f, nil
+}
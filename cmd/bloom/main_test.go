@@ -0,0 +1,35 @@
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dkmccandless/bloom"
+)
+
+// runCmd runs the bloom command with args and the standard input stdin,
+// and returns its standard output, standard error, and exit status.
+func runCmd(stdin string, args ...string) (stdout, stderr string, status int) {
+	var out, errOut bytes.Buffer
+	status = run(args, strings.NewReader(stdin), &out, &errOut)
+	return out.String(), errOut.String(), status
+}
+
+func TestRun(t *testing.T) {
+	for _, args := range [][]string{nil, {"frobnicate"}} {
+		if _, stderr, status := runCmd("", args...); status != 2 || !strings.Contains(stderr, "usage") {
+			t.Errorf("TestRun(%q): got status %v, stderr %q", args, status, stderr)
+		}
+	}
+}
+
+// tempFilter returns the path of a new filter of m bits and k hash values in a temporary directory.
+func tempFilter(t *testing.T, m, k int) string {
+	path := filepath.Join(t.TempDir(), "filter.bf")
+	if err := bloom.NewWithHash(m, k, bloom.BitsAndBlooms).SaveFile(path); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"encoding/binary"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"math/rand/v2"
+	"text/tabwriter"
+	"time"
+
+	"github.com/dkmccandless/bloom"
+	"github.com/dkmccandless/bloom/internal/cli"
+)
+
+// simulate implements the simulate command.
+func simulate(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("simulate", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	n := fs.Int("n", 0, "the number of `items` to insert")
+	probes := fs.Int("probes", 1000000, "the `number` of non-members to query")
+	p := fs.Float64("p", 0, "the false positive `rate` for which to size the filter")
+	m := fs.Int("m", 0, "the filter size in `bits`")
+	k := fs.Int("k", 0, "the number of `hashes`")
+	hashName := fs.String("hash", bloom.BitsAndBlooms.String(), "the hash `algorithm`")
+	seed := fs.Uint64("seed", 0, "the `seed` from which items are generated (default random)")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 0 || *n <= 0 || *probes <= 0 || (*p == 0) == (*m == 0 && *k == 0) || (*m == 0) != (*k == 0) {
+		fmt.Fprint(stderr, "usage: bloom simulate -n items [-probes number] (-p rate | -m bits -k hashes) [-hash algorithm] [-seed seed]\n")
+		return 2
+	}
+	h, err := cli.ParseHash(*hashName)
+	if err != nil {
+		fmt.Fprintf(stderr, "bloom simulate: %v\n", err)
+		return 2
+	}
+	if *p != 0 {
+		*m, *k, err = cli.Optimal(float64(*n), *p)
+		if err == nil {
+			*m, *k, err = cli.Fit(h, *m, *k)
+		}
+		if err != nil {
+			fmt.Fprintf(stderr, "bloom simulate: %v\n", err)
+			return 2
+		}
+	}
+	f, err := cli.NewFilter(*m, *k, h)
+	if err != nil {
+		fmt.Fprintf(stderr, "bloom simulate: %v\n", err)
+		return 2
+	}
+	if !flagSet(fs, "seed") {
+		*seed = rand.Uint64()
+	}
+	r := runSimulation(f, *n, *probes, *seed)
+
+	tw := tabwriter.NewWriter(stdout, 0, 0, 1, ' ', 0)
+	fmt.Fprintf(tw, "hash:\t%v\n", h)
+	fmt.Fprintf(tw, "size:\t%d bits (%s)\n", *m, byteSize((*m+7)/8))
+	fmt.Fprintf(tw, "hashes:\t%d\n", *k)
+	fmt.Fprintf(tw, "seed:\t%d\n", *seed)
+	fmt.Fprintf(tw, "items inserted:\t%d\n", *n)
+	fmt.Fprintf(tw, "fill ratio:\t%.4f\n", f.FillRatio())
+	fmt.Fprintf(tw, "false negatives:\t%d\n", r.falseNegatives)
+	fmt.Fprintf(tw, "non-members probed:\t%d\n", *probes)
+	fmt.Fprintf(tw, "false positives:\t%d\n", r.falsePositives)
+	lo, hi := wilson(r.falsePositives, *probes)
+	fmt.Fprintf(tw, "observed false positive rate:\t%.4g (95%% interval %.4g to %.4g)\n", float64(r.falsePositives)/float64(*probes), lo, hi)
+	fmt.Fprintf(tw, "expected false positive rate:\t%.4g\n", cli.FalsePositiveRate(*m, *k, float64(*n)))
+	fmt.Fprintf(tw, "insert time:\t%v per item\n", r.insertTime/time.Duration(*n))
+	fmt.Fprintf(tw, "query time:\t%v per item\n", r.queryTime/time.Duration(*n+*probes))
+	tw.Flush()
+	if r.falseNegatives != 0 {
+		return 1
+	}
+	return 0
+}
+
+// A simulation holds the results of runSimulation.
+type simulation struct {
+	falseNegatives, falsePositives int
+	insertTime, queryTime          time.Duration
+}
+
+// runSimulation inserts n synthetic items into f, queries them and probes synthetic items that were not inserted,
+// and reports the number of errors and the time taken. The items are derived from seed,
+// so that a simulation can be repeated exactly.
+func runSimulation(f *bloom.Filter, n, probes int, seed uint64) simulation {
+	var r simulation
+	// Item i is the 8 bytes of seed followed by the 8 bytes of i;
+	// members are numbered from 0 and non-members from n.
+	item := binary.BigEndian.AppendUint64(make([]byte, 0, 16), seed)
+	at := func(i int) []byte {
+		return binary.BigEndian.AppendUint64(item[:8], uint64(i))
+	}
+
+	start := time.Now()
+	for i := range n {
+		f.Insert(at(i))
+	}
+	r.insertTime = time.Since(start)
+
+	start = time.Now()
+	for i := range n {
+		if !f.MaybeContains(at(i)) {
+			r.falseNegatives++
+		}
+	}
+	for i := range probes {
+		if f.MaybeContains(at(n + i)) {
+			r.falsePositives++
+		}
+	}
+	r.queryTime = time.Since(start)
+	return r
+}
+
+// wilson returns the 95% Wilson score interval of the rate of x successes in n trials.
+func wilson(x, n int) (lo, hi float64) {
+	const z = 1.959964
+	p, nf := float64(x)/float64(n), float64(n)
+	c := p + z*z/(2*nf)
+	d := z * math.Sqrt(p*(1-p)/nf+z*z/(4*nf*nf))
+	e := 1 + z*z/nf
+	return max(0, (c-d)/e), min(1, (c+d)/e)
+}
+
+// flagSet reports whether the flag of the given name was set in fs.
+func flagSet(fs *flag.FlagSet, name string) bool {
+	var set bool
+	fs.Visit(func(f *flag.Flag) { set = set || f.Name == name })
+	return set
+}
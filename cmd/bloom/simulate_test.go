@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/dkmccandless/bloom"
+)
+
+func TestSimulate(t *testing.T) {
+	args := []string{"simulate", "-n", "10000", "-probes", "100000", "-p", "0.01", "-seed", "1"}
+	stdout, stderr, status := runCmd("", args...)
+	if status != 0 || stderr != "" {
+		t.Fatalf("TestSimulate: got status %v, stderr %q", status, stderr)
+	}
+	for _, want := range []string{
+		"size:                         95851 bits (11.7 KiB)\n",
+		"hashes:                       7\n",
+		"seed:                         1\n",
+		"false negatives:              0\n",
+		"non-members probed:           100000\n",
+		"expected false positive rate: 0.01004\n",
+	} {
+		if !strings.Contains(stdout, want) {
+			t.Errorf("TestSimulate: output %q does not contain %q", stdout, want)
+		}
+	}
+	var fp int
+	if _, rest, ok := strings.Cut(stdout, "false positives:"); !ok {
+		t.Errorf("TestSimulate: output %q has no false positives", stdout)
+	} else if fmt.Sscan(rest, &fp); fp < 800 || fp > 1200 {
+		t.Errorf("TestSimulate: got %v false positives, want about 1000", fp)
+	}
+	if again, _, _ := runCmd("", args...); !strings.Contains(again, fmt.Sprintf("false positives:              %d\n", fp)) {
+		t.Errorf("TestSimulate: repeated simulation got %q", again)
+	}
+
+	for _, args := range [][]string{
+		{},
+		{"-n", "1000"},
+		{"-n", "0", "-p", "0.01"},
+		{"-n", "1000", "-p", "0.01", "-probes", "0"},
+		{"-n", "1000", "-p", "0.01", "-m", "1000", "-k", "3"},
+		{"-n", "1000", "-m", "1000"},
+		{"-n", "1000", "-p", "2"},
+		{"-n", "1000", "-p", "0.01", "-hash", "md5"},
+		{"-n", "1000", "-m", "1000", "-k", "3", "-hash", "sha256"},
+	} {
+		if _, stderr, status := runCmd("", append([]string{"simulate"}, args...)...); status != 2 || stderr == "" {
+			t.Errorf("TestSimulate(%q): got status %v, stderr %q", args, status, stderr)
+		}
+	}
+}
+
+func TestRunSimulation(t *testing.T) {
+	f := bloom.NewWithHash(1000, 3, bloom.BitsAndBlooms)
+	r := runSimulation(f, 100, 10000, 42)
+	if r.falseNegatives != 0 || r.falsePositives == 0 || r.falsePositives > 500 {
+		t.Errorf("TestRunSimulation: got %+v", r)
+	}
+	if g := bloom.NewWithHash(1000, 3, bloom.BitsAndBlooms); runSimulation(g, 100, 10000, 42).falsePositives != r.falsePositives {
+		t.Errorf("TestRunSimulation: simulation with the same seed differs")
+	}
+}
+
+func TestWilson(t *testing.T) {
+	for _, test := range []struct {
+		x, n   int
+		lo, hi float64
+	}{
+		{0, 100, 0, 0.037},
+		{50, 100, 0.404, 0.596},
+		{100, 100, 0.963, 1},
+		{1000, 100000, 0.0094, 0.0106},
+	} {
+		lo, hi := wilson(test.x, test.n)
+		if math.Abs(lo-test.lo) > 0.001 || math.Abs(hi-test.hi) > 0.001 {
+			t.Errorf("TestWilson(%v, %v): got %v, %v, want %v, %v", test.x, test.n, lo, hi, test.lo, test.hi)
+		}
+	}
+}
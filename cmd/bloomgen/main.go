@@ -0,0 +1,178 @@
+// Command bloomgen generates a Go source file that holds a pre-built Bloom filter of the lines of a file,
+// so that a list of items, such as a blocklist, can be compiled into a program.
+//
+// Usage:
+//
+//	bloomgen -i items.txt -name Name [-o file.go] [-pkg package] [-p rate | -m bits -k hashes] [-hash algorithm]
+//
+// Bloomgen reads the input file, inserts each of its lines, without the line ending, into a filter,
+// and writes a Go file declaring a string constant holding the filter in the binary form of package
+// github.com/dkmccandless/bloom and a function Name that returns it as a *bloom.Filter.
+// The filter's size and number of hash values are given by -m and -k, or chosen by -p for a false positive rate of p
+// for the number of lines of the input. The default false positive rate is 0.001,
+// and the default hash algorithm is bits-and-blooms.
+//
+// The output is written to the standard output, or to the file named by -o.
+// The package name defaults to that of the file containing the go:generate directive, so bloomgen is typically run as
+//
+//	//go:generate bloomgen -i blocklist.txt -name Blocklist -o blocklist_bloom.go
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/format"
+	"go/token"
+	"io"
+	"os"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/dkmccandless/bloom"
+	"github.com/dkmccandless/bloom/internal/cli"
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+const usage = "usage: bloomgen -i items.txt -name Name [-o file.go] [-pkg package] [-p rate | -m bits -k hashes] [-hash algorithm]\n"
+
+// run runs the bloomgen command with arguments args and returns its exit status.
+func run(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("bloomgen", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	in := fs.String("i", "", "the input `file`, of one item per line")
+	out := fs.String("o", "", "the output `file` (default standard output)")
+	pkg := fs.String("pkg", os.Getenv("GOPACKAGE"), "the `package` name of the output")
+	name := fs.String("name", "", "the `name` of the generated function")
+	p := fs.Float64("p", 0, "the false positive `rate` (default 0.001)")
+	m := fs.Int("m", 0, "the filter size in `bits`")
+	k := fs.Int("k", 0, "the number of `hashes`")
+	hashName := fs.String("hash", bloom.BitsAndBlooms.String(), "the hash `algorithm`")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 0 || *in == "" || *name == "" {
+		fmt.Fprint(stderr, usage)
+		return 2
+	}
+	if !token.IsIdentifier(*name) || !token.IsIdentifier(*pkg) {
+		fmt.Fprintf(stderr, "bloomgen: invalid name %q or package %q\n", *name, *pkg)
+		return 2
+	}
+	h, err := cli.ParseHash(*hashName)
+	if err != nil {
+		fmt.Fprintf(stderr, "bloomgen: %v\n", err)
+		return 2
+	}
+	if (*m == 0) != (*k == 0) || *m != 0 && *p != 0 {
+		fmt.Fprint(stderr, "bloomgen: either -p or both -m and -k are required\n")
+		return 2
+	}
+
+	items, err := readItems(*in)
+	if err != nil {
+		fmt.Fprintf(stderr, "bloomgen: %v\n", err)
+		return 1
+	}
+	if *m == 0 {
+		if *p == 0 {
+			*p = 0.001
+		}
+		*m, *k, err = cli.Optimal(float64(max(1, len(items))), *p)
+		if err == nil {
+			*m, *k, err = cli.Fit(h, *m, *k)
+		}
+		if err != nil {
+			fmt.Fprintf(stderr, "bloomgen: %v\n", err)
+			return 2
+		}
+	}
+	f, err := cli.NewFilter(*m, *k, h)
+	if err != nil {
+		fmt.Fprintf(stderr, "bloomgen: %v\n", err)
+		return 2
+	}
+	f.InsertBatch(items)
+
+	src, err := generate(f, *pkg, *name, *in, len(items))
+	if err != nil {
+		fmt.Fprintf(stderr, "bloomgen: %v\n", err)
+		return 1
+	}
+	if *out == "" {
+		_, err = stdout.Write(src)
+	} else {
+		err = os.WriteFile(*out, src, 0o644)
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "bloomgen: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// readItems returns the lines of the file at path, without their line endings.
+func readItems(path string) ([][]byte, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	var items [][]byte
+	err = cli.ReadLines(file, func(line []byte) { items = append(items, bytes.Clone(line)) })
+	return items, err
+}
+
+// generate returns the formatted source of a file of package pkg that declares the function name,
+// returning f, which holds the n items of the file in.
+func generate(f *bloom.Filter, pkg, name, in string, n int) ([]byte, error) {
+	data, err := f.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	lower := unexported(name)
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "// Code generated by bloomgen; DO NOT EDIT.\n\n")
+	fmt.Fprintf(&b, "package %s\n\n", pkg)
+	fmt.Fprintf(&b, "import (\n\t\"sync\"\n\n\t\"github.com/dkmccandless/bloom\"\n)\n\n")
+	fmt.Fprintf(&b, "// %sData is the filter returned by %s, in the binary form produced by bloom.Filter.MarshalBinary.\n", lower, name)
+	fmt.Fprintf(&b, "const %sData = \"\" +\n", lower)
+	for len(data) > 0 {
+		line := data[:min(len(data), 16)]
+		data = data[len(line):]
+		b.WriteString("\t\"")
+		for _, c := range line {
+			fmt.Fprintf(&b, "\\x%02x", c)
+		}
+		b.WriteString("\"")
+		if len(data) > 0 {
+			b.WriteString(" +")
+		}
+		b.WriteString("\n")
+	}
+	fmt.Fprintf(&b, `
+var %[1]sFilter = sync.OnceValue(func() *bloom.Filter {
+	f := new(bloom.Filter)
+	if err := f.UnmarshalBinary([]byte(%[1]sData)); err != nil {
+		panic(err)
+	}
+	return f
+})
+
+// %[2]s returns a Filter of the %[3]d items of %[4]s, of size %[5]d bits using %[6]d hash values derived by %[7]v.
+// Each call returns a separate snapshot of the filter, which the caller may modify.
+func %[2]s() *bloom.Filter {
+	return %[1]sFilter().Snapshot()
+}
+`, lower, name, n, in, f.Size(), f.HashValues(), f.HashAlgorithm())
+	return format.Source(b.Bytes())
+}
+
+// unexported returns name with its first letter in lower case.
+func unexported(name string) string {
+	r, size := utf8.DecodeRuneInString(name)
+	return string(unicode.ToLower(r)) + name[size:]
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/dkmccandless/bloom"
+)
+
+// constValue returns the value of the string constant name declared in src, a concatenation of string literals.
+func constValue(t *testing.T, src []byte, name string) []byte {
+	file, err := parser.ParseFile(token.NewFileSet(), "gen.go", src, parser.ParseComments)
+	if err != nil {
+		t.Fatalf("constValue: %v", err)
+	}
+	obj := file.Scope.Lookup(name)
+	if obj == nil || obj.Kind != ast.Con {
+		t.Fatalf("constValue: no constant %v", name)
+	}
+	var b []byte
+	ast.Inspect(obj.Decl.(*ast.ValueSpec).Values[0], func(n ast.Node) bool {
+		if lit, ok := n.(*ast.BasicLit); ok {
+			s, err := strconv.Unquote(lit.Value)
+			if err != nil {
+				t.Fatalf("constValue: %v", err)
+			}
+			b = append(b, s...)
+		}
+		return true
+	})
+	return b
+}
+
+func TestBloomgen(t *testing.T) {
+	dir := t.TempDir()
+	in := filepath.Join(dir, "items.txt")
+	var items []string
+	for i := range 1000 {
+		items = append(items, fmt.Sprint("item", i))
+	}
+	if err := os.WriteFile(in, []byte(strings.Join(items, "\n")), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range []struct {
+		args []string
+		m, k int
+		h    bloom.Hash
+	}{
+		{[]string{"-pkg", "lists"}, 14378, 10, bloom.BitsAndBlooms},
+		{[]string{"-pkg", "lists", "-p", "0.01", "-hash", "sha256"}, 16384, 7, bloom.SHA256},
+		{[]string{"-pkg", "lists", "-m", "20000", "-k", "5"}, 20000, 5, bloom.BitsAndBlooms},
+	} {
+		out := filepath.Join(dir, "blocklist_bloom.go")
+		var stdout, stderr bytes.Buffer
+		args := append([]string{"-i", in, "-name", "Blocklist", "-o", out}, test.args...)
+		if status := run(args, &stdout, &stderr); status != 0 {
+			t.Fatalf("TestBloomgen(%q): got status %v, stderr %q", test.args, status, stderr.String())
+		}
+		src, err := os.ReadFile(out)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, want := range []string{
+			"// Code generated by bloomgen; DO NOT EDIT.\n\npackage lists\n",
+			"\nfunc Blocklist() *bloom.Filter {\n",
+			fmt.Sprintf("the 1000 items of %s, of size %d bits using %d hash values derived by %v.", in, test.m, test.k, test.h),
+		} {
+			if !strings.Contains(string(src), want) {
+				t.Errorf("TestBloomgen(%q): output does not contain %q", test.args, want)
+			}
+		}
+		f := new(bloom.Filter)
+		if err := f.UnmarshalBinary(constValue(t, src, "blocklistData")); err != nil {
+			t.Fatalf("TestBloomgen(%q): %v", test.args, err)
+		}
+		if f.Size() != test.m || f.HashValues() != test.k || f.HashAlgorithm() != test.h {
+			t.Errorf("TestBloomgen(%q): got %v, want m=%v k=%v %v", test.args, f, test.m, test.k, test.h)
+		}
+		for _, item := range items {
+			if !f.MaybeContains([]byte(item)) {
+				t.Errorf("TestBloomgen(%q): %q not in filter", test.args, item)
+			}
+		}
+	}
+
+	var stdout, stderr bytes.Buffer
+	if status := run([]string{"-i", in, "-name", "list", "-pkg", "main"}, &stdout, &stderr); status != 0 {
+		t.Fatalf("TestBloomgen(stdout): got status %v, stderr %q", status, stderr.String())
+	}
+	if src := stdout.Bytes(); !bytes.Contains(src, []byte("\nfunc list() *bloom.Filter {\n")) || len(constValue(t, src, "listData")) == 0 {
+		t.Errorf("TestBloomgen(stdout): got %q", src)
+	}
+}
+
+func TestBloomgenErrors(t *testing.T) {
+	dir := t.TempDir()
+	in := filepath.Join(dir, "items.txt")
+	if err := os.WriteFile(in, []byte("a\nb\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for _, args := range [][]string{
+		{},
+		{"-i", in, "-pkg", "p"},
+		{"-name", "X", "-pkg", "p"},
+		{"-i", in, "-name", "X"},
+		{"-i", in, "-name", "not valid", "-pkg", "p"},
+		{"-i", in, "-name", "X", "-pkg", "p", "-m", "100"},
+		{"-i", in, "-name", "X", "-pkg", "p", "-m", "100", "-k", "3", "-p", "0.1"},
+		{"-i", in, "-name", "X", "-pkg", "p", "-hash", "md5"},
+		{"-i", in, "-name", "X", "-pkg", "p", "-m", "100", "-k", "3", "-hash", "sha256"},
+		{"-i", in, "-name", "X", "-pkg", "p", "-p", "2"},
+		{"-i", filepath.Join(dir, "missing.txt"), "-name", "X", "-pkg", "p"},
+		{"-i", in, "-name", "X", "-pkg", "p", "-o", filepath.Join(dir, "missing", "x.go")},
+	} {
+		var stdout, stderr bytes.Buffer
+		if status := run(args, &stdout, &stderr); status == 0 || stderr.Len() == 0 {
+			t.Errorf("TestBloomgenErrors(%q): got status %v, stderr %q", args, status, stderr.String())
+		}
+	}
+}
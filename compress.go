@@ -0,0 +1,81 @@
+package bloom
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+)
+
+// A Codec compresses and decompresses streams of data.
+// Compression formats such as zstd and snappy can be used by adapting their packages' writers and readers.
+type Codec interface {
+	// NewWriter returns a WriteCloser that compresses data written to it and writes the result to w.
+	// Closing it flushes any pending data, but does not close w.
+	NewWriter(w io.Writer) (io.WriteCloser, error)
+
+	// NewReader returns a ReadCloser that reads compressed data from r and decompresses it.
+	NewReader(r io.Reader) (io.ReadCloser, error)
+}
+
+// Gzip is a Codec that uses gzip compression at the default level.
+var Gzip Codec = gzipCodec{}
+
+type gzipCodec struct{}
+
+func (gzipCodec) NewWriter(w io.Writer) (io.WriteCloser, error) { return gzip.NewWriter(w), nil }
+
+func (gzipCodec) NewReader(r io.Reader) (io.ReadCloser, error) { return gzip.NewReader(r) }
+
+// WriteCompressed writes to w the binary form of f produced by MarshalBinary, compressed with c.
+// Sparsely populated filters compress to a small fraction of their size.
+func (f *Filter) WriteCompressed(w io.Writer, c Codec) error {
+	zw, err := c.NewWriter(w)
+	if err != nil {
+		return err
+	}
+	if _, err := f.WriteTo(zw); err != nil {
+		zw.Close()
+		return err
+	}
+	return zw.Close()
+}
+
+// ReadCompressed reads from r data written by WriteCompressed with a compatible Codec and stores the result in f.
+// It reads to the end of the compressed stream.
+// It validates the decompressed data in the same manner as UnmarshalBinary, but does not accept the legacy form.
+// If it returns an error, it does not modify the contents of f.
+func (f *Filter) ReadCompressed(r io.Reader, c Codec) error {
+	zr, err := c.NewReader(r)
+	if err != nil {
+		return err
+	}
+	defer zr.Close()
+	b, h, _, err := decode(zr, false, nil, 0)
+	if err != nil {
+		return err
+	}
+	// Reading to the end of the stream allows the codec to verify its own checksums, if any.
+	if n, err := io.Copy(io.Discard, zr); err != nil {
+		return err
+	} else if n != 0 {
+		return newError(ErrCorrupt, "filter size does not match data length")
+	}
+	f.load(h, b)
+	return nil
+}
+
+// MarshalCompressed marshals f into the binary form produced by MarshalBinary, compressed with gzip.
+func (f *Filter) MarshalCompressed() ([]byte, error) {
+	var buf bytes.Buffer
+	if err := f.WriteCompressed(&buf, Gzip); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// UnmarshalCompressed unmarshals data produced by MarshalCompressed and stores the result in f.
+// It validates the decompressed data in the same manner as UnmarshalBinary, but does not accept the legacy form.
+// If it returns an error, it does not modify the contents of f.
+func (f *Filter) UnmarshalCompressed(data []byte) error {
+	return f.ReadCompressed(bytes.NewReader(data), Gzip)
+}
@@ -0,0 +1,90 @@
+package bloom
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"reflect"
+	"testing"
+)
+
+// zlibCodec is a Codec that uses zlib compression, standing in for codecs provided by other packages.
+type zlibCodec struct{}
+
+func (zlibCodec) NewWriter(w io.Writer) (io.WriteCloser, error) { return zlib.NewWriter(w), nil }
+
+func (zlibCodec) NewReader(r io.Reader) (io.ReadCloser, error) { return zlib.NewReader(r) }
+
+func TestCodecs(t *testing.T) {
+	for _, c := range []Codec{Gzip, zlibCodec{}} {
+		for _, test := range marshalTests {
+			var buf bytes.Buffer
+			if err := test.f.WriteCompressed(&buf, c); err != nil {
+				t.Errorf("TestCodecs(%T): %v", c, err)
+			}
+			f := new(Filter)
+			if err := f.ReadCompressed(&buf, c); err != nil {
+				t.Errorf("TestCodecs(%T): %v", c, err)
+			}
+			if !reflect.DeepEqual(f, test.f) {
+				t.Errorf("TestCodecs(%T): got %v, want %v", c, f, test.f)
+			}
+		}
+	}
+}
+
+func TestCompressed(t *testing.T) {
+	for _, test := range marshalTests {
+		data, err := test.f.MarshalCompressed()
+		if err != nil {
+			t.Errorf("TestCompressed: %v", err)
+		}
+		f := new(Filter)
+		if err := f.UnmarshalCompressed(data); err != nil {
+			t.Errorf("TestCompressed: %v", err)
+		}
+		if !reflect.DeepEqual(f, test.f) {
+			t.Errorf("TestCompressed: got %v, want %v", f, test.f)
+		}
+	}
+
+	f := New(8192, 4)
+	for i := 0; i < 100; i++ {
+		f.Insert([]byte{byte(i)})
+	}
+	data, err := f.MarshalCompressed()
+	if err != nil {
+		t.Fatalf("TestCompressed: %v", err)
+	}
+	if len(data) > 2048 {
+		t.Errorf("TestCompressed: compressed filter of size 8192 with 100 items to %v bytes", len(data))
+	}
+}
+
+func TestUnmarshalCompressedErrors(t *testing.T) {
+	gz := func(b []byte) []byte {
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		zw.Write(b)
+		zw.Close()
+		return buf.Bytes()
+	}
+	valid := gz(binaryOf(1, 0))
+	for _, data := range [][]byte{
+		nil,
+		binaryOf(1, 0),                      // not compressed
+		gz([]byte{0, 1}),                    // legacy form
+		gz(binaryOf(1, 0)[:25]),             // truncated
+		gz(append(binaryOf(1, 0), 0)),       // trailing data
+		withByte(valid, len(valid)-5, 0xff), // corrupted gzip checksum
+	} {
+		f := New(1, 1)
+		if err := f.UnmarshalCompressed(data); err == nil {
+			t.Errorf("TestUnmarshalCompressedErrors(%v): got nil error", data)
+		}
+		if !reflect.DeepEqual(f, New(1, 1)) {
+			t.Errorf("TestUnmarshalCompressedErrors(%v): modified filter to %v", data, f)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package bloom
+
+import (
+	"math"
+	"sync/atomic"
+)
+
+// A CountingFilter is a Bloom filter that supports removal, by keeping a counter in place of each bit.
+// Its counters are incremented and decremented atomically, so its methods may be called concurrently
+// from multiple goroutines without a lock.
+// A counter that reaches its maximum value of 2^32-1 sticks there, so that removals cannot cause false negatives.
+type CountingFilter struct {
+	c    []uint32
+	k    int
+	hash Hash
+}
+
+// NewCountingFilter returns a CountingFilter of m counters that uses k hash values derived by the hash algorithm h.
+// It panics if h does not support a filter of size m bits or k hash values.
+func NewCountingFilter(m, k int, h Hash) *CountingFilter {
+	NewWithHash(m, k, h) // validate the parameters
+	return &CountingFilter{c: make([]uint32, m), k: k, hash: h}
+}
+
+// filter returns a Filter with c's parameters, by which the locations of items are derived.
+func (c *CountingFilter) filter() *Filter {
+	return &Filter{k: c.k, m: len(c.c), hash: c.hash}
+}
+
+// Insert inserts item into c's set.
+func (c *CountingFilter) Insert(item []byte) {
+	f := c.filter()
+	d := c.hash.digest(item)
+	for i := 0; i < c.k; i++ {
+		p := &c.c[f.location(&d, i)]
+		for {
+			n := atomic.LoadUint32(p)
+			if n == math.MaxUint32 || atomic.CompareAndSwapUint32(p, n, n+1) {
+				break
+			}
+		}
+	}
+}
+
+// Remove removes item from c's set and reports whether it did so.
+// If MaybeContains(item) is false, Remove returns false without modifying c.
+// The caller must only remove items that were inserted, and no more times than they were inserted;
+// otherwise c may report false negatives for other items.
+func (c *CountingFilter) Remove(item []byte) bool {
+	if !c.MaybeContains(item) {
+		return false
+	}
+	f := c.filter()
+	d := c.hash.digest(item)
+	for i := 0; i < c.k; i++ {
+		p := &c.c[f.location(&d, i)]
+		for {
+			n := atomic.LoadUint32(p)
+			if n == 0 || n == math.MaxUint32 || atomic.CompareAndSwapUint32(p, n, n-1) {
+				break
+			}
+		}
+	}
+	return true
+}
+
+// MaybeContains reports whether item is probably in c's set, in the manner of Filter.MaybeContains.
+func (c *CountingFilter) MaybeContains(item []byte) bool {
+	f := c.filter()
+	d := c.hash.digest(item)
+	for i := 0; i < c.k; i++ {
+		if atomic.LoadUint32(&c.c[f.location(&d, i)]) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Filter returns a Filter with the same parameters as c, whose bits are set where c's counters are nonzero.
+func (c *CountingFilter) Filter() *Filter {
+	g := NewWithHash(len(c.c), c.k, c.hash)
+	for i := range c.c {
+		if atomic.LoadUint32(&c.c[i]) != 0 {
+			g.setBit(i)
+		}
+	}
+	return g
+}
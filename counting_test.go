@@ -0,0 +1,72 @@
+package bloom
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestCountingFilter(t *testing.T) {
+	c := NewCountingFilter(1<<12, 4, BitsAndBlooms)
+	var wg sync.WaitGroup
+	for g := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range 100 {
+				c.Insert([]byte(fmt.Sprint(g, i)))
+			}
+			// Each goroutine removes the odd-numbered items it inserted.
+			for i := 1; i < 100; i += 2 {
+				if !c.Remove([]byte(fmt.Sprint(g, i))) {
+					t.Errorf("TestCountingFilter(%v, %v): inserted item not removed", g, i)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	want := NewWithHash(1<<12, 4, BitsAndBlooms)
+	for g := range 8 {
+		for i := 0; i < 100; i += 2 {
+			item := []byte(fmt.Sprint(g, i))
+			want.Insert(item)
+			if !c.MaybeContains(item) {
+				t.Errorf("TestCountingFilter(%v, %v): item not found", g, i)
+			}
+		}
+	}
+	if got := c.Filter(); !reflect.DeepEqual(got, want) {
+		t.Errorf("TestCountingFilter: got %v, want %v", got, want)
+	}
+	for g := range 8 {
+		for i := 0; i < 100; i += 2 {
+			c.Remove([]byte(fmt.Sprint(g, i)))
+		}
+	}
+	if got, want := c.Filter(), NewWithHash(1<<12, 4, BitsAndBlooms); !reflect.DeepEqual(got, want) {
+		t.Errorf("TestCountingFilter: got %v, want empty filter", got)
+	}
+	if c.Remove([]byte("not inserted")) {
+		t.Errorf("TestCountingFilter: removed item that was not inserted")
+	}
+}
+
+func TestCountingFilterSaturation(t *testing.T) {
+	c := NewCountingFilter(64, 1, BitsAndBlooms)
+	item := []byte("item")
+	d := c.hash.digest(item)
+	n := c.filter().location(&d, 0)
+	c.c[n] = math.MaxUint32 - 1
+	c.Insert(item)
+	c.Insert(item)
+	if c.c[n] != math.MaxUint32 {
+		t.Errorf("TestCountingFilterSaturation: got %v, want %v", c.c[n], uint32(math.MaxUint32))
+	}
+	c.Remove(item)
+	if c.c[n] != math.MaxUint32 {
+		t.Errorf("TestCountingFilterSaturation: got %v after removal, want %v", c.c[n], uint32(math.MaxUint32))
+	}
+}
@@ -0,0 +1,189 @@
+package bloom
+
+import (
+	"bytes"
+	"encoding/binary"
+	"maps"
+	"slices"
+	"sync"
+)
+
+// A VersionedFilter is a Filter with a version vector, so that it can be replicated as a grow-only set CRDT.
+// Each replica has an actor name, unique among the replicas, and counts the insertions that change its bits.
+// The version vector records, for each actor, the count of that actor's insertions reflected in the filter.
+//
+// Merge takes the union of two replicas' bits and the pointwise maximum of their version vectors.
+// Both operations are commutative, associative, and idempotent, so replicas that exchange their states
+// in any order, any number of times, converge to the same bits and version vector once each has merged,
+// directly or indirectly, the state of every other. Because replicas only gain bits, no merge conflicts.
+// Descends compares version vectors to tell whether merging another replica's state could change a replica.
+//
+// A VersionedFilter is safe for concurrent use by multiple goroutines.
+type VersionedFilter struct {
+	actor string
+
+	mu      sync.Mutex
+	f       *Filter
+	version map[string]uint64
+}
+
+// The binary form of a VersionedFilter consists of the magic number "BLMV", a version byte of value 1,
+// the number of entries of the version vector as a uvarint, the entries, and the filter in the binary form
+// produced by MarshalBinary. Each entry consists of the length of the actor name as a uvarint, the name,
+// and the count as a uvarint. Entries are in increasing order of actor name.
+const (
+	versionedMagic   = "BLMV"
+	versionedVersion = 1
+)
+
+// NewVersionedFilter returns a VersionedFilter for the replica actor that takes ownership of f,
+// with an empty version vector. The caller must not use f after calling NewVersionedFilter.
+func NewVersionedFilter(actor string, f *Filter) *VersionedFilter {
+	return &VersionedFilter{actor: actor, f: f, version: make(map[string]uint64)}
+}
+
+// Insert inserts item into v's set. If doing so sets any bits, it increments v's count of its own insertions.
+func (v *VersionedFilter) Insert(item []byte) {
+	d := v.f.hash.digest(item)
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if !v.f.testAndInsert(&d) {
+		v.version[v.actor]++
+	}
+}
+
+// MaybeContains reports whether item is probably in v's set, in the manner of Filter.MaybeContains.
+func (v *VersionedFilter) MaybeContains(item []byte) bool {
+	return v.f.MaybeContains(item)
+}
+
+// Version returns a copy of v's version vector.
+func (v *VersionedFilter) Version() map[string]uint64 {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return maps.Clone(v.version)
+}
+
+// Descends reports whether v's version vector is at least w's for every actor,
+// in which case v reflects every insertion that w does, and merging w into v would not change v.
+func (v *VersionedFilter) Descends(w *VersionedFilter) bool {
+	wv := w.Version()
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	for a, n := range wv {
+		if v.version[a] < n {
+			return false
+		}
+	}
+	return true
+}
+
+// Merge merges w's state into v: it sets the bits of v that are set in w and raises each count
+// of v's version vector to w's. It returns an error without modifying v if the filters differ in size,
+// number of hash values, or hash algorithm.
+func (v *VersionedFilter) Merge(w *VersionedFilter) error {
+	if v == w {
+		return nil
+	}
+	w.mu.Lock()
+	g := w.f.Snapshot()
+	wv := maps.Clone(w.version)
+	w.mu.Unlock()
+	return v.merge(g, wv)
+}
+
+// merge merges the filter g and version vector wv into v.
+func (v *VersionedFilter) merge(g *Filter, wv map[string]uint64) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if err := v.f.Union(g); err != nil {
+		return err
+	}
+	for a, n := range wv {
+		v.version[a] = max(v.version[a], n)
+	}
+	return nil
+}
+
+// Filter returns a snapshot of v's Filter, in the manner of Filter.Snapshot.
+func (v *VersionedFilter) Filter() *Filter {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return v.f.Snapshot()
+}
+
+// MarshalBinary marshals v's version vector and filter, but not its actor name,
+// so that the state can be sent to another replica and merged with MergeBinary.
+// It satisfies the encoding.BinaryMarshaler interface.
+func (v *VersionedFilter) MarshalBinary() ([]byte, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	b := append([]byte(versionedMagic), versionedVersion)
+	b = binary.AppendUvarint(b, uint64(len(v.version)))
+	for _, a := range slices.Sorted(maps.Keys(v.version)) {
+		b = binary.AppendUvarint(b, uint64(len(a)))
+		b = append(b, a...)
+		b = binary.AppendUvarint(b, v.version[a])
+	}
+	buf := bytes.NewBuffer(b)
+	v.f.WriteTo(buf)
+	return buf.Bytes(), nil
+}
+
+// MergeBinary merges into v the state in data, produced by the MarshalBinary method of another replica,
+// in the manner of Merge. If data is malformed or the filters are incompatible, it returns an error without modifying v.
+func (v *VersionedFilter) MergeBinary(data []byte) error {
+	if len(data) < len(versionedMagic)+1 || string(data[:len(versionedMagic)]) != versionedMagic {
+		return newError(ErrCorrupt, "missing magic number")
+	}
+	if data[len(versionedMagic)] != versionedVersion {
+		return newError(ErrUnsupported, "unsupported format version")
+	}
+	data = data[len(versionedMagic)+1:]
+	n, data, err := readUvarint(data)
+	if err != nil {
+		return err
+	}
+	if n > uint64(len(data)) {
+		return newError(ErrCorrupt, "version vector too long")
+	}
+	wv := make(map[string]uint64, n)
+	for range n {
+		var l, c uint64
+		if l, data, err = readUvarint(data); err != nil {
+			return err
+		}
+		if l > uint64(len(data)) {
+			return newError(ErrTruncated, "unexpected end of version vector")
+		}
+		a := string(data[:l])
+		if c, data, err = readUvarint(data[l:]); err != nil {
+			return err
+		}
+		if _, ok := wv[a]; ok {
+			return newError(ErrCorrupt, "duplicate actor in version vector")
+		}
+		wv[a] = c
+	}
+	v.mu.Lock()
+	m := v.f.m
+	v.mu.Unlock()
+	// A replica's filter of another size is rejected before its bits are allocated.
+	if h, _, err := readHeader(data); err == nil && h.m != uint64(m) {
+		return newError(ErrIncompatible, "incompatible filters")
+	}
+	g := new(Filter)
+	if err := g.UnmarshalBinaryLimit(data, m); err != nil {
+		return err
+	}
+	return v.merge(g, wv)
+}
+
+// readUvarint reads a uvarint from the front of data and returns its value and the remainder of data.
+func readUvarint(data []byte) (uint64, []byte, error) {
+	v, n := binary.Uvarint(data)
+	if n <= 0 {
+		return 0, nil, newError(ErrCorrupt, "malformed uvarint")
+	}
+	return v, data[n:], nil
+}
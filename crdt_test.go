@@ -0,0 +1,156 @@
+package bloom
+
+import (
+	"errors"
+	"maps"
+	"testing"
+)
+
+// replica returns a VersionedFilter for actor into which items have been inserted.
+func replica(actor string, items ...string) *VersionedFilter {
+	v := NewVersionedFilter(actor, NewWithHash(2048, 4, BitsAndBlooms))
+	for _, item := range items {
+		v.Insert([]byte(item))
+	}
+	return v
+}
+
+// sameState reports whether v and w have the same bits and version vector.
+func sameState(v, w *VersionedFilter) bool {
+	return equal(v.Filter(), w.Filter()) && maps.Equal(v.Version(), w.Version())
+}
+
+func TestVersionedFilterInsert(t *testing.T) {
+	v := replica("a", "x", "y", "x")
+	if got, want := v.Version(), map[string]uint64{"a": 2}; !maps.Equal(got, want) {
+		t.Errorf("TestVersionedFilterInsert: got version %v, want %v", got, want)
+	}
+	for _, item := range []string{"x", "y"} {
+		if !v.MaybeContains([]byte(item)) {
+			t.Errorf("TestVersionedFilterInsert: %q not found", item)
+		}
+	}
+}
+
+func TestVersionedFilterMerge(t *testing.T) {
+	// The states are of replicas a, b, and c, each of which may have merged earlier states of the others.
+	states := func() []*VersionedFilter {
+		a, b, c := replica("a", "1", "2"), replica("b", "3"), replica("c", "4", "5", "6")
+		b.Merge(replica("a", "1"))
+		c.Merge(b)
+		return []*VersionedFilter{a, b, c}
+	}
+	merged := func(order ...int) *VersionedFilter {
+		s := states()
+		v := replica("z")
+		for _, i := range order {
+			if err := v.Merge(s[i]); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return v
+	}
+
+	want := merged(0, 1, 2)
+	for _, order := range [][]int{{2, 1, 0}, {1, 0, 2}, {0, 0, 1, 2, 2}, {2, 0, 1, 0}} {
+		if got := merged(order...); !sameState(got, want) {
+			t.Errorf("TestVersionedFilterMerge(%v): got version %v, want %v", order, got.Version(), want.Version())
+		}
+	}
+	if got, want := want.Version(), map[string]uint64{"a": 2, "b": 1, "c": 3}; !maps.Equal(got, want) {
+		t.Errorf("TestVersionedFilterMerge: got version %v, want %v", got, want)
+	}
+
+	// Merging a merged state is the same as merging its parts.
+	s := states()
+	s[0].Merge(s[1])
+	s[2].Merge(s[0])
+	if !sameState(s[2], want) {
+		t.Errorf("TestVersionedFilterMerge(associative): got version %v, want %v", s[2].Version(), want.Version())
+	}
+	for _, item := range []string{"1", "2", "3", "4", "5", "6"} {
+		if !s[2].MaybeContains([]byte(item)) {
+			t.Errorf("TestVersionedFilterMerge: %q not found", item)
+		}
+	}
+
+	// Merging a replica into itself changes nothing.
+	if err := s[2].Merge(s[2]); err != nil || !sameState(s[2], want) {
+		t.Errorf("TestVersionedFilterMerge(self): got version %v, error %v", s[2].Version(), err)
+	}
+
+	other := NewVersionedFilter("d", NewWithHash(1024, 4, BitsAndBlooms))
+	if err := s[2].Merge(other); err == nil {
+		t.Errorf("TestVersionedFilterMerge(incompatible): got nil error")
+	}
+}
+
+func TestVersionedFilterDescends(t *testing.T) {
+	a, b := replica("a", "1"), replica("b", "2")
+	for _, test := range []struct {
+		v, w *VersionedFilter
+		want bool
+	}{
+		{a, a, true},
+		{a, b, false},
+		{b, a, false},
+		{a, replica("a"), true},
+		{replica("a"), a, false},
+	} {
+		if got := test.v.Descends(test.w); got != test.want {
+			t.Errorf("TestVersionedFilterDescends(%v, %v): got %v, want %v", test.v.Version(), test.w.Version(), got, test.want)
+		}
+	}
+	a.Merge(b)
+	if !a.Descends(b) || b.Descends(a) {
+		t.Errorf("TestVersionedFilterDescends: after merge, got %v and %v", a.Descends(b), b.Descends(a))
+	}
+}
+
+func TestVersionedFilterMarshal(t *testing.T) {
+	v := replica("a", "1", "2")
+	v.Merge(replica("b", "3"))
+	v.Merge(replica("", "4"))
+	data, err := v.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := replica("c")
+	if err := w.MergeBinary(data); err != nil {
+		t.Fatalf("TestVersionedFilterMarshal: %v", err)
+	}
+	if !sameState(w, v) {
+		t.Errorf("TestVersionedFilterMarshal: got version %v, want %v", w.Version(), v.Version())
+	}
+	if again, _ := v.MarshalBinary(); string(again) != string(data) {
+		t.Errorf("TestVersionedFilterMarshal: marshaling is not deterministic")
+	}
+
+	filter := mustMarshal(v.Filter())
+	for i, data := range [][]byte{
+		nil,
+		[]byte("BLMV"),
+		append([]byte("BLMX\x01\x00"), filter...),
+		append([]byte("BLMV\x02\x00"), filter...),
+		[]byte("BLMV\x01\x80"),
+		append([]byte("BLMV\x01\x7f"), filter...),
+		append([]byte("BLMV\x01\x01\x05a\x01"), filter...),
+		append([]byte("BLMV\x01\x02\x01a\x01\x01a\x02"), filter...),
+		[]byte("BLMV\x01\x01\x01a\x01"),
+		append([]byte("BLMV\x01\x00"), mustMarshal(NewWithHash(1024, 4, BitsAndBlooms))...),
+	} {
+		w := replica("c")
+		if err := w.MergeBinary(data); err == nil {
+			t.Errorf("TestVersionedFilterMarshal(invalid %v): got nil error", i)
+		}
+		if got := w.Version(); len(got) != 0 {
+			t.Errorf("TestVersionedFilterMarshal(invalid %v): got version %v", i, got)
+		}
+	}
+	for _, size := range []int{1024, 1 << 20} {
+		data := append([]byte("BLMV\x01\x00"), mustMarshal(NewWithHash(size, 4, BitsAndBlooms))...)
+		if err := replica("c").MergeBinary(data); !errors.Is(err, ErrIncompatible) {
+			t.Errorf("TestVersionedFilterMarshal(size %v): got %v, want %v", size, err, ErrIncompatible)
+		}
+	}
+}
@@ -0,0 +1,179 @@
+package bloom
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"iter"
+	"time"
+)
+
+// A DedupOption configures NewDedupWriter and Dedup.
+type DedupOption func(*dedupOptions)
+
+type dedupOptions struct {
+	delim byte
+	ttl   time.Duration
+}
+
+// WithDelimiter sets the byte that terminates each record written to a DedupWriter.
+// If the option is not given, records are lines, terminated by '\n'. Dedup ignores it.
+func WithDelimiter(delim byte) DedupOption {
+	return func(o *dedupOptions) { o.delim = delim }
+}
+
+// WithTTL makes Dedup forget items that it has not seen for a time: it rotates its filter every ttl, in the manner
+// of RotatingFilter, so that an item is dropped as a duplicate for between ttl and twice ttl after it was last seen,
+// and the filter's fill ratio, and so its false positive rate, stays bounded however long the stream.
+// NewDedupWriter ignores it. WithTTL panics if ttl is negative.
+func WithTTL(ttl time.Duration) DedupOption {
+	if ttl < 0 {
+		panic("bloom: negative TTL")
+	}
+	return func(o *dedupOptions) { o.ttl = ttl }
+}
+
+// newDedupOptions returns the options configured by opts.
+func newDedupOptions(opts []DedupOption) dedupOptions {
+	o := dedupOptions{delim: '\n'}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// A DedupWriter is an io.WriteCloser that splits the data written to it into records terminated by a delimiter,
+// and forwards to an underlying writer only the records that its Filter probably does not contain,
+// inserting each record as it goes. It thus removes duplicate records from a stream of any length
+// in the memory of the Filter, at the cost of dropping the occasional record that is a false positive.
+// A record is identified by its bytes without the delimiter. A DedupWriter must not be used concurrently.
+type DedupWriter struct {
+	w     io.Writer
+	f     *Filter
+	delim byte
+	rec   []byte // the start of a record not yet terminated
+	out   []byte
+	err   error
+}
+
+// NewDedupWriter returns a DedupWriter that writes to w the records written to it that are not in f's set,
+// inserting them into f. The DedupWriter takes ownership of f: the caller must not modify f while it is in use,
+// but may query it or, after Close, use it to deduplicate a later stream against the same records.
+func NewDedupWriter(w io.Writer, f *Filter, opts ...DedupOption) *DedupWriter {
+	o := newDedupOptions(opts)
+	return &DedupWriter{w: w, f: f, delim: o.delim}
+}
+
+// Write forwards the complete records in p, together with any record begun by earlier writes,
+// that are not duplicates, holding back a final record not yet terminated by the delimiter.
+// It returns len(p) unless writing to the underlying writer fails, after which it returns the same error
+// for every call: the records consumed by the failing call have been inserted, and cannot be written again.
+func (d *DedupWriter) Write(p []byte) (int, error) {
+	if d.err != nil {
+		return 0, d.err
+	}
+	d.out = d.out[:0]
+	n := len(p)
+	for {
+		i := bytes.IndexByte(p, d.delim)
+		if i < 0 {
+			break
+		}
+		rec := p[:i]
+		if len(d.rec) > 0 {
+			d.rec = append(d.rec, rec...)
+			rec = d.rec
+		}
+		d.forward(rec, true)
+		d.rec = d.rec[:0]
+		p = p[i+1:]
+	}
+	d.rec = append(d.rec, p...)
+	if err := d.flush(); err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+// Close forwards the final record, if it is not terminated by the delimiter and is not a duplicate.
+// It does not close the underlying writer.
+func (d *DedupWriter) Close() error {
+	if d.err != nil {
+		return d.err
+	}
+	d.out = d.out[:0]
+	if len(d.rec) > 0 {
+		d.forward(d.rec, false)
+		d.rec = d.rec[:0]
+	}
+	return d.flush()
+}
+
+// forward inserts rec into d's Filter and, if it was not in its set, appends rec to the output,
+// followed by the delimiter if terminated.
+func (d *DedupWriter) forward(rec []byte, terminated bool) {
+	dg := d.f.hash.digest(rec)
+	if d.f.testAndInsert(&dg) {
+		return
+	}
+	d.out = append(d.out, rec...)
+	if terminated {
+		d.out = append(d.out, d.delim)
+	}
+}
+
+// flush writes the output to the underlying writer, recording any error.
+func (d *DedupWriter) flush() error {
+	if len(d.out) == 0 {
+		return nil
+	}
+	if _, err := d.w.Write(d.out); err != nil {
+		d.err = err
+		return err
+	}
+	return nil
+}
+
+// ScanUnique returns an iterator over the tokens of sc, as split by its split function, that f's set
+// probably does not contain, inserting each token into f as it goes. With the default split function,
+// it yields the distinct lines of sc's input in the order in which they first appear, in place of
+// sorting an input too large to sort. Each token is yielded at most once, even if it is repeated,
+// but a token whose bits were all set by others is dropped as a false positive.
+// Iteration stops when sc stops, after which the caller should check sc.Err.
+func ScanUnique(sc *bufio.Scanner, f *Filter) iter.Seq[string] {
+	return func(yield func(string) bool) {
+		for sc.Scan() {
+			d := f.hash.digest(sc.Bytes())
+			if !f.testAndInsert(&d) && !yield(sc.Text()) {
+				return
+			}
+		}
+	}
+}
+
+// Dedup returns a channel on which it forwards, in order, the values received from in whose keys f's set
+// probably does not contain, inserting each key into f as it goes. The returned channel is unbuffered,
+// and is closed after in is closed; the goroutine that forwards values runs until then,
+// so the caller must receive from the channel until it is closed.
+// Dedup takes ownership of f: the caller must not modify f until the channel is closed,
+// and if the WithTTL option is given, must not use f at all.
+func Dedup[T any](in <-chan T, key func(T) []byte, f *Filter, opts ...DedupOption) <-chan T {
+	o := newDedupOptions(opts)
+	seen := func(k []byte) bool {
+		d := f.hash.digest(k)
+		return f.testAndInsert(&d)
+	}
+	if o.ttl > 0 {
+		seen = NewRotatingFilter(f, o.ttl).TestAndInsert
+	}
+	out := make(chan T)
+	go func() {
+		defer close(out)
+		for v := range in {
+			if !seen(key(v)) {
+				out <- v
+			}
+		}
+	}()
+	return out
+}
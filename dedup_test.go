@@ -0,0 +1,134 @@
+package bloom
+
+import (
+	"bufio"
+	"bytes"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDedupWriter(t *testing.T) {
+	for _, test := range []struct {
+		writes []string
+		opts   []DedupOption
+		want   string
+	}{
+		{nil, nil, ""},
+		{[]string{"a\nb\na\nc\nb\n"}, nil, "a\nb\nc\n"},
+		{[]string{"a\nb", "\na", "\nc\n", "a"}, nil, "a\nb\nc\n"},
+		{[]string{"al", "pha\nbe", "ta\nalpha", "\nbeta"}, nil, "alpha\nbeta\n"},
+		{[]string{"a\nb\nc"}, nil, "a\nb\nc"},
+		{[]string{"\n\nx\n"}, nil, "\nx\n"},
+		{[]string{"a,b,a,,a,"}, []DedupOption{WithDelimiter(',')}, "a,b,,"},
+	} {
+		var buf bytes.Buffer
+		d := NewDedupWriter(&buf, NewWithHash(1024, 4, BitsAndBlooms), test.opts...)
+		for _, s := range test.writes {
+			if n, err := d.Write([]byte(s)); n != len(s) || err != nil {
+				t.Errorf("TestDedupWriter(%q): Write(%q) got %v, %v", test.writes, s, n, err)
+			}
+		}
+		if err := d.Close(); err != nil {
+			t.Errorf("TestDedupWriter(%q): Close: %v", test.writes, err)
+		}
+		if got := buf.String(); got != test.want {
+			t.Errorf("TestDedupWriter(%q): got %q, want %q", test.writes, got, test.want)
+		}
+	}
+}
+
+func TestDedupWriterError(t *testing.T) {
+	var buf bytes.Buffer
+	d := NewDedupWriter(&failWriter{w: &buf, n: 2}, New(64, 3))
+	if n, err := d.Write([]byte("a\nb")); n != 3 || err != nil {
+		t.Errorf("TestDedupWriterError: Write got %v, %v", n, err)
+	}
+	_, err1 := d.Write([]byte("\n"))
+	_, err2 := d.Write([]byte("c\n"))
+	err3 := d.Close()
+	if err1 == nil || err2 != err1 || err3 != err1 {
+		t.Errorf("TestDedupWriterError: got errors %v, %v, %v, want the same error", err1, err2, err3)
+	}
+	if got := buf.String(); got != "a\n" {
+		t.Errorf("TestDedupWriterError: got %q, want %q", got, "a\n")
+	}
+}
+
+func TestScanUnique(t *testing.T) {
+	for _, test := range []struct {
+		input string
+		split bufio.SplitFunc
+		want  []string
+	}{
+		{"", nil, nil},
+		{"b\na\nb\n\nc\na\n\n", nil, []string{"b", "a", "", "c"}},
+		{"x\r\ny\nx", nil, []string{"x", "y"}},
+		{"the cat saw the dog", bufio.ScanWords, []string{"the", "cat", "saw", "dog"}},
+	} {
+		sc := bufio.NewScanner(strings.NewReader(test.input))
+		if test.split != nil {
+			sc.Split(test.split)
+		}
+		if got := slices.Collect(ScanUnique(sc, NewWithHash(1024, 4, BitsAndBlooms))); !slices.Equal(got, test.want) {
+			t.Errorf("TestScanUnique(%q): got %q, want %q", test.input, got, test.want)
+		}
+		if err := sc.Err(); err != nil {
+			t.Errorf("TestScanUnique(%q): %v", test.input, err)
+		}
+	}
+
+	// Stopping early leaves the remaining tokens unscanned and uninserted.
+	f := NewWithHash(1024, 4, BitsAndBlooms)
+	sc := bufio.NewScanner(strings.NewReader("a\nb\nc\n"))
+	for line := range ScanUnique(sc, f) {
+		if line == "b" {
+			break
+		}
+	}
+	if !f.MaybeContainsString("b") || f.MaybeContainsString("c") {
+		t.Errorf("TestScanUnique(break): got b %v, c %v, want true, false", f.MaybeContainsString("b"), f.MaybeContainsString("c"))
+	}
+}
+
+func TestDedup(t *testing.T) {
+	in := make(chan int)
+	go func() {
+		defer close(in)
+		for _, v := range []int{3, 1, 3, 4, 1, 5, 9, 2, 6, 5, 3, 5} {
+			in <- v
+		}
+	}()
+	var got []int
+	for v := range Dedup(in, EncodeInt[int], NewWithHash(1024, 4, BitsAndBlooms)) {
+		got = append(got, v)
+	}
+	if want := []int{3, 1, 4, 5, 9, 2, 6}; !slices.Equal(got, want) {
+		t.Errorf("TestDedup: got %v, want %v", got, want)
+	}
+}
+
+func TestDedupTTL(t *testing.T) {
+	const ttl = 100 * time.Millisecond
+	in := make(chan string)
+	out := Dedup(in, EncodeString, NewWithHash(1024, 4, BitsAndBlooms), WithTTL(ttl))
+	got := make(chan []string)
+	go func() {
+		var items []string
+		for v := range out {
+			items = append(items, v)
+		}
+		got <- items
+	}()
+	in <- "a"
+	in <- "a"
+	in <- "b"
+	// An item not seen for two intervals is forgotten.
+	time.Sleep(5 * ttl / 2)
+	in <- "a"
+	close(in)
+	if got, want := <-got, []string{"a", "b", "a"}; !slices.Equal(got, want) {
+		t.Errorf("TestDedupTTL: got %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,51 @@
+package bloom
+
+import (
+	"bytes"
+)
+
+// MarshalDelta marshals the bits that are set in f but not in since, an earlier snapshot of f.
+// Applying the result to since with ApplyDelta makes it equal to f,
+// so replicas can be kept in sync by transferring only the bits set since the last synchronization.
+// It returns an error if since and f differ in size, number of hash values, or hash algorithm.
+func (f *Filter) MarshalDelta(since *Filter) ([]byte, error) {
+	if !f.compatible(since) {
+		return nil, newError(ErrIncompatible, "incompatible filters")
+	}
+	d, s := f.bytes(), since.bytes()
+	for i := range d {
+		d[i] &^= s[i]
+	}
+	h := f.header()
+	h.flags |= flagDelta
+	var buf bytes.Buffer
+	encode(&buf, h, d)
+	return buf.Bytes(), nil
+}
+
+// ApplyDelta sets the bits of f that are recorded in data, which must have been produced by MarshalDelta
+// from a filter of the same size, number of hash values, and hash algorithm as f.
+// If data is malformed or was produced from an incompatible filter, ApplyDelta returns an error without modifying the contents of f.
+func (f *Filter) ApplyDelta(data []byte) error {
+	// A delta of a filter of another size is rejected before its bits are allocated.
+	if h, _, err := readHeader(data); err == nil && h.m != uint64(f.m) {
+		return newError(ErrIncompatible, "incompatible filters")
+	}
+	r := bytes.NewReader(data)
+	d, h, _, err := decode(r, true, nil, f.m)
+	if err != nil {
+		return err
+	}
+	if r.Len() != 0 {
+		return newError(ErrCorrupt, "filter size does not match data length")
+	}
+	var g Filter
+	g.load(h, d)
+	return f.Union(&g)
+}
+
+// compatible reports whether f and g have the same size, number of hash values, and hash algorithm,
+// so that an item has the same bit positions in each.
+func (f *Filter) compatible(g *Filter) bool {
+	return f.m == g.m && f.k == g.k && f.hash == g.hash
+}
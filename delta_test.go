@@ -0,0 +1,68 @@
+package bloom
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDelta(t *testing.T) {
+	f := New(1024, 4)
+	replica := New(1024, 4)
+	for i := 0; i < 5; i++ {
+		since := filter(f.k, f.bytes()...)
+		for j := 0; j < 10*i; j++ {
+			f.Insert([]byte{byte(i), byte(j)})
+		}
+		data, err := f.MarshalDelta(since)
+		if err != nil {
+			t.Fatalf("TestDelta: %v", err)
+		}
+		if full, _ := f.MarshalBinary(); len(data) >= len(full) && i > 1 {
+			t.Errorf("TestDelta: delta of %v bytes is no smaller than full filter of %v bytes", len(data), len(full))
+		}
+		if err := replica.ApplyDelta(data); err != nil {
+			t.Fatalf("TestDelta: %v", err)
+		}
+		if !reflect.DeepEqual(replica, f) {
+			t.Errorf("TestDelta: got %v, want %v", replica, f)
+		}
+	}
+}
+
+func TestDeltaErrors(t *testing.T) {
+	f := New(4, 2)
+	f.Insert([]byte("a"))
+	if _, err := f.MarshalDelta(New(8, 2)); err == nil {
+		t.Errorf("TestDeltaErrors: MarshalDelta accepted filter of different size")
+	}
+	if _, err := f.MarshalDelta(New(4, 3)); err == nil {
+		t.Errorf("TestDeltaErrors: MarshalDelta accepted filter with different number of hash values")
+	}
+
+	data, err := f.MarshalDelta(New(4, 2))
+	if err != nil {
+		t.Fatalf("TestDeltaErrors: %v", err)
+	}
+	full, _ := f.MarshalBinary()
+	for _, test := range []struct {
+		f    *Filter
+		data []byte
+	}{
+		{New(8, 2), data},
+		{New(4, 3), data},
+		{New(4, 2), full},
+		{New(4, 2), data[:len(data)-1]},
+		{New(4, 2), append(data, 0)},
+	} {
+		g := filter(test.f.k, test.f.bytes()...)
+		if err := g.ApplyDelta(test.data); err == nil {
+			t.Errorf("TestDeltaErrors(%v, %v): got nil error", test.f, test.data)
+		}
+		if !reflect.DeepEqual(g, test.f) {
+			t.Errorf("TestDeltaErrors(%v, %v): modified filter to %v", test.f, test.data, g)
+		}
+	}
+	if err := new(Filter).UnmarshalBinary(data); err == nil {
+		t.Errorf("TestDeltaErrors: UnmarshalBinary accepted delta")
+	}
+}
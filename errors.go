@@ -0,0 +1,71 @@
+package bloom
+
+import (
+	"errors"
+	"io"
+)
+
+// Errors returned in decoding a Filter or combining filters. An error returned by UnmarshalBinary,
+// ReadFrom, NewFromBytesNoCopy, ApplyDelta, MarshalMissing, Union, Intersect, or a method that decodes
+// or encodes another of a Filter's forms that concerns its data or parameters wraps one of these,
+// so that callers can test its kind with errors.Is; its message describes the particular problem.
+var (
+	// ErrEmptyData is returned for data of length zero.
+	ErrEmptyData = errors.New("empty data")
+
+	// ErrTruncated is returned for data that ends before the filter it describes.
+	// An error that wraps it also wraps io.ErrUnexpectedEOF.
+	ErrTruncated = errors.New("unexpected end of data")
+
+	// ErrCorrupt is returned for data that is malformed or fails checksum verification.
+	ErrCorrupt = errors.New("corrupt data")
+
+	// ErrUnsupported is returned for data in a format version, form, hash algorithm, or seed
+	// that this package or the method does not support.
+	ErrUnsupported = errors.New("unsupported format")
+
+	// ErrBadSize is returned for a filter size that its hash algorithm does not support.
+	ErrBadSize = errors.New("invalid filter size")
+
+	// ErrBadHashCount is returned for a number of hash values that its hash algorithm does not support.
+	ErrBadHashCount = errors.New("invalid number of hash values")
+
+	// ErrIncompatible is returned for filters that differ in size, number of hash values, or hash algorithm.
+	ErrIncompatible = errors.New("incompatible filters")
+)
+
+// A dataError is an error of one of the kinds above, with a message that describes it more specifically.
+type dataError struct {
+	kind error // one of the exported errors
+	msg  string
+	err  error // an underlying error, or nil
+}
+
+func (e *dataError) Error() string { return e.msg }
+
+func (e *dataError) Unwrap() []error {
+	if e.err == nil {
+		return []error{e.kind}
+	}
+	return []error{e.kind, e.err}
+}
+
+// newError returns an error of the given kind with message msg.
+// An error of kind ErrTruncated also wraps io.ErrUnexpectedEOF.
+func newError(kind error, msg string) error {
+	if kind == ErrTruncated {
+		return &dataError{kind: kind, msg: msg, err: io.ErrUnexpectedEOF}
+	}
+	return &dataError{kind: kind, msg: msg}
+}
+
+// errTruncated is the error for data that ends before the filter it describes.
+var errTruncated error = &dataError{kind: ErrTruncated, msg: io.ErrUnexpectedEOF.Error(), err: io.ErrUnexpectedEOF}
+
+// eofError converts an io.EOF or io.ErrUnexpectedEOF encountered partway through a Filter's data into errTruncated.
+func eofError(err error) error {
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return errTruncated
+	}
+	return err
+}
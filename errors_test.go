@@ -0,0 +1,94 @@
+package bloom
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+)
+
+var unmarshalErrorTests = []struct {
+	data []byte
+	want error
+}{
+	{nil, ErrEmptyData},
+	{[]byte{'B', 'L', 'M', 'F'}, ErrTruncated},
+	{binaryOf(1)[:23], ErrTruncated},
+	{binaryOf(1), ErrBadSize},
+	{binaryOf(1, 0, 0, 0), ErrBadSize},
+	{binaryOf(0, 0), ErrBadHashCount},
+	{binaryOf(17, 0), ErrBadHashCount},
+	{unchecked(1, 0)[:24], ErrTruncated},
+	{binaryOf(1, 0)[:27], ErrTruncated},
+	{append(unchecked(1, 0), 0), ErrCorrupt},
+	{withByte(binaryOf(1, 0), 4, 2), ErrUnsupported},
+	{withByte(binaryOf(1, 0), 5, 9), ErrUnsupported},
+	{withByte(binaryOf(1, 0), 6, 3), ErrUnsupported},
+	{withByte(binaryOf(1, 0), 15, 1), ErrUnsupported},
+	{withByte(binaryOf(1, 0), 24, 1), ErrCorrupt},
+	{sparse(1, 32, 1, 32), ErrCorrupt},
+	{sparse(1, 32, 33), ErrCorrupt},
+	{sparse(1, 32, 2, 3)[:26], ErrTruncated},
+	{withByte(unchecked(1, 0), 6, flagDelta), ErrUnsupported},
+	{[]byte{0, 0, 0, 1}, ErrBadSize},
+	{[]byte{0, 0}, ErrBadHashCount},
+	{[]byte{0, 17}, ErrBadHashCount},
+}
+
+func TestUnmarshalBinaryErrors(t *testing.T) {
+	for _, test := range unmarshalErrorTests {
+		err := new(Filter).UnmarshalBinary(test.data)
+		if !errors.Is(err, test.want) {
+			t.Errorf("TestUnmarshalBinaryErrors(%v): got %v, want %v", test.data, err, test.want)
+		}
+		if errors.Is(test.want, ErrTruncated) != errors.Is(err, io.ErrUnexpectedEOF) {
+			t.Errorf("TestUnmarshalBinaryErrors(%v): got %v, which wraps io.ErrUnexpectedEOF only with ErrTruncated", test.data, err)
+		}
+	}
+}
+
+func TestFormatErrors(t *testing.T) {
+	f := New(8, 2)
+	proto, _ := f.MarshalProto()
+	text, _ := f.MarshalText()
+	for name, test := range map[string]struct {
+		err  error
+		want error
+	}{
+		"UnmarshalCBOR":        {new(Filter).UnmarshalCBOR([]byte{0x82}), ErrTruncated},
+		"UnmarshalMsgpack":     {new(Filter).UnmarshalMsgpack([]byte{0xc0}), ErrCorrupt},
+		"UnmarshalProto":       {new(Filter).UnmarshalProto(proto[:len(proto)-1]), ErrTruncated},
+		"UnmarshalText":        {new(Filter).UnmarshalText(text[:len(text)-3]), ErrCorrupt},
+		"UnmarshalRedisBloom":  {new(Filter).UnmarshalRedisBloom(nil), ErrTruncated},
+		"UnmarshalBits":        {new(Filter).UnmarshalBits([]byte{0}, BitLayout{WordSize: 3}), ErrUnsupported},
+		"UnmarshalCBOR(tag)":   {new(Filter).UnmarshalCBOR([]byte{0xd8, 0x18, 0x82, 0x01, 0x41, 0}), ErrUnsupported},
+		"WriteParquet(hash)":   {errOf(f.WriteParquet(io.Discard)), ErrUnsupported},
+		"ReadGuava(truncated)": {errOf(new(Filter).ReadGuava(bytes.NewReader([]byte{1}))), ErrTruncated},
+	} {
+		if !errors.Is(test.err, test.want) {
+			t.Errorf("TestFormatErrors(%v): got %v, want %v", name, test.err, test.want)
+		}
+	}
+}
+
+// errOf returns the error of a call that also returns a value.
+func errOf[T any](_ T, err error) error { return err }
+
+func TestIncompatibleErrors(t *testing.T) {
+	f, g := New(1, 1), New(2, 1)
+	for name, err := range map[string]error{
+		"Union":      f.Union(g),
+		"Intersect":  f.Intersect(g),
+		"ApplyDelta": f.ApplyDelta(withByte(unchecked(1, 0, 0), 6, flagDelta)),
+	} {
+		if !errors.Is(err, ErrIncompatible) {
+			t.Errorf("TestIncompatibleErrors(%v): got %v, want %v", name, err, ErrIncompatible)
+		}
+	}
+	if _, err := f.MarshalDelta(g); !errors.Is(err, ErrIncompatible) {
+		t.Errorf("TestIncompatibleErrors(MarshalDelta): got %v, want %v", err, ErrIncompatible)
+	}
+	if _, err := f.MarshalMissing(g.Summary(8)); !errors.Is(err, ErrIncompatible) {
+		t.Errorf("TestIncompatibleErrors(MarshalMissing): got %v, want %v", err, ErrIncompatible)
+	}
+}
@@ -0,0 +1,47 @@
+package bloom
+
+import (
+	"expvar"
+	"math"
+)
+
+// PublishExpvar publishes f's statistics as the expvar variable name, so that they are served,
+// as a JSON object computed at each request, by the standard expvar handler at /debug/vars:
+//
+//	{"size": 65536, "hashValues": 7, "hashAlgorithm": "sha256", "bitCount": 1234,
+//	 "fillRatio": 0.0188, "estimatedItems": 177.9, "falsePositiveRate": 8.4e-13}
+//
+// The estimated number of items is null if every bit is set. Like expvar.Publish,
+// PublishExpvar panics if a variable of the same name is already published.
+func (f *Filter) PublishExpvar(name string) {
+	expvar.Publish(name, expvar.Func(func() any { return expvarStats(f.Stats()) }))
+}
+
+// PublishExpvar publishes s's statistics as the expvar variable name, in the manner of Filter.PublishExpvar.
+// If s counts insertions and lookups, the object also reports them as "inserts" and "queries".
+func (s *SyncFilter) PublishExpvar(name string) {
+	expvar.Publish(name, expvar.Func(func() any {
+		v := expvarStats(s.Stats())
+		if inserts, queries, ok := s.Counts(); ok {
+			v["inserts"], v["queries"] = inserts, queries
+		}
+		return v
+	}))
+}
+
+// expvarStats returns the JSON object that represents st.
+func expvarStats(st Stats) map[string]any {
+	v := map[string]any{
+		"size":              st.Size,
+		"hashValues":        st.HashValues,
+		"hashAlgorithm":     st.HashAlgorithm.String(),
+		"bitCount":          st.BitCount,
+		"fillRatio":         st.FillRatio,
+		"estimatedItems":    st.EstimatedItems,
+		"falsePositiveRate": st.FalsePositiveRate,
+	}
+	if math.IsInf(st.EstimatedItems, 0) {
+		v["estimatedItems"] = nil // JSON has no infinity
+	}
+	return v
+}
@@ -0,0 +1,67 @@
+package bloom
+
+import (
+	"encoding/json"
+	"expvar"
+	"testing"
+)
+
+// expvarValue returns the published expvar variable name, decoded from JSON.
+func expvarValue(t *testing.T, name string) map[string]any {
+	v := expvar.Get(name)
+	if v == nil {
+		t.Fatalf("expvarValue(%v): not published", name)
+	}
+	var m map[string]any
+	if err := json.Unmarshal([]byte(v.String()), &m); err != nil {
+		t.Fatalf("expvarValue(%v): %v", name, err)
+	}
+	return m
+}
+
+func TestPublishExpvar(t *testing.T) {
+	f := New(8, 3)
+	f.PublishExpvar("TestPublishExpvar.f")
+	f.InsertString("a")
+	got := expvarValue(t, "TestPublishExpvar.f")
+	st := f.Stats()
+	for key, want := range map[string]any{
+		"size":              64.0,
+		"hashValues":        3.0,
+		"hashAlgorithm":     "sha256",
+		"bitCount":          float64(st.BitCount),
+		"fillRatio":         st.FillRatio,
+		"estimatedItems":    st.EstimatedItems,
+		"falsePositiveRate": st.FalsePositiveRate,
+	} {
+		if got[key] != want {
+			t.Errorf("TestPublishExpvar(%v): got %v, want %v", key, got[key], want)
+		}
+	}
+	if _, ok := got["inserts"]; ok {
+		t.Errorf("TestPublishExpvar: Filter reports inserts")
+	}
+
+	// A saturated filter has no finite estimate.
+	full := filter(1, 0xff)
+	full.PublishExpvar("TestPublishExpvar.full")
+	if got := expvarValue(t, "TestPublishExpvar.full"); got["estimatedItems"] != nil || got["fillRatio"] != 1.0 {
+		t.Errorf("TestPublishExpvar(full): got %v", got)
+	}
+
+	s := NewSyncFilter(New(8, 3), WithCounts())
+	s.PublishExpvar("TestPublishExpvar.s")
+	s.Insert([]byte("a"))
+	s.MaybeContains([]byte("b"))
+	s.MaybeContains([]byte("c"))
+	if got := expvarValue(t, "TestPublishExpvar.s"); got["inserts"] != 1.0 || got["queries"] != 2.0 || got["hashValues"] != 3.0 {
+		t.Errorf("TestPublishExpvar(SyncFilter): got %v", got)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("TestPublishExpvar: reused name did not panic")
+		}
+	}()
+	f.PublishExpvar("TestPublishExpvar.f")
+}
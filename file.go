@@ -0,0 +1,239 @@
+package bloom
+
+import (
+	"encoding/binary"
+	"errors"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// A FileFilter is a Filter bound to a file, to which Sync writes the pages of bits modified since the previous Sync.
+// The file holds the filter in the binary form produced by MarshalBinary, without sparse encoding or a checksum,
+// so that it remains valid if writing is interrupted: at worst it lacks some of the bits set since the previous Sync.
+// Its methods may be called concurrently from multiple goroutines.
+type FileFilter struct {
+	mu     sync.RWMutex // held for writing while dirty pages are collected
+	syncMu sync.Mutex   // serializes Sync
+	f      *Filter
+	file   *os.File
+	resync bool // a Sync failed, so the next one must write every page
+
+	wal    *os.File     // the write-ahead log, if enabled
+	walOff atomic.Int64 // the offset of the next record in the log
+	errMu  sync.Mutex
+	walErr error // the first error encountered in appending to the log since the previous Sync
+
+	syncInserts int64         // the number of insertions that trigger an automatic Sync, or 0
+	inserts     atomic.Int64  // insertions since the previous automatic Sync
+	kick        chan struct{} // signals the automatic Sync goroutine, if enabled, that syncInserts is reached
+	stop        chan struct{} // closed to stop the automatic Sync goroutine
+	done        chan struct{} // closed when the automatic Sync goroutine has returned
+}
+
+// A FileOption configures CreateFile and OpenFile.
+type FileOption func(*fileOptions)
+
+type fileOptions struct {
+	wal          bool
+	syncInterval time.Duration
+	syncInserts  int
+	onSyncError  func(error)
+}
+
+// WithWAL enables a write-ahead log, kept in a file whose path is that of the filter followed by ".wal".
+// Insert appends each item's hash to the log before setting its bits, and Sync empties the log
+// once the bits it records are stored in the filter's file. OpenFile replays the log,
+// so that an insertion survives a crash of the process as soon as Insert returns,
+// rather than only after the next Sync.
+func WithWAL() FileOption {
+	return func(o *fileOptions) { o.wal = true }
+}
+
+// newFileOptions returns the options configured by opts.
+func newFileOptions(opts []FileOption) fileOptions {
+	var o fileOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// CreateFile creates or truncates the file at path, writes f to it, and returns a FileFilter bound to it.
+// The FileFilter takes ownership of f: the caller must not use f after calling CreateFile.
+func CreateFile(path string, f *Filter, opts ...FileOption) (*FileFilter, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	s := &FileFilter{f: f, file: file}
+	if err := s.writeAll(); err != nil {
+		file.Close()
+		return nil, err
+	}
+	o := newFileOptions(opts)
+	if err := s.openWAL(path, &o, true); err != nil {
+		file.Close()
+		return nil, err
+	}
+	s.startAutoSync(&o)
+	return s, nil
+}
+
+// OpenFile opens the file at path, which must hold a Filter in the binary form produced by MarshalBinary,
+// and returns a FileFilter bound to it. If the file's data is in sparse encoding or has a checksum,
+// OpenFile rewrites it in the form described for FileFilter.
+func OpenFile(path string, opts ...FileOption) (*FileFilter, error) {
+	file, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		return nil, err
+	}
+	s := &FileFilter{f: new(Filter), file: file}
+	if err := s.open(); err != nil {
+		file.Close()
+		return nil, err
+	}
+	o := newFileOptions(opts)
+	if err := s.openWAL(path, &o, false); err != nil {
+		file.Close()
+		return nil, err
+	}
+	s.startAutoSync(&o)
+	return s, nil
+}
+
+// open reads s's Filter from its file, rewriting the file if it is not in the form described for FileFilter.
+func (s *FileFilter) open() error {
+	var hb [headerSize]byte
+	if _, err := s.file.ReadAt(hb[:], 0); err != nil {
+		return eofError(err)
+	}
+	h, _, err := readHeader(hb[:])
+	if err != nil {
+		return err
+	}
+	n, err := s.f.ReadFrom(s.file)
+	if err != nil {
+		return err
+	}
+	fi, err := s.file.Stat()
+	if err != nil {
+		return err
+	}
+	if fi.Size() != n {
+		return newError(ErrCorrupt, "filter size does not match file length")
+	}
+	if h.flags != 0 {
+		return s.writeAll()
+	}
+	s.f.markShared() // every page is clean
+	return nil
+}
+
+// writeAll writes the whole of s's Filter to its file and synchronizes it.
+func (s *FileFilter) writeAll() error {
+	h := s.f.header()
+	h.flags = 0
+	s.f.markShared() // every page is clean
+	b := appendHeader(make([]byte, 0, headerSize+(s.f.m+7)/8), h)
+	b = append(b, s.f.bytes()...)
+	if _, err := s.file.WriteAt(b, 0); err != nil {
+		return err
+	}
+	if err := s.file.Truncate(int64(len(b))); err != nil {
+		return err
+	}
+	return s.file.Sync()
+}
+
+// Insert inserts item into s's set. The insertion is written to the file by the next call to Sync.
+// If s has a write-ahead log, Insert first appends the item's hash to it;
+// an error in doing so is reported by the next call to Sync.
+func (s *FileFilter) Insert(item []byte) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	d := s.f.hash.digest(item)
+	if s.wal != nil {
+		s.appendWAL(&d)
+	}
+	s.f.insertDigest(&d)
+	if s.kick != nil && s.inserts.Add(1) >= s.syncInserts {
+		select {
+		case s.kick <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// MaybeContains reports whether item is probably in s's set, in the manner of Filter.MaybeContains.
+func (s *FileFilter) MaybeContains(item []byte) bool {
+	return s.f.MaybeContains(item)
+}
+
+// Sync writes to the file the pages of bits that have been modified since the previous call to Sync,
+// and then commits the file to stable storage. Insertions may proceed while it writes.
+// If s has a write-ahead log, Sync then removes from it the records of the insertions that have been written.
+func (s *FileFilter) Sync() error {
+	s.syncMu.Lock()
+	defer s.syncMu.Unlock()
+
+	// A page that is not shared has been copied, and so modified, since the pages were last marked clean.
+	// Marking a page shared keeps it from being modified again, so it can be written without holding s.mu.
+	type dirtyPage struct {
+		i int
+		p *page
+	}
+	var dirty []dirtyPage
+	s.mu.Lock()
+	for i := range s.f.pages {
+		if p := s.f.page(i); s.resync || !p.shared.Load() {
+			p.shared.Store(true)
+			dirty = append(dirty, dirtyPage{i, p})
+		}
+	}
+	walOff := s.walOff.Load()
+	s.errMu.Lock()
+	walErr := s.walErr
+	s.walErr = nil
+	s.errMu.Unlock()
+	s.mu.Unlock()
+
+	s.resync = true
+	n := (s.f.m + 7) / 8
+	var b []byte
+	for _, d := range dirty {
+		b = b[:0]
+		for j := range d.p.w {
+			b = binary.LittleEndian.AppendUint64(b, atomic.LoadUint64(&d.p.w[j]))
+		}
+		off := d.i * pageWords * 8
+		if _, err := s.file.WriteAt(b[:min(len(b), n-off)], int64(headerSize+off)); err != nil {
+			return errors.Join(walErr, err)
+		}
+	}
+	if err := s.file.Sync(); err != nil {
+		return errors.Join(walErr, err)
+	}
+	s.resync = false
+	if s.wal != nil {
+		if err := s.trimWAL(walOff); err != nil {
+			return errors.Join(walErr, err)
+		}
+	}
+	return walErr
+}
+
+// Close stops automatic synchronization, if enabled, calls Sync, and closes the file.
+// The FileFilter must not be used after Close.
+func (s *FileFilter) Close() error {
+	if s.stop != nil {
+		close(s.stop)
+		<-s.done
+	}
+	err := errors.Join(s.Sync(), s.file.Close())
+	if s.wal != nil {
+		err = errors.Join(err, s.wal.Close())
+	}
+	return err
+}
@@ -0,0 +1,145 @@
+package bloom
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+// fileContents returns the Filter stored in the file at path.
+func fileContents(t *testing.T, path string) *Filter {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data[6] != 0 {
+		t.Errorf("fileContents(%v): got flags %#x, want 0", path, data[6])
+	}
+	f := new(Filter)
+	if err := f.UnmarshalBinary(data); err != nil {
+		t.Fatalf("fileContents(%v): %v", path, err)
+	}
+	return f
+}
+
+func TestFileFilter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "filter")
+	const m = 2*pageWords*64 + 100
+	s, err := CreateFile(path, NewWithHash(m, 4, BitsAndBlooms))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := NewWithHash(m, 4, BitsAndBlooms)
+	if got := fileContents(t, path); !equal(got, want) {
+		t.Errorf("TestFileFilter: created file holds %v, want %v", got, want)
+	}
+
+	for _, n := range []int{100, 1} {
+		for i := range n {
+			item := []byte(fmt.Sprint(n, i))
+			s.Insert(item)
+			want.Insert(item)
+		}
+		if got := fileContents(t, path); n == 100 && equal(got, want) {
+			t.Errorf("TestFileFilter(%v): file modified before Sync", n)
+		}
+		if err := s.Sync(); err != nil {
+			t.Errorf("TestFileFilter(%v): %v", n, err)
+		}
+		if got := fileContents(t, path); !equal(got, want) {
+			t.Errorf("TestFileFilter(%v): got %v, want %v", n, got, want)
+		}
+	}
+	s.Insert([]byte("last"))
+	want.Insert([]byte("last"))
+	if err := s.Close(); err != nil {
+		t.Errorf("TestFileFilter: %v", err)
+	}
+
+	s, err = OpenFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !equal(s.f, want) || !s.MaybeContains([]byte("last")) {
+		t.Errorf("TestFileFilter: reopened filter is %v, want %v", s.f, want)
+	}
+	s.Insert([]byte("reopened"))
+	want.Insert([]byte("reopened"))
+	if err := s.Close(); err != nil {
+		t.Errorf("TestFileFilter: %v", err)
+	}
+	if got := fileContents(t, path); !equal(got, want) {
+		t.Errorf("TestFileFilter: got %v, want %v", got, want)
+	}
+}
+
+func TestOpenFile(t *testing.T) {
+	dir := t.TempDir()
+	for _, test := range marshalTests {
+		// A file in another form is rewritten.
+		path := filepath.Join(dir, "filter")
+		if err := os.WriteFile(path, test.data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		s, err := OpenFile(path)
+		if err != nil {
+			t.Errorf("TestOpenFile(%v): %v", test.f, err)
+			continue
+		}
+		if err := s.Close(); err != nil {
+			t.Errorf("TestOpenFile(%v): %v", test.f, err)
+		}
+		if got := fileContents(t, path); !equal(got, test.f) {
+			t.Errorf("TestOpenFile(%v): got %v", test.f, got)
+		}
+	}
+
+	for i, data := range [][]byte{nil, append(mustMarshal(New(1, 1)), 0), unchecked(1, 0, 0, 0)} {
+		path := filepath.Join(dir, "invalid")
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if s, err := OpenFile(path); err == nil {
+			t.Errorf("TestOpenFile(invalid %v): got %v, nil error", i, s.f)
+			s.Close()
+		}
+	}
+	if _, err := OpenFile(filepath.Join(dir, "missing")); err == nil {
+		t.Errorf("TestOpenFile(missing): got nil error")
+	}
+}
+
+func TestFileFilterConcurrentSync(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "filter")
+	s, err := CreateFile(path, NewWithHash(4*pageWords*64, 4, BitsAndBlooms))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var wg sync.WaitGroup
+	for g := range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range 200 {
+				s.Insert([]byte(fmt.Sprint(g, i)))
+				if i%50 == 0 {
+					s.Sync()
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if err := s.Close(); err != nil {
+		t.Errorf("TestFileFilterConcurrentSync: %v", err)
+	}
+	got := fileContents(t, path)
+	for g := range 4 {
+		for i := range 200 {
+			if !got.MaybeContains([]byte(fmt.Sprint(g, i))) {
+				t.Errorf("TestFileFilterConcurrentSync(%v, %v): item not in file", g, i)
+			}
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package bloom
+
+import (
+	"sync/atomic"
+	"unsafe"
+)
+
+// A FrozenFilter is an immutable copy of a Filter that supports only queries.
+// Its bits are held in a single contiguous array and read without atomic operations,
+// which makes its lookups faster than those of a Filter.
+// It is safe for concurrent use by multiple goroutines.
+type FrozenFilter struct {
+	w      []uint64 // bit n is bit n%64 of w[n/64]
+	params Filter   // the size, number of hash values, and hash algorithm, without bits
+}
+
+// Freeze returns a FrozenFilter holding a copy of f's current contents.
+// It may be called concurrently with the same methods as MaybeContains.
+func (f *Filter) Freeze() *FrozenFilter {
+	w := make([]uint64, 0, (f.m+63)/64)
+	for i := range f.pages {
+		p := f.page(i)
+		for j := range p.w {
+			w = append(w, atomic.LoadUint64(&p.w[j]))
+		}
+	}
+	return &FrozenFilter{w: w, params: Filter{k: f.k, m: f.m, hash: f.hash}}
+}
+
+// MaybeContains reports whether item is probably in z's set, in the manner of Filter.MaybeContains.
+func (z *FrozenFilter) MaybeContains(item []byte) bool {
+	d := z.params.hash.digest(item)
+	for i := 0; i < z.params.k; i++ {
+		n := z.params.location(&d, i)
+		if z.w[n/64]>>uint(n%64)&1 == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// MaybeContainsString reports whether the bytes of s are probably in z's set,
+// in the manner of MaybeContains but without copying s.
+func (z *FrozenFilter) MaybeContainsString(s string) bool {
+	return z.MaybeContains(unsafe.Slice(unsafe.StringData(s), len(s)))
+}
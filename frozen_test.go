@@ -0,0 +1,33 @@
+package bloom
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestFreeze(t *testing.T) {
+	for h := range Hash(len(hashNames)) {
+		k := 4
+		if h == ParquetSBBF {
+			k = sbbfWords
+		}
+		f := NewWithHash(1<<16, k, h)
+		for i := 0; i < 1000; i += 2 {
+			f.Insert([]byte(fmt.Sprint(i)))
+		}
+		z := f.Freeze()
+		f.Insert([]byte("after"))
+		for i := range 1000 {
+			item := fmt.Sprint(i)
+			if got, want := z.MaybeContains([]byte(item)), f.MaybeContains([]byte(item)); got != want {
+				t.Errorf("TestFreeze(%v, %v): got %v, want %v", h, i, got, want)
+			}
+			if got, want := z.MaybeContainsString(item), z.MaybeContains([]byte(item)); got != want {
+				t.Errorf("TestFreeze(%v, %v): MaybeContainsString got %v, want %v", h, i, got, want)
+			}
+		}
+		if z.MaybeContains([]byte("after")) {
+			t.Errorf("TestFreeze(%v): frozen filter modified by insertion", h)
+		}
+	}
+}
@@ -0,0 +1,21 @@
+package bloom
+
+import "io/fs"
+
+// LoadFS returns the Filter in the file name in fsys, which must hold a Filter in the binary form
+// produced by MarshalBinary or in the legacy form, validating it in the same manner as UnmarshalBinary.
+// It suits filters built in advance and embedded in a program with an embed.FS:
+//
+//	//go:embed blocklist.bloom
+//	var assets embed.FS
+//
+//	blocklist, err := bloom.LoadFS(assets, "blocklist.bloom")
+//
+// The Filter holds the file's contents without copying them a second time.
+func LoadFS(fsys fs.FS, name string) (*Filter, error) {
+	data, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return nil, err
+	}
+	return NewFromBytesNoCopy(data)
+}
@@ -0,0 +1,32 @@
+package bloom
+
+import (
+	"testing"
+	"testing/fstest"
+)
+
+func TestLoadFS(t *testing.T) {
+	f := NewWithHash(1<<12, 4, BitsAndBlooms)
+	f.Insert([]byte("x"))
+	data := mustMarshal(f)
+	fsys := fstest.MapFS{
+		"f.bloom":     {Data: data},
+		"short.bloom": {Data: data[:len(data)-1]},
+	}
+	g, err := LoadFS(fsys, "f.bloom")
+	if err != nil {
+		t.Fatalf("TestLoadFS: %v", err)
+	}
+	if !equal(g, f) {
+		t.Errorf("TestLoadFS: got %v, want %v", g, f)
+	}
+	g.Insert([]byte("y"))
+	if h, _ := LoadFS(fsys, "f.bloom"); h.MaybeContains([]byte("y")) && !f.MaybeContains([]byte("y")) {
+		t.Errorf("TestLoadFS: Insert modified file contents")
+	}
+	for _, name := range []string{"short.bloom", "missing.bloom"} {
+		if _, err := LoadFS(fsys, name); err == nil {
+			t.Errorf("TestLoadFS(%v): got nil error", name)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package bloom
+
+import (
+	"encoding/binary"
+	"math/rand"
+	"reflect"
+)
+
+// Generate returns a random Filter for property-based tests with testing/quick, and satisfies the quick.Generator interface.
+// The Filter uses a random hash algorithm, with a size and number of hash values that the algorithm supports,
+// and contains up to size random items of up to size bytes. A larger size also permits a larger filter.
+func (*Filter) Generate(r *rand.Rand, size int) reflect.Value {
+	f := arbitrary(r.Intn, size)
+	for range r.Intn(size + 1) {
+		item := make([]byte, r.Intn(size+1))
+		r.Read(item)
+		f.Insert(item)
+	}
+	return reflect.ValueOf(f)
+}
+
+// ArbitraryFilter returns a Filter derived from data, for fuzz tests of code that consumes Filters:
+// every data, including whatever a fuzzing engine's mutations produce, yields a valid Filter, and the same data yields the same Filter.
+// The first bytes of data choose the Filter's hash algorithm, size, and number of hash values,
+// and the remainder is a sequence of items to insert, each preceded by its length as a single byte.
+func ArbitraryFilter(data []byte) *Filter {
+	intn := func(n int) int {
+		var b [2]byte
+		data = data[copy(b[:], data):]
+		return int(binary.BigEndian.Uint16(b[:])) % n
+	}
+	f := arbitrary(intn, 255)
+	for len(data) > 0 {
+		l := min(int(data[0]), len(data)-1)
+		f.Insert(data[1 : 1+l])
+		data = data[1+l:]
+	}
+	return f
+}
+
+// arbitrary returns an empty Filter whose hash algorithm, size, and number of hash values are chosen by intn,
+// which returns a value in the range [0, n). Except for SHA256 filters, the size grows with size.
+func arbitrary(intn func(n int) int, size int) *Filter {
+	h := Hash(intn(len(hashNames)))
+	switch h {
+	case SHA256:
+		return New(1<<intn(14), 1+intn(maxHashValues))
+	case GuavaMitz32, GuavaMitz64:
+		return NewWithHash(64*(1+intn(size+1)), 1+intn(maxK), h)
+	case ParquetSBBF:
+		return NewWithHash(sbbfBlockBits*(1+intn(size+1)), sbbfWords, h)
+	default:
+		return NewWithHash(8*(1+intn(8*size+1)), 1+intn(maxK), h)
+	}
+}
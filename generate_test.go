@@ -0,0 +1,64 @@
+package bloom
+
+import (
+	"bytes"
+	"testing"
+	"testing/quick"
+)
+
+func TestGenerate(t *testing.T) {
+	// Every generated Filter is valid, so it survives a round trip through its binary form.
+	hashes := make(map[Hash]bool)
+	if err := quick.Check(func(f *Filter) bool {
+		hashes[f.hash] = true
+		g := new(Filter)
+		return checkParams(f.hash, uint64(f.m), uint64(f.k)) == nil &&
+			g.UnmarshalBinary(mustMarshal(f)) == nil && equal(f, g)
+	}, &quick.Config{MaxCount: 200}); err != nil {
+		t.Errorf("TestGenerate: %v", err)
+	}
+	if len(hashes) != len(hashNames) {
+		t.Errorf("TestGenerate: generated filters use %v hash algorithms, want %v", len(hashes), len(hashNames))
+	}
+}
+
+func TestArbitraryFilter(t *testing.T) {
+	for _, data := range [][]byte{
+		nil,
+		{0},
+		{0, 0, 0, 5, 0, 2, 3, 'a', 'b', 'c', 0, 9, 'x'},
+		{1, 0, 0, 200, 0, 8, 3, 'a', 'b', 'c', 1, 'd'},
+		{5, 7, 0, 3, 1, 'a'},
+		bytes.Repeat([]byte{255}, 100),
+	} {
+		f := ArbitraryFilter(data)
+		if err := checkParams(f.hash, uint64(f.m), uint64(f.k)); err != nil {
+			t.Errorf("TestArbitraryFilter(%v): %v", data, err)
+		}
+		if g := ArbitraryFilter(data); !equal(f, g) {
+			t.Errorf("TestArbitraryFilter(%v): got %v and %v from the same data", data, f, g)
+		}
+	}
+
+	f := ArbitraryFilter([]byte{0, 1, 0, 200, 0, 8, 3, 'a', 'b', 'c', 1, 'd', 5, 'e'})
+	if f.hash != BitsAndBlooms || f.m != 8*201 || f.k != 9 {
+		t.Errorf("TestArbitraryFilter: got %v filter of %v bits using %v hash values, want %v, %v, %v", f.hash, f.m, f.k, BitsAndBlooms, 8*201, 9)
+	}
+	for _, item := range []string{"abc", "d", "e"} {
+		if !f.MaybeContainsString(item) {
+			t.Errorf("TestArbitraryFilter(%q): inserted item not found", item)
+		}
+	}
+}
+
+func FuzzArbitraryFilter(f *testing.F) {
+	f.Add([]byte{})
+	f.Add([]byte{0, 3, 0, 64, 0, 4, 1, 'a', 2, 'b', 'c'})
+	f.Fuzz(func(t *testing.T, data []byte) {
+		g := ArbitraryFilter(data)
+		r := new(Filter)
+		if err := r.UnmarshalBinary(mustMarshal(g)); err != nil || !equal(r, g) {
+			t.Errorf("FuzzArbitraryFilter(%v): round trip got %v, %v, want %v", data, r, err, g)
+		}
+	})
+}
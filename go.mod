@@ -0,0 +1,3 @@
+module github.com/dkmccandless/bloom
+
+go 1.24
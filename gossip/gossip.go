@@ -0,0 +1,132 @@
+// Package gossip replicates a Bloom filter among peers by gossip, so that every replica converges
+// to the union of the items inserted at any of them. It suits distributed suppression of duplicates,
+// such as of messages already seen, where each replica may answer from its own copy.
+//
+// In each round, a Replicator sends the bits set in its filter since the previous round to a few peers
+// chosen at random. Bits a replica receives are new to its filter, so it passes them on in its next round,
+// and they spread through the cluster epidemically. Every few rounds, a Replicator also sends its entire filter
+// to one peer, which repairs the loss of deltas that could not be delivered.
+// Because filters only gain bits and merging is a union, deltas may be lost, duplicated, or reordered
+// without affecting the result. Filters must not be reset while replicated.
+package gossip
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"github.com/dkmccandless/bloom"
+)
+
+// A Transport carries messages between the peers of a cluster, in the manner of a memberlist-style library.
+// It delivers each message it receives to the Replicator's Receive method.
+type Transport interface {
+	// Peers returns the addresses of the cluster's other members.
+	Peers() []string
+
+	// Send sends msg to the peer at addr. It does not retain msg.
+	Send(ctx context.Context, addr string, msg []byte) error
+}
+
+// Message types, which are the first byte of each message
+const (
+	msgDelta = 'D' // followed by a delta produced by bloom.Filter.MarshalDelta
+	msgFull  = 'F' // followed by a filter in the binary form produced by bloom.Filter.MarshalBinary
+)
+
+// A Replicator replicates a SyncFilter among the peers of a Transport. Its methods may be called concurrently.
+type Replicator struct {
+	s         *bloom.SyncFilter
+	t         Transport
+	fanout    int
+	fullEvery int
+
+	mu    sync.Mutex // serializes rounds
+	last  *bloom.Filter
+	round int
+}
+
+// NewReplicator returns a Replicator that sends each delta to fanout peers, and its entire filter to one peer
+// every fullEvery rounds, or never if fullEvery is 0. It panics if fanout is not positive or fullEvery is negative.
+func NewReplicator(s *bloom.SyncFilter, t Transport, fanout, fullEvery int) *Replicator {
+	if fanout <= 0 || fullEvery < 0 {
+		panic("gossip: fanout or full synchronization interval out of range")
+	}
+	return &Replicator{s: s, t: t, fanout: fanout, fullEvery: fullEvery, last: s.Snapshot()}
+}
+
+// Gossip performs a round of gossip: it sends the bits set since the previous round, if any, to fanout peers,
+// and, if the round is due, the entire filter to one peer. It returns the errors of any failed sends.
+// The bits of a failed delta are not resent except by a later full synchronization.
+func (r *Replicator) Gossip(ctx context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	cur := r.s.Snapshot()
+	last := r.last
+	r.last = cur
+	r.round++
+	peers := r.t.Peers()
+	if len(peers) == 0 {
+		return nil
+	}
+	rand.Shuffle(len(peers), func(i, j int) { peers[i], peers[j] = peers[j], peers[i] })
+
+	var errs []error
+	if cur.BitCount() != last.BitCount() {
+		d, err := cur.MarshalDelta(last)
+		if err != nil {
+			return err
+		}
+		msg := append([]byte{msgDelta}, d...)
+		for _, p := range peers[:min(r.fanout, len(peers))] {
+			errs = append(errs, r.t.Send(ctx, p, msg))
+		}
+	}
+	if r.fullEvery > 0 && r.round%r.fullEvery == 0 {
+		b, err := cur.MarshalBinary()
+		if err != nil {
+			return err
+		}
+		errs = append(errs, r.t.Send(ctx, peers[rand.N(len(peers))], append([]byte{msgFull}, b...)))
+	}
+	return errors.Join(errs...)
+}
+
+// Run calls Gossip every interval until ctx is done, passing its errors to onError if it is not nil.
+func (r *Replicator) Run(ctx context.Context, interval time.Duration, onError func(error)) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+		if err := r.Gossip(ctx); err != nil && onError != nil {
+			onError(err)
+		}
+	}
+}
+
+// Receive merges into r's filter the bits in msg, a message sent by a peer's Replicator.
+// It returns an error without modifying the filter if msg is malformed or describes an incompatible filter.
+func (r *Replicator) Receive(msg []byte) error {
+	if len(msg) == 0 {
+		return errors.New("empty message")
+	}
+	switch msg[0] {
+	case msgDelta:
+		return r.s.ApplyDelta(msg[1:])
+	case msgFull:
+		// A peer's filter of any other size is incompatible, so a larger one is rejected before its bits are allocated.
+		g := new(bloom.Filter)
+		if err := g.UnmarshalBinaryLimit(msg[1:], r.s.Size()); err != nil {
+			return err
+		}
+		return r.s.Union(g)
+	default:
+		return errors.New("unknown message type")
+	}
+}
@@ -0,0 +1,118 @@
+package gossip
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/dkmccandless/bloom"
+)
+
+// network is a Transport among Replicators in memory, which drops messages to the peers in down.
+type network struct {
+	self  string
+	nodes map[string]*Replicator
+	down  map[string]bool
+}
+
+func (n *network) Peers() []string {
+	var peers []string
+	for addr := range n.nodes {
+		if addr != n.self {
+			peers = append(peers, addr)
+		}
+	}
+	return peers
+}
+
+func (n *network) Send(ctx context.Context, addr string, msg []byte) error {
+	if n.down[addr] {
+		return errors.New("peer unreachable")
+	}
+	return n.nodes[addr].Receive(msg)
+}
+
+func newFilter() *bloom.Filter { return bloom.NewWithHash(1<<14, 4, bloom.BitsAndBlooms) }
+
+func TestReplicator(t *testing.T) {
+	const n = 8
+	nodes := make(map[string]*Replicator)
+	down := make(map[string]bool)
+	filters := make(map[string]*bloom.SyncFilter)
+	want := newFilter()
+	for i := range n {
+		addr := fmt.Sprint("node", i)
+		filters[addr] = bloom.NewSyncFilter(newFilter())
+		nodes[addr] = NewReplicator(filters[addr], &network{addr, nodes, down}, 2, 4)
+		for j := range 20 {
+			item := []byte(fmt.Sprint(i, j))
+			filters[addr].Insert(item)
+			want.Insert(item)
+		}
+	}
+	// One node misses the first rounds of deltas, and relies on full synchronization.
+	down["node0"] = true
+
+	ctx := context.Background()
+	converged := func() bool {
+		for _, f := range filters {
+			if f.Snapshot().BitCount() != want.BitCount() {
+				return false
+			}
+		}
+		return true
+	}
+	rounds := 0
+	for ; rounds < 100 && !converged(); rounds++ {
+		if rounds == 3 {
+			down["node0"] = false
+		}
+		for _, r := range nodes {
+			r.Gossip(ctx)
+		}
+	}
+	for addr, f := range filters {
+		for i := range n {
+			for j := range 20 {
+				if !f.MaybeContains([]byte(fmt.Sprint(i, j))) {
+					t.Fatalf("TestReplicator: after %v rounds, %v lacks item %v %v", rounds, addr, i, j)
+				}
+			}
+		}
+	}
+}
+
+func TestReplicatorErrors(t *testing.T) {
+	nodes := make(map[string]*Replicator)
+	s := bloom.NewSyncFilter(newFilter())
+	r := NewReplicator(s, &network{"a", nodes, map[string]bool{"b": true}}, 1, 1)
+	nodes["a"] = r
+	nodes["b"] = NewReplicator(bloom.NewSyncFilter(newFilter()), &network{"b", nodes, nil}, 1, 0)
+	s.Insert([]byte("x"))
+	if err := r.Gossip(context.Background()); err == nil {
+		t.Errorf("TestReplicatorErrors: unreachable peer: got nil error")
+	}
+
+	other, _ := bloom.NewWithHash(1<<12, 4, bloom.BitsAndBlooms).MarshalBinary()
+	for _, msg := range [][]byte{nil, []byte("X"), []byte("D"), []byte("F"), append([]byte("F"), other...)} {
+		if err := r.Receive(msg); err == nil {
+			t.Errorf("TestReplicatorErrors(%q): got nil error", msg)
+		}
+	}
+	larger, _ := bloom.NewWithHash(1<<15, 4, bloom.BitsAndBlooms).MarshalBinary()
+	if err := r.Receive(append([]byte("F"), larger...)); !errors.Is(err, bloom.ErrBadSize) {
+		t.Errorf("TestReplicatorErrors(larger filter): got %v, want %v", err, bloom.ErrBadSize)
+	}
+
+	for _, test := range []struct{ fanout, fullEvery int }{{0, 1}, {1, -1}} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("TestReplicatorErrors(%v, %v): did not panic", test.fanout, test.fullEvery)
+				}
+			}()
+			NewReplicator(s, nil, test.fanout, test.fullEvery)
+		}()
+	}
+}
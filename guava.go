@@ -0,0 +1,77 @@
+package bloom
+
+import (
+	"encoding/binary"
+	"io"
+	"math"
+)
+
+// The serialized form written by the writeTo method of Guava's BloomFilter
+// consists of the following fields, with integers in big-endian byte order:
+//
+//	strategy uint8    ordinal of the hash strategy: 0 for MURMUR128_MITZ_32, 1 for MURMUR128_MITZ_64
+//	k        uint8    number of hash functions
+//	length   int32    number of words
+//	words    [length]int64
+//
+// Bit n of the filter is bit n%64 of word n/64, counting from the least significant bit.
+
+// WriteGuava writes f to w in the serialized form used by Guava's BloomFilter,
+// and returns the number of bytes written and any error encountered.
+// It returns an error if f does not use the GuavaMitz32 or GuavaMitz64 hash algorithm,
+// or is too large for Guava to represent.
+func (f *Filter) WriteGuava(w io.Writer) (int64, error) {
+	var strategy byte
+	switch f.hash {
+	case GuavaMitz32:
+		strategy = 0
+	case GuavaMitz64:
+		strategy = 1
+	default:
+		return 0, newError(ErrUnsupported, "filter does not use a Guava hash algorithm")
+	}
+	if f.m/64 > math.MaxInt32 {
+		return 0, newError(ErrBadSize, "filter size out of range")
+	}
+	b := make([]byte, 0, 6+f.m/8)
+	b = append(b, strategy, byte(f.k))
+	b = binary.BigEndian.AppendUint32(b, uint32(f.m/64))
+	n, err := w.Write(appendWords(b, f.bytes()))
+	return int64(n), err
+}
+
+// ReadGuava reads a filter in the serialized form used by Guava's BloomFilter from r
+// and stores it in f as a filter that uses the GuavaMitz32 or GuavaMitz64 hash algorithm.
+// It reads no further than the end of the filter's data, and returns the number of bytes read and any error encountered.
+// If it returns an error, it does not modify the contents of f.
+func (f *Filter) ReadGuava(r io.Reader) (int64, error) {
+	cr := &countReader{r: r}
+	p := make([]byte, 6)
+	if _, err := io.ReadFull(cr, p); err != nil {
+		return cr.n, eofError(err)
+	}
+	var h header
+	switch p[0] {
+	case 0:
+		h.hashAlgorithm = byte(GuavaMitz32)
+	case 1:
+		h.hashAlgorithm = byte(GuavaMitz64)
+	default:
+		return cr.n, newError(ErrUnsupported, "unsupported Guava strategy")
+	}
+	h.k = p[1]
+	length := int32(binary.BigEndian.Uint32(p[2:]))
+	if length <= 0 {
+		return cr.n, newError(ErrBadSize, "filter size out of range")
+	}
+	h.m = uint64(length) * 64
+	if err := checkParams(Hash(h.hashAlgorithm), h.m, uint64(h.k)); err != nil {
+		return cr.n, err
+	}
+	b, err := readWords(cr, h.m)
+	if err != nil {
+		return cr.n, err
+	}
+	f.load(h, b)
+	return cr.n, nil
+}
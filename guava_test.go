@@ -0,0 +1,80 @@
+package bloom
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestGuavaLocations(t *testing.T) {
+	// Locations for a filter of 1024 bits using 5 hash values, following Guava's BloomFilterStrategies
+	for _, test := range []struct {
+		h    Hash
+		s    string
+		locs []int
+	}{
+		{GuavaMitz32, "abc", []int{0x59, 0x11a, 0x224, 0x163, 0x35d}},
+		{GuavaMitz32, "0123456789abcde", []int{0x147, 0x33d, 0x2cc, 0x329, 0x2e0}},
+		{GuavaMitz64, "abc", []int{0x67, 0x1b9, 0x30b, 0x5d, 0x1af}},
+		{GuavaMitz64, "0123456789abcde", []int{0x351, 0x41, 0x131, 0x221, 0x311}},
+	} {
+		f := NewWithHash(1024, 5, test.h)
+		d := f.hash.digest([]byte(test.s))
+		for i, want := range test.locs {
+			if got := f.location(&d, i); got != want {
+				t.Errorf("TestGuavaLocations(%v, %q, %v): got %#x, want %#x", test.h, test.s, i, got, want)
+			}
+		}
+	}
+}
+
+func TestGuava(t *testing.T) {
+	for _, h := range []Hash{GuavaMitz32, GuavaMitz64} {
+		f := NewWithHash(128, 3, h)
+		f.Insert([]byte("a"))
+		f.Insert([]byte("b"))
+
+		var buf bytes.Buffer
+		n, err := f.WriteGuava(&buf)
+		if err != nil || n != 22 || n != int64(buf.Len()) {
+			t.Errorf("TestGuava(%v): wrote %v bytes, %v", h, n, err)
+		}
+		want := append([]byte{byte(h - GuavaMitz32), 3, 0, 0, 0, 2}, appendWords(nil, f.bytes())...)
+		if !bytes.Equal(buf.Bytes(), want) {
+			t.Errorf("TestGuava(%v): got %v, want %v", h, buf.Bytes(), want)
+		}
+
+		g := new(Filter)
+		if n, err := g.ReadGuava(bytes.NewReader(buf.Bytes())); err != nil || n != 22 {
+			t.Errorf("TestGuava(%v): read %v bytes, %v", h, n, err)
+		}
+		if !reflect.DeepEqual(g, f) {
+			t.Errorf("TestGuava(%v): got %v, want %v", h, g, f)
+		}
+	}
+
+	if _, err := New(1, 1).WriteGuava(new(bytes.Buffer)); err == nil {
+		t.Errorf("TestGuava: wrote a filter using SHA256")
+	}
+}
+
+func TestReadGuavaError(t *testing.T) {
+	for _, data := range [][]byte{
+		nil,
+		{0, 3, 0, 0},
+		{2, 3, 0, 0, 0, 1, 0, 0, 0, 0, 0, 0, 0, 0},    // unknown strategy
+		{0, 0, 0, 0, 0, 1, 0, 0, 0, 0, 0, 0, 0, 0},    // no hash functions
+		{0, 3, 0, 0, 0, 0},                            // no words
+		{0, 3, 0xff, 0xff, 0xff, 0xff},                // negative length
+		{0, 3, 0, 0, 0, 1, 0, 0, 0, 0, 0, 0, 0},       // truncated
+		{1, 3, 0, 0, 0, 2, 0, 0, 0, 0, 0, 0, 0, 0x01}, // truncated
+	} {
+		f := New(1, 1)
+		if _, err := f.ReadGuava(bytes.NewReader(data)); err == nil {
+			t.Errorf("TestReadGuavaError(%v): got nil error", data)
+		}
+		if !reflect.DeepEqual(f, New(1, 1)) {
+			t.Errorf("TestReadGuavaError(%v): modified filter: %v", data, f)
+		}
+	}
+}
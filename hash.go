@@ -0,0 +1,185 @@
+package bloom
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"math"
+	"math/bits"
+	"strconv"
+)
+
+// A Hash identifies the algorithm that a Filter uses to derive the bit positions of an item.
+// Algorithms other than SHA256 are provided for compatibility with other Bloom filter implementations,
+// and support filters of up to 2^40 bits using up to 255 hash values, subject to the constraints noted for each.
+type Hash uint8
+
+const (
+	// SHA256 derives each bit position from a pair of bytes of the item's SHA-256 hash.
+	// It supports filters whose size in bits is a power of 2 in the range [8, 65536], using up to 16 hash values.
+	SHA256 Hash = iota
+
+	// BitsAndBlooms derives bit positions in the manner of github.com/bits-and-blooms/bloom,
+	// from the 128-bit x64 MurmurHash3 hashes of the item and of the item followed by a byte of value 1.
+	BitsAndBlooms
+
+	// GuavaMitz32 and GuavaMitz64 derive bit positions in the manner of the MURMUR128_MITZ_32 and MURMUR128_MITZ_64
+	// strategies of Guava's BloomFilter, from the 128-bit x64 MurmurHash3 hash of the item.
+	// The bytes of an item are those that the filter's Funnel would write:
+	// for example, the UTF-8 encoding of a string for Funnels.stringFunnel(UTF_8),
+	// or the little-endian encoding of a long for Funnels.longFunnel().
+	// They support filters whose size in bits is a multiple of 64.
+	GuavaMitz32
+	GuavaMitz64
+
+	// RedisBloom derives bit positions in the manner of the filters created by RedisBloom's BF.RESERVE and BF.ADD,
+	// from the 64-bit MurmurHash64A hashes of the item.
+	// It supports filters whose size in bits is a multiple of 8.
+	RedisBloom
+
+	// ParquetSBBF derives bit positions in the manner of the split block Bloom filters of Apache Parquet,
+	// from the 64-bit xxHash64 hash of the item. The bytes of an item are the PLAIN encoding of a column value:
+	// for example, the little-endian encoding of an INT64, or the bytes of a BYTE_ARRAY without its length.
+	// It supports filters whose size in bits is a multiple of 256, using 8 hash values,
+	// one in each 32-bit word of a 256-bit block.
+	ParquetSBBF
+)
+
+// hashNames holds the names of the hash algorithms, as returned by Hash.String.
+var hashNames = [...]string{
+	SHA256:        "sha256",
+	BitsAndBlooms: "bits-and-blooms",
+	GuavaMitz32:   "guava-mitz32",
+	GuavaMitz64:   "guava-mitz64",
+	RedisBloom:    "redisbloom",
+	ParquetSBBF:   "parquet-sbbf",
+}
+
+// String returns the name of h.
+func (h Hash) String() string {
+	if int(h) < len(hashNames) {
+		return hashNames[h]
+	}
+	return "Hash(" + strconv.Itoa(int(h)) + ")"
+}
+
+// Bounds on the parameters of filters that use hash algorithms other than SHA256
+const (
+	maxBits = 1 << 40
+	maxK    = 255
+)
+
+// checkParams reports whether the hash algorithm h supports a filter of m bits using k hash values.
+func checkParams(h Hash, m, k uint64) error {
+	switch h {
+	case SHA256:
+		if m < 8 || m > maxFilterSize*8 {
+			return newError(ErrBadSize, "filter size out of range")
+		}
+		if bits.OnesCount64(m) != 1 {
+			return newError(ErrBadSize, "filter size not a power of 2")
+		}
+		if k == 0 || k > maxHashValues {
+			return newError(ErrBadHashCount, "number of hash values out of range")
+		}
+	case BitsAndBlooms, GuavaMitz32, GuavaMitz64, RedisBloom:
+		if m == 0 || m > maxBits {
+			return newError(ErrBadSize, "filter size out of range")
+		}
+		if (h == GuavaMitz32 || h == GuavaMitz64) && m%64 != 0 {
+			return newError(ErrBadSize, "filter size not a multiple of 64")
+		}
+		if h == RedisBloom && m%8 != 0 {
+			return newError(ErrBadSize, "filter size not a multiple of 8")
+		}
+		if k == 0 || k > maxK {
+			return newError(ErrBadHashCount, "number of hash values out of range")
+		}
+	case ParquetSBBF:
+		if m == 0 || m > maxBits {
+			return newError(ErrBadSize, "filter size out of range")
+		}
+		if m%sbbfBlockBits != 0 {
+			return newError(ErrBadSize, "filter size not a multiple of 256")
+		}
+		if k != sbbfWords {
+			return newError(ErrBadHashCount, "number of hash values not 8")
+		}
+	default:
+		return newError(ErrUnsupported, "unsupported hash algorithm")
+	}
+	return nil
+}
+
+// A digest holds the hash values of an item from which its bit positions are derived.
+type digest [4]uint64
+
+// digest returns the digest of item under h.
+func (h Hash) digest(item []byte) digest {
+	var d digest
+	switch h {
+	case BitsAndBlooms:
+		d[0], d[1] = murmur3(item, nil)
+		d[2], d[3] = murmur3(item, []byte{1})
+	case GuavaMitz32, GuavaMitz64:
+		d[0], d[1] = murmur3(item, nil)
+	case RedisBloom:
+		d[0] = murmur64A(item, 0xc6a4a7935bd1e995)
+		d[1] = murmur64A(item, d[0])
+	case ParquetSBBF:
+		d[0] = xxhash64(item)
+	default:
+		s := sha256.Sum256(item)
+		for i := range d {
+			d[i] = binary.BigEndian.Uint64(s[8*i:])
+		}
+	}
+	return d
+}
+
+// Locations returns the positions of the k bits that a filter of m bits using h sets for item,
+// in the order in which they are derived, so that a filter can be kept in storage that this package does not manage.
+// Bit n of a filter is bit n%8 of byte n/8 of its bits in the binary form produced by MarshalBinary,
+// counting from the least significant bit. Locations panics if h does not support a filter of size m bits or k hash values.
+func (h Hash) Locations(item []byte, m, k int) []int {
+	if m < 0 || k < 0 {
+		panic("bloom: negative filter size or number of hash values")
+	}
+	if err := checkParams(h, uint64(m), uint64(k)); err != nil {
+		panic("bloom: " + err.Error())
+	}
+	f := Filter{k: k, m: m, hash: h}
+	d := h.digest(item)
+	locs := make([]int, k)
+	for i := range locs {
+		locs[i] = f.location(&d, i)
+	}
+	return locs
+}
+
+// location returns the ith bit position in f of an item with digest d.
+func (f *Filter) location(d *digest, i int) int {
+	switch f.hash {
+	case BitsAndBlooms:
+		n := uint64(i)
+		return int((d[n%2] + n*d[2+(n+n%2)%4/2]) % uint64(f.m))
+	case GuavaMitz32:
+		// Java int arithmetic on the halves of the hash's first 8 bytes, counting from 1
+		c := int32(d[0]) + int32(i+1)*int32(d[0]>>32)
+		if c < 0 {
+			c = ^c
+		}
+		return int(uint64(c) % uint64(f.m))
+	case GuavaMitz64:
+		// Java long arithmetic on the hash's first and last 8 bytes, cleared of the sign bit
+		return int((d[0] + uint64(i)*d[1]) & math.MaxInt64 % uint64(f.m))
+	case RedisBloom:
+		return int((d[0] + uint64(i)*d[1]) % uint64(f.m))
+	case ParquetSBBF:
+		// The upper half of the hash selects a block, and the lower half a bit in word i of the block.
+		block := (d[0] >> 32) * uint64(f.m/sbbfBlockBits) >> 32
+		return int(block)*sbbfBlockBits + i*32 + int(uint32(d[0])*sbbfSalt[i]>>27)
+	default:
+		// The ith pair of bytes of the SHA-256 hash
+		return int(d[i/4]>>(48-16*(i%4))) & 0xffff & (f.m - 1)
+	}
+}
@@ -0,0 +1,188 @@
+// Package httpclient provides a client for a Bloom filter served by package httpserver.
+//
+// A Client's methods mirror those of bloom.Filter, with a context and an error added to each.
+// Bulk operations are divided into batches, and requests that fail with a network error
+// or a server error are retried with exponential backoff.
+package httpclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/dkmccandless/bloom"
+	"github.com/dkmccandless/bloom/httpserver"
+)
+
+// A Client is a client for a filter served by httpserver. It is safe for concurrent use by multiple goroutines.
+type Client struct {
+	url       string
+	client    *http.Client
+	retries   int
+	backoff   time.Duration
+	batchSize int
+}
+
+// An Option configures New.
+type Option func(*Client)
+
+// WithHTTPClient sets the http.Client with which the Client makes requests. The default is http.DefaultClient.
+func WithHTTPClient(c *http.Client) Option {
+	return func(cl *Client) { cl.client = c }
+}
+
+// WithRetries sets the number of times a failed request is retried, and the delay before the first retry,
+// which doubles before each subsequent one. The default is 3 retries, beginning after 100 milliseconds.
+func WithRetries(n int, backoff time.Duration) Option {
+	return func(cl *Client) { cl.retries, cl.backoff = n, backoff }
+}
+
+// WithBatchSize sets the largest number of items sent in a single request by InsertBatch and ContainsBatch.
+// The default is 1000. If n is not positive, the default is used.
+func WithBatchSize(n int) Option {
+	return func(cl *Client) { cl.batchSize = n }
+}
+
+// New returns a Client for the filter served at baseURL, the URL at which the httpserver handler is mounted.
+func New(baseURL string, opts ...Option) *Client {
+	c := &Client{
+		url:       strings.TrimSuffix(baseURL, "/"),
+		client:    http.DefaultClient,
+		retries:   3,
+		backoff:   100 * time.Millisecond,
+		batchSize: 1000,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.batchSize <= 0 {
+		c.batchSize = 1000
+	}
+	return c
+}
+
+// Insert inserts item into the filter's set.
+func (c *Client) Insert(ctx context.Context, item []byte) error {
+	return c.do(ctx, http.MethodPost, "/insert", item, nil)
+}
+
+// MaybeContains reports whether item is probably in the filter's set, in the manner of bloom.Filter.MaybeContains.
+func (c *Client) MaybeContains(ctx context.Context, item []byte) (bool, error) {
+	var res struct {
+		MaybeContains bool `json:"maybeContains"`
+	}
+	if err := c.do(ctx, http.MethodPost, "/query", item, &res); err != nil {
+		return false, err
+	}
+	return res.MaybeContains, nil
+}
+
+// InsertBatch inserts every item of items into the filter's set.
+// If it returns an error, the items of the batches sent before the failure have been inserted.
+func (c *Client) InsertBatch(ctx context.Context, items [][]byte) error {
+	for len(items) > 0 {
+		n := min(len(items), c.batchSize)
+		body, err := json.Marshal(httpserver.Bulk{Items: items[:n]})
+		if err != nil {
+			return err
+		}
+		if err := c.do(ctx, http.MethodPost, "/bulk/insert", body, nil); err != nil {
+			return err
+		}
+		items = items[n:]
+	}
+	return nil
+}
+
+// ContainsBatch reports, for each item of items, whether it is probably in the filter's set.
+func (c *Client) ContainsBatch(ctx context.Context, items [][]byte) ([]bool, error) {
+	res := make([]bool, 0, len(items))
+	for len(items) > 0 {
+		n := min(len(items), c.batchSize)
+		body, err := json.Marshal(httpserver.Bulk{Items: items[:n]})
+		if err != nil {
+			return nil, err
+		}
+		var r struct {
+			MaybeContains []bool `json:"maybeContains"`
+		}
+		if err := c.do(ctx, http.MethodPost, "/bulk/query", body, &r); err != nil {
+			return nil, err
+		}
+		if len(r.MaybeContains) != n {
+			return nil, fmt.Errorf("got %d results for %d items", len(r.MaybeContains), n)
+		}
+		res = append(res, r.MaybeContains...)
+		items = items[n:]
+	}
+	return res, nil
+}
+
+// Stats returns the filter's parameters and contents.
+func (c *Client) Stats(ctx context.Context) (httpserver.Stats, error) {
+	var st httpserver.Stats
+	err := c.do(ctx, http.MethodGet, "/stats", nil, &st)
+	return st, err
+}
+
+// Snapshot downloads a copy of the filter.
+func (c *Client) Snapshot(ctx context.Context) (*bloom.Filter, error) {
+	f := new(bloom.Filter)
+	err := c.do(ctx, http.MethodGet, "/snapshot", nil, f)
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// do makes a request with the given method, path, and body, retrying it if it fails,
+// and decodes the response into v: a *bloom.Filter is read in binary form, and any other non-nil v as JSON.
+func (c *Client) do(ctx context.Context, method, path string, body []byte, v any) error {
+	delay := c.backoff
+	for attempt := 0; ; attempt++ {
+		retry, err := c.try(ctx, method, path, body, v)
+		if err == nil || !retry || attempt >= c.retries {
+			return err
+		}
+		t := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return ctx.Err()
+		case <-t.C:
+		}
+		delay *= 2
+	}
+}
+
+// try makes a single request in the manner of do, and reports whether a failed request may be retried.
+func (c *Client) try(ctx context.Context, method, path string, body []byte, v any) (retry bool, err error) {
+	req, err := http.NewRequestWithContext(ctx, method, c.url+path, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return ctx.Err() == nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		err := fmt.Errorf("%s %s: %s: %s", method, path, resp.Status, bytes.TrimSpace(msg))
+		return resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests, err
+	}
+	switch v := v.(type) {
+	case nil:
+		return false, nil
+	case *bloom.Filter:
+		_, err = v.ReadFrom(resp.Body)
+	default:
+		err = json.NewDecoder(resp.Body).Decode(v)
+	}
+	return false, err
+}
@@ -0,0 +1,115 @@
+package httpclient
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/dkmccandless/bloom"
+	"github.com/dkmccandless/bloom/httpserver"
+)
+
+func TestClient(t *testing.T) {
+	var requests atomic.Int64
+	h := httpserver.New(bloom.NewSyncFilter(bloom.NewWithHash(1<<14, 4, bloom.BitsAndBlooms)))
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		h.ServeHTTP(w, r)
+	}))
+	defer srv.Close()
+	c := New(srv.URL+"/", WithBatchSize(10))
+	ctx := context.Background()
+
+	if err := c.Insert(ctx, []byte("x")); err != nil {
+		t.Fatalf("TestClient: %v", err)
+	}
+	for item, want := range map[string]bool{"x": true, "y": false} {
+		if got, err := c.MaybeContains(ctx, []byte(item)); err != nil || got != want {
+			t.Errorf("TestClient(%v): got %v, %v, want %v, nil", item, got, err, want)
+		}
+	}
+
+	var items [][]byte
+	for i := range 25 {
+		items = append(items, []byte(fmt.Sprint(i)))
+	}
+	requests.Store(0)
+	if err := c.InsertBatch(ctx, items); err != nil {
+		t.Errorf("TestClient: %v", err)
+	}
+	if n := requests.Load(); n != 3 {
+		t.Errorf("TestClient: InsertBatch made %v requests, want 3", n)
+	}
+	got, err := c.ContainsBatch(ctx, append(items, []byte("absent")))
+	if err != nil {
+		t.Errorf("TestClient: %v", err)
+	}
+	if want := append(slices.Repeat([]bool{true}, 25), false); !slices.Equal(got, want) {
+		t.Errorf("TestClient: ContainsBatch: got %v, want %v", got, want)
+	}
+
+	st, err := c.Stats(ctx)
+	if err != nil || st.Size != 1<<14 || st.HashValues != 4 || st.BitCount == 0 {
+		t.Errorf("TestClient: Stats: got %+v, %v", st, err)
+	}
+	f, err := c.Snapshot(ctx)
+	if err != nil {
+		t.Fatalf("TestClient: %v", err)
+	}
+	for _, item := range append(items, []byte("x")) {
+		if !f.MaybeContains(item) {
+			t.Errorf("TestClient: Snapshot: item %s not found", item)
+		}
+	}
+}
+
+func TestClientRetries(t *testing.T) {
+	var requests atomic.Int64
+	h := httpserver.New(bloom.NewSyncFilter(bloom.NewWithHash(1<<12, 4, bloom.BitsAndBlooms)))
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Every other request fails.
+		if requests.Add(1)%2 == 1 {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		h.ServeHTTP(w, r)
+	}))
+	defer srv.Close()
+	ctx := context.Background()
+
+	c := New(srv.URL, WithRetries(1, time.Millisecond))
+	if err := c.Insert(ctx, []byte("x")); err != nil {
+		t.Errorf("TestClientRetries: %v", err)
+	}
+	if n := requests.Load(); n != 2 {
+		t.Errorf("TestClientRetries: made %v requests, want 2", n)
+	}
+
+	c = New(srv.URL, WithRetries(0, time.Millisecond))
+	if err := c.Insert(ctx, []byte("x")); err == nil {
+		t.Errorf("TestClientRetries: no retries: got nil error")
+	}
+
+	// Client errors are not retried.
+	requests.Store(0)
+	c = New(srv.URL+"/missing", WithRetries(3, time.Millisecond))
+	if err := c.Insert(ctx, []byte("x")); err == nil {
+		t.Errorf("TestClientRetries: not found: got nil error")
+	}
+	if n := requests.Load(); n != 2 {
+		t.Errorf("TestClientRetries: not found: made %v requests, want 2", n)
+	}
+
+	// A canceled context stops retries.
+	ctx, cancel := context.WithCancel(ctx)
+	cancel()
+	c = New(srv.URL, WithRetries(3, time.Hour))
+	if err := c.Insert(ctx, []byte("x")); err == nil {
+		t.Errorf("TestClientRetries: canceled: got nil error")
+	}
+}
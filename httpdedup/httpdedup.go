@@ -0,0 +1,110 @@
+// Package httpdedup provides HTTP middleware that rejects probable duplicates of earlier requests,
+// for endpoints such as webhook receivers whose senders retry deliveries that have already succeeded.
+//
+// The middleware derives a key from each request, by default a hash of its method, path and query, and body,
+// and inserts it into a bloom.RotatingFilter. A request whose key the filter probably contains is answered
+// without calling the wrapped handler: by default with 409 Conflict, or with an empty 200 OK by WithStatus,
+// for senders that retry until they receive a success status. Rotating the filter at an interval
+// bounds the period within which a duplicate is detected and the memory doing so takes.
+//
+// A key is inserted before the wrapped handler is called, so that concurrent deliveries of the same request
+// are handled once; a delivery that the handler fails to process is therefore not retried successfully
+// until the filter forgets it. A request is mistaken for a duplicate with the filter's false positive rate.
+package httpdedup
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/dkmccandless/bloom"
+)
+
+// maxBodySize is the largest request body whose hash the default key includes.
+const maxBodySize = 32 << 20
+
+type handler struct {
+	next   http.Handler
+	f      *bloom.RotatingFilter
+	key    func(r *http.Request) ([]byte, error)
+	status int
+}
+
+// An Option configures New.
+type Option func(*handler)
+
+// WithKey sets the function that derives the key of a request. A nil key exempts the request from deduplication,
+// and an error rejects it with 400 Bad Request, or 413 Request Entity Too Large for an *http.MaxBytesError.
+// If the function reads the request body, it must replace it for the wrapped handler.
+func WithKey(key func(r *http.Request) ([]byte, error)) Option {
+	return func(h *handler) { h.key = key }
+}
+
+// WithHeaderKey sets the key of a request to the value of its header name, such as "Idempotency-Key"
+// or a webhook delivery ID, exempting requests without the header from deduplication.
+func WithHeaderKey(name string) Option {
+	return WithKey(func(r *http.Request) ([]byte, error) {
+		if v := r.Header.Get(name); v != "" {
+			return []byte(v), nil
+		}
+		return nil, nil
+	})
+}
+
+// WithStatus sets the status code with which duplicates are answered. The default is 409 Conflict.
+func WithStatus(code int) Option {
+	return func(h *handler) { h.status = code }
+}
+
+// New returns middleware that calls next for requests whose keys f does not probably contain,
+// inserting each key into f, and answers other requests itself.
+func New(next http.Handler, f *bloom.RotatingFilter, opts ...Option) http.Handler {
+	h := &handler{next: next, f: f, key: BodyKey, status: http.StatusConflict}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	key, err := h.key(r)
+	if err != nil {
+		var mbe *http.MaxBytesError
+		if errors.As(err, &mbe) {
+			http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+		} else {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		}
+		return
+	}
+	if key != nil && h.f.TestAndInsert(key) {
+		if h.status == http.StatusOK {
+			w.WriteHeader(http.StatusOK)
+		} else {
+			http.Error(w, "duplicate request", h.status)
+		}
+		return
+	}
+	h.next.ServeHTTP(w, r)
+}
+
+// BodyKey returns the default key of r: the SHA-256 hash of its method, path and query, and body.
+// It reads the body, of at most 32 MiB, and replaces it with a reader of the same bytes.
+func BodyKey(r *http.Request) ([]byte, error) {
+	var body []byte
+	if r.Body != nil {
+		var err error
+		body, err = io.ReadAll(http.MaxBytesReader(nil, r.Body, maxBodySize))
+		r.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	h := sha256.New()
+	io.WriteString(h, r.Method+"\x00"+r.URL.RequestURI()+"\x00")
+	h.Write(body)
+	return h.Sum(nil), nil
+}
@@ -0,0 +1,91 @@
+package httpdedup
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dkmccandless/bloom"
+)
+
+// echo is a handler that counts its calls and echoes the request body.
+type echo struct{ calls int }
+
+func (e *echo) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	e.calls++
+	io.Copy(w, r.Body)
+}
+
+func newFilter() *bloom.RotatingFilter {
+	return bloom.NewRotatingFilter(bloom.NewWithHash(1<<12, 4, bloom.BitsAndBlooms), time.Hour)
+}
+
+func TestNew(t *testing.T) {
+	e := new(echo)
+	h := New(e, newFilter())
+	for _, test := range []struct {
+		method, target, body string
+		want                 int
+		wantBody             string
+	}{
+		{"POST", "/hook", "a", http.StatusOK, "a"},
+		{"POST", "/hook", "a", http.StatusConflict, "duplicate request\n"},
+		{"POST", "/hook", "b", http.StatusOK, "b"},
+		{"POST", "/hook?x=1", "a", http.StatusOK, "a"},
+		{"PUT", "/hook", "a", http.StatusOK, "a"},
+		{"POST", "/other", "a", http.StatusOK, "a"},
+		{"POST", "/other", "a", http.StatusConflict, "duplicate request\n"},
+		{"GET", "/hook", "", http.StatusOK, ""},
+	} {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(test.method, test.target, strings.NewReader(test.body)))
+		if w.Code != test.want || w.Body.String() != test.wantBody {
+			t.Errorf("TestNew(%v %v %q): got %v %q, want %v %q",
+				test.method, test.target, test.body, w.Code, w.Body.String(), test.want, test.wantBody)
+		}
+	}
+	if e.calls != 6 {
+		t.Errorf("TestNew: handler called %v times, want 6", e.calls)
+	}
+}
+
+func TestHeaderKey(t *testing.T) {
+	e := new(echo)
+	h := New(e, newFilter(), WithHeaderKey("Idempotency-Key"), WithStatus(http.StatusOK))
+	for _, test := range []struct {
+		key, body string
+		wantBody  string
+	}{
+		{"1", "a", "a"},
+		{"1", "b", ""},
+		{"2", "b", "b"},
+		{"", "b", "b"},
+		{"", "b", "b"},
+	} {
+		r := httptest.NewRequest("POST", "/hook", strings.NewReader(test.body))
+		if test.key != "" {
+			r.Header.Set("Idempotency-Key", test.key)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		if w.Code != http.StatusOK || w.Body.String() != test.wantBody {
+			t.Errorf("TestHeaderKey(%q, %q): got %v %q, want %v %q", test.key, test.body, w.Code, w.Body.String(), http.StatusOK, test.wantBody)
+		}
+	}
+	if e.calls != 4 {
+		t.Errorf("TestHeaderKey: handler called %v times, want 4", e.calls)
+	}
+}
+
+func TestBodyTooLarge(t *testing.T) {
+	e := new(echo)
+	h := New(e, newFilter())
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("POST", "/hook", strings.NewReader(strings.Repeat("x", maxBodySize+1))))
+	if w.Code != http.StatusRequestEntityTooLarge || e.calls != 0 {
+		t.Errorf("TestBodyTooLarge: got %v with %v calls, want %v with 0 calls", w.Code, e.calls, http.StatusRequestEntityTooLarge)
+	}
+}
@@ -0,0 +1,172 @@
+// Package httpserver provides an HTTP handler that serves a Bloom filter as a membership service.
+//
+// The handler serves the following endpoints, relative to the path at which it is mounted:
+//
+//	POST /insert        inserts the request body as an item
+//	POST /query         reports whether the request body is probably an item in the set
+//	POST /bulk/insert   inserts the items of a JSON request {"items": [...]}
+//	POST /bulk/query    reports whether each item of a JSON request {"items": [...]} is probably in the set
+//	GET  /stats         reports the filter's parameters and fill ratio
+//	GET  /snapshot      downloads the filter in the binary form produced by bloom.Filter.MarshalBinary
+//
+// Items in JSON are encoded as base64 strings. Query results are JSON objects:
+// {"maybeContains": true} for /query and {"maybeContains": [true, false]} for /bulk/query.
+package httpserver
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/dkmccandless/bloom"
+)
+
+const (
+	// maxItemSize is the largest request body accepted by /insert and /query.
+	maxItemSize = 1 << 20
+
+	// maxBulkSize is the largest request body accepted by /bulk/insert and /bulk/query.
+	maxBulkSize = 32 << 20
+)
+
+// A Stats reports a filter's parameters and contents, as served by /stats.
+type Stats struct {
+	Size          int     `json:"size"`
+	HashValues    int     `json:"hashValues"`
+	HashAlgorithm string  `json:"hashAlgorithm"`
+	BitCount      int     `json:"bitCount"`
+	FillRatio     float64 `json:"fillRatio"`
+}
+
+// A Bulk is the request body of /bulk/insert and /bulk/query.
+type Bulk struct {
+	Items [][]byte `json:"items"`
+}
+
+// New returns an http.Handler that serves f.
+func New(f *bloom.SyncFilter) http.Handler {
+	s := &server{f: f}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/insert", only(http.MethodPost, s.insert))
+	mux.HandleFunc("/query", only(http.MethodPost, s.query))
+	mux.HandleFunc("/bulk/insert", only(http.MethodPost, s.bulkInsert))
+	mux.HandleFunc("/bulk/query", only(http.MethodPost, s.bulkQuery))
+	mux.HandleFunc("/stats", only(http.MethodGet, s.stats))
+	mux.HandleFunc("/snapshot", only(http.MethodGet, s.snapshot))
+	return mux
+}
+
+// only returns a handler that calls h for requests with the given method and rejects others.
+// It does not rely on method patterns, which ServeMux ignores under older GODEBUG settings.
+func only(method string, h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != method {
+			w.Header().Set("Allow", method)
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+		h(w, r)
+	}
+}
+
+type server struct {
+	f *bloom.SyncFilter
+}
+
+func (s *server) insert(w http.ResponseWriter, r *http.Request) {
+	item, ok := readItem(w, r)
+	if !ok {
+		return
+	}
+	s.f.Insert(item)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *server) query(w http.ResponseWriter, r *http.Request) {
+	item, ok := readItem(w, r)
+	if !ok {
+		return
+	}
+	writeJSON(w, struct {
+		MaybeContains bool `json:"maybeContains"`
+	}{s.f.MaybeContains(item)})
+}
+
+func (s *server) bulkInsert(w http.ResponseWriter, r *http.Request) {
+	b, ok := readBulk(w, r)
+	if !ok {
+		return
+	}
+	for _, item := range b.Items {
+		s.f.Insert(item)
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *server) bulkQuery(w http.ResponseWriter, r *http.Request) {
+	b, ok := readBulk(w, r)
+	if !ok {
+		return
+	}
+	res := make([]bool, len(b.Items))
+	for i, item := range b.Items {
+		res[i] = s.f.MaybeContains(item)
+	}
+	writeJSON(w, struct {
+		MaybeContains []bool `json:"maybeContains"`
+	}{res})
+}
+
+func (s *server) stats(w http.ResponseWriter, r *http.Request) {
+	st := s.f.Stats()
+	writeJSON(w, Stats{
+		Size:          st.Size,
+		HashValues:    st.HashValues,
+		HashAlgorithm: st.HashAlgorithm.String(),
+		BitCount:      st.BitCount,
+		FillRatio:     st.FillRatio,
+	})
+}
+
+// snapshot streams the filter from its pages rather than taking a Snapshot, which would exclude insertions
+// while it copied and make each later insertion copy the page it changes.
+func (s *server) snapshot(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/octet-stream")
+	s.f.WriteTo(w)
+}
+
+// readItem reads the item in r's body, or reports an error to w and returns false.
+func readItem(w http.ResponseWriter, r *http.Request) ([]byte, bool) {
+	item, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxItemSize))
+	if err != nil {
+		http.Error(w, err.Error(), statusFor(err))
+		return nil, false
+	}
+	return item, true
+}
+
+// readBulk reads the Bulk in r's body, or reports an error to w and returns false.
+func readBulk(w http.ResponseWriter, r *http.Request) (Bulk, bool) {
+	var b Bulk
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBulkSize)).Decode(&b); err != nil {
+		http.Error(w, err.Error(), statusFor(err))
+		return Bulk{}, false
+	}
+	return b, true
+}
+
+// statusFor returns the status code that reports the error err in reading a request body.
+func statusFor(err error) int {
+	var mbe *http.MaxBytesError
+	if errors.As(err, &mbe) {
+		return http.StatusRequestEntityTooLarge
+	}
+	return http.StatusBadRequest
+}
+
+// writeJSON writes v to w as JSON.
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,103 @@
+package httpserver
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/dkmccandless/bloom"
+)
+
+func TestHandler(t *testing.T) {
+	srv := httptest.NewServer(New(bloom.NewSyncFilter(bloom.NewWithHash(1<<12, 4, bloom.BitsAndBlooms))))
+	defer srv.Close()
+
+	post := func(path string, body []byte) *http.Response {
+		resp, err := http.Post(srv.URL+path, "application/octet-stream", bytes.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+	decode := func(resp *http.Response, v any) {
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("TestHandler(%v): got status %v", resp.Request.URL.Path, resp.Status)
+		}
+		if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+			t.Fatalf("TestHandler(%v): %v", resp.Request.URL.Path, err)
+		}
+	}
+
+	if resp := post("/insert", []byte("x")); resp.StatusCode != http.StatusNoContent {
+		t.Errorf("TestHandler(/insert): got status %v", resp.Status)
+	}
+	for item, want := range map[string]bool{"x": true, "y": false} {
+		var res struct{ MaybeContains bool }
+		decode(post("/query", []byte(item)), &res)
+		if res.MaybeContains != want {
+			t.Errorf("TestHandler(/query, %v): got %v, want %v", item, res.MaybeContains, want)
+		}
+	}
+
+	bulk, _ := json.Marshal(Bulk{Items: [][]byte{[]byte("a"), []byte("b")}})
+	if resp := post("/bulk/insert", bulk); resp.StatusCode != http.StatusNoContent {
+		t.Errorf("TestHandler(/bulk/insert): got status %v", resp.Status)
+	}
+	bulk, _ = json.Marshal(Bulk{Items: [][]byte{[]byte("a"), []byte("x"), []byte("c")}})
+	var res struct{ MaybeContains []bool }
+	decode(post("/bulk/query", bulk), &res)
+	if want := []bool{true, true, false}; !slices.Equal(res.MaybeContains, want) {
+		t.Errorf("TestHandler(/bulk/query): got %v, want %v", res.MaybeContains, want)
+	}
+
+	resp, err := http.Get(srv.URL + "/stats")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var st Stats
+	decode(resp, &st)
+	if st.Size != 1<<12 || st.HashValues != 4 || st.HashAlgorithm != bloom.BitsAndBlooms.String() ||
+		st.BitCount == 0 || st.BitCount > 12 || st.FillRatio != float64(st.BitCount)/(1<<12) {
+		t.Errorf("TestHandler(/stats): got %+v", st)
+	}
+
+	resp, err = http.Get(srv.URL + "/snapshot")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	f := new(bloom.Filter)
+	if _, err := f.ReadFrom(resp.Body); err != nil {
+		t.Errorf("TestHandler(/snapshot): %v", err)
+	}
+	for _, item := range []string{"x", "a", "b"} {
+		if !f.MaybeContains([]byte(item)) {
+			t.Errorf("TestHandler(/snapshot): item %v not found", item)
+		}
+	}
+
+	for _, test := range []struct {
+		method, path string
+		body         []byte
+		status       int
+	}{
+		{"POST", "/bulk/query", []byte("{"), http.StatusBadRequest},
+		{"POST", "/insert", bytes.Repeat([]byte("x"), maxItemSize+1), http.StatusRequestEntityTooLarge},
+		{"GET", "/insert", nil, http.StatusMethodNotAllowed},
+		{"GET", "/missing", nil, http.StatusNotFound},
+	} {
+		req, _ := http.NewRequest(test.method, srv.URL+test.path, bytes.NewReader(test.body))
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != test.status {
+			t.Errorf("TestHandler(%v %v): got status %v, want %v", test.method, test.path, resp.StatusCode, test.status)
+		}
+	}
+}
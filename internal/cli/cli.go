@@ -0,0 +1,63 @@
+// Package cli holds the functions shared by the commands of this module:
+// the sizing of filters, and the parsing of their parameters and inputs.
+package cli
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/dkmccandless/bloom"
+)
+
+// ReadLines calls fn with each line of r, without its line ending.
+// The line is valid only until fn returns.
+func ReadLines(r io.Reader, fn func(line []byte)) error {
+	br := bufio.NewReader(r)
+	var line []byte
+	for {
+		b, err := br.ReadSlice('\n')
+		if errors.Is(err, bufio.ErrBufferFull) {
+			line = append(line, b...)
+			continue
+		}
+		if len(line) > 0 {
+			b = append(line, b...)
+			line = line[:0]
+		}
+		if len(b) > 0 && (err == nil || err == io.EOF) {
+			b = bytes.TrimSuffix(b, []byte("\n"))
+			fn(bytes.TrimSuffix(b, []byte("\r")))
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// ParseHash returns the hash algorithm whose name, as returned by bloom.Hash.String, is name.
+func ParseHash(name string) (bloom.Hash, error) {
+	for h := bloom.Hash(0); !strings.HasPrefix(h.String(), "Hash("); h++ {
+		if h.String() == name {
+			return h, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown hash algorithm %q", name)
+}
+
+// NewFilter returns a filter of m bits that uses k hash values derived by h,
+// or an error if h does not support a filter of size m bits or k hash values.
+func NewFilter(m, k int, h bloom.Hash) (f *bloom.Filter, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+	return bloom.NewWithHash(m, k, h), nil
+}
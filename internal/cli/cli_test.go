@@ -0,0 +1,51 @@
+package cli
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/dkmccandless/bloom"
+)
+
+func TestReadLines(t *testing.T) {
+	long := strings.Repeat("x", 10000)
+	for _, test := range []struct {
+		in   string
+		want []string
+	}{
+		{"", nil},
+		{"a", []string{"a"}},
+		{"a\n", []string{"a"}},
+		{"a\r\nb\n\nc", []string{"a", "b", "", "c"}},
+		{long + "\n" + long, []string{long, long}},
+	} {
+		var got []string
+		if err := ReadLines(strings.NewReader(test.in), func(line []byte) { got = append(got, string(line)) }); err != nil {
+			t.Errorf("TestReadLines(%.20q): %v", test.in, err)
+		}
+		if strings.Join(got, "|") != strings.Join(test.want, "|") || len(got) != len(test.want) {
+			t.Errorf("TestReadLines(%.20q): got %.40q, want %.40q", test.in, got, test.want)
+		}
+	}
+}
+
+func TestParseHash(t *testing.T) {
+	for _, h := range []bloom.Hash{bloom.SHA256, bloom.BitsAndBlooms, bloom.ParquetSBBF} {
+		if got, err := ParseHash(h.String()); got != h || err != nil {
+			t.Errorf("TestParseHash(%v): got %v, %v", h, got, err)
+		}
+	}
+	if _, err := ParseHash("md5"); err == nil {
+		t.Errorf("TestParseHash(md5): got nil error")
+	}
+}
+
+func TestNewFilter(t *testing.T) {
+	f, err := NewFilter(1000, 3, bloom.BitsAndBlooms)
+	if err != nil || f.Size() != 1000 || f.HashValues() != 3 || f.HashAlgorithm() != bloom.BitsAndBlooms {
+		t.Errorf("TestNewFilter: got %v, %v", f, err)
+	}
+	if f, err := NewFilter(1000, 3, bloom.SHA256); err == nil {
+		t.Errorf("TestNewFilter(sha256): got %v, nil error", f)
+	}
+}
@@ -0,0 +1,55 @@
+package cli
+
+import (
+	"errors"
+	"math"
+	"math/bits"
+
+	"github.com/dkmccandless/bloom"
+)
+
+// Optimal returns the size in bits and number of hash values of the smallest filter
+// whose false positive rate is at most p after n insertions.
+func Optimal(n, p float64) (m, k int, err error) {
+	if !(n >= 1) || math.IsInf(n, 1) {
+		return 0, 0, errors.New("number of items must be at least 1")
+	}
+	if !(p > 0 && p < 1) {
+		return 0, 0, errors.New("false positive rate must be between 0 and 1")
+	}
+	bm := math.Ceil(-n * math.Log(p) / (math.Ln2 * math.Ln2))
+	if bm > 1<<40 {
+		return 0, 0, errors.New("filter size too large")
+	}
+	m = int(bm)
+	k = max(1, int(math.Round(float64(m)/n*math.Ln2)))
+	return m, k, nil
+}
+
+// Fit returns the size m rounded up, and number of hash values k adjusted, to the nearest that the hash algorithm h
+// supports, or an error if h supports no filter of at least m bits.
+func Fit(h bloom.Hash, m, k int) (int, int, error) {
+	switch h {
+	case bloom.SHA256:
+		m = max(8, 1<<bits.Len(uint(m-1)))
+		k = min(k, 16)
+		if m > 65536 {
+			return 0, 0, errors.New("filter size too large for hash algorithm sha256")
+		}
+	case bloom.GuavaMitz32, bloom.GuavaMitz64:
+		m = (m + 63) / 64 * 64
+	case bloom.RedisBloom:
+		m = (m + 7) / 8 * 8
+	case bloom.ParquetSBBF:
+		m, k = (m+255)/256*256, 8
+	}
+	if m > 1<<40 {
+		return 0, 0, errors.New("filter size too large")
+	}
+	return m, min(k, 255), nil
+}
+
+// FalsePositiveRate returns the expected false positive rate of a filter of m bits and k hash values after n insertions.
+func FalsePositiveRate(m, k int, n float64) float64 {
+	return math.Pow(-math.Expm1(-float64(k)*n/float64(m)), float64(k))
+}
@@ -0,0 +1,81 @@
+package cli
+
+import (
+	"math"
+	"testing"
+
+	"github.com/dkmccandless/bloom"
+)
+
+func TestOptimal(t *testing.T) {
+	for _, test := range []struct {
+		n, p float64
+		m, k int
+	}{
+		{1000, 0.01, 9586, 7},
+		{1e6, 0.001, 14377588, 10},
+		{1, 0.5, 2, 1},
+		{10, 0.9, 3, 1},
+	} {
+		m, k, err := Optimal(test.n, test.p)
+		if m != test.m || k != test.k || err != nil {
+			t.Errorf("TestOptimal(%v, %v): got %v, %v, %v, want %v, %v", test.n, test.p, m, k, err, test.m, test.k)
+		}
+	}
+	for _, test := range []struct{ n, p float64 }{
+		{0, 0.01}, {math.NaN(), 0.01}, {math.Inf(1), 0.01}, {1000, 0}, {1000, 1}, {1000, math.NaN()}, {1e15, 1e-10},
+	} {
+		if _, _, err := Optimal(test.n, test.p); err == nil {
+			t.Errorf("TestOptimal(%v, %v): got nil error", test.n, test.p)
+		}
+	}
+}
+
+func TestFit(t *testing.T) {
+	for _, test := range []struct {
+		h          bloom.Hash
+		m, k       int
+		wantM, wnk int
+	}{
+		{bloom.BitsAndBlooms, 1001, 300, 1001, 255},
+		{bloom.SHA256, 1001, 20, 1024, 16},
+		{bloom.SHA256, 3, 1, 8, 1},
+		{bloom.SHA256, 1024, 1, 1024, 1},
+		{bloom.GuavaMitz32, 65, 3, 128, 3},
+		{bloom.RedisBloom, 9, 3, 16, 3},
+		{bloom.ParquetSBBF, 1, 3, 256, 8},
+	} {
+		if m, k, err := Fit(test.h, test.m, test.k); m != test.wantM || k != test.wnk || err != nil {
+			t.Errorf("TestFit(%v, %v, %v): got %v, %v, %v, want %v, %v", test.h, test.m, test.k, m, k, err, test.wantM, test.wnk)
+		}
+	}
+	for _, test := range []struct {
+		h bloom.Hash
+		m int
+	}{
+		{bloom.SHA256, 65537},
+		{bloom.BitsAndBlooms, 1<<40 + 1},
+		{bloom.GuavaMitz64, 1<<40 + 1},
+	} {
+		if _, _, err := Fit(test.h, test.m, 4); err == nil {
+			t.Errorf("TestFit(%v, %v): got nil error", test.h, test.m)
+		}
+	}
+}
+
+func TestFalsePositiveRate(t *testing.T) {
+	for _, test := range []struct {
+		m, k int
+		n    float64
+		want float64
+	}{
+		{9586, 7, 1000, 0.01},
+		{14377588, 10, 1e6, 0.001},
+		{1000, 4, 0, 0},
+		{1000, 1, 1e6, 1},
+	} {
+		if got := FalsePositiveRate(test.m, test.k, test.n); math.Abs(got-test.want) > test.want*0.01 {
+			t.Errorf("TestFalsePositiveRate(%v, %v, %v): got %v, want %v", test.m, test.k, test.n, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package bloom
+
+import "encoding/binary"
+
+// InsertKeyed inserts item into the logical set named namespace within f's set.
+// Several logical sets may share one filter: an item inserted under one namespace
+// is not found, except as a false positive, under another or by MaybeContains.
+// Each logical set's false positive rate is that of the filter as a whole, for all the items of all namespaces.
+func (f *Filter) InsertKeyed(namespace string, item []byte) {
+	var buf [keyedBufSize]byte
+	f.Insert(keyedItem(buf[:0], namespace, item))
+}
+
+// MaybeContainsKeyed reports whether item is probably in the logical set named namespace within f's set,
+// in the manner of MaybeContains.
+func (f *Filter) MaybeContainsKeyed(namespace string, item []byte) bool {
+	var buf [keyedBufSize]byte
+	return f.MaybeContains(keyedItem(buf[:0], namespace, item))
+}
+
+// keyedBufSize is the size of the buffer in which InsertKeyed and MaybeContainsKeyed
+// form an item without allocating memory.
+const keyedBufSize = 128
+
+// keyedItem appends to dst the item that represents item in the namespace:
+// the length of namespace as a uvarint, namespace, and item.
+// The length distinguishes, for instance, namespace "a" and item "bc" from namespace "ab" and item "c".
+func keyedItem(dst []byte, namespace string, item []byte) []byte {
+	dst = binary.AppendUvarint(dst, uint64(len(namespace)))
+	dst = append(dst, namespace...)
+	return append(dst, item...)
+}
@@ -0,0 +1,54 @@
+package bloom
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestKeyed(t *testing.T) {
+	f := NewWithHash(4096, 4, BitsAndBlooms)
+	long := []byte(strings.Repeat("x", 200))
+	for _, test := range []struct {
+		namespace string
+		item      []byte
+	}{
+		{"a", []byte("bc")},
+		{"users", []byte("alice")},
+		{"", []byte("bare")},
+		{"long", long},
+	} {
+		f.InsertKeyed(test.namespace, test.item)
+		if !f.MaybeContainsKeyed(test.namespace, test.item) {
+			t.Errorf("TestKeyed(%q, %q): not found", test.namespace, test.item)
+		}
+	}
+	for _, test := range []struct {
+		namespace string
+		item      []byte
+	}{
+		{"ab", []byte("c")},
+		{"groups", []byte("alice")},
+		{"", []byte("alice")},
+		{"", long},
+	} {
+		if f.MaybeContainsKeyed(test.namespace, test.item) {
+			t.Errorf("TestKeyed(%q, %q): found", test.namespace, test.item)
+		}
+	}
+	for _, item := range []string{"bc", "alice", "bare"} {
+		if f.MaybeContains([]byte(item)) {
+			t.Errorf("TestKeyed(%q): found without namespace", item)
+		}
+	}
+}
+
+func TestKeyedAllocs(t *testing.T) {
+	f := NewWithHash(4096, 4, BitsAndBlooms)
+	item := []byte("alice")
+	if n := testing.AllocsPerRun(100, func() {
+		f.InsertKeyed("users", item)
+		f.MaybeContainsKeyed("users", item)
+	}); n != 0 {
+		t.Errorf("TestKeyedAllocs: got %v allocations, want 0", n)
+	}
+}
@@ -0,0 +1,184 @@
+package bloom
+
+import (
+	"container/list"
+	"encoding/binary"
+	"sync"
+)
+
+// A KV is a key-value store in which a KVStore keeps the pages of its bits,
+// such as a thin wrapper around a Pebble database or a Bolt bucket.
+type KV interface {
+	// Get returns the value stored under key, or nil and a nil error if there is none.
+	// The caller does not retain the returned slice past the next call to Get or Put.
+	Get(key []byte) ([]byte, error)
+
+	// Put stores value under key. It does not retain key or value.
+	Put(key, value []byte) error
+}
+
+// kvPageBytes is the size of a page of a KVStore's bits.
+const kvPageBytes = pageWords * 8
+
+// A KVStore is a Bitstore that keeps its bits in a KV, in pages of 4096 bytes each stored under the key prefix
+// followed by the page's index as a big-endian 64-bit integer, so that a filter need not fit in memory.
+// Recently used pages are cached in memory; pages that are modified are written back to the KV
+// when they are evicted from the cache or when Flush is called.
+// Pages that have never been modified are not stored, and read as zero.
+// A KVStore is safe for concurrent use by multiple goroutines.
+//
+// Because the methods of a Bitstore do not return errors, an error returned by the KV is retained
+// and reported by the next call to Flush. A bit of a page that could not be read reads as set,
+// so that a lookup reports an item as probably present rather than missing it, until the page is read
+// successfully, which is retried at each lookup and when it is written back; a page that could not be written back
+// remains cached, beyond the cache size if need be, until it is written back successfully.
+type KVStore struct {
+	kv     KV
+	prefix []byte
+	m      uint64
+	cap    int
+
+	mu    sync.Mutex
+	pages map[uint64]*list.Element
+	lru   list.List // of *kvPage, most recently used first
+	err   error
+}
+
+// kvPage is a cached page of a KVStore.
+type kvPage struct {
+	i      uint64
+	w      [pageWords]uint64
+	dirty  bool
+	unread bool // the page could not be read from the KV, so its stored bits are yet to be merged
+}
+
+// NewKVStore returns a KVStore of m bits that keeps its pages in kv under keys beginning with prefix
+// and caches up to cache pages in memory. It panics if cache is not positive.
+func NewKVStore(kv KV, prefix string, m uint64, cache int) *KVStore {
+	if cache <= 0 {
+		panic("bloom: cache size out of range")
+	}
+	return &KVStore{kv: kv, prefix: []byte(prefix), m: m, cap: cache, pages: make(map[uint64]*list.Element)}
+}
+
+func (s *KVStore) Get(n uint64) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p := s.page(n / 64 / pageWords)
+	if p.w[n/64%pageWords]>>(n%64)&1 != 0 {
+		return true
+	}
+	// The bit may be set in the stored page.
+	return !s.merge(p)
+}
+
+func (s *KVStore) Set(n uint64) {
+	s.OrWord(n/64, 1<<(n%64))
+}
+
+func (s *KVStore) OrWord(i uint64, w uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p := s.page(i / pageWords)
+	if v := p.w[i%pageWords]; v|w != v {
+		p.w[i%pageWords] = v | w
+		p.dirty = true
+	}
+}
+
+func (s *KVStore) Len() uint64 { return s.m }
+
+// Flush writes every modified page in s's cache to its KV, and returns the first error
+// encountered since the previous call to Flush, if any.
+func (s *KVStore) Flush() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for e := s.lru.Front(); e != nil; e = e.Next() {
+		s.writeBack(e.Value.(*kvPage))
+	}
+	err := s.err
+	s.err = nil
+	return err
+}
+
+// page returns the page at index i, reading it from s's KV and evicting the least recently used page
+// if it is not cached. The caller must hold s.mu.
+func (s *KVStore) page(i uint64) *kvPage {
+	if e, ok := s.pages[i]; ok {
+		s.lru.MoveToFront(e)
+		return e.Value.(*kvPage)
+	}
+	var p *kvPage
+	if e := s.lru.Back(); s.lru.Len() >= s.cap && s.writeBack(e.Value.(*kvPage)) {
+		p = e.Value.(*kvPage)
+		s.lru.Remove(e)
+		delete(s.pages, p.i)
+		*p = kvPage{}
+	} else {
+		// The cache has room, or its least recently used page could not be written back
+		// and is retained so that its bits are not lost.
+		p = new(kvPage)
+	}
+	p.i = i
+	p.unread = !s.read(p)
+	s.pages[i] = s.lru.PushFront(p)
+	return p
+}
+
+// read ORs into p the bits stored in s's KV, and reports whether it succeeded. The caller must hold s.mu.
+func (s *KVStore) read(p *kvPage) bool {
+	b, err := s.kv.Get(s.key(p.i))
+	if err != nil {
+		s.fail(err)
+		return false
+	}
+	for j := range min(len(b)/8, pageWords) {
+		p.w[j] |= binary.LittleEndian.Uint64(b[8*j:])
+	}
+	return true
+}
+
+// merge ORs into p the bits stored in s's KV if they have not yet been read, and reports whether p holds them.
+// The caller must hold s.mu.
+func (s *KVStore) merge(p *kvPage) bool {
+	if p.unread {
+		if !s.read(p) {
+			return false
+		}
+		p.unread = false
+	}
+	return true
+}
+
+// writeBack writes p to s's KV if it has been modified, and reports whether p need not be retained.
+// The caller must hold s.mu.
+func (s *KVStore) writeBack(p *kvPage) bool {
+	if !s.merge(p) {
+		return false
+	}
+	if !p.dirty {
+		return true
+	}
+	b := make([]byte, kvPageBytes)
+	for j, v := range p.w {
+		binary.LittleEndian.PutUint64(b[8*j:], v)
+	}
+	if err := s.kv.Put(s.key(p.i), b); err != nil {
+		s.fail(err)
+		return false
+	}
+	p.dirty = false
+	return true
+}
+
+// key returns the key of the page at index i.
+func (s *KVStore) key(i uint64) []byte {
+	return binary.BigEndian.AppendUint64(append([]byte(nil), s.prefix...), i)
+}
+
+// fail records err if no error has been recorded since the previous call to Flush. The caller must hold s.mu.
+func (s *KVStore) fail(err error) {
+	if s.err == nil {
+		s.err = err
+	}
+}
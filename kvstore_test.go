@@ -0,0 +1,127 @@
+package bloom
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+// mapKV is a KV held in a map, that can be made to fail.
+type mapKV struct {
+	m    map[string][]byte
+	puts int
+	err  error
+}
+
+func (kv *mapKV) Get(key []byte) ([]byte, error) {
+	if kv.err != nil {
+		return nil, kv.err
+	}
+	return kv.m[string(key)], nil
+}
+
+func (kv *mapKV) Put(key, value []byte) error {
+	if kv.err != nil {
+		return kv.err
+	}
+	kv.puts++
+	kv.m[string(key)] = append([]byte(nil), value...)
+	return nil
+}
+
+func TestKVStore(t *testing.T) {
+	const m = 1 << 18 // 8 pages
+	kv := &mapKV{m: make(map[string][]byte)}
+	f := NewWithHash(m, 4, BitsAndBlooms)
+	sf := NewStoreFilter(NewKVStore(kv, "f/", m, 2), 4, BitsAndBlooms)
+	for i := 0; i < 1000; i += 2 {
+		item := []byte(fmt.Sprint(i))
+		f.Insert(item)
+		sf.Insert(item)
+	}
+	for i := range 1000 {
+		item := []byte(fmt.Sprint(i))
+		if got, want := sf.MaybeContains(item), f.MaybeContains(item); got != want {
+			t.Errorf("TestKVStore(%v): got %v, want %v", i, got, want)
+		}
+	}
+	if err := sf.s.(*KVStore).Flush(); err != nil {
+		t.Errorf("TestKVStore: %v", err)
+	}
+	if len(kv.m) != m/8/kvPageBytes {
+		t.Errorf("TestKVStore: stored %v pages, want %v", len(kv.m), m/8/kvPageBytes)
+	}
+
+	// A new store over the same KV holds the same bits.
+	sf = NewStoreFilter(NewKVStore(kv, "f/", m, 1), 4, BitsAndBlooms)
+	for i := range 1000 {
+		item := []byte(fmt.Sprint(i))
+		if got, want := sf.MaybeContains(item), f.MaybeContains(item); got != want {
+			t.Errorf("TestKVStore(%v): after reopening: got %v, want %v", i, got, want)
+		}
+	}
+
+	// Unmodified pages are not written back.
+	puts := kv.puts
+	if err := sf.s.(*KVStore).Flush(); err != nil {
+		t.Errorf("TestKVStore: %v", err)
+	}
+	if kv.puts != puts {
+		t.Errorf("TestKVStore: flushed %v unmodified pages", kv.puts-puts)
+	}
+}
+
+func TestKVStoreError(t *testing.T) {
+	errKV := errors.New("kv failure")
+	kv := &mapKV{m: make(map[string][]byte)}
+	s := NewKVStore(kv, "", 1<<16, 1)
+	s.Set(1)
+	kv.err = errKV
+	s.Set(1 << 15)
+	if !s.Get(1 << 15) {
+		t.Errorf("TestKVStoreError: bit not set")
+	}
+	if err := s.Flush(); err != errKV {
+		t.Errorf("TestKVStoreError: got %v, want %v", err, errKV)
+	}
+	kv.err = nil
+	if err := s.Flush(); err != nil {
+		t.Errorf("TestKVStoreError: %v", err)
+	}
+	if !s.Get(1) {
+		t.Errorf("TestKVStoreError: bit not retained")
+	}
+
+	// A page that could not be read is merged with its stored bits before it is written back.
+	kv.err = errKV
+	s = NewKVStore(kv, "", 1<<16, 1)
+	s.Set(2)
+	kv.err = nil
+	if err := s.Flush(); err != errKV {
+		t.Errorf("TestKVStoreError: got %v, want %v", err, errKV)
+	}
+	s = NewKVStore(kv, "", 1<<16, 1)
+	if !s.Get(1) || !s.Get(2) || !s.Get(1<<15) {
+		t.Errorf("TestKVStoreError: bits not merged")
+	}
+
+	// A bit of a page that cannot be read reads as set, and as stored once the page is read.
+	s = NewKVStore(kv, "", 1<<16, 1)
+	kv.err = errKV
+	if !s.Get(3) {
+		t.Errorf("TestKVStoreError: unread bit reads as clear")
+	}
+	kv.err = nil
+	if s.Get(3) || !s.Get(2) {
+		t.Errorf("TestKVStoreError: bits not read after failure")
+	}
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Errorf("TestKVStoreError: did not panic")
+			}
+		}()
+		NewKVStore(kv, "", 64, 0)
+	}()
+}
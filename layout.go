@@ -0,0 +1,94 @@
+package bloom
+
+import (
+	"encoding/binary"
+	"math/bits"
+)
+
+// A BitLayout specifies how the bits of a filter are arranged in the raw form produced by MarshalBits,
+// for consumers in other languages that index bits differently.
+// The bits are grouped into words of WordSize bytes, and bit n of the filter is bit n%(8*WordSize) of word n/(8*WordSize).
+// The zero BitLayout describes the layout of MarshalBinary: bit n is bit n%8 of byte n/8, counting from the least significant bit.
+type BitLayout struct {
+	// MSBFirst reports whether bits are counted from the most significant bit of each word
+	// rather than the least significant.
+	MSBFirst bool
+
+	// WordSize is the size in bytes of each word: 1, 2, 4, or 8. 0 means 1.
+	WordSize int
+
+	// BigEndian reports whether words are stored in big-endian rather than little-endian byte order.
+	BigEndian bool
+}
+
+// wordSize returns the size of l's words, or an error if it is not supported.
+func (l BitLayout) wordSize() (int, error) {
+	switch l.WordSize {
+	case 0:
+		return 1, nil
+	case 1, 2, 4, 8:
+		return l.WordSize, nil
+	}
+	return 0, newError(ErrUnsupported, "unsupported word size")
+}
+
+// convert rearranges the bits of src, a whole number of words of w bytes, between layout l and the native layout,
+// and stores them in dst. The conversion is its own inverse.
+func (l BitLayout) convert(dst, src []byte, w int) {
+	shift := 64 - 8*w
+	var buf [8]byte
+	for i := 0; i < len(src); i += w {
+		copy(buf[:], src[i:i+w])
+		v := binary.LittleEndian.Uint64(buf[:])
+		if l.BigEndian {
+			v = bits.ReverseBytes64(v) >> shift
+		}
+		if l.MSBFirst {
+			v = bits.Reverse64(v) >> shift
+		}
+		binary.LittleEndian.PutUint64(buf[:], v)
+		copy(dst[i:], buf[:w])
+	}
+}
+
+// MarshalBits returns f's bits, without its parameters, in layout l.
+// Their length is the size of f in bits rounded up to a whole number of words.
+func (f *Filter) MarshalBits(l BitLayout) ([]byte, error) {
+	w, err := l.wordSize()
+	if err != nil {
+		return nil, err
+	}
+	b := f.bytes()
+	src := make([]byte, (len(b)+w-1)/w*w)
+	copy(src, b)
+	l.convert(src, src, w)
+	return src, nil
+}
+
+// UnmarshalBits sets f's bits to those of data in layout l, as produced by MarshalBits from a filter of the same size.
+// It does not change f's size, number of hash values, or hash algorithm.
+// If the length of data does not match f's size or data sets bits beyond f's size,
+// UnmarshalBits returns an error without modifying the contents of f.
+func (f *Filter) UnmarshalBits(data []byte, l BitLayout) error {
+	w, err := l.wordSize()
+	if err != nil {
+		return err
+	}
+	n := (f.m + 7) / 8
+	if len(data) != (n+w-1)/w*w {
+		return newError(ErrCorrupt, "filter size does not match data length")
+	}
+	b := make([]byte, len(data))
+	l.convert(b, data, w)
+	for _, c := range b[n:] {
+		if c != 0 {
+			return newError(ErrCorrupt, "bits set beyond filter size")
+		}
+	}
+	b = b[:n]
+	if err := checkPadding(b, uint64(f.m)); err != nil {
+		return err
+	}
+	f.pages = newPages(words(b))
+	return nil
+}
@@ -0,0 +1,68 @@
+package bloom
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestMarshalBits(t *testing.T) {
+	// A filter of 24 bits with bits 0, 9, and 23 set
+	f := filter(1, bitsAt(3, 0, 9, 23)...)
+	for _, test := range []struct {
+		l    BitLayout
+		data []byte
+	}{
+		{BitLayout{}, []byte{0x01, 0x02, 0x80}},
+		{BitLayout{WordSize: 1, BigEndian: true}, []byte{0x01, 0x02, 0x80}},
+		{BitLayout{MSBFirst: true}, []byte{0x80, 0x40, 0x01}},
+		{BitLayout{WordSize: 2}, []byte{0x01, 0x02, 0x80, 0x00}},
+		{BitLayout{WordSize: 2, BigEndian: true}, []byte{0x02, 0x01, 0x00, 0x80}},
+		{BitLayout{WordSize: 2, MSBFirst: true}, []byte{0x40, 0x80, 0x00, 0x01}},
+		{BitLayout{WordSize: 2, MSBFirst: true, BigEndian: true}, []byte{0x80, 0x40, 0x01, 0x00}},
+		{BitLayout{WordSize: 4, BigEndian: true}, []byte{0x00, 0x80, 0x02, 0x01}},
+		{BitLayout{WordSize: 8, BigEndian: true}, []byte{0, 0, 0, 0, 0, 0x80, 0x02, 0x01}},
+		{BitLayout{WordSize: 8, MSBFirst: true, BigEndian: true}, []byte{0x80, 0x40, 0x01, 0, 0, 0, 0, 0}},
+	} {
+		data, err := f.MarshalBits(test.l)
+		if err != nil {
+			t.Errorf("TestMarshalBits(%+v): %v", test.l, err)
+		}
+		if !bytes.Equal(data, test.data) {
+			t.Errorf("TestMarshalBits(%+v): got %#x, want %#x", test.l, data, test.data)
+		}
+
+		g := filter(1, 0, 0, 0)
+		if err := g.UnmarshalBits(test.data, test.l); err != nil {
+			t.Errorf("TestMarshalBits(%+v): %v", test.l, err)
+		}
+		if !reflect.DeepEqual(g, f) {
+			t.Errorf("TestMarshalBits(%+v): got %v, want %v", test.l, g, f)
+		}
+	}
+
+	if _, err := f.MarshalBits(BitLayout{WordSize: 3}); err == nil {
+		t.Errorf("TestMarshalBits: got nil error for word size 3")
+	}
+}
+
+func TestUnmarshalBitsError(t *testing.T) {
+	for _, test := range []struct {
+		l    BitLayout
+		data []byte
+	}{
+		{BitLayout{WordSize: 3}, []byte{0, 0, 0}},
+		{BitLayout{}, []byte{0, 0}},
+		{BitLayout{WordSize: 2}, []byte{0, 0, 0}},
+		{BitLayout{WordSize: 2}, []byte{0, 0, 0, 1}},                  // bits set beyond filter size
+		{BitLayout{WordSize: 2, BigEndian: true}, []byte{0, 0, 1, 0}}, // bits set beyond filter size
+	} {
+		f := filter(1, 0, 0, 0)
+		if err := f.UnmarshalBits(test.data, test.l); err == nil {
+			t.Errorf("TestUnmarshalBitsError(%+v, %v): got nil error", test.l, test.data)
+		}
+		if !reflect.DeepEqual(f, filter(1, 0, 0, 0)) {
+			t.Errorf("TestUnmarshalBitsError(%+v, %v): modified filter: %v", test.l, test.data, f)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package bloom
+
+import (
+	"context"
+	"iter"
+	"runtime"
+	"sync"
+)
+
+// loadBatchSize is the number of items that LoadFrom hands to a worker goroutine at once.
+const loadBatchSize = 1024
+
+// A LoadOption configures LoadFrom.
+type LoadOption func(*loadOptions)
+
+type loadOptions struct {
+	workers  int
+	progress func(n int64)
+}
+
+// WithWorkers sets the number of goroutines among which LoadFrom divides its insertions.
+// If n is not positive, or the option is not given, LoadFrom uses runtime.GOMAXPROCS(0) goroutines.
+func WithWorkers(n int) LoadOption {
+	return func(o *loadOptions) { o.workers = n }
+}
+
+// WithProgress sets a function that LoadFrom calls with the total number of items inserted so far,
+// each time a batch of items has been inserted. Calls are not concurrent, and their totals increase.
+func WithProgress(fn func(n int64)) LoadOption {
+	return func(o *loadOptions) { o.progress = fn }
+}
+
+// A loadBatch holds copies of a batch of items, which end at the given offsets in data.
+type loadBatch struct {
+	data []byte
+	ends []int
+}
+
+// LoadFrom inserts into f's set every item yielded by seq, dividing them in batches among a bounded number
+// of goroutines. It copies each item, so seq may reuse the memory of an item once it has been yielded.
+// If ctx is canceled, LoadFrom stops consuming seq, waits for batches in progress to be inserted,
+// and returns ctx's error; the items inserted up to that point remain in f. Otherwise it returns nil.
+// LoadFrom may be called concurrently with the same methods as Insert.
+func (f *Filter) LoadFrom(ctx context.Context, seq iter.Seq[[]byte], opts ...LoadOption) error {
+	var o loadOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.workers <= 0 {
+		o.workers = runtime.GOMAXPROCS(0)
+	}
+
+	var (
+		wg      sync.WaitGroup
+		batches = make(chan *loadBatch, o.workers)
+		free    = sync.Pool{New: func() any { return new(loadBatch) }}
+		mu      sync.Mutex
+		total   int64
+	)
+	for range o.workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for b := range batches {
+				if ctx.Err() != nil {
+					continue
+				}
+				start := 0
+				for _, end := range b.ends {
+					f.Insert(b.data[start:end])
+					start = end
+				}
+				if o.progress != nil {
+					mu.Lock()
+					total += int64(len(b.ends))
+					o.progress(total)
+					mu.Unlock()
+				}
+				b.data, b.ends = b.data[:0], b.ends[:0]
+				free.Put(b)
+			}
+		}()
+	}
+
+	b := free.Get().(*loadBatch)
+	send := func() bool {
+		select {
+		case batches <- b:
+			b = free.Get().(*loadBatch)
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+	for item := range seq {
+		if ctx.Err() != nil {
+			break
+		}
+		b.data = append(b.data, item...)
+		b.ends = append(b.ends, len(b.data))
+		if len(b.ends) == loadBatchSize && !send() {
+			break
+		}
+	}
+	if len(b.ends) > 0 && ctx.Err() == nil {
+		send()
+	}
+	close(batches)
+	wg.Wait()
+	return ctx.Err()
+}
@@ -0,0 +1,76 @@
+package bloom
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+// numbers returns a sequence of the decimal representations of the integers in [0, n),
+// reusing the memory of each item for the next.
+func numbers(n int) func(func([]byte) bool) {
+	return func(yield func([]byte) bool) {
+		var buf []byte
+		for i := range n {
+			buf = fmt.Appendf(buf[:0], "%d", i)
+			if !yield(buf) {
+				return
+			}
+		}
+	}
+}
+
+func TestLoadFrom(t *testing.T) {
+	for _, n := range []int{0, 1, loadBatchSize, 5*loadBatchSize + 3} {
+		want := NewWithHash(1<<16, 5, BitsAndBlooms)
+		for i := range n {
+			want.Insert([]byte(fmt.Sprint(i)))
+		}
+		for _, workers := range []int{0, 1, 4} {
+			f := NewWithHash(1<<16, 5, BitsAndBlooms)
+			var last, calls int64
+			err := f.LoadFrom(context.Background(), numbers(n), WithWorkers(workers), WithProgress(func(total int64) {
+				if total <= last {
+					t.Errorf("TestLoadFrom(%v, %v): progress %v after %v", n, workers, total, last)
+				}
+				last = total
+				calls++
+			}))
+			if err != nil {
+				t.Errorf("TestLoadFrom(%v, %v): %v", n, workers, err)
+			}
+			if !reflect.DeepEqual(f, want) {
+				t.Errorf("TestLoadFrom(%v, %v): got %v, want %v", n, workers, f, want)
+			}
+			if last != int64(n) {
+				t.Errorf("TestLoadFrom(%v, %v): got progress %v, want %v", n, workers, last, n)
+			}
+			if wantCalls := int64((n + loadBatchSize - 1) / loadBatchSize); calls != wantCalls {
+				t.Errorf("TestLoadFrom(%v, %v): got %v progress calls, want %v", n, workers, calls, wantCalls)
+			}
+		}
+	}
+}
+
+func TestLoadFromCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	f := NewWithHash(1<<16, 5, BitsAndBlooms)
+	consumed := 0
+	seq := func(yield func([]byte) bool) {
+		for item := range numbers(100 * loadBatchSize) {
+			if consumed++; consumed == 2*loadBatchSize {
+				cancel()
+			}
+			if !yield(item) {
+				return
+			}
+		}
+	}
+	if err := f.LoadFrom(ctx, seq, WithWorkers(2)); err != context.Canceled {
+		t.Errorf("TestLoadFromCanceled: got %v, want %v", err, context.Canceled)
+	}
+	if consumed > 2*loadBatchSize+1 {
+		t.Errorf("TestLoadFromCanceled: consumed %v items after cancellation", consumed-2*loadBatchSize)
+	}
+}
@@ -0,0 +1,38 @@
+package bloom
+
+// A MappedFilter is a Filter whose bits are held in a memory-mapped file rather than on the Go heap,
+// so that a large filter occupies only the page cache and is not scanned by the garbage collector.
+// It must be closed when no longer needed, after which its Filter must not be used.
+type MappedFilter struct {
+	*Filter
+	data []byte // the mapped file, or nil if the Filter holds a copy
+}
+
+// OpenMmap maps the file at path, which must hold a Filter in the binary form produced by MarshalBinary
+// or in the legacy form, and returns a MappedFilter for querying it.
+// It validates the data in the same manner as NewFromBytesNoCopy, and maps it read-only:
+// Insert and other methods that set bits copy the affected pages to the heap, and never modify the file.
+// If the bits cannot be used in place, for instance because they are in sparse encoding, the Filter holds a copy.
+// OpenMmap returns an error on systems that do not support memory mapping.
+func OpenMmap(path string) (*MappedFilter, error) {
+	data, err := mmap(path)
+	if err != nil {
+		return nil, err
+	}
+	f, err := NewFromBytesNoCopy(data)
+	if err != nil {
+		munmap(data)
+		return nil, err
+	}
+	return &MappedFilter{Filter: f, data: data}, nil
+}
+
+// Close unmaps the file.
+func (m *MappedFilter) Close() error {
+	data := m.data
+	m.data = nil
+	if data == nil {
+		return nil
+	}
+	return munmap(data)
+}
@@ -0,0 +1,20 @@
+//go:build !unix
+
+package bloom
+
+import (
+	"errors"
+	"os"
+)
+
+func mmap(path string) ([]byte, error) {
+	return nil, errors.New("memory mapping not supported")
+}
+
+func mmapWritable(file *os.File) ([]byte, error) {
+	return nil, errors.New("memory mapping not supported")
+}
+
+func munmap(data []byte) error {
+	return nil
+}
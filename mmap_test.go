@@ -0,0 +1,83 @@
+//go:build unix
+
+package bloom
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestOpenMmap(t *testing.T) {
+	dir := t.TempDir()
+	for _, test := range marshalTests {
+		for i, data := range [][]byte{test.data, test.legacy} {
+			path := filepath.Join(dir, "filter")
+			if err := os.WriteFile(path, data, 0o644); err != nil {
+				t.Fatal(err)
+			}
+			m, err := OpenMmap(path)
+			if err != nil {
+				t.Errorf("TestOpenMmap(%v, %v): %v", test.f, i, err)
+				continue
+			}
+			if !equal(m.Filter, test.f) {
+				t.Errorf("TestOpenMmap(%v, %v): got %v", test.f, i, m.Filter)
+			}
+			// Insertion does not modify the file.
+			m.Insert([]byte("item"))
+			if !m.MaybeContains([]byte("item")) {
+				t.Errorf("TestOpenMmap(%v, %v): inserted item not found", test.f, i)
+			}
+			if err := m.Close(); err != nil {
+				t.Errorf("TestOpenMmap(%v, %v): %v", test.f, i, err)
+			}
+			if got, _ := os.ReadFile(path); string(got) != string(data) {
+				t.Errorf("TestOpenMmap(%v, %v): file modified", test.f, i)
+			}
+		}
+	}
+
+	for i, data := range append([][]byte{nil}, invalidBinary...) {
+		path := filepath.Join(dir, "invalid")
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if m, err := OpenMmap(path); err == nil {
+			t.Errorf("TestOpenMmap(invalid %v): got %v, nil error", i, m.Filter)
+			m.Close()
+		}
+	}
+	if _, err := OpenMmap(filepath.Join(dir, "missing")); err == nil {
+		t.Errorf("TestOpenMmap(missing): got nil error")
+	}
+}
+
+func TestMappedFilterValidate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "filter")
+	if err := os.WriteFile(path, mustMarshal(New(64, 3)), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	m, err := OpenMmap(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+	if err := m.Validate(); err != nil {
+		t.Errorf("TestMappedFilterValidate: %v", err)
+	}
+
+	// Another process rewrites the file in place with different parameters.
+	file, err := os.OpenFile(path, os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	if _, err := file.WriteAt([]byte{5}, 7); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Validate(); !errors.Is(err, ErrCorrupt) {
+		t.Errorf("TestMappedFilterValidate(rewritten): got %v, want %v", err, ErrCorrupt)
+	}
+}
@@ -0,0 +1,46 @@
+//go:build unix
+
+package bloom
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+// mmap maps the file at path read-only and returns its contents.
+func mmap(path string) ([]byte, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return mapFile(file, syscall.PROT_READ)
+}
+
+// mmapWritable maps file, which must be open for reading and writing, so that its contents can be modified
+// in place and the modifications are visible to other processes that map it, and returns its contents.
+func mmapWritable(file *os.File) ([]byte, error) {
+	return mapFile(file, syscall.PROT_READ|syscall.PROT_WRITE)
+}
+
+// mapFile maps file with the memory protection prot and returns its contents.
+func mapFile(file *os.File, prot int) ([]byte, error) {
+	fi, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	size := fi.Size()
+	if size == 0 {
+		return nil, errors.New("empty file")
+	}
+	if int64(int(size)) != size {
+		return nil, errors.New("file too large to map")
+	}
+	return syscall.Mmap(int(file.Fd()), 0, int(size), prot, syscall.MAP_SHARED)
+}
+
+// munmap unmaps data returned by mmap.
+func munmap(data []byte) error {
+	return syscall.Munmap(data)
+}
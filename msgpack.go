@@ -0,0 +1,121 @@
+package bloom
+
+import (
+	"encoding/binary"
+)
+
+// MarshalMsgpack marshals f into a MessagePack array of four elements, the number of hash values as an integer,
+// the filter's bits as a bin object, the hash algorithm as an integer, and the filter's size in bits as an integer.
+// It satisfies the Marshaler interfaces of common MessagePack packages.
+func (f *Filter) MarshalMsgpack() ([]byte, error) {
+	l := (f.m + 7) / 8
+	b := make([]byte, 0, 1+9+5+l+9+9)
+	b = append(b, 0x90|4) // fixarray
+	b = appendMsgpackUint(b, uint64(f.k))
+	switch {
+	case l <= 0xff:
+		b = append(b, 0xc4, byte(l))
+	case l <= 0xffff:
+		b = binary.BigEndian.AppendUint16(append(b, 0xc5), uint16(l))
+	default:
+		b = binary.BigEndian.AppendUint32(append(b, 0xc6), uint32(l))
+	}
+	b = f.appendBits(b, 0, l)
+	b = appendMsgpackUint(b, uint64(f.hash))
+	b = appendMsgpackUint(b, uint64(f.m))
+	return b, nil
+}
+
+// UnmarshalMsgpack unmarshals a MessagePack array produced by MarshalMsgpack and stores the result in f.
+// It also accepts the array of two elements, the number of hash values and the bits,
+// written by earlier versions of this package for filters that use SHA256.
+// It validates the filter size and number of hash values in the same manner as UnmarshalBinary,
+// and returns an error without modifying the contents of f if data is not a well-formed encoding of a Filter.
+// It satisfies the Unmarshaler interfaces of common MessagePack packages.
+func (f *Filter) UnmarshalMsgpack(data []byte) error {
+	fields, data, err := readMsgpackUint(data, 0x90, 0xf0, msgpackArray)
+	if err != nil {
+		return err
+	}
+	if fields != 2 && fields != 4 {
+		return newError(ErrCorrupt, "MessagePack data is not an array of four elements")
+	}
+	k, data, err := readMsgpackUint(data, 0x00, 0x80, msgpackUint)
+	if err != nil {
+		return err
+	}
+	l, data, err := readMsgpackUint(data, 0, 0, msgpackBin)
+	if err != nil {
+		return err
+	}
+	if l > uint64(len(data)) {
+		return newError(ErrTruncated, "unexpected end of MessagePack data")
+	}
+	b, data := data[:l], data[l:]
+	h := header{hashAlgorithm: byte(SHA256), k: byte(k), m: l * 8}
+	if fields == 4 {
+		var hash uint64
+		if hash, data, err = readMsgpackUint(data, 0x00, 0x80, msgpackUint); err != nil {
+			return err
+		}
+		if h.m, data, err = readMsgpackUint(data, 0x00, 0x80, msgpackUint); err != nil {
+			return err
+		}
+		if hash >= uint64(len(hashNames)) {
+			return newError(ErrUnsupported, "unsupported hash algorithm")
+		}
+		h.hashAlgorithm = byte(hash)
+	}
+	if len(data) != 0 {
+		return newError(ErrCorrupt, "unexpected data after MessagePack array")
+	}
+	return f.loadChecked(h, k, b)
+}
+
+// appendMsgpackUint appends to b the shortest MessagePack encoding of the unsigned integer n.
+func appendMsgpackUint(b []byte, n uint64) []byte {
+	switch {
+	case n < 0x80:
+		return append(b, byte(n)) // positive fixint
+	case n <= 0xff:
+		return append(b, 0xcc, byte(n))
+	case n <= 0xffff:
+		return binary.BigEndian.AppendUint16(append(b, 0xcd), uint16(n))
+	case n <= 0xffffffff:
+		return binary.BigEndian.AppendUint32(append(b, 0xce), uint32(n))
+	default:
+		return binary.BigEndian.AppendUint64(append(b, 0xcf), n)
+	}
+}
+
+// MessagePack format families whose values are unsigned integers or whose lengths precede their contents,
+// mapping each non-fixed format's first byte to the size in bytes of the integer that follows it
+var (
+	msgpackUint  = map[byte]int{0xcc: 1, 0xcd: 2, 0xce: 4, 0xcf: 8}
+	msgpackArray = map[byte]int{0xdc: 2, 0xdd: 4}
+	msgpackBin   = map[byte]int{0xc4: 1, 0xc5: 2, 0xc6: 4}
+)
+
+// readMsgpackUint reads from the front of data the first byte of a MessagePack value and the unsigned integer
+// that follows it or, if the first byte matches fix under mask, is contained in its remaining bits.
+// It returns the integer and the remainder of data.
+func readMsgpackUint(data []byte, fix, mask byte, formats map[byte]int) (n uint64, rest []byte, err error) {
+	if len(data) == 0 {
+		return 0, nil, newError(ErrTruncated, "unexpected end of MessagePack data")
+	}
+	c := data[0]
+	if mask != 0 && c&mask == fix {
+		return uint64(c &^ mask), data[1:], nil
+	}
+	l, ok := formats[c]
+	if !ok {
+		return 0, nil, newError(ErrCorrupt, "unexpected MessagePack format")
+	}
+	if len(data) < 1+l {
+		return 0, nil, newError(ErrTruncated, "unexpected end of MessagePack data")
+	}
+	for _, c := range data[1 : 1+l] {
+		n = n<<8 | uint64(c)
+	}
+	return n, data[1+l:], nil
+}
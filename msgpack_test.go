@@ -0,0 +1,86 @@
+package bloom
+
+import (
+	"reflect"
+	"testing"
+)
+
+var msgpackTests = []struct {
+	f    *Filter
+	data []byte
+}{
+	{New(1, 1), []byte{0x94, 0x01, 0xc4, 0x01, 0, 0x00, 0x08}},
+	{New(4, 3), []byte{0x94, 0x03, 0xc4, 0x04, 0, 0, 0, 0, 0x00, 0x20}},
+	{filter(16, 15, 23), []byte{0x94, 0x10, 0xc4, 0x02, 15, 23, 0x00, 0x10}},
+	{New(32, 8), append(append([]byte{0x94, 0x08, 0xc4, 0x20}, make([]byte, 32)...), 0x00, 0xcd, 0x01, 0x00)},
+	{New(256, 8), append(append([]byte{0x94, 0x08, 0xc5, 0x01, 0x00}, make([]byte, 256)...), 0x00, 0xcd, 0x08, 0x00)},
+	{NewWithHash(100, 3, BitsAndBlooms), append(append([]byte{0x94, 0x03, 0xc4, 0x0d}, make([]byte, 13)...), 0x01, 0x64)},
+	{NewWithHash(1000, 200, BitsAndBlooms), append(append([]byte{0x94, 0xcc, 0xc8, 0xc4, 0x7d}, make([]byte, 125)...), 0x01, 0xcd, 0x03, 0xe8)},
+}
+
+func TestMarshalMsgpack(t *testing.T) {
+	for _, test := range msgpackTests {
+		data, err := test.f.MarshalMsgpack()
+		if err != nil {
+			t.Errorf("TestMarshalMsgpack: %v", err)
+		}
+		if !reflect.DeepEqual(data, test.data) {
+			t.Errorf("TestMarshalMsgpack: got %v, want %v", data, test.data)
+		}
+	}
+}
+
+func TestUnmarshalMsgpack(t *testing.T) {
+	for _, test := range append(msgpackTests, []struct {
+		f    *Filter
+		data []byte
+	}{
+		// The array of two elements written by earlier versions.
+		{New(1, 1), []byte{0x92, 0x01, 0xc4, 0x01, 0}},
+		{filter(16, 15, 23), []byte{0x92, 0x10, 0xc4, 0x02, 15, 23}},
+	}...) {
+		f := new(Filter)
+		if err := f.UnmarshalMsgpack(test.data); err != nil {
+			t.Errorf("TestUnmarshalMsgpack(%v): %v", test.data, err)
+		}
+		if !reflect.DeepEqual(f, test.f) {
+			t.Errorf("TestUnmarshalMsgpack: got %v, want %v", f, test.f)
+		}
+	}
+
+	// Longer encodings of the same values are also accepted.
+	f := new(Filter)
+	if err := f.UnmarshalMsgpack([]byte{0xdc, 0, 4, 0xcf, 0, 0, 0, 0, 0, 0, 0, 3, 0xc6, 0, 0, 0, 4, 0, 0, 0, 0, 0xcc, 0, 0xce, 0, 0, 0, 0x20}); err != nil {
+		t.Errorf("TestUnmarshalMsgpack: %v", err)
+	}
+	if want := New(4, 3); !reflect.DeepEqual(f, want) {
+		t.Errorf("TestUnmarshalMsgpack: got %v, want %v", f, want)
+	}
+
+	for _, data := range [][]byte{
+		nil,
+		{0x92},
+		{0x93, 0x01, 0xc4, 0x01, 0},                   // three-element array
+		{0x92, 0xc4, 0x01, 0, 0x01},                   // elements out of order
+		{0x92, 0x01, 0xc4, 0x02, 0},                   // truncated bin
+		{0x92, 0x01, 0xc4, 0x03, 0, 0},                // size not a power of 2
+		{0x92, 0x00, 0xc4, 0x01, 0},                   // no hash values
+		{0x92, 0x11, 0xc4, 0x01, 0},                   // too many hash values
+		{0x92, 0xff, 0xc4, 0x01, 0},                   // negative number of hash values
+		{0x92, 0x01, 0xc4, 0x01, 0, 0},                // trailing data
+		{0x94, 0x01, 0xc4, 0x01, 0, 0x09, 0x08},       // unsupported hash algorithm
+		{0x94, 0x01, 0xc4, 0x01, 0, 0xcd, 1, 0, 0x08}, // hash algorithm out of range
+		{0x94, 0x01, 0xc4, 0x01, 0, 0x00, 0x10},       // size does not match bits
+		{0x94, 0x01, 0xc4, 0x01, 0x80, 0x01, 0x07},    // bit set beyond size
+		{0x94, 0x01, 0xc4, 0x01, 0, 0x00, 0xc4, 0x01}, // size not an integer
+		{0x94, 0x01, 0xc4, 0x01, 0, 0x00},             // missing size
+	} {
+		f := New(1, 1)
+		if err := f.UnmarshalMsgpack(data); err == nil {
+			t.Errorf("TestUnmarshalMsgpack(%v): got nil error", data)
+		}
+		if !reflect.DeepEqual(f, New(1, 1)) {
+			t.Errorf("TestUnmarshalMsgpack(%v): modified filter to %v", data, f)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package bloom
+
+import (
+	"encoding/binary"
+	"math/bits"
+)
+
+// murmur3 returns the 128-bit x64 variant of MurmurHash3, with a seed of 0,
+// of the concatenation of data and suffix, which must be shorter than 16 bytes.
+func murmur3(data, suffix []byte) (h1, h2 uint64) {
+	const c1, c2 = 0x87c37b91114253d5, 0x4cf5ad432745937f
+	n := len(data) + len(suffix)
+	block := func(b []byte) {
+		k1, k2 := binary.LittleEndian.Uint64(b), binary.LittleEndian.Uint64(b[8:])
+		h1 ^= bits.RotateLeft64(k1*c1, 31) * c2
+		h1 = (bits.RotateLeft64(h1, 27)+h2)*5 + 0x52dce729
+		h2 ^= bits.RotateLeft64(k2*c2, 33) * c1
+		h2 = (bits.RotateLeft64(h2, 31)+h1)*5 + 0x38495ab5
+	}
+	for ; len(data) >= 16; data = data[16:] {
+		block(data)
+	}
+	var buf [32]byte
+	tail := append(append(buf[:0], data...), suffix...)
+	if len(tail) >= 16 {
+		block(tail)
+		tail = tail[16:]
+	}
+	if len(tail) > 0 {
+		var t [16]byte
+		copy(t[:], tail)
+		k1, k2 := binary.LittleEndian.Uint64(t[:]), binary.LittleEndian.Uint64(t[8:])
+		if len(tail) > 8 {
+			h2 ^= bits.RotateLeft64(k2*c2, 33) * c1
+		}
+		h1 ^= bits.RotateLeft64(k1*c1, 31) * c2
+	}
+	h1 ^= uint64(n)
+	h2 ^= uint64(n)
+	h1 += h2
+	h2 += h1
+	h1, h2 = fmix64(h1), fmix64(h2)
+	h1 += h2
+	h2 += h1
+	return h1, h2
+}
+
+// fmix64 is MurmurHash3's 64-bit finalization mix.
+func fmix64(k uint64) uint64 {
+	k ^= k >> 33
+	k *= 0xff51afd7ed558ccd
+	k ^= k >> 33
+	k *= 0xc4ceb9fe1a85ec53
+	k ^= k >> 33
+	return k
+}
+
+// murmur64A returns the 64-bit MurmurHash64A of data with the given seed.
+func murmur64A(data []byte, seed uint64) uint64 {
+	const m, r = 0xc6a4a7935bd1e995, 47
+	h := seed ^ uint64(len(data))*m
+	for ; len(data) >= 8; data = data[8:] {
+		k := binary.LittleEndian.Uint64(data) * m
+		k ^= k >> r
+		h ^= k * m
+		h *= m
+	}
+	if len(data) > 0 {
+		var t [8]byte
+		copy(t[:], data)
+		h ^= binary.LittleEndian.Uint64(t[:])
+		h *= m
+	}
+	h ^= h >> r
+	h *= m
+	h ^= h >> r
+	return h
+}
@@ -0,0 +1,27 @@
+package bloom
+
+import "testing"
+
+func TestMurmur3(t *testing.T) {
+	for _, test := range []struct {
+		s      string
+		h1, h2 uint64
+	}{
+		{"", 0x0000000000000000, 0x0000000000000000},
+		{"a", 0x85555565f6597889, 0xe6b53a48510e895a},
+		{"hello", 0xcbd8a7b341bd9b02, 0x5b1e906a48ae1d19},
+		{"The quick brown fox jumps over the lazy dog", 0xe34bbc7bbc071b6c, 0x7a433ca9c49a9347},
+		{"0123456789abcde", 0xa62dd5f6c0bf2351, 0x4fccf50c7c544cf0},
+		{"0123456789abcdef", 0x4be06d94cf4ad1a7, 0x87c35b5c63a708da},
+		{"0123456789abcdef0123456789abcde", 0x9afbac977e4daf00, 0x89fe4cda7efd8251},
+		{"0123456789abcdef0123456789abcdef0", 0x2e088f3b47fef53b, 0x1e388e32f1e800cf},
+	} {
+		// The result must not depend on how the input is divided between data and suffix.
+		for n := max(0, len(test.s)-15); n <= len(test.s); n++ {
+			h1, h2 := murmur3([]byte(test.s[:n]), []byte(test.s[n:]))
+			if h1 != test.h1 || h2 != test.h2 {
+				t.Errorf("TestMurmur3(%q, %v): got %x, %x, want %x, %x", test.s, n, h1, h2, test.h1, test.h2)
+			}
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package bloom
+
+import (
+	"context"
+	"io"
+)
+
+// An ObjectStore is an object storage service, such as Amazon S3 or Google Cloud Storage,
+// in which SaveObject and LoadObject store filters. Services can be used by adapting their client packages.
+type ObjectStore interface {
+	// Put stores data as the object named key, replacing any existing object.
+	Put(ctx context.Context, key string, data []byte) error
+
+	// Get returns a reader of the object named key. The caller closes it when done.
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+}
+
+// A MultipartStore is an ObjectStore that can store an object by uploading it in parts,
+// in the manner of the S3 multipart upload API.
+type MultipartStore interface {
+	ObjectStore
+
+	// CreateMultipartUpload begins an upload of the object named key and returns its ID.
+	CreateMultipartUpload(ctx context.Context, key string) (uploadID string, err error)
+
+	// UploadPart uploads data as part number part, beginning with 1, and returns its entity tag.
+	// It does not retain data.
+	UploadPart(ctx context.Context, key, uploadID string, part int, data []byte) (etag string, err error)
+
+	// CompleteMultipartUpload stores the object made of the uploaded parts, whose entity tags are etags, in order.
+	CompleteMultipartUpload(ctx context.Context, key, uploadID string, etags []string) error
+
+	// AbortMultipartUpload abandons the upload and discards its parts.
+	AbortMultipartUpload(ctx context.Context, key, uploadID string) error
+}
+
+// objectPartSize is the size of each part but the last of a multipart upload by SaveObject.
+const objectPartSize = 64 << 20
+
+// SaveObject stores f in s as the object named key, in the binary form produced by MarshalBinary.
+// If s is a MultipartStore and the binary form is larger than 64 MiB, SaveObject uploads it in parts of that size,
+// streaming it from f's pages so that it is not copied to a separate buffer in its entirety;
+// if the upload fails, it is aborted.
+func (f *Filter) SaveObject(ctx context.Context, s ObjectStore, key string) error {
+	return f.saveObject(ctx, s, key, objectPartSize)
+}
+
+// saveObject is SaveObject with parts of size bytes.
+func (f *Filter) saveObject(ctx context.Context, s ObjectStore, key string, size int) error {
+	ms, ok := s.(MultipartStore)
+	if !ok || headerSize+(f.m+7)/8+4 <= size {
+		data, err := f.MarshalBinary()
+		if err != nil {
+			return err
+		}
+		return s.Put(ctx, key, data)
+	}
+	id, err := ms.CreateMultipartUpload(ctx, key)
+	if err != nil {
+		return err
+	}
+	pw := &partWriter{ctx: ctx, s: ms, key: key, id: id, buf: make([]byte, 0, size)}
+	if _, err = f.WriteTo(pw); err == nil {
+		err = pw.flush()
+	}
+	if err == nil {
+		err = ms.CompleteMultipartUpload(ctx, key, id, pw.etags)
+	}
+	if err != nil {
+		ms.AbortMultipartUpload(context.WithoutCancel(ctx), key, id)
+		return err
+	}
+	return nil
+}
+
+// A partWriter uploads the data written to it as the parts of a multipart upload, each the size of its buffer.
+type partWriter struct {
+	ctx   context.Context
+	s     MultipartStore
+	key   string
+	id    string
+	buf   []byte
+	etags []string
+}
+
+func (pw *partWriter) Write(p []byte) (int, error) {
+	var n int
+	for len(p) > 0 {
+		if len(pw.buf) == cap(pw.buf) {
+			if err := pw.flush(); err != nil {
+				return n, err
+			}
+		}
+		c := copy(pw.buf[len(pw.buf):cap(pw.buf)], p)
+		pw.buf = pw.buf[:len(pw.buf)+c]
+		p = p[c:]
+		n += c
+	}
+	return n, nil
+}
+
+// flush uploads the buffered data, if any, as the next part.
+func (pw *partWriter) flush() error {
+	if len(pw.buf) == 0 {
+		return nil
+	}
+	if err := pw.ctx.Err(); err != nil {
+		return err
+	}
+	etag, err := pw.s.UploadPart(pw.ctx, pw.key, pw.id, len(pw.etags)+1, pw.buf)
+	if err != nil {
+		return err
+	}
+	pw.etags = append(pw.etags, etag)
+	pw.buf = pw.buf[:0]
+	return nil
+}
+
+// LoadObject reads the object named key from s, which must hold a filter in the binary form produced by MarshalBinary,
+// and stores the filter in f. It validates the data in the same manner as UnmarshalBinary, but does not accept the legacy form.
+// If it returns an error, it does not modify the contents of f.
+func (f *Filter) LoadObject(ctx context.Context, s ObjectStore, key string) error {
+	rc, err := s.Get(ctx, key)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	g := new(Filter)
+	if _, err := g.ReadFrom(rc); err != nil {
+		return err
+	}
+	if n, err := io.Copy(io.Discard, rc); err != nil {
+		return err
+	} else if n != 0 {
+		return newError(ErrCorrupt, "filter size does not match data length")
+	}
+	*f = *g
+	return nil
+}
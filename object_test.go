@@ -0,0 +1,120 @@
+package bloom
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+)
+
+// memObjects is an ObjectStore held in memory.
+type memObjects struct {
+	objects map[string][]byte
+}
+
+func (s *memObjects) Put(ctx context.Context, key string, data []byte) error {
+	s.objects[key] = append([]byte(nil), data...)
+	return nil
+}
+
+func (s *memObjects) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	data, ok := s.objects[key]
+	if !ok {
+		return nil, errors.New("no such object")
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+// memMultipart is a MultipartStore held in memory, whose uploads fail after failAfter parts if failAfter is positive.
+type memMultipart struct {
+	memObjects
+	uploads   map[string][][]byte
+	failAfter int
+	aborted   int
+}
+
+func (s *memMultipart) CreateMultipartUpload(ctx context.Context, key string) (string, error) {
+	id := fmt.Sprint(len(s.uploads))
+	s.uploads[id] = nil
+	return id, nil
+}
+
+func (s *memMultipart) UploadPart(ctx context.Context, key, id string, part int, data []byte) (string, error) {
+	if part != len(s.uploads[id])+1 {
+		return "", fmt.Errorf("part %v out of order", part)
+	}
+	if s.failAfter > 0 && part > s.failAfter {
+		return "", errors.New("upload failed")
+	}
+	s.uploads[id] = append(s.uploads[id], append([]byte(nil), data...))
+	return fmt.Sprint(part), nil
+}
+
+func (s *memMultipart) CompleteMultipartUpload(ctx context.Context, key, id string, etags []string) error {
+	if len(etags) != len(s.uploads[id]) {
+		return errors.New("missing parts")
+	}
+	s.objects[key] = bytes.Join(s.uploads[id], nil)
+	delete(s.uploads, id)
+	return nil
+}
+
+func (s *memMultipart) AbortMultipartUpload(ctx context.Context, key, id string) error {
+	delete(s.uploads, id)
+	s.aborted++
+	return nil
+}
+
+func TestObject(t *testing.T) {
+	f := NewWithHash(1<<14, 4, BitsAndBlooms)
+	for i := range 1000 {
+		f.Insert([]byte(fmt.Sprint(i)))
+	}
+	ctx := context.Background()
+	for _, test := range []struct {
+		s    ObjectStore
+		size int
+	}{
+		{&memObjects{make(map[string][]byte)}, 100},
+		{&memMultipart{memObjects{make(map[string][]byte)}, make(map[string][][]byte), 0, 0}, objectPartSize},
+		{&memMultipart{memObjects{make(map[string][]byte)}, make(map[string][][]byte), 0, 0}, 1000},
+	} {
+		if err := f.saveObject(ctx, test.s, "f", test.size); err != nil {
+			t.Errorf("TestObject(%T, %v): %v", test.s, test.size, err)
+			continue
+		}
+		g := new(Filter)
+		if err := g.LoadObject(ctx, test.s, "f"); err != nil {
+			t.Errorf("TestObject(%T, %v): %v", test.s, test.size, err)
+		} else if !equal(g, f) {
+			t.Errorf("TestObject(%T, %v): got %v, want %v", test.s, test.size, g, f)
+		}
+	}
+
+	// A failed upload is aborted.
+	s := &memMultipart{memObjects{make(map[string][]byte)}, make(map[string][][]byte), 1, 0}
+	if err := f.saveObject(ctx, s, "f", 1000); err == nil {
+		t.Errorf("TestObject: failed upload: got nil error")
+	}
+	if s.aborted != 1 || len(s.uploads) != 0 || s.objects["f"] != nil {
+		t.Errorf("TestObject: failed upload not aborted")
+	}
+
+	// Invalid objects are rejected without modifying the filter.
+	data := mustMarshal(f)
+	for _, b := range [][]byte{nil, data[:len(data)-1], append(data, 0)} {
+		s := &memObjects{map[string][]byte{"f": b}}
+		g := NewWithHash(64, 1, BitsAndBlooms)
+		if err := g.LoadObject(ctx, s, "f"); err == nil {
+			t.Errorf("TestObject(%v bytes): got nil error", len(b))
+		}
+		if !equal(g, NewWithHash(64, 1, BitsAndBlooms)) {
+			t.Errorf("TestObject(%v bytes): filter modified", len(b))
+		}
+	}
+	if err := new(Filter).LoadObject(ctx, &memObjects{make(map[string][]byte)}, "f"); err == nil {
+		t.Errorf("TestObject: missing object: got nil error")
+	}
+}
@@ -0,0 +1,65 @@
+package bloom
+
+import (
+	"runtime"
+	"sync"
+)
+
+// InsertBatchParallel inserts each of items into f's set, dividing them among the given number of goroutines,
+// which derive the items' bit positions in parallel and set them with atomic operations.
+// If workers is not positive, InsertBatchParallel uses runtime.GOMAXPROCS(0) goroutines.
+// It returns when all items have been inserted, and may be called concurrently with the same methods as Insert.
+func (f *Filter) InsertBatchParallel(items [][]byte, workers int) {
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	workers = min(workers, len(items))
+	var wg sync.WaitGroup
+	for w := range workers {
+		batch := items[w*len(items)/workers : (w+1)*len(items)/workers]
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			f.InsertBatch(batch)
+		}()
+	}
+	wg.Wait()
+}
+
+// A Builder builds a Filter from the insertions of multiple goroutines without contention,
+// by giving each goroutine its own local Filter and merging them when the goroutines are done.
+// Its methods may be called concurrently from multiple goroutines.
+type Builder struct {
+	m, k   int
+	h      Hash
+	mu     sync.Mutex
+	locals []*Filter
+}
+
+// NewBuilder returns a Builder of filters of size m bits that use k hash values derived by the hash algorithm h.
+// It panics if h does not support a filter of size m bits or k hash values.
+func NewBuilder(m, k int, h Hash) *Builder {
+	NewWithHash(m, k, h) // validate the parameters
+	return &Builder{m: m, k: k, h: h}
+}
+
+// Local returns a new empty Filter for the exclusive use of one goroutine, whose items Gather will include.
+func (b *Builder) Local() *Filter {
+	f := NewWithHash(b.m, b.k, b.h)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.locals = append(b.locals, f)
+	return f
+}
+
+// Gather returns a Filter whose set is the union of the sets of the filters returned by Local.
+// It must not be called while items are being inserted into them.
+func (b *Builder) Gather() *Filter {
+	g := NewWithHash(b.m, b.k, b.h)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, f := range b.locals {
+		g.Union(f)
+	}
+	return g
+}
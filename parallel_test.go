@@ -0,0 +1,62 @@
+package bloom
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestInsertBatchParallel(t *testing.T) {
+	items := make([][]byte, 1000)
+	for i := range items {
+		items[i] = []byte(fmt.Sprint(i))
+	}
+	want := NewWithHash(1<<14, 5, BitsAndBlooms)
+	for _, item := range items {
+		want.Insert(item)
+	}
+	for _, workers := range []int{-1, 0, 1, 3, 8, 5000} {
+		for _, n := range []int{0, 1, 7, 1000} {
+			f := NewWithHash(1<<14, 5, BitsAndBlooms)
+			f.InsertBatchParallel(items[:n], workers)
+			for _, item := range items[:n] {
+				if !f.MaybeContains(item) {
+					t.Errorf("TestInsertBatchParallel(%v, %v): item %s not found", workers, n, item)
+				}
+			}
+			if n == len(items) && !reflect.DeepEqual(f, want) {
+				t.Errorf("TestInsertBatchParallel(%v, %v): got %v, want %v", workers, n, f, want)
+			}
+		}
+	}
+}
+
+func TestBuilder(t *testing.T) {
+	b := NewBuilder(1<<14, 5, BitsAndBlooms)
+	if got, want := b.Gather(), NewWithHash(1<<14, 5, BitsAndBlooms); !reflect.DeepEqual(got, want) {
+		t.Errorf("TestBuilder: got %v, want %v", got, want)
+	}
+	var wg sync.WaitGroup
+	for g := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			f := b.Local()
+			for i := range 100 {
+				f.Insert([]byte(fmt.Sprint(g, i)))
+			}
+		}()
+	}
+	wg.Wait()
+
+	want := NewWithHash(1<<14, 5, BitsAndBlooms)
+	for g := range 8 {
+		for i := range 100 {
+			want.Insert([]byte(fmt.Sprint(g, i)))
+		}
+	}
+	if got := b.Gather(); !reflect.DeepEqual(got, want) {
+		t.Errorf("TestBuilder: got %v, want %v", got, want)
+	}
+}
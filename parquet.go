@@ -0,0 +1,260 @@
+package bloom
+
+import (
+	"encoding/binary"
+	"io"
+)
+
+// Parameters of Parquet's split block Bloom filters
+const (
+	sbbfBlockBits = 256
+	sbbfWords     = 8
+
+	// maxParquetBytes is the largest bitset that Parquet permits.
+	maxParquetBytes = 128 << 20
+)
+
+// sbbfSalt holds the odd constants by which the key is multiplied to select a bit in each word of a block.
+var sbbfSalt = [sbbfWords]uint32{
+	0x47b6137b, 0x44974d91, 0x8824ad5b, 0xa2b7289d,
+	0x705495c7, 0x2df1424b, 0x9efc4947, 0x5c6bfb31,
+}
+
+// A Parquet Bloom filter, as stored in a file before the bloom_filter_offset of a column chunk,
+// consists of a BloomFilterHeader serialized with the Thrift compact protocol:
+//
+//	struct BloomFilterHeader {
+//	  1: required i32 numBytes;
+//	  2: required BloomFilterAlgorithm algorithm;     // union { 1: SplitBlockAlgorithm BLOCK; }
+//	  3: required BloomFilterHash hash;               // union { 1: XxHash XXHASH; }
+//	  4: required BloomFilterCompression compression; // union { 1: Uncompressed UNCOMPRESSED; }
+//	}
+//
+// followed by numBytes bytes of the bitset, in which each block is 8 little-endian 32-bit words.
+// This is the layout of a Filter's bits.
+
+// Thrift compact protocol types
+const (
+	thriftStop   = 0
+	thriftTrue   = 1
+	thriftFalse  = 2
+	thriftByte   = 3
+	thriftI16    = 4
+	thriftI32    = 5
+	thriftI64    = 6
+	thriftDouble = 7
+	thriftBinary = 8
+	thriftList   = 9
+	thriftSet    = 10
+	thriftMap    = 11
+	thriftStruct = 12
+)
+
+// WriteParquet writes f to w as a Parquet split block Bloom filter, consisting of a BloomFilterHeader followed by the bitset,
+// and returns the number of bytes written and any error encountered.
+// It returns an error if f does not use the ParquetSBBF hash algorithm or is larger than Parquet permits.
+func (f *Filter) WriteParquet(w io.Writer) (int64, error) {
+	if f.hash != ParquetSBBF {
+		return 0, newError(ErrUnsupported, "filter does not use the ParquetSBBF hash algorithm")
+	}
+	bits := f.bytes()
+	if len(bits) > maxParquetBytes {
+		return 0, newError(ErrBadSize, "filter size out of range")
+	}
+	b := []byte{1<<4 | thriftI32}
+	b = binary.AppendVarint(b, int64(len(bits)))
+	for range 3 {
+		// A union whose field 1 is an empty struct
+		b = append(b, 1<<4|thriftStruct, 1<<4|thriftStruct, thriftStop, thriftStop)
+	}
+	b = append(b, thriftStop)
+	cw := &countWriter{w: w}
+	cw.Write(b)
+	cw.Write(bits)
+	return cw.n, cw.err
+}
+
+// ReadParquet reads a Parquet split block Bloom filter, consisting of a BloomFilterHeader followed by the bitset, from r,
+// and stores it in f as a filter that uses the ParquetSBBF hash algorithm.
+// It reads no further than the end of the bitset, and returns the number of bytes read and any error encountered.
+// If it returns an error, it does not modify the contents of f.
+func (f *Filter) ReadParquet(r io.Reader) (int64, error) {
+	cr := &countReader{r: r}
+	var (
+		numBytes int64
+		seen     [5]bool
+	)
+	for id := int16(0); ; {
+		typ, err := readThriftFieldHeader(cr, &id)
+		if err != nil {
+			return cr.n, eofError(err)
+		}
+		if typ == thriftStop {
+			break
+		}
+		switch {
+		case id == 1 && typ == thriftI32:
+			numBytes, err = binary.ReadVarint(cr)
+		case id >= 2 && id <= 4 && typ == thriftStruct:
+			err = readThriftUnion(cr)
+		default:
+			err = skipThrift(cr, typ, true, 0)
+		}
+		if err != nil {
+			return cr.n, eofError(err)
+		}
+		if id >= 1 && id <= 4 {
+			if seen[id] {
+				return cr.n, newError(ErrCorrupt, "duplicate header field")
+			}
+			seen[id] = true
+		}
+	}
+	if !seen[1] || !seen[2] || !seen[3] || !seen[4] {
+		return cr.n, newError(ErrCorrupt, "missing header field")
+	}
+	if numBytes <= 0 || numBytes > maxParquetBytes || numBytes%(sbbfBlockBits/8) != 0 {
+		return cr.n, newError(ErrBadSize, "filter size out of range")
+	}
+	b, err := readDense(cr, nil, uint64(numBytes))
+	if err != nil {
+		return cr.n, eofError(err)
+	}
+	f.load(header{hashAlgorithm: byte(ParquetSBBF), k: sbbfWords, m: uint64(numBytes) * 8}, b)
+	return cr.n, nil
+}
+
+// readThriftFieldHeader reads the header of a struct field in the Thrift compact protocol and returns its type.
+// It updates id, which holds the previous field's ID, to the field's ID.
+func readThriftFieldHeader(r io.ByteReader, id *int16) (byte, error) {
+	c, err := r.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+	typ := c & 0x0f
+	if typ == thriftStop {
+		return thriftStop, nil
+	}
+	if delta := int16(c >> 4); delta != 0 {
+		*id += delta
+	} else {
+		v, err := binary.ReadVarint(r)
+		if err != nil {
+			return 0, err
+		}
+		*id = int16(v)
+	}
+	return typ, nil
+}
+
+// readThriftUnion reads a union in the Thrift compact protocol whose only supported member is field 1, an empty struct,
+// as for each of the algorithm, hash, and compression of a BloomFilterHeader.
+func readThriftUnion(r io.ByteReader) error {
+	var id int16
+	typ, err := readThriftFieldHeader(r, &id)
+	if err != nil {
+		return err
+	}
+	if id != 1 || typ != thriftStruct {
+		return newError(ErrUnsupported, "unsupported algorithm, hash, or compression")
+	}
+	if err := skipThrift(r, thriftStruct, true, 0); err != nil {
+		return err
+	}
+	if typ, err := readThriftFieldHeader(r, &id); err != nil || typ != thriftStop {
+		if err == nil {
+			err = newError(ErrCorrupt, "malformed union")
+		}
+		return err
+	}
+	return nil
+}
+
+// maxThriftDepth bounds the nesting of skipped Thrift values.
+const maxThriftDepth = 64
+
+// skipThrift reads and discards a value of type typ in the Thrift compact protocol.
+// In a struct field, a boolean's value is held in the field header; in a container, it occupies a byte.
+func skipThrift(r io.ByteReader, typ byte, field bool, depth int) error {
+	if depth > maxThriftDepth {
+		return newError(ErrCorrupt, "value nested too deeply")
+	}
+	switch typ {
+	case thriftTrue, thriftFalse:
+		if !field {
+			_, err := r.ReadByte()
+			return err
+		}
+	case thriftByte:
+		_, err := r.ReadByte()
+		return err
+	case thriftI16, thriftI32, thriftI64:
+		_, err := binary.ReadVarint(r)
+		return err
+	case thriftDouble:
+		return skipBytes(r, 8)
+	case thriftBinary:
+		n, err := binary.ReadUvarint(r)
+		if err != nil {
+			return err
+		}
+		return skipBytes(r, n)
+	case thriftList, thriftSet:
+		c, err := r.ReadByte()
+		if err != nil {
+			return err
+		}
+		n := uint64(c >> 4)
+		if n == 15 {
+			if n, err = binary.ReadUvarint(r); err != nil {
+				return err
+			}
+		}
+		for range n {
+			if err := skipThrift(r, c&0x0f, false, depth+1); err != nil {
+				return err
+			}
+		}
+	case thriftMap:
+		n, err := binary.ReadUvarint(r)
+		if err != nil || n == 0 {
+			return err
+		}
+		c, err := r.ReadByte()
+		if err != nil {
+			return err
+		}
+		for range n {
+			if err := skipThrift(r, c>>4, false, depth+1); err != nil {
+				return err
+			}
+			if err := skipThrift(r, c&0x0f, false, depth+1); err != nil {
+				return err
+			}
+		}
+	case thriftStruct:
+		var id int16
+		for {
+			typ, err := readThriftFieldHeader(r, &id)
+			if err != nil || typ == thriftStop {
+				return err
+			}
+			if err := skipThrift(r, typ, true, depth+1); err != nil {
+				return err
+			}
+		}
+	default:
+		return newError(ErrCorrupt, "unknown value type")
+	}
+	return nil
+}
+
+// skipBytes reads and discards n bytes from r.
+func skipBytes(r io.ByteReader, n uint64) error {
+	for range n {
+		if _, err := r.ReadByte(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
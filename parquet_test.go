@@ -0,0 +1,110 @@
+package bloom
+
+import (
+	"bytes"
+	"encoding/binary"
+	"reflect"
+	"testing"
+)
+
+func TestParquetLocations(t *testing.T) {
+	f := NewWithHash(4*sbbfBlockBits, sbbfWords, ParquetSBBF)
+	for _, s := range []string{"", "a", "abc", "The quick brown fox jumps over the lazy dog"} {
+		h := xxhash64([]byte(s))
+		d := f.hash.digest([]byte(s))
+		block := int(h>>32) * 4 >> 32
+		for i := range sbbfWords {
+			loc := f.location(&d, i)
+			if loc/sbbfBlockBits != block || loc%sbbfBlockBits/32 != i {
+				t.Errorf("TestParquetLocations(%q, %v): got %v, want word %v of block %v", s, i, loc, i, block)
+			}
+			if want := int(uint32(h) * sbbfSalt[i] >> 27); loc%32 != want {
+				t.Errorf("TestParquetLocations(%q, %v): got bit %v, want %v", s, i, loc%32, want)
+			}
+		}
+	}
+}
+
+// parquetHeader is the BloomFilterHeader of a 64-byte bitset.
+var parquetHeader = []byte{0x15, 0x80, 0x01, 0x1c, 0x1c, 0x00, 0x00, 0x1c, 0x1c, 0x00, 0x00, 0x1c, 0x1c, 0x00, 0x00, 0x00}
+
+func TestParquet(t *testing.T) {
+	f := NewWithHash(512, 8, ParquetSBBF)
+	f.Insert([]byte("a"))
+	f.Insert(binary.LittleEndian.AppendUint64(nil, 42))
+
+	var buf bytes.Buffer
+	n, err := f.WriteParquet(&buf)
+	if err != nil || n != int64(len(parquetHeader)+64) {
+		t.Errorf("TestParquet: wrote %v bytes, %v", n, err)
+	}
+	if want := append(append([]byte(nil), parquetHeader...), f.bytes()...); !bytes.Equal(buf.Bytes(), want) {
+		t.Errorf("TestParquet: got %#x, want %#x", buf.Bytes(), want)
+	}
+	data := buf.Bytes()
+
+	buf.WriteString("trailing")
+	g := new(Filter)
+	if n, err := g.ReadParquet(&buf); err != nil || n != int64(len(data)) {
+		t.Errorf("TestParquet: read %v bytes, %v", n, err)
+	}
+	if !reflect.DeepEqual(g, f) {
+		t.Errorf("TestParquet: got %v, want %v", g, f)
+	}
+
+	// Unknown fields, field IDs in long form, and fields in any order are accepted.
+	long := []byte{
+		0x0c, 0x04, 0x1c, 0x00, 0x00, // field 2 in long form
+		0x0c, 0x06, 0x1c, 0x00, 0x00, // field 3
+		0x05, 0x02, 0x80, 0x01, // field 1
+		0x0c, 0x08, 0x1c, 0x00, 0x00, // field 4
+		0x16, 0x02, // field 5: i64
+		0x18, 0x02, 'h', 'i', // field 6: binary
+		0x19, 0x21, 0x01, 0x02, // field 7: list of 2 bools
+		0x1b, 0x01, 0x56, 0x02, 0x04, // field 8: map of 1 i32 to i64
+		0x11, // field 9: true
+		0x00,
+	}
+	g = new(Filter)
+	if _, err := g.ReadParquet(bytes.NewReader(append(long, f.bytes()...))); err != nil {
+		t.Errorf("TestParquet: %v", err)
+	}
+	if !reflect.DeepEqual(g, f) {
+		t.Errorf("TestParquet: got %v, want %v", g, f)
+	}
+
+	if _, err := New(1, 1).WriteParquet(new(bytes.Buffer)); err == nil {
+		t.Errorf("TestParquet: wrote a filter using SHA256")
+	}
+}
+
+func TestReadParquetError(t *testing.T) {
+	withHeader := func(i int, c byte) []byte {
+		return append(withByte(parquetHeader, i, c), make([]byte, 64)...)
+	}
+	for _, test := range []struct {
+		name string
+		data []byte
+	}{
+		{"empty", nil},
+		{"truncated header", parquetHeader[:5]},
+		{"truncated bitset", append(parquetHeader, make([]byte, 63)...)},
+		{"missing field", append([]byte{0x15, 0x80, 0x01, 0x2c, 0x1c, 0x00, 0x00, 0x1c, 0x1c, 0x00, 0x00, 0x00}, make([]byte, 64)...)},
+		{"duplicate field", append([]byte{0x15, 0x80, 0x01, 0x1c, 0x1c, 0x00, 0x00, 0x1c, 0x1c, 0x00, 0x00, 0x1c, 0x1c, 0x00, 0x00, 0x0c, 0x04, 0x1c, 0x00, 0x00, 0x00}, make([]byte, 64)...)},
+		{"unsupported algorithm", withHeader(4, 0x2c)},
+		{"unsupported hash", withHeader(8, 0x2c)},
+		{"unsupported compression", withHeader(12, 0x2c)},
+		{"malformed union", withHeader(6, 0x15)},
+		{"size not a multiple of 32", withHeader(1, 0x82)},
+		{"negative size", withHeader(1, 0x81)},
+		{"unknown type", withHeader(15, 0x2d)},
+	} {
+		f := New(1, 1)
+		if _, err := f.ReadParquet(bytes.NewReader(test.data)); err == nil {
+			t.Errorf("TestReadParquetError(%v): got nil error", test.name)
+		}
+		if !reflect.DeepEqual(f, New(1, 1)) {
+			t.Errorf("TestReadParquetError(%v): modified filter: %v", test.name, f)
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package bloom
+
+import (
+	"encoding/binary"
+)
+
+// Field numbers of the Filter message defined in bloom.proto
+const (
+	protoBits          = 1
+	protoM             = 2
+	protoK             = 3
+	protoHashAlgorithm = 4
+	protoSeed          = 5
+)
+
+// Protocol buffer wire types
+const (
+	protoVarint = 0
+	protoI64    = 1
+	protoLen    = 2
+	protoI32    = 5
+)
+
+// MarshalProto marshals f into the protocol buffer wire encoding of the Filter message defined in bloom.proto,
+// so that it can be decoded by code generated from that file. MarshalProto and UnmarshalProto are the supported
+// way to convert a Filter to and from that message in Go; the package provides no generated message type.
+func (f *Filter) MarshalProto() ([]byte, error) {
+	bits := f.bytes()
+	b := make([]byte, 0, 1+binary.MaxVarintLen64+len(bits)+3*(1+binary.MaxVarintLen64))
+	b = binary.AppendUvarint(b, protoBits<<3|protoLen)
+	b = binary.AppendUvarint(b, uint64(len(bits)))
+	b = append(b, bits...)
+	b = binary.AppendUvarint(b, protoM<<3|protoVarint)
+	b = binary.AppendUvarint(b, uint64(f.m))
+	b = binary.AppendUvarint(b, protoK<<3|protoVarint)
+	b = binary.AppendUvarint(b, uint64(f.k))
+	if f.hash != SHA256 {
+		b = binary.AppendUvarint(b, protoHashAlgorithm<<3|protoVarint)
+		b = binary.AppendUvarint(b, uint64(f.hash))
+	}
+	// seed has its default value of zero and is omitted.
+	return b, nil
+}
+
+// UnmarshalProto unmarshals the protocol buffer wire encoding of the Filter message defined in bloom.proto
+// and stores the result in f. Unknown fields are ignored.
+// It validates the filter size and number of hash values in the same manner as UnmarshalBinary,
+// and also returns an error if m is inconsistent with the length of bits
+// or if the message specifies a hash algorithm or seed that is not supported.
+// If it returns an error, it does not modify the contents of f.
+func (f *Filter) UnmarshalProto(data []byte) error {
+	var (
+		bits []byte
+		vals [protoSeed + 1]uint64 // values of the varint fields, indexed by field number
+	)
+	for len(data) > 0 {
+		tag, n := binary.Uvarint(data)
+		if n <= 0 {
+			return newError(ErrCorrupt, "malformed protobuf field tag")
+		}
+		data = data[n:]
+		field, typ := tag>>3, tag&7
+		var err error
+		switch {
+		case field == protoBits && typ == protoLen:
+			bits, data, err = readProtoLen(data)
+		case field > protoBits && field <= protoSeed && typ == protoVarint:
+			vals[field], data, err = readProtoVarint(data)
+		case field >= protoBits && field <= protoSeed:
+			err = newError(ErrCorrupt, "unexpected protobuf wire type")
+		default:
+			data, err = skipProtoField(data, typ)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if vals[protoHashAlgorithm] > 0xff {
+		return newError(ErrUnsupported, "unsupported hash algorithm")
+	}
+	if vals[protoSeed] != 0 {
+		return newError(ErrUnsupported, "unsupported seed")
+	}
+	h := header{hashAlgorithm: byte(vals[protoHashAlgorithm]), m: vals[protoM]}
+	if err := checkParams(Hash(h.hashAlgorithm), h.m, vals[protoK]); err != nil {
+		return err
+	}
+	h.k = byte(vals[protoK])
+	if uint64(len(bits)) != (h.m+7)/8 {
+		return newError(ErrCorrupt, "filter size does not match length of bits")
+	}
+	if err := checkPadding(bits, h.m); err != nil {
+		return err
+	}
+	f.load(h, bits)
+	return nil
+}
+
+// readProtoVarint reads a varint from the front of data and returns its value and the remainder of data.
+func readProtoVarint(data []byte) (v uint64, rest []byte, err error) {
+	v, n := binary.Uvarint(data)
+	if n == 0 {
+		return 0, nil, newError(ErrTruncated, "unexpected end of protobuf data")
+	}
+	if n < 0 {
+		return 0, nil, newError(ErrCorrupt, "malformed protobuf varint")
+	}
+	return v, data[n:], nil
+}
+
+// readProtoLen reads a length-delimited value from the front of data and returns it and the remainder of data.
+func readProtoLen(data []byte) (v, rest []byte, err error) {
+	l, data, err := readProtoVarint(data)
+	if err != nil {
+		return nil, nil, err
+	}
+	if l > uint64(len(data)) {
+		return nil, nil, newError(ErrTruncated, "unexpected end of protobuf data")
+	}
+	return data[:l], data[l:], nil
+}
+
+// skipProtoField returns the remainder of data following a value of wire type typ.
+func skipProtoField(data []byte, typ uint64) (rest []byte, err error) {
+	switch typ {
+	case protoVarint:
+		_, data, err = readProtoVarint(data)
+		return data, err
+	case protoLen:
+		_, data, err = readProtoLen(data)
+		return data, err
+	case protoI64, protoI32:
+		n := 8
+		if typ == protoI32 {
+			n = 4
+		}
+		if len(data) < n {
+			return nil, newError(ErrTruncated, "unexpected end of protobuf data")
+		}
+		return data[n:], nil
+	default:
+		return nil, newError(ErrUnsupported, "unsupported protobuf wire type")
+	}
+}
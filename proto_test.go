@@ -0,0 +1,80 @@
+package bloom
+
+import (
+	"reflect"
+	"testing"
+)
+
+var protoTests = []struct {
+	f    *Filter
+	data []byte
+}{
+	{New(1, 1), []byte{0x0a, 0x01, 0, 0x10, 0x08, 0x18, 0x01}},
+	{filter(16, 15, 23), []byte{0x0a, 0x02, 15, 23, 0x10, 0x10, 0x18, 0x10}},
+	{New(16, 3), append(append([]byte{0x0a, 0x10}, make([]byte, 16)...), 0x10, 0x80, 0x01, 0x18, 0x03)},
+}
+
+func TestMarshalProto(t *testing.T) {
+	for _, test := range protoTests {
+		data, err := test.f.MarshalProto()
+		if err != nil {
+			t.Errorf("TestMarshalProto: %v", err)
+		}
+		if !reflect.DeepEqual(data, test.data) {
+			t.Errorf("TestMarshalProto: got %v, want %v", data, test.data)
+		}
+	}
+}
+
+func TestUnmarshalProto(t *testing.T) {
+	for _, test := range protoTests {
+		f := new(Filter)
+		if err := f.UnmarshalProto(test.data); err != nil {
+			t.Errorf("TestUnmarshalProto: %v", err)
+		}
+		if !reflect.DeepEqual(f, test.f) {
+			t.Errorf("TestUnmarshalProto: got %v, want %v", f, test.f)
+		}
+	}
+
+	// Fields may appear in any order, explicit default values are accepted, and unknown fields are skipped.
+	f := new(Filter)
+	if err := f.UnmarshalProto([]byte{
+		0x18, 0x01, // k
+		0x30, 0x96, 0x01, // unknown varint
+		0x3a, 0x02, 1, 2, // unknown bytes
+		0x41, 1, 2, 3, 4, 5, 6, 7, 8, // unknown fixed64
+		0x4d, 1, 2, 3, 4, // unknown fixed32
+		0x20, 0x00, // hash_algorithm
+		0x0a, 0x01, 0xff, // bits
+		0x28, 0x00, // seed
+		0x10, 0x08, // m
+	}); err != nil {
+		t.Errorf("TestUnmarshalProto: %v", err)
+	}
+	if want := filter(1, 0xff); !reflect.DeepEqual(f, want) {
+		t.Errorf("TestUnmarshalProto: got %v, want %v", f, want)
+	}
+
+	for _, data := range [][]byte{
+		nil,
+		{0x0a, 0x01, 0, 0x10, 0x08},                         // no hash values
+		{0x0a, 0x01, 0, 0x10, 0x10, 0x18, 0x01},             // m does not match bits
+		{0x0a, 0x02, 0, 0x10, 0x08, 0x18, 0x01},             // truncated bits
+		{0x0a, 0x03, 0, 0, 0, 0x10, 0x18, 0x18, 0x01},       // size not a power of 2
+		{0x0a, 0x01, 0, 0x10, 0x08, 0x18, 0x11},             // too many hash values
+		{0x0a, 0x01, 0, 0x10, 0x08, 0x18, 0x01, 0x20, 0x7f}, // unknown hash algorithm
+		{0x0a, 0x01, 0, 0x10, 0x08, 0x18, 0x01, 0x28, 0x01}, // nonzero seed
+		{0x0a, 0x01, 0, 0x10, 0x08, 0x1a, 0x01, 0x01},       // k with wrong wire type
+		{0x0a, 0x01, 0, 0x10, 0x08, 0x18},                   // truncated varint
+		{0x0a, 0x01, 0, 0x10, 0x08, 0x18, 0x01, 0x33},       // group wire type
+	} {
+		f := New(1, 1)
+		if err := f.UnmarshalProto(data); err == nil {
+			t.Errorf("TestUnmarshalProto(%v): got nil error", data)
+		}
+		if !reflect.DeepEqual(f, New(1, 1)) {
+			t.Errorf("TestUnmarshalProto(%v): modified filter to %v", data, f)
+		}
+	}
+}
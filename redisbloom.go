@@ -0,0 +1,164 @@
+package bloom
+
+import (
+	"encoding/binary"
+	"math"
+)
+
+// RedisBloom's BF.SCANDUMP command returns a filter as a sequence of chunks, each paired with an iterator.
+// The first chunk, with iterator 1, is a header of the following fields in little-endian byte order,
+// followed by one link for each of the layers of the scalable filter:
+//
+//	size     uint64   number of items added
+//	nfilters uint32   number of layers
+//	options  uint32   creation options
+//	growth   uint32   expansion factor of successive layers
+//
+// Each link consists of:
+//
+//	bytes    uint64   size of the layer in bytes
+//	bits     uint64   size of the layer in bits, by which hash values are reduced
+//	size     uint64   number of items added to the layer
+//	error    float64  false positive rate
+//	bpe      float64  bits per entry
+//	hashes   uint32   number of hash values
+//	entries  uint64   capacity of the layer
+//	n2       uint8    base-2 logarithm of bits if bits is a power of 2 rounded up from the capacity, or 0
+//
+// The remaining chunks hold the bits of the layers in order,
+// with bit n of a layer being bit n%8 of byte n/8, counting from the least significant bit.
+// The iterator of each is one more than the offset of the end of its data, and BF.LOADCHUNK uses it to place the data.
+
+// Options of a RedisBloom filter
+const (
+	redisBloomNoRound   = 1
+	redisBloomForce64   = 4
+	redisBloomNoScaling = 8
+)
+
+const (
+	redisBloomHeaderSize = 20
+	redisBloomLinkSize   = 53
+	redisBloomChunkSize  = 16 << 20
+)
+
+// A RedisBloomChunk is a chunk of a filter in the form returned by RedisBloom's BF.SCANDUMP command
+// and accepted by its BF.LOADCHUNK command.
+type RedisBloomChunk struct {
+	Iter int64
+	Data []byte
+}
+
+// MarshalRedisBloom returns the chunks that BF.SCANDUMP would return for a non-scaling RedisBloom filter with the contents of f.
+// Passing each of them in order to BF.LOADCHUNK recreates f in RedisBloom.
+// Since f does not count the items added to it, the item count recorded in the filter is an estimate.
+// It returns an error if f does not use the RedisBloom hash algorithm.
+func (f *Filter) MarshalRedisBloom() ([]RedisBloomChunk, error) {
+	if f.hash != RedisBloom {
+		return nil, newError(ErrUnsupported, "filter does not use the RedisBloom hash algorithm")
+	}
+
+	// RedisBloom derives the number of hash values and the size from the false positive rate and capacity.
+	// Choose values from which it would derive those of f.
+	e := math.Exp2(0.5 - float64(f.k))
+	bpe := -math.Log(e) / (math.Ln2 * math.Ln2)
+	entries := max(uint64(float64(f.m)/bpe), 1)
+	fb := f.bytes()
+	x := f.BitCount()
+	size := entries
+	if n := -float64(f.m) / float64(f.k) * math.Log1p(-float64(x)/float64(f.m)); n < float64(entries) {
+		size = uint64(math.Round(n))
+	}
+
+	b := make([]byte, 0, redisBloomHeaderSize+redisBloomLinkSize)
+	b = binary.LittleEndian.AppendUint64(b, size)
+	b = binary.LittleEndian.AppendUint32(b, 1)
+	b = binary.LittleEndian.AppendUint32(b, redisBloomNoRound|redisBloomForce64|redisBloomNoScaling)
+	b = binary.LittleEndian.AppendUint32(b, 2)
+	b = binary.LittleEndian.AppendUint64(b, uint64(len(fb)))
+	b = binary.LittleEndian.AppendUint64(b, uint64(f.m))
+	b = binary.LittleEndian.AppendUint64(b, size)
+	b = binary.LittleEndian.AppendUint64(b, math.Float64bits(e))
+	b = binary.LittleEndian.AppendUint64(b, math.Float64bits(bpe))
+	b = binary.LittleEndian.AppendUint32(b, uint32(f.k))
+	b = binary.LittleEndian.AppendUint64(b, entries)
+	b = append(b, 0)
+
+	chunks := []RedisBloomChunk{{1, b}}
+	for off := 0; off < len(fb); off += redisBloomChunkSize {
+		end := min(off+redisBloomChunkSize, len(fb))
+		data := fb[off:end:end]
+		chunks = append(chunks, RedisBloomChunk{int64(off + len(data) + 1), data})
+	}
+	return chunks, nil
+}
+
+// UnmarshalRedisBloom stores in f the filter whose chunks, as returned by BF.SCANDUMP, are given in order.
+// The final chunk with iterator 0, which marks the end of the dump, may be included or omitted.
+// It supports filters that use RedisBloom's 64-bit hashing, which it uses by default, and that have a single layer.
+// If it returns an error, it does not modify the contents of f.
+func (f *Filter) UnmarshalRedisBloom(chunks []RedisBloomChunk) error {
+	if len(chunks) > 0 && chunks[len(chunks)-1].Iter == 0 {
+		if len(chunks[len(chunks)-1].Data) != 0 {
+			return newError(ErrCorrupt, "data in final chunk")
+		}
+		chunks = chunks[:len(chunks)-1]
+	}
+	if len(chunks) == 0 || chunks[0].Iter != 1 {
+		return newError(ErrTruncated, "missing header")
+	}
+	p := chunks[0].Data
+	if len(p) < redisBloomHeaderSize {
+		return newError(ErrTruncated, "header too short")
+	}
+	if binary.LittleEndian.Uint32(p[8:]) != 1 {
+		return newError(ErrUnsupported, "unsupported number of layers")
+	}
+	if binary.LittleEndian.Uint32(p[12:])&redisBloomForce64 == 0 {
+		return newError(ErrUnsupported, "unsupported hashing")
+	}
+	if len(p) != redisBloomHeaderSize+redisBloomLinkSize {
+		return newError(ErrCorrupt, "header size mismatch")
+	}
+	p = p[redisBloomHeaderSize:]
+	h := header{
+		hashAlgorithm: byte(RedisBloom),
+		m:             binary.LittleEndian.Uint64(p[8:]),
+	}
+	if binary.LittleEndian.Uint64(p) != h.m/8 || h.m%8 != 0 {
+		return newError(ErrCorrupt, "filter size mismatch")
+	}
+	if n2 := p[52]; n2 != 0 && (n2 > 63 || h.m != 1<<n2) {
+		return newError(ErrCorrupt, "filter size mismatch")
+	}
+	k := binary.LittleEndian.Uint32(p[40:])
+	if err := checkParams(RedisBloom, h.m, uint64(k)); err != nil {
+		return err
+	}
+	h.k = byte(k)
+
+	// The chunks are counted before the bits are allocated, so that a header's size is not trusted.
+	var total uint64
+	for _, c := range chunks[1:] {
+		total += uint64(len(c.Data))
+	}
+	if total < h.m/8 {
+		return newError(ErrTruncated, "missing data")
+	}
+	b := make([]byte, h.m/8)
+	var off int64
+	for _, c := range chunks[1:] {
+		if c.Iter != off+int64(len(c.Data))+1 {
+			return newError(ErrCorrupt, "chunk out of order")
+		}
+		if int64(len(c.Data)) > int64(len(b))-off {
+			return newError(ErrCorrupt, "data beyond filter size")
+		}
+		off += int64(copy(b[off:], c.Data))
+	}
+	if off != int64(len(b)) {
+		return newError(ErrTruncated, "missing data")
+	}
+	f.load(h, b)
+	return nil
+}
@@ -0,0 +1,123 @@
+package bloom
+
+import (
+	"bytes"
+	"encoding/binary"
+	"reflect"
+	"testing"
+)
+
+func TestRedisBloomLocations(t *testing.T) {
+	// Locations for a filter of 1000 bits using 6 hash values, following RedisBloom's 64-bit hashing
+	for _, test := range []struct {
+		s    string
+		locs []int
+	}{
+		{"", []int{0x24e, 0x375, 0x234, 0xf3, 0x39a, 0xd9}},
+		{"abc", []int{0x223, 0x236, 0xc9, 0x344, 0x1d7, 0x1ea}},
+		{"0123456789abcde", []int{0x206, 0x3d3, 0x1b8, 0x385, 0x16a, 0x337}},
+	} {
+		f := NewWithHash(1000, 6, RedisBloom)
+		d := f.hash.digest([]byte(test.s))
+		for i, want := range test.locs {
+			if got := f.location(&d, i); got != want {
+				t.Errorf("TestRedisBloomLocations(%q, %v): got %#x, want %#x", test.s, i, got, want)
+			}
+		}
+	}
+}
+
+func TestRedisBloom(t *testing.T) {
+	for _, test := range []struct {
+		m    int
+		size uint64
+	}{
+		{8, 1}, // limited by the capacity
+		{1000, 3},
+		{redisBloomChunkSize * 8, 3},
+		{redisBloomChunkSize*8 + 64, 3},
+	} {
+		m := test.m
+		f := NewWithHash(m, 7, RedisBloom)
+		for _, s := range []string{"a", "b", "c"} {
+			f.Insert([]byte(s))
+		}
+		chunks, err := f.MarshalRedisBloom()
+		if err != nil {
+			t.Fatalf("TestRedisBloom(%v): %v", m, err)
+		}
+
+		p := chunks[0].Data
+		if chunks[0].Iter != 1 || len(p) != redisBloomHeaderSize+redisBloomLinkSize {
+			t.Errorf("TestRedisBloom(%v): got header chunk %v, %v bytes", m, chunks[0].Iter, len(p))
+		}
+		if got := binary.LittleEndian.Uint64(p); got != test.size {
+			t.Errorf("TestRedisBloom(%v): got size %v, want %v", m, got, test.size)
+		}
+		if got := binary.LittleEndian.Uint32(p[redisBloomHeaderSize+40:]); got != 7 {
+			t.Errorf("TestRedisBloom(%v): got %v hashes, want 7", m, got)
+		}
+		var data []byte
+		for _, c := range chunks[1:] {
+			data = append(data, c.Data...)
+			if c.Iter != int64(len(data)+1) {
+				t.Errorf("TestRedisBloom(%v): got iterator %v, want %v", m, c.Iter, len(data)+1)
+			}
+		}
+		if !bytes.Equal(data, f.bytes()) {
+			t.Errorf("TestRedisBloom(%v): chunk data differs from filter", m)
+		}
+
+		g := new(Filter)
+		if err := g.UnmarshalRedisBloom(append(chunks, RedisBloomChunk{})); err != nil {
+			t.Errorf("TestRedisBloom(%v): %v", m, err)
+		}
+		if !reflect.DeepEqual(g, f) {
+			t.Errorf("TestRedisBloom(%v): filters differ", m)
+		}
+	}
+
+	if _, err := New(1, 1).MarshalRedisBloom(); err == nil {
+		t.Errorf("TestRedisBloom: marshaled a filter using SHA256")
+	}
+}
+
+func TestUnmarshalRedisBloomError(t *testing.T) {
+	f := NewWithHash(64, 3, RedisBloom)
+	f.Insert([]byte("a"))
+	chunks, _ := f.MarshalRedisBloom()
+	hdr, data := chunks[0], chunks[1]
+	with := func(off int, v ...byte) RedisBloomChunk {
+		c := RedisBloomChunk{1, append([]byte(nil), hdr.Data...)}
+		copy(c.Data[off:], v)
+		return c
+	}
+	for _, test := range []struct {
+		name   string
+		chunks []RedisBloomChunk
+	}{
+		{"empty", nil},
+		{"no header", []RedisBloomChunk{data}},
+		{"short header", []RedisBloomChunk{{1, hdr.Data[:redisBloomHeaderSize-1]}, data}},
+		{"long header", []RedisBloomChunk{{1, append(hdr.Data, 0)}, data}},
+		{"two layers", []RedisBloomChunk{with(8, 2), data}},
+		{"32-bit hashing", []RedisBloomChunk{with(12, redisBloomNoRound), data}},
+		{"bytes mismatch", []RedisBloomChunk{with(redisBloomHeaderSize, 9), data}},
+		{"bits not bytes", []RedisBloomChunk{with(redisBloomHeaderSize+8, 63), data}},
+		{"n2 mismatch", []RedisBloomChunk{with(redisBloomHeaderSize+52, 5), data}},
+		{"no hashes", []RedisBloomChunk{with(redisBloomHeaderSize+40, 0), data}},
+		{"missing data", []RedisBloomChunk{hdr}},
+		{"short data", []RedisBloomChunk{hdr, {8, data.Data[:7]}}},
+		{"bad iterator", []RedisBloomChunk{hdr, {10, data.Data}}},
+		{"extra data", []RedisBloomChunk{hdr, data, {10, []byte{0}}}},
+		{"final chunk with data", []RedisBloomChunk{hdr, data, {0, []byte{0}}}},
+	} {
+		g := New(1, 1)
+		if err := g.UnmarshalRedisBloom(test.chunks); err == nil {
+			t.Errorf("TestUnmarshalRedisBloomError(%v): got nil error", test.name)
+		}
+		if !reflect.DeepEqual(g, New(1, 1)) {
+			t.Errorf("TestUnmarshalRedisBloomError(%v): modified filter: %v", test.name, g)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package redisfilter
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// A redisError is an error reply from the server.
+type redisError string
+
+func (e redisError) Error() string { return string(e) }
+
+// A conn is a connection to a Redis server on which commands are pipelined. It is safe for concurrent use;
+// calls to do are serialized. Once an I/O error has occurred, the connection is unusable and do returns the error.
+type conn struct {
+	mu  sync.Mutex
+	c   net.Conn
+	r   *bufio.Reader
+	w   *bufio.Writer
+	err error
+}
+
+func newConn(c net.Conn) *conn {
+	return &conn{c: c, r: bufio.NewReader(c), w: bufio.NewWriter(c)}
+}
+
+// do sends cmds to the server in a single pipeline and returns their replies, in order.
+// A reply is an int64, a string for a simple string, a []byte or nil for a bulk string, a []any for an array,
+// or a redisError. If ctx is done before the replies are read, do returns ctx's error and the connection is unusable.
+func (c *conn) do(ctx context.Context, cmds ...[][]byte) ([]any, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.err != nil {
+		return nil, c.err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	// Expiring the connection's deadline when ctx is done interrupts the round trip.
+	expired := make(chan struct{})
+	stop := context.AfterFunc(ctx, func() {
+		c.c.SetDeadline(time.Unix(1, 0))
+		close(expired)
+	})
+	replies, err := c.roundTrip(cmds)
+	if !stop() {
+		// ctx was done during the round trip, which may nonetheless have completed.
+		<-expired
+		c.c.SetDeadline(time.Time{})
+	}
+	if err != nil {
+		if ctx.Err() != nil {
+			err = ctx.Err()
+		}
+		c.err = fmt.Errorf("connection unusable: %w", err)
+		return nil, err
+	}
+	return replies, nil
+}
+
+func (c *conn) roundTrip(cmds [][][]byte) ([]any, error) {
+	for _, args := range cmds {
+		c.w.WriteString("*" + strconv.Itoa(len(args)) + "\r\n")
+		for _, a := range args {
+			c.w.WriteString("$" + strconv.Itoa(len(a)) + "\r\n")
+			c.w.Write(a)
+			c.w.WriteString("\r\n")
+		}
+	}
+	if err := c.w.Flush(); err != nil {
+		return nil, err
+	}
+	replies := make([]any, len(cmds))
+	for i := range replies {
+		v, err := c.readReply()
+		if err != nil {
+			return nil, err
+		}
+		replies[i] = v
+	}
+	return replies, nil
+}
+
+// readReply reads a reply from the server.
+func (c *conn) readReply() (any, error) {
+	line, err := c.r.ReadSlice('\n')
+	if err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	if len(line) < 3 || line[len(line)-2] != '\r' {
+		return nil, errors.New("malformed reply")
+	}
+	body := string(line[1 : len(line)-2])
+	switch line[0] {
+	case '+':
+		return body, nil
+	case '-':
+		return redisError(body), nil
+	case ':':
+		return strconv.ParseInt(body, 10, 64)
+	case '$':
+		n, err := strconv.Atoi(body)
+		if err != nil || n < -1 {
+			return nil, errors.New("malformed bulk length")
+		}
+		if n == -1 {
+			return nil, nil
+		}
+		b := make([]byte, n+2)
+		if _, err := io.ReadFull(c.r, b); err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	case '*':
+		n, err := strconv.Atoi(body)
+		if err != nil || n < -1 {
+			return nil, errors.New("malformed array length")
+		}
+		if n == -1 {
+			return nil, nil
+		}
+		a := make([]any, n)
+		for i := range a {
+			if a[i], err = c.readReply(); err != nil {
+				return nil, err
+			}
+		}
+		return a, nil
+	default:
+		return nil, errors.New("unsupported reply type")
+	}
+}
@@ -0,0 +1,174 @@
+// Package redisfilter provides a Bloom filter whose bits are stored in Redis,
+// so that multiple service instances can share one authoritative filter through the familiar local API.
+//
+// A filter is stored either as a plain Redis string manipulated with SETBIT and GETBIT,
+// which requires no server modules, or as a RedisBloom filter manipulated with the module's BF commands.
+package redisfilter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+
+	"github.com/dkmccandless/bloom"
+)
+
+// A Filter is a Bloom filter stored in Redis under a key. It is safe for concurrent use by multiple goroutines.
+// Each call makes a single round trip to the server, and calls are serialized on the Filter's connection.
+type Filter struct {
+	c   *conn
+	key []byte
+
+	// The parameters of a bitmap filter; k is 0 for a RedisBloom filter.
+	m, k int
+	h    bloom.Hash
+}
+
+// NewBitmap returns a Filter of m bits using k hash values derived by h, stored on the Redis server at the other end of c
+// as a string under key, which is created when the first item is inserted.
+// Bit n of the filter is the bit at offset n of the string, as addressed by SETBIT and GETBIT,
+// so the string holds the filter's bits in the layout bloom.BitLayout{MSBFirst: true}.
+// NewBitmap panics if h does not support a filter of size m bits or k hash values.
+// The Filter takes ownership of c.
+func NewBitmap(c net.Conn, key string, m, k int, h bloom.Hash) *Filter {
+	h.Locations(nil, m, k) // panics if the parameters are not supported
+	return &Filter{c: newConn(c), key: []byte(key), m: m, k: k, h: h}
+}
+
+// NewRedisBloom returns a Filter stored on the Redis server at the other end of c as the RedisBloom filter under key.
+// The filter's parameters are those with which it was created by BF.RESERVE or, if it does not exist
+// when the first item is inserted, the server's defaults. The Filter takes ownership of c.
+func NewRedisBloom(c net.Conn, key string) *Filter {
+	return &Filter{c: newConn(c), key: []byte(key)}
+}
+
+// Insert inserts item into f's set.
+func (f *Filter) Insert(ctx context.Context, item []byte) error {
+	var cmds [][][]byte
+	if f.k == 0 {
+		cmds = [][][]byte{{[]byte("BF.ADD"), f.key, item}}
+	} else {
+		for _, n := range f.h.Locations(item, f.m, f.k) {
+			cmds = append(cmds, [][]byte{[]byte("SETBIT"), f.key, offset(n), []byte("1")})
+		}
+	}
+	replies, err := f.c.do(ctx, cmds...)
+	if err != nil {
+		return err
+	}
+	for _, r := range replies {
+		if err := replyErr(r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// MaybeContains reports whether item is probably in f's set, in the manner of bloom.Filter.MaybeContains.
+func (f *Filter) MaybeContains(ctx context.Context, item []byte) (bool, error) {
+	var cmds [][][]byte
+	if f.k == 0 {
+		cmds = [][][]byte{{[]byte("BF.EXISTS"), f.key, item}}
+	} else {
+		for _, n := range f.h.Locations(item, f.m, f.k) {
+			cmds = append(cmds, [][]byte{[]byte("GETBIT"), f.key, offset(n)})
+		}
+	}
+	replies, err := f.c.do(ctx, cmds...)
+	if err != nil {
+		return false, err
+	}
+	ok := true
+	for _, r := range replies {
+		if err := replyErr(r); err != nil {
+			return false, err
+		}
+		v, isInt := r.(int64)
+		if !isInt {
+			return false, fmt.Errorf("unexpected reply %v", r)
+		}
+		ok = ok && v == 1
+	}
+	return ok, nil
+}
+
+// Snapshot returns a copy of f.
+func (f *Filter) Snapshot(ctx context.Context) (*bloom.Filter, error) {
+	if f.k == 0 {
+		return f.scanDump(ctx)
+	}
+	replies, err := f.c.do(ctx, [][]byte{[]byte("GET"), f.key})
+	if err != nil {
+		return nil, err
+	}
+	if err := replyErr(replies[0]); err != nil {
+		return nil, err
+	}
+	data, ok := replies[0].([]byte)
+	if !ok && replies[0] != nil {
+		return nil, fmt.Errorf("unexpected reply %v", replies[0])
+	}
+	// SETBIT extends the string only as far as the highest bit set.
+	n := (f.m + 7) / 8
+	if len(data) > n {
+		return nil, errors.New("stored filter larger than filter size")
+	}
+	data = append(data, make([]byte, n-len(data))...)
+	g := bloom.NewWithHash(f.m, f.k, f.h)
+	if err := g.UnmarshalBits(data, bloom.BitLayout{MSBFirst: true}); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+// scanDump returns a copy of a RedisBloom filter, read with BF.SCANDUMP.
+func (f *Filter) scanDump(ctx context.Context) (*bloom.Filter, error) {
+	var chunks []bloom.RedisBloomChunk
+	for it := int64(0); ; {
+		replies, err := f.c.do(ctx, [][]byte{[]byte("BF.SCANDUMP"), f.key, []byte(strconv.FormatInt(it, 10))})
+		if err != nil {
+			return nil, err
+		}
+		if err := replyErr(replies[0]); err != nil {
+			return nil, err
+		}
+		a, ok := replies[0].([]any)
+		if !ok || len(a) != 2 {
+			return nil, fmt.Errorf("unexpected reply %v", replies[0])
+		}
+		it, ok = a[0].(int64)
+		data, _ := a[1].([]byte)
+		if !ok {
+			return nil, fmt.Errorf("unexpected reply %v", replies[0])
+		}
+		if it == 0 {
+			break
+		}
+		chunks = append(chunks, bloom.RedisBloomChunk{Iter: it, Data: data})
+	}
+	g := new(bloom.Filter)
+	if err := g.UnmarshalRedisBloom(chunks); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+// Close closes f's connection.
+func (f *Filter) Close() error {
+	return f.c.c.Close()
+}
+
+// offset returns the SETBIT offset of bit n of a filter.
+func offset(n int) []byte {
+	return []byte(strconv.Itoa(n))
+}
+
+// replyErr returns the error in reply r, if any.
+func replyErr(r any) error {
+	if err, ok := r.(redisError); ok {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,176 @@
+package redisfilter
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dkmccandless/bloom"
+	"github.com/dkmccandless/bloom/redisserver"
+)
+
+// fakeRedis serves the SETBIT, GETBIT, and GET commands on c, storing strings in data.
+func fakeRedis(c net.Conn, data map[string][]byte) {
+	defer c.Close()
+	r := bufio.NewReader(c)
+	for {
+		var args []string
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		n, _ := strconv.Atoi(strings.TrimSpace(line[1:]))
+		for range n {
+			r.ReadString('\n')
+			arg, _ := r.ReadString('\n')
+			args = append(args, strings.TrimSuffix(arg, "\r\n"))
+		}
+		var reply string
+		switch args[0] {
+		case "SETBIT", "GETBIT":
+			off, _ := strconv.Atoi(args[2])
+			b := data[args[1]]
+			if args[0] == "SETBIT" && off/8 >= len(b) {
+				b = append(b, make([]byte, off/8+1-len(b))...)
+				data[args[1]] = b
+			}
+			bit := 0
+			if off/8 < len(b) {
+				bit = int(b[off/8] >> (7 - off%8) & 1)
+			}
+			if args[0] == "SETBIT" {
+				b[off/8] |= 1 << (7 - off%8)
+			}
+			reply = fmt.Sprintf(":%d\r\n", bit)
+		case "GET":
+			if b, ok := data[args[1]]; ok {
+				reply = fmt.Sprintf("$%d\r\n%s\r\n", len(b), b)
+			} else {
+				reply = "$-1\r\n"
+			}
+		default:
+			reply = "-ERR unknown command\r\n"
+		}
+		if _, err := io.WriteString(c, reply); err != nil {
+			return
+		}
+	}
+}
+
+func TestBitmap(t *testing.T) {
+	data := make(map[string][]byte)
+	client, server := net.Pipe()
+	go fakeRedis(server, data)
+	f := NewBitmap(client, "f", 1<<12, 4, bloom.BitsAndBlooms)
+	defer f.Close()
+	ctx := context.Background()
+
+	want := bloom.NewWithHash(1<<12, 4, bloom.BitsAndBlooms)
+	if g, err := f.Snapshot(ctx); err != nil || g.BitCount() != 0 {
+		t.Errorf("TestBitmap: empty Snapshot: got %v, %v", g, err)
+	}
+	for i := range 50 {
+		item := []byte(fmt.Sprint(i))
+		if err := f.Insert(ctx, item); err != nil {
+			t.Fatalf("TestBitmap: %v", err)
+		}
+		want.Insert(item)
+	}
+	for i := range 100 {
+		item := []byte(fmt.Sprint(i))
+		if got, err := f.MaybeContains(ctx, item); err != nil || got != want.MaybeContains(item) {
+			t.Errorf("TestBitmap(%v): got %v, %v, want %v, nil", i, got, err, want.MaybeContains(item))
+		}
+	}
+
+	// The stored string holds the filter's bits in SETBIT order.
+	g, err := f.Snapshot(ctx)
+	if err != nil {
+		t.Fatalf("TestBitmap: %v", err)
+	}
+	wb, _ := want.MarshalBinary()
+	gb, _ := g.MarshalBinary()
+	if string(gb) != string(wb) {
+		t.Errorf("TestBitmap: Snapshot differs from local filter")
+	}
+	bits, _ := want.MarshalBits(bloom.BitLayout{MSBFirst: true})
+	if stored := data["f"]; string(stored) != string(bits[:len(stored)]) {
+		t.Errorf("TestBitmap: stored %x, want %x", stored, bits)
+	}
+
+	// Errors from the server are returned.
+	bad := NewBitmap(clientOf(fakeRedis, map[string][]byte{"f": make([]byte, 1<<10)}), "f", 1<<12, 4, bloom.BitsAndBlooms)
+	defer bad.Close()
+	if _, err := bad.Snapshot(ctx); err == nil {
+		t.Errorf("TestBitmap: oversized string: got nil error")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("TestBitmap: did not panic")
+		}
+	}()
+	NewBitmap(nil, "f", 100, 4, bloom.SHA256)
+}
+
+// clientOf returns the client end of a connection served by serve with data.
+func clientOf(serve func(net.Conn, map[string][]byte), data map[string][]byte) net.Conn {
+	client, server := net.Pipe()
+	go serve(server, data)
+	return client
+}
+
+func TestRedisBloom(t *testing.T) {
+	client, server := net.Pipe()
+	go redisserver.NewServer().ServeConn(server)
+	f := NewRedisBloom(client, "f")
+	defer f.Close()
+	ctx := context.Background()
+
+	if err := f.Insert(ctx, []byte("x")); err != nil {
+		t.Fatalf("TestRedisBloom: %v", err)
+	}
+	for item, want := range map[string]bool{"x": true, "y": false} {
+		if got, err := f.MaybeContains(ctx, []byte(item)); err != nil || got != want {
+			t.Errorf("TestRedisBloom(%v): got %v, %v, want %v, nil", item, got, err, want)
+		}
+	}
+	// The server does not support BF.SCANDUMP.
+	var re redisError
+	if _, err := f.Snapshot(ctx); !errors.As(err, &re) {
+		t.Errorf("TestRedisBloom: Snapshot: got %v, want a server error", err)
+	}
+}
+
+func TestConnContext(t *testing.T) {
+	// The server never replies.
+	client, server := net.Pipe()
+	defer server.Close()
+	go io.Copy(io.Discard, server)
+	f := NewRedisBloom(client, "f")
+	defer f.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := f.Insert(ctx, []byte("x")); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("TestConnContext: got %v, want %v", err, context.DeadlineExceeded)
+	}
+	// The connection is unusable afterward.
+	if err := f.Insert(context.Background(), []byte("x")); err == nil {
+		t.Errorf("TestConnContext: got nil error after failure")
+	}
+	ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+	g := NewRedisBloom(clientOf(fakeRedis, nil), "g")
+	defer g.Close()
+	if _, err := g.MaybeContains(ctx, []byte("x")); !errors.Is(err, context.Canceled) {
+		t.Errorf("TestConnContext: got %v, want %v", err, context.Canceled)
+	}
+}
@@ -0,0 +1,227 @@
+// Package redisserver implements a server that speaks the Redis protocol (RESP) and serves Bloom filters
+// with a subset of the commands of the RedisBloom module, so that existing Redis clients can use it
+// as a lightweight replacement for RedisBloom.
+//
+// It supports the following commands:
+//
+//	BF.RESERVE key error_rate capacity [NONSCALING]
+//	BF.ADD key item
+//	BF.MADD key item [item ...]
+//	BF.EXISTS key item
+//	BF.MEXISTS key item [item ...]
+//	PING [message]
+//	QUIT
+//
+// Filters are created by BF.RESERVE or, with the server's default parameters, by the first BF.ADD or BF.MADD
+// to their key. They use the RedisBloom hash algorithm and are sized in the manner of RedisBloom.
+// Unlike RedisBloom's default filters, they do not add layers when they reach their capacity.
+package redisserver
+
+import (
+	"bufio"
+	"errors"
+	"math"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/dkmccandless/bloom"
+)
+
+const (
+	// maxBulkLength is the length of the longest argument the server accepts.
+	maxBulkLength = 64 << 20
+
+	// maxArgs is the largest number of arguments of a command the server accepts.
+	maxArgs = 1 << 20
+
+	// maxCommandLength is the largest total length of the arguments of a command the server accepts.
+	maxCommandLength = 256 << 20
+)
+
+// A Server serves Bloom filters over the Redis protocol. Its exported fields must not be modified
+// once it has begun serving.
+type Server struct {
+	// DefaultErrorRate and DefaultCapacity are the parameters of filters created by BF.ADD and BF.MADD.
+	DefaultErrorRate float64
+	DefaultCapacity  int
+
+	mu      sync.Mutex
+	filters map[string]*bloom.SyncFilter
+}
+
+// NewServer returns a Server with no filters, whose default parameters are those of RedisBloom:
+// an error rate of 0.01 and a capacity of 100.
+func NewServer() *Server {
+	return &Server{DefaultErrorRate: 0.01, DefaultCapacity: 100, filters: make(map[string]*bloom.SyncFilter)}
+}
+
+// Serve accepts connections on l and serves each in its own goroutine, until l returns an error,
+// which Serve returns.
+func (s *Server) Serve(l net.Listener) error {
+	for {
+		c, err := l.Accept()
+		if err != nil {
+			return err
+		}
+		go s.ServeConn(c)
+	}
+}
+
+// ServeConn serves commands read from c until the client quits or the connection fails, and then closes c.
+func (s *Server) ServeConn(c net.Conn) {
+	defer c.Close()
+	r := bufio.NewReader(c)
+	w := bufio.NewWriter(c)
+	for {
+		args, err := readCommand(r)
+		if err != nil {
+			var pe protocolError
+			if errors.As(err, &pe) {
+				writeError(w, "ERR Protocol error: "+string(pe))
+				w.Flush()
+			}
+			return
+		}
+		if len(args) == 0 {
+			continue
+		}
+		quit := s.exec(w, args)
+		// Replies to pipelined commands are written together.
+		if r.Buffered() == 0 || quit {
+			if err := w.Flush(); err != nil || quit {
+				return
+			}
+		}
+	}
+}
+
+// exec executes the command args and writes its reply to w. It reports whether the client has quit.
+func (s *Server) exec(w *bufio.Writer, args [][]byte) (quit bool) {
+	name := strings.ToLower(string(args[0]))
+	cmd, ok := commands[name]
+	if !ok {
+		writeError(w, "ERR unknown command '"+string(args[0])+"'")
+		return false
+	}
+	if n := len(args) - 1; n < cmd.min || cmd.max >= 0 && n > cmd.max {
+		writeError(w, "ERR wrong number of arguments for '"+name+"' command")
+		return false
+	}
+	if name == "quit" {
+		writeSimple(w, "OK")
+		return true
+	}
+	cmd.fn(s, w, args[1:])
+	return false
+}
+
+// A command is a command the server supports, which takes between min and max arguments, or at least min if max is -1.
+type command struct {
+	min, max int
+	fn       func(s *Server, w *bufio.Writer, args [][]byte)
+}
+
+var commands map[string]command
+
+func init() {
+	commands = map[string]command{
+		"bf.reserve": {3, 4, (*Server).reserve},
+		"bf.add":     {2, 2, func(s *Server, w *bufio.Writer, args [][]byte) { s.add(w, args, false) }},
+		"bf.madd":    {2, -1, func(s *Server, w *bufio.Writer, args [][]byte) { s.add(w, args, true) }},
+		"bf.exists":  {2, 2, func(s *Server, w *bufio.Writer, args [][]byte) { s.exists(w, args, false) }},
+		"bf.mexists": {2, -1, func(s *Server, w *bufio.Writer, args [][]byte) { s.exists(w, args, true) }},
+		"ping":       {0, 1, (*Server).ping},
+		"quit":       {0, 0, nil},
+	}
+}
+
+func (s *Server) reserve(w *bufio.Writer, args [][]byte) {
+	rate, err := strconv.ParseFloat(string(args[1]), 64)
+	if err != nil || !(rate > 0 && rate < 1) {
+		writeError(w, "ERR (0 < error rate range < 1)")
+		return
+	}
+	capacity, err := strconv.Atoi(string(args[2]))
+	if err != nil || capacity <= 0 {
+		writeError(w, "ERR (capacity should be larger than 0)")
+		return
+	}
+	if len(args) == 4 && !strings.EqualFold(string(args[3]), "NONSCALING") {
+		writeError(w, "ERR scaling filters are not supported")
+		return
+	}
+	f, err := newFilter(rate, capacity)
+	if err != nil {
+		writeError(w, "ERR "+err.Error())
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.filters[string(args[0])]; ok {
+		writeError(w, "ERR item exists")
+		return
+	}
+	s.filters[string(args[0])] = f
+	writeSimple(w, "OK")
+}
+
+// add serves BF.ADD and, if multi is true, BF.MADD, whose reply is an array.
+func (s *Server) add(w *bufio.Writer, args [][]byte, multi bool) {
+	s.mu.Lock()
+	f, ok := s.filters[string(args[0])]
+	if !ok {
+		var err error
+		if f, err = newFilter(s.DefaultErrorRate, s.DefaultCapacity); err != nil {
+			s.mu.Unlock()
+			writeError(w, "ERR "+err.Error())
+			return
+		}
+		s.filters[string(args[0])] = f
+	}
+	s.mu.Unlock()
+	reply(w, args[1:], multi, func(item []byte) bool { return !f.TestAndInsert(item) })
+}
+
+// exists serves BF.EXISTS and, if multi is true, BF.MEXISTS, whose reply is an array.
+func (s *Server) exists(w *bufio.Writer, args [][]byte, multi bool) {
+	s.mu.Lock()
+	f := s.filters[string(args[0])]
+	s.mu.Unlock()
+	reply(w, args[1:], multi, func(item []byte) bool { return f != nil && f.MaybeContains(item) })
+}
+
+// reply writes the result of fn for each item as an integer, preceded by the header of an array if multi is true.
+func reply(w *bufio.Writer, items [][]byte, multi bool, fn func(item []byte) bool) {
+	if multi {
+		writeArrayHeader(w, len(items))
+	}
+	for _, item := range items {
+		writeBool(w, fn(item))
+	}
+}
+
+func (s *Server) ping(w *bufio.Writer, args [][]byte) {
+	if len(args) == 1 {
+		writeBulk(w, args[0])
+		return
+	}
+	writeSimple(w, "PONG")
+}
+
+// newFilter returns a filter that uses the RedisBloom hash algorithm with the size and number of hash values
+// that RedisBloom chooses for the given error rate and capacity.
+func newFilter(rate float64, capacity int) (*bloom.SyncFilter, error) {
+	bpe := -math.Log(rate) / (math.Ln2 * math.Ln2)
+	bits := math.Ceil(float64(capacity) * bpe)
+	if bits > 1<<40 {
+		return nil, errors.New("filter too large")
+	}
+	m := (int(bits) + 7) / 8 * 8
+	k := int(math.Ceil(math.Ln2 * bpe))
+	if k > 255 {
+		return nil, errors.New("error rate too small")
+	}
+	return bloom.NewSyncFilter(bloom.NewWithHash(m, k, bloom.RedisBloom)), nil
+}
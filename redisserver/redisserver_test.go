@@ -0,0 +1,137 @@
+package redisserver
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// encode returns the command args in the form sent by Redis clients.
+func encode(args ...string) string {
+	s := fmt.Sprintf("*%d\r\n", len(args))
+	for _, a := range args {
+		s += fmt.Sprintf("$%d\r\n%s\r\n", len(a), a)
+	}
+	return s
+}
+
+// exchange sends input to a connection served by s, and returns everything s writes until it closes the connection.
+func exchange(s *Server, input string) string {
+	client, server := net.Pipe()
+	go s.ServeConn(server)
+	go func() {
+		client.Write([]byte(input))
+	}()
+	var b strings.Builder
+	r := bufio.NewReader(client)
+	for {
+		line, err := r.ReadString('\n')
+		b.WriteString(line)
+		if err != nil {
+			return b.String()
+		}
+	}
+}
+
+func TestServer(t *testing.T) {
+	s := NewServer()
+	for _, test := range []struct {
+		input, want string
+	}{
+		{encode("PING") + encode("QUIT"), "+PONG\r\n+OK\r\n"},
+		{"PING hello\r\nquit\n", "$5\r\nhello\r\n+OK\r\n"},
+		{encode("BF.RESERVE", "f", "0.001", "1000") + encode("BF.RESERVE", "f", "0.01", "10") + encode("QUIT"),
+			"+OK\r\n-ERR item exists\r\n+OK\r\n"},
+		{encode("BF.ADD", "f", "a") + encode("BF.ADD", "f", "a") + encode("bf.madd", "f", "a", "b") + encode("QUIT"),
+			":1\r\n:0\r\n*2\r\n:0\r\n:1\r\n+OK\r\n"},
+		{encode("BF.EXISTS", "f", "a") + encode("BF.EXISTS", "f", "c") + encode("BF.MEXISTS", "f", "b", "c") + encode("QUIT"),
+			":1\r\n:0\r\n*2\r\n:1\r\n:0\r\n+OK\r\n"},
+		// Adding to a missing key creates a filter; querying one does not.
+		{encode("BF.EXISTS", "g", "a") + encode("BF.MEXISTS", "g", "a") + encode("BF.ADD", "g", "a") + encode("BF.EXISTS", "g", "a") + encode("QUIT"),
+			":0\r\n*1\r\n:0\r\n:1\r\n:1\r\n+OK\r\n"},
+		{encode("BF.RESERVE", "h", "2", "10") + encode("BF.RESERVE", "h", "0.1", "0") + encode("BF.RESERVE", "h", "0.1", "10", "EXPANSION") + encode("QUIT"),
+			"-ERR (0 < error rate range < 1)\r\n-ERR (capacity should be larger than 0)\r\n-ERR scaling filters are not supported\r\n+OK\r\n"},
+		{encode("BF.RESERVE", "h", "0.1", "10", "nonscaling") + encode("QUIT"), "+OK\r\n+OK\r\n"},
+		{encode("GET", "f") + encode("BF.ADD", "f") + encode("QUIT"),
+			"-ERR unknown command 'GET'\r\n-ERR wrong number of arguments for 'bf.add' command\r\n+OK\r\n"},
+		{"*1\r\n+PING\r\n", "-ERR Protocol error: expected '$'\r\n"},
+		{"*x\r\n", "-ERR Protocol error: invalid multibulk length\r\n"},
+		{"*1\r\n$4\r\nPINGxx", "-ERR Protocol error: expected CRLF\r\n"},
+		{"*1\r\n$-1\r\n", "-ERR Protocol error: invalid bulk length\r\n"},
+	} {
+		if got := exchange(s, test.input); got != test.want {
+			t.Errorf("TestServer(%q): got %q, want %q", test.input, got, test.want)
+		}
+	}
+}
+
+func TestServe(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan error)
+	go func() { done <- NewServer().Serve(l) }()
+	c, err := net.Dial("tcp", l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	fmt.Fprint(c, encode("BF.ADD", "f", "x")+encode("BF.EXISTS", "f", "x"))
+	r := bufio.NewReader(c)
+	for _, want := range []string{":1\r\n", ":1\r\n"} {
+		if got, err := r.ReadString('\n'); err != nil || got != want {
+			t.Errorf("TestServe: got %q, %v, want %q", got, err, want)
+		}
+	}
+	l.Close()
+	if err := <-done; err == nil {
+		t.Errorf("TestServe: got nil error")
+	}
+}
+
+func TestNewFilter(t *testing.T) {
+	for _, test := range []struct {
+		rate     float64
+		capacity int
+		m, k     int
+	}{
+		{0.01, 100, 960, 7},
+		{0.001, 1000, 14384, 10},
+	} {
+		f, err := newFilter(test.rate, test.capacity)
+		if err != nil {
+			t.Errorf("TestNewFilter(%v, %v): %v", test.rate, test.capacity, err)
+			continue
+		}
+		g := f.Snapshot()
+		if g.Size() != test.m || g.HashValues() != test.k {
+			t.Errorf("TestNewFilter(%v, %v): got %v, %v, want %v, %v", test.rate, test.capacity, g.Size(), g.HashValues(), test.m, test.k)
+		}
+	}
+	if _, err := newFilter(1e-300, 1); err == nil {
+		t.Errorf("TestNewFilter: tiny error rate: got nil error")
+	}
+	if _, err := newFilter(0.01, 1<<40); err == nil {
+		t.Errorf("TestNewFilter: huge capacity: got nil error")
+	}
+}
+
+func TestReadCommandLengths(t *testing.T) {
+	// A command's claimed lengths are not allocated before its arguments arrive.
+	input := fmt.Sprintf("*%d\r\n$%d\r\nab", maxArgs, maxBulkLength)
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	_, err := readCommand(bufio.NewReader(strings.NewReader(input)))
+	runtime.ReadMemStats(&after)
+	if err != io.ErrUnexpectedEOF {
+		t.Errorf("TestReadCommandLengths: got %v, want %v", err, io.ErrUnexpectedEOF)
+	}
+	if n := after.TotalAlloc - before.TotalAlloc; n > 1<<20 {
+		t.Errorf("TestReadCommandLengths: allocated %v bytes", n)
+	}
+}
@@ -0,0 +1,107 @@
+package redisserver
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"strconv"
+)
+
+// A protocolError reports a malformed command, after which the connection is closed.
+type protocolError string
+
+func (e protocolError) Error() string { return string(e) }
+
+// readCommand reads a command from r: either an array of bulk strings, as sent by Redis clients,
+// or an inline command of words separated by spaces, as typed in a terminal.
+// The lengths that a command claims are not trusted: its buffers grow only as its arguments arrive.
+func readCommand(r *bufio.Reader) ([][]byte, error) {
+	line, err := readLine(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(line) == 0 || line[0] != '*' {
+		return bytes.Fields(line), nil
+	}
+	n, err := strconv.Atoi(string(line[1:]))
+	if err != nil || n > maxArgs {
+		return nil, protocolError("invalid multibulk length")
+	}
+	args := make([][]byte, 0, min(max(n, 0), 16))
+	total := 0
+	for range n {
+		line, err := readLine(r)
+		if err != nil {
+			return nil, err
+		}
+		if len(line) == 0 || line[0] != '$' {
+			return nil, protocolError("expected '$'")
+		}
+		l, err := strconv.Atoi(string(line[1:]))
+		if err != nil || l < 0 || l > maxBulkLength {
+			return nil, protocolError("invalid bulk length")
+		}
+		if total += l; total > maxCommandLength {
+			return nil, protocolError("command too long")
+		}
+		var buf bytes.Buffer
+		if _, err := io.CopyN(&buf, r, int64(l+2)); err != nil {
+			return nil, unexpectedEOF(err)
+		}
+		arg := buf.Bytes()
+		if string(arg[l:]) != "\r\n" {
+			return nil, protocolError("expected CRLF")
+		}
+		args = append(args, arg[:l])
+	}
+	return args, nil
+}
+
+// readLine reads a line terminated by CRLF or LF from r and returns it without its terminator.
+func readLine(r *bufio.Reader) ([]byte, error) {
+	line, err := r.ReadSlice('\n')
+	if err == bufio.ErrBufferFull {
+		return nil, protocolError("line too long")
+	}
+	if err != nil {
+		if len(line) > 0 {
+			return nil, unexpectedEOF(err)
+		}
+		return nil, err
+	}
+	return bytes.TrimSuffix(line[:len(line)-1], []byte("\r")), nil
+}
+
+// unexpectedEOF returns io.ErrUnexpectedEOF if err is io.EOF, and err otherwise.
+func unexpectedEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+func writeSimple(w *bufio.Writer, s string) {
+	w.WriteString("+" + s + "\r\n")
+}
+
+func writeError(w *bufio.Writer, s string) {
+	w.WriteString("-" + s + "\r\n")
+}
+
+func writeBool(w *bufio.Writer, ok bool) {
+	if ok {
+		w.WriteString(":1\r\n")
+	} else {
+		w.WriteString(":0\r\n")
+	}
+}
+
+func writeBulk(w *bufio.Writer, b []byte) {
+	w.WriteString("$" + strconv.Itoa(len(b)) + "\r\n")
+	w.Write(b)
+	w.WriteString("\r\n")
+}
+
+func writeArrayHeader(w *bufio.Writer, n int) {
+	w.WriteString("*" + strconv.Itoa(n) + "\r\n")
+}
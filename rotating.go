@@ -0,0 +1,115 @@
+package bloom
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// A RotatingFilter is a Bloom filter whose items expire: it holds two generations of SyncFilter,
+// inserts items into the current generation, and reports an item if either generation contains it.
+// Rotate empties the older generation and makes it current, so that an item inserted or found
+// since the previous rotation is remembered until the next but one: an item found in the older generation
+// is inserted into the current one. Rotating at a fixed interval
+// thus remembers each item for between one and two intervals after it is last seen,
+// and keeps the fill ratio of a filter that receives an unending stream of items bounded.
+// Its methods may be called concurrently from multiple goroutines.
+type RotatingFilter struct {
+	gens     [2]*SyncFilter
+	cur      int // the index in gens of the current generation; guarded by mu
+	interval time.Duration
+	deadline atomic.Int64 // the time of the next automatic rotation, in nanoseconds since the Unix epoch
+	mu       sync.RWMutex // held for reading by each operation and for writing by rotations
+	now      func() time.Time
+}
+
+// NewRotatingFilter returns a RotatingFilter whose current generation is f and whose other generation
+// is an empty Filter with f's parameters. The RotatingFilter takes ownership of f.
+// If interval is positive, the RotatingFilter rotates itself whenever a method is called at least interval
+// after the previous rotation, rotating twice if the previous rotation was at least two intervals earlier;
+// otherwise it rotates only when Rotate is called. NewRotatingFilter panics if interval is negative.
+func NewRotatingFilter(f *Filter, interval time.Duration) *RotatingFilter {
+	if interval < 0 {
+		panic("bloom: negative rotation interval")
+	}
+	r := &RotatingFilter{
+		gens:     [2]*SyncFilter{NewSyncFilter(f), NewSyncFilter(newFilter(f.m, f.k, f.hash))},
+		interval: interval,
+		now:      time.Now,
+	}
+	r.deadline.Store(r.now().Add(interval).UnixNano())
+	return r
+}
+
+// Insert inserts item into r's current generation.
+func (r *RotatingFilter) Insert(item []byte) {
+	r.tick()
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	r.gens[r.cur].Insert(item)
+}
+
+// TestAndInsert inserts item into r's current generation and reports whether either generation
+// probably contained it beforehand. Of concurrent calls with the same item that was in neither generation,
+// exactly one returns false, in the manner of SyncFilter.TestAndInsert, even if r rotates meanwhile.
+func (r *RotatingFilter) TestAndInsert(item []byte) bool {
+	r.tick()
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.gens[r.cur].TestAndInsert(item) || r.gens[1-r.cur].MaybeContains(item)
+}
+
+// MaybeContains reports whether item is probably in either of r's generations.
+// If item is found only in the older generation, MaybeContains inserts it into the current one,
+// so that it is remembered until the next but one rotation.
+func (r *RotatingFilter) MaybeContains(item []byte) bool {
+	r.tick()
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if r.gens[r.cur].MaybeContains(item) {
+		return true
+	}
+	if r.gens[1-r.cur].MaybeContains(item) {
+		r.gens[r.cur].Insert(item)
+		return true
+	}
+	return false
+}
+
+// Rotate empties r's older generation and makes it current, forgetting the items that were inserted
+// only before the previous rotation. If r rotates automatically, the next automatic rotation
+// is one interval after the call.
+func (r *RotatingFilter) Rotate() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.rotate()
+	r.deadline.Store(r.now().Add(r.interval).UnixNano())
+}
+
+// rotate empties r's older generation and makes it current. The caller must hold r.mu for writing.
+func (r *RotatingFilter) rotate() {
+	r.cur = 1 - r.cur
+	r.gens[r.cur].Reset()
+}
+
+// tick rotates r if its interval has elapsed since the previous rotation.
+func (r *RotatingFilter) tick() {
+	if r.interval == 0 {
+		return
+	}
+	now := r.now().UnixNano()
+	if now < r.deadline.Load() {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	deadline := r.deadline.Load()
+	if now < deadline {
+		return // rotated by a concurrent call
+	}
+	r.rotate()
+	if now >= deadline+int64(r.interval) {
+		r.rotate() // both generations have expired
+	}
+	r.deadline.Store(now + int64(r.interval))
+}
@@ -0,0 +1,99 @@
+package bloom
+
+import (
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRotatingFilter(t *testing.T) {
+	now := time.Unix(0, 0)
+	r := NewRotatingFilter(NewWithHash(1024, 4, BitsAndBlooms), time.Minute)
+	r.now = func() time.Time { return now }
+	r.deadline.Store(now.Add(time.Minute).UnixNano())
+
+	for _, step := range []struct {
+		elapsed time.Duration
+		insert  string
+		want    map[string]bool
+	}{
+		{0, "a", map[string]bool{"a": true, "b": false}},
+		{30 * time.Second, "b", map[string]bool{"a": true, "b": true}},
+		// The first rotation keeps both items in the older generation, where MaybeContains would refresh them.
+		{30 * time.Second, "c", map[string]bool{"c": true}},
+		// The second forgets them.
+		{time.Minute, "", map[string]bool{"a": false, "b": false, "c": true}},
+		// An idle period of two intervals forgets everything.
+		{2 * time.Minute, "", map[string]bool{"c": false}},
+	} {
+		now = now.Add(step.elapsed)
+		if step.insert != "" {
+			r.Insert([]byte(step.insert))
+		}
+		for item, want := range step.want {
+			if got := r.MaybeContains([]byte(item)); got != want {
+				t.Errorf("TestRotatingFilter(%v, %v): got %v, want %v", now.Unix(), item, got, want)
+			}
+		}
+	}
+
+	if r.TestAndInsert([]byte("d")) || !r.TestAndInsert([]byte("d")) {
+		t.Errorf("TestRotatingFilter: TestAndInsert does not report a repeated item")
+	}
+	r.Rotate()
+	if !r.TestAndInsert([]byte("d")) {
+		t.Errorf("TestRotatingFilter: TestAndInsert does not report an item in the older generation")
+	}
+	// The item found in the older generation was inserted into the current one, and survives another rotation.
+	r.Rotate()
+	if !r.MaybeContains([]byte("d")) {
+		t.Errorf("TestRotatingFilter: item refreshed by TestAndInsert was forgotten")
+	}
+	r.Rotate()
+	r.Rotate()
+	if r.MaybeContains([]byte("d")) {
+		t.Errorf("TestRotatingFilter: item was not forgotten")
+	}
+}
+
+func TestRotatingFilterManual(t *testing.T) {
+	r := NewRotatingFilter(New(64, 3), 0)
+	r.Insert([]byte("a"))
+	r.Insert([]byte("b"))
+	r.Rotate()
+	if !r.MaybeContains([]byte("a")) {
+		t.Errorf("TestRotatingFilterManual: item forgotten after one rotation")
+	}
+	r.Rotate()
+	if !r.MaybeContains([]byte("a")) {
+		t.Errorf("TestRotatingFilterManual: item found after one rotation forgotten after two")
+	}
+	if r.MaybeContains([]byte("b")) {
+		t.Errorf("TestRotatingFilterManual: item remembered after two rotations")
+	}
+}
+
+func TestRotatingFilterTestAndInsertDuringRotate(t *testing.T) {
+	r := NewRotatingFilter(New(8192, 3), 0)
+	for i := range 200 {
+		item := []byte(strconv.Itoa(i))
+		var wg sync.WaitGroup
+		var found atomic.Int32
+		for range 4 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if r.TestAndInsert(item) {
+					found.Add(1)
+				}
+			}()
+		}
+		r.Rotate()
+		wg.Wait()
+		if n := found.Load(); n != 3 {
+			t.Fatalf("TestRotatingFilterTestAndInsertDuringRotate(%q): %d of 4 calls found item, want 3", item, n)
+		}
+	}
+}
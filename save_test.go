@@ -0,0 +1,66 @@
+package bloom
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+// loadFile returns the Filter in the binary form produced by MarshalBinary in the file at path.
+func loadFile(t *testing.T, path string) *Filter {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := new(Filter)
+	if err := f.UnmarshalBinary(data); err != nil {
+		t.Fatalf("loadFile(%v): %v", path, err)
+	}
+	return f
+}
+
+func TestSaveFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "filter")
+	f := NewWithHash(1<<12, 4, BitsAndBlooms)
+	f.Insert([]byte("x"))
+	if err := f.SaveFile(path); err != nil {
+		t.Fatalf("TestSaveFile: %v", err)
+	}
+	if got := loadFile(t, path); !equal(got, f) {
+		t.Errorf("TestSaveFile: got %v, want %v", got, f)
+	}
+
+	// Saving again replaces the file and keeps its permissions.
+	if runtime.GOOS != "windows" {
+		if err := os.Chmod(path, 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	f.Insert([]byte("y"))
+	if err := f.SaveFile(path); err != nil {
+		t.Fatalf("TestSaveFile: %v", err)
+	}
+	if got := loadFile(t, path); !equal(got, f) {
+		t.Errorf("TestSaveFile: got %v, want %v", got, f)
+	}
+	if fi, err := os.Stat(path); err != nil {
+		t.Error(err)
+	} else if runtime.GOOS != "windows" && fi.Mode().Perm() != 0o600 {
+		t.Errorf("TestSaveFile: got mode %v, want %v", fi.Mode().Perm(), os.FileMode(0o600))
+	}
+
+	// No temporary files remain, including after a failure.
+	if err := f.SaveFile(filepath.Join(dir, "missing", "filter")); err == nil {
+		t.Errorf("TestSaveFile: missing directory: got nil error")
+	}
+	if err := f.SaveFile(dir); err == nil {
+		t.Errorf("TestSaveFile: directory: got nil error")
+	}
+	if entries, err := os.ReadDir(dir); err != nil {
+		t.Error(err)
+	} else if len(entries) != 1 {
+		t.Errorf("TestSaveFile: got %v files, want 1", len(entries))
+	}
+}
@@ -0,0 +1,119 @@
+package bloom
+
+import (
+	"encoding"
+	"encoding/binary"
+	"hash/maphash"
+	"unsafe"
+)
+
+// A Set is a Filter of values of type T, each of which it encodes as an item by a function given to NewSet,
+// so that callers need not convert values to bytes at each insertion and lookup.
+// EncodeString, EncodeInt, and EncodeBinaryMarshaler encode common types, and EncodeComparable any comparable type.
+// A Set may be used concurrently in the same manner as its Filter.
+type Set[T any] struct {
+	f      *Filter
+	encode func(T) []byte
+}
+
+// NewSet returns a Set that stores its values in f as the items returned by encode.
+// The Set does not retain the items, so encode may return a slice of a buffer that it reuses,
+// provided that it does so safely if the Set is used concurrently.
+func NewSet[T any](f *Filter, encode func(T) []byte) *Set[T] {
+	return &Set[T]{f: f, encode: encode}
+}
+
+// Insert inserts v into s's set.
+func (s *Set[T]) Insert(v T) {
+	s.f.Insert(s.encode(v))
+}
+
+// MaybeContains reports whether v is probably in s's set, in the manner of Filter.MaybeContains.
+func (s *Set[T]) MaybeContains(v T) bool {
+	return s.f.MaybeContains(s.encode(v))
+}
+
+// Filter returns s's Filter, so that it can be marshaled or combined with others.
+func (s *Set[T]) Filter() *Filter {
+	return s.f
+}
+
+// EncodeString returns the bytes of str without copying them. The caller must not modify them.
+func EncodeString(str string) []byte {
+	return unsafe.Slice(unsafe.StringData(str), len(str))
+}
+
+// EncodeNormalizedString returns an encoding function for NewSet that applies normalize to a string
+// before encoding it in the manner of EncodeString, so that strings with the same normal form are the same item:
+//
+//	names := bloom.NewSet(f, bloom.EncodeNormalizedString(strings.ToLower))
+//	names.Insert("Foo")
+//	names.MaybeContains("foo") // true
+//
+// A normalizer such as the NFC.String method of golang.org/x/text/unicode/norm, composed with case folding,
+// identifies strings that differ only in Unicode representation or case.
+func EncodeNormalizedString(normalize func(string) string) func(string) []byte {
+	return func(str string) []byte {
+		return EncodeString(normalize(str))
+	}
+}
+
+// integer is the set of integer types.
+type integer interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 | ~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr
+}
+
+// EncodeInt returns the 8-byte little-endian two's complement encoding of v, whatever its type's size,
+// so that equal values of different integer types are the same item.
+// This is the encoding that Guava's Funnels.longFunnel() writes.
+func EncodeInt[T integer](v T) []byte {
+	return binary.LittleEndian.AppendUint64(make([]byte, 0, 8), uint64(v))
+}
+
+// EncodeComparable returns an encoding function for NewSet that encodes a value of any comparable type,
+// such as a struct or an array, as the 8-byte little-endian encoding of its hash by maphash.Comparable with seed,
+// so that such values can be stored without a byte encoding of their own:
+//
+//	type edge struct{ from, to int }
+//	edges := bloom.NewSet(f, bloom.EncodeComparable[edge](maphash.MakeSeed()))
+//
+// The hash depends on seed, which cannot be saved, and values that contain pointers or channels
+// are hashed by the addresses they hold rather than by what they refer to, so the items are meaningful only within the process
+// that encodes them: sets whose filters are combined by Union must share a seed, and a filter of such items
+// must not be marshaled to be read elsewhere. Distinct values have the same hash with probability about 2^-64.
+func EncodeComparable[T comparable](seed maphash.Seed) func(T) []byte {
+	return func(v T) []byte {
+		return EncodeInt(maphash.Comparable(seed, v))
+	}
+}
+
+// EncodeBinaryMarshaler returns the result of v's MarshalBinary method.
+// It panics if MarshalBinary returns an error; InsertMarshaler and MaybeContainsMarshaler return it instead.
+func EncodeBinaryMarshaler[T encoding.BinaryMarshaler](v T) []byte {
+	b, err := v.MarshalBinary()
+	if err != nil {
+		panic("bloom: " + err.Error())
+	}
+	return b
+}
+
+// InsertMarshaler inserts into f's set the item returned by m's MarshalBinary method,
+// or returns the error that MarshalBinary returns without modifying f.
+func (f *Filter) InsertMarshaler(m encoding.BinaryMarshaler) error {
+	item, err := m.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	f.Insert(item)
+	return nil
+}
+
+// MaybeContainsMarshaler reports whether the item returned by m's MarshalBinary method is probably in f's set,
+// in the manner of MaybeContains, or returns the error that MarshalBinary returns.
+func (f *Filter) MaybeContainsMarshaler(m encoding.BinaryMarshaler) (bool, error) {
+	item, err := m.MarshalBinary()
+	if err != nil {
+		return false, err
+	}
+	return f.MaybeContains(item), nil
+}
@@ -0,0 +1,130 @@
+package bloom
+
+import (
+	"errors"
+	"hash/maphash"
+	"net/netip"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSet(t *testing.T) {
+	s := NewSet(NewWithHash(1024, 4, BitsAndBlooms), EncodeString)
+	s.Insert("apple")
+	if !s.MaybeContains("apple") || s.MaybeContains("banana") {
+		t.Errorf("TestSet: got %v, %v, want true, false", s.MaybeContains("apple"), s.MaybeContains("banana"))
+	}
+	if !s.Filter().MaybeContainsString("apple") {
+		t.Errorf("TestSet: apple not in Filter")
+	}
+
+	ints := NewSet(NewWithHash(1024, 4, BitsAndBlooms), EncodeInt[int32])
+	ints.Insert(-7)
+	if !ints.MaybeContains(-7) || ints.MaybeContains(7) {
+		t.Errorf("TestSet: got %v, %v, want true, false", ints.MaybeContains(-7), ints.MaybeContains(7))
+	}
+	// Equal values of different integer types are the same item.
+	if !NewSet(ints.Filter(), EncodeInt[int64]).MaybeContains(-7) {
+		t.Errorf("TestSet: int64(-7) not in set of int32(-7)")
+	}
+
+	addrs := NewSet(NewWithHash(1024, 4, BitsAndBlooms), EncodeBinaryMarshaler[netip.Addr])
+	addrs.Insert(netip.MustParseAddr("192.0.2.1"))
+	if !addrs.MaybeContains(netip.MustParseAddr("192.0.2.1")) || addrs.MaybeContains(netip.MustParseAddr("192.0.2.2")) {
+		t.Errorf("TestSet: got wrong membership of addresses")
+	}
+}
+
+func TestEncodeNormalizedString(t *testing.T) {
+	s := NewSet(NewWithHash(1024, 4, BitsAndBlooms), EncodeNormalizedString(strings.ToLower))
+	s.Insert("Foo")
+	for _, str := range []string{"Foo", "foo", "FOO"} {
+		if !s.MaybeContains(str) {
+			t.Errorf("TestEncodeNormalizedString(%q): got false, want true", str)
+		}
+	}
+	if s.MaybeContains("bar") {
+		t.Errorf("TestEncodeNormalizedString(%q): got true, want false", "bar")
+	}
+	// The Filter holds the normal form.
+	if f := s.Filter(); !f.MaybeContainsString("foo") || f.MaybeContainsString("Foo") {
+		t.Errorf("TestEncodeNormalizedString: got %v, %v, want true, false", f.MaybeContainsString("foo"), f.MaybeContainsString("Foo"))
+	}
+}
+
+func TestEncodeComparable(t *testing.T) {
+	type edge struct {
+		from, to string
+	}
+	seed := maphash.MakeSeed()
+	s := NewSet(NewWithHash(1024, 4, BitsAndBlooms), EncodeComparable[edge](seed))
+	s.Insert(edge{"a", "b"})
+	// Equal values are the same item, whatever their memory.
+	if !s.MaybeContains(edge{strings.Repeat("a", 1), "b"}) {
+		t.Errorf("TestEncodeComparable: inserted value not found")
+	}
+	if s.MaybeContains(edge{"b", "a"}) {
+		t.Errorf("TestEncodeComparable: got true for value not inserted")
+	}
+	// Sets sharing a seed share items.
+	if !NewSet(s.Filter(), EncodeComparable[edge](seed)).MaybeContains(edge{"a", "b"}) {
+		t.Errorf("TestEncodeComparable: value not found with the same seed")
+	}
+}
+
+func TestEncodeInt(t *testing.T) {
+	for _, test := range []struct {
+		got  []byte
+		want string
+	}{
+		{EncodeInt(0), "\x00\x00\x00\x00\x00\x00\x00\x00"},
+		{EncodeInt(int8(-1)), "\xff\xff\xff\xff\xff\xff\xff\xff"},
+		{EncodeInt(uint16(0x0102)), "\x02\x01\x00\x00\x00\x00\x00\x00"},
+		{EncodeInt(time.Duration(1 << 56)), "\x00\x00\x00\x00\x00\x00\x00\x01"},
+	} {
+		if string(test.got) != test.want {
+			t.Errorf("TestEncodeInt: got %q, want %q", test.got, test.want)
+		}
+	}
+}
+
+// badMarshaler is a BinaryMarshaler that fails.
+type badMarshaler struct{}
+
+func (badMarshaler) MarshalBinary() ([]byte, error) { return nil, errors.New("bad") }
+
+func TestEncodeBinaryMarshalerPanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("TestEncodeBinaryMarshalerPanics: did not panic")
+		}
+	}()
+	EncodeBinaryMarshaler(badMarshaler{})
+}
+
+func TestMarshaler(t *testing.T) {
+	f := NewWithHash(1024, 4, BitsAndBlooms)
+	addr := netip.MustParseAddr("2001:db8::1")
+	if err := f.InsertMarshaler(addr); err != nil {
+		t.Fatalf("TestMarshaler: %v", err)
+	}
+	if ok, err := f.MaybeContainsMarshaler(addr); !ok || err != nil {
+		t.Errorf("TestMarshaler(%v): got %v, %v, want true, nil", addr, ok, err)
+	}
+	if ok, err := f.MaybeContainsMarshaler(netip.MustParseAddr("2001:db8::2")); ok || err != nil {
+		t.Errorf("TestMarshaler: got %v, %v, want false, nil", ok, err)
+	}
+	b, _ := addr.MarshalBinary()
+	if !f.MaybeContains(b) {
+		t.Errorf("TestMarshaler: marshaled form of %v not found", addr)
+	}
+
+	g := NewWithHash(1024, 4, BitsAndBlooms)
+	if err := g.InsertMarshaler(badMarshaler{}); err == nil || g.BitCount() != 0 {
+		t.Errorf("TestMarshaler: InsertMarshaler got error %v and set %v bits", err, g.BitCount())
+	}
+	if _, err := g.MaybeContainsMarshaler(badMarshaler{}); err == nil {
+		t.Errorf("TestMarshaler: MaybeContainsMarshaler got nil error")
+	}
+}
@@ -0,0 +1,60 @@
+package bloom
+
+// shardSeed seeds the hash that routes items to shards, so that routing is independent of the bit positions
+// derived by any hash algorithm.
+const shardSeed = 0x5bd1e9955bd1e995
+
+// A ShardedFilter is a set of independent sub-filters, or shards, to which items are routed by a hash of the item.
+// It is safe for concurrent use by multiple goroutines, since each shard sets and reads its bits atomically,
+// and spreads the contention of concurrent insertions for the same cache lines across shards.
+type ShardedFilter struct {
+	shards []shard
+}
+
+// shard is a sub-filter of a ShardedFilter, padded to occupy its own cache lines.
+type shard struct {
+	f *Filter
+	_ [64]byte
+}
+
+// NewShardedFilter returns a ShardedFilter of n shards, each a filter of m bits that uses k hash values derived by h.
+// It panics if n is not positive or h does not support a filter of size m bits or k hash values.
+func NewShardedFilter(n, m, k int, h Hash) *ShardedFilter {
+	if n <= 0 {
+		panic("bloom: number of shards out of range")
+	}
+	s := &ShardedFilter{shards: make([]shard, n)}
+	for i := range s.shards {
+		s.shards[i].f = NewWithHash(m, k, h)
+	}
+	return s
+}
+
+// shard returns the shard to which item is routed.
+func (s *ShardedFilter) shard(item []byte) *shard {
+	h := murmur64A(item, shardSeed)
+	return &s.shards[(h>>32)*uint64(len(s.shards))>>32]
+}
+
+// Insert inserts item into s's set.
+func (s *ShardedFilter) Insert(item []byte) {
+	s.shard(item).f.Insert(item)
+}
+
+// MaybeContains reports whether item is probably in s's set, in the manner of Filter.MaybeContains.
+func (s *ShardedFilter) MaybeContains(item []byte) bool {
+	return s.shard(item).f.MaybeContains(item)
+}
+
+// Merge returns a single Filter of the same size, number of hash values, and hash algorithm as each shard,
+// whose set is the union of the shards' sets.
+// Because it holds the items of all shards, its false positive rate is higher than that of s.
+// It may be called concurrently with Insert; the Filter holds every item inserted before Merge was called.
+func (s *ShardedFilter) Merge() *Filter {
+	f := s.shards[0].f
+	g := newFilter(f.m, f.k, f.hash)
+	for i := range s.shards {
+		g.Union(s.shards[i].f)
+	}
+	return g
+}
@@ -0,0 +1,59 @@
+package bloom
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestShardedFilter(t *testing.T) {
+	s := NewShardedFilter(4, 4096, 5, BitsAndBlooms)
+	var wg sync.WaitGroup
+	for g := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range 100 {
+				item := []byte(fmt.Sprint(g, i))
+				s.Insert(item)
+				if !s.MaybeContains(item) {
+					t.Errorf("TestShardedFilter(%v, %v): inserted item not found", g, i)
+				}
+				if i%25 == 0 && !s.Merge().MaybeContains(item) {
+					t.Errorf("TestShardedFilter(%v, %v): inserted item not found in merged filter", g, i)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	// Items are spread across shards.
+	for i := range s.shards {
+		if reflect.DeepEqual(s.shards[i].f, NewWithHash(4096, 5, BitsAndBlooms)) {
+			t.Errorf("TestShardedFilter: shard %v is empty", i)
+		}
+	}
+
+	// The merged filter contains every item, and equals a single filter into which they were all inserted.
+	want := NewWithHash(4096, 5, BitsAndBlooms)
+	for g := range 8 {
+		for i := range 100 {
+			want.Insert([]byte(fmt.Sprint(g, i)))
+		}
+	}
+	if got := s.Merge(); !reflect.DeepEqual(got, want) {
+		t.Errorf("TestShardedFilter: merged filter differs from single filter")
+	}
+
+	for _, n := range []int{0, -1} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("TestShardedFilter(%v): did not panic", n)
+				}
+			}()
+			NewShardedFilter(n, 8, 1, SHA256)
+		}()
+	}
+}
@@ -0,0 +1,97 @@
+package bloom
+
+import (
+	"errors"
+	"os"
+	"unsafe"
+)
+
+// A SharedFilter is a Filter whose bits are held in a file mapped read-write into memory,
+// so that multiple processes on one host that open the same file share a single filter.
+// Because a Filter sets and reads its bits with atomic operations, processes may insert and query concurrently,
+// in the same manner as goroutines sharing a Filter; each insertion is visible to every process once Insert returns.
+// Methods that replace the Filter's contents, such as UnmarshalBinary, must not be used,
+// nor may the embedded Filter's Snapshot method: it would mark the mapped pages to be copied to the heap
+// when next modified, so that later insertions would no longer reach the file. SharedFilter's Snapshot copies instead.
+// The file must not be open as a FileFilter at the same time, because FileFilter.Sync would overwrite
+// bits set by other processes. A SharedFilter must be closed when no longer needed,
+// after which its Filter must not be used.
+type SharedFilter struct {
+	*Filter
+	file *os.File
+	data []byte
+}
+
+// OpenShared opens and maps the file at path, which must hold a Filter in the form written by CreateFile,
+// and returns a SharedFilter bound to it. The filter's size must be a multiple of 64 bits,
+// so that its bits occupy whole words in the file, and the machine must be little-endian.
+// OpenShared returns an error on systems that do not support memory mapping.
+func OpenShared(path string) (*SharedFilter, error) {
+	file, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		return nil, err
+	}
+	data, err := mmapWritable(file)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	f, err := sharedFilter(data)
+	if err != nil {
+		munmap(data)
+		file.Close()
+		return nil, err
+	}
+	return &SharedFilter{Filter: f, file: file, data: data}, nil
+}
+
+// sharedFilter returns the Filter in data, in the form written by CreateFile, whose bits alias data.
+func sharedFilter(data []byte) (*Filter, error) {
+	h, b, err := readHeader(data)
+	if err != nil {
+		return nil, err
+	}
+	if h.flags != 0 {
+		return nil, newError(ErrUnsupported, "unsupported format flags for shared filter")
+	}
+	if h.m%64 != 0 {
+		return nil, newError(ErrBadSize, "filter size is not a multiple of 64 bits")
+	}
+	if !nativeLittleEndian {
+		return nil, newError(ErrUnsupported, "shared filters require a little-endian machine")
+	}
+	if uint64(len(b)) != h.m/8 {
+		return nil, newError(ErrCorrupt, "filter size does not match data length")
+	}
+	var w []uint64
+	if len(b) > 0 {
+		w = unsafe.Slice((*uint64)(unsafe.Pointer(&b[0])), len(b)/8)
+	}
+	return &Filter{pages: newPages(w), k: int(h.k), m: int(h.m), hash: Hash(h.hashAlgorithm)}, nil
+}
+
+// Snapshot returns a copy of s's Filter held in memory, which is unaffected by later insertions into s.
+// Unlike Filter.Snapshot, it copies every bit rather than sharing pages with s,
+// so that s's pages remain in the mapping and insertions into s remain visible to other processes.
+// It may be called concurrently with insertions, whose bits it may or may not include.
+func (s *SharedFilter) Snapshot() *Filter {
+	g := newFilter(s.m, s.k, s.hash)
+	g.Union(s.Filter)
+	return g
+}
+
+// Sync commits the file to stable storage. On Linux, this includes the bits set through the mapping;
+// other systems may write them only when the file is unmapped.
+func (s *SharedFilter) Sync() error {
+	return s.file.Sync()
+}
+
+// Close unmaps and closes the file.
+func (s *SharedFilter) Close() error {
+	data := s.data
+	s.data = nil
+	if data == nil {
+		return nil
+	}
+	return errors.Join(munmap(data), s.file.Close())
+}
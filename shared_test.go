@@ -0,0 +1,98 @@
+//go:build unix
+
+package bloom
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func TestOpenShared(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "filter")
+	s, err := CreateFile(path, NewWithHash(1<<16, 4, BitsAndBlooms))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Each SharedFilter maps the file separately, as another process would.
+	a, err := OpenShared(path)
+	if err != nil {
+		t.Fatalf("TestOpenShared: %v", err)
+	}
+	b, err := OpenShared(path)
+	if err != nil {
+		t.Fatalf("TestOpenShared: %v", err)
+	}
+	want := NewWithHash(1<<16, 4, BitsAndBlooms)
+	var wg sync.WaitGroup
+	for g, s := range []*SharedFilter{a, b} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range 100 {
+				s.Insert([]byte(fmt.Sprint(g, i)))
+			}
+		}()
+		for i := range 100 {
+			want.Insert([]byte(fmt.Sprint(g, i)))
+		}
+	}
+	wg.Wait()
+
+	// A snapshot copies the bits, so that insertions after it still reach the other mapping.
+	snap := a.Snapshot()
+	a.Insert([]byte("after snapshot"))
+	want.Insert([]byte("after snapshot"))
+	if !b.MaybeContains([]byte("after snapshot")) {
+		t.Errorf("TestOpenShared: insertion after Snapshot not visible to another mapping")
+	}
+	if snap.MaybeContains([]byte("after snapshot")) {
+		t.Errorf("TestOpenShared: insertion after Snapshot visible in snapshot")
+	}
+	for _, s := range []*SharedFilter{a, b} {
+		if !equal(s.Filter, want) {
+			t.Errorf("TestOpenShared: got %v, want %v", s.Filter, want)
+		}
+		if err := s.Sync(); err != nil {
+			t.Errorf("TestOpenShared: %v", err)
+		}
+		if err := s.Close(); err != nil {
+			t.Errorf("TestOpenShared: %v", err)
+		}
+	}
+	if got := fileContents(t, path); !equal(got, want) {
+		t.Errorf("TestOpenShared: file holds %v, want %v", got, want)
+	}
+
+	for name, data := range map[string][]byte{
+		"checksum":  mustMarshal(NewWithHash(1<<12, 4, BitsAndBlooms)),
+		"size":      fileForm(NewWithHash(100, 4, BitsAndBlooms)),
+		"truncated": fileForm(NewWithHash(1<<12, 4, BitsAndBlooms))[:headerSize+8],
+		"empty":     nil,
+	} {
+		path := filepath.Join(t.TempDir(), "filter")
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if s, err := OpenShared(path); err == nil {
+			t.Errorf("TestOpenShared(%v): got nil error", name)
+			s.Close()
+		}
+	}
+	if _, err := OpenShared(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Errorf("TestOpenShared(missing): got nil error")
+	}
+}
+
+// fileForm returns f in the form written by CreateFile.
+func fileForm(f *Filter) []byte {
+	h := f.header()
+	h.flags = 0
+	return append(appendHeader(nil, h), f.bytes()...)
+}
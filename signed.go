@@ -0,0 +1,107 @@
+package bloom
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+)
+
+// The signed form of a filter consists of the magic number "BLMS", a version byte of value 1,
+// and a byte that identifies the means of protection, followed by:
+//
+//   - for signedHMAC, the binary form produced by MarshalBinary followed by its HMAC-SHA256 tag,
+//     computed over all preceding bytes;
+//   - for signedAESGCM, a 12-byte nonce followed by the binary form encrypted and authenticated with AES-GCM,
+//     with the first 6 bytes as additional authenticated data.
+const (
+	signedMagic   = "BLMS"
+	signedVersion = 1
+	signedHeader  = 6
+)
+
+// Means of protection of the signed form
+const (
+	signedHMAC = iota
+	signedAESGCM
+)
+
+// MarshalSigned marshals f into the binary form produced by MarshalBinary, authenticated with HMAC-SHA256 using key,
+// so that filters distributed to semi-trusted parties cannot be tampered with, for instance to force false positives.
+func (f *Filter) MarshalSigned(key []byte) ([]byte, error) {
+	data, _ := f.MarshalBinary()
+	b := append([]byte(signedMagic), signedVersion, signedHMAC)
+	b = append(b, data...)
+	mac := hmac.New(sha256.New, key)
+	mac.Write(b)
+	return mac.Sum(b), nil
+}
+
+// MarshalEncrypted marshals f into the binary form produced by MarshalBinary, encrypted and authenticated
+// with AES-GCM using key, which must be 16, 24, or 32 bytes long to select AES-128, AES-192, or AES-256.
+func (f *Filter) MarshalEncrypted(key []byte) ([]byte, error) {
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	data, _ := f.MarshalBinary()
+	b := append([]byte(signedMagic), signedVersion, signedAESGCM)
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	b = append(b, nonce...)
+	return aead.Seal(b, nonce, data, b[:signedHeader]), nil
+}
+
+// UnmarshalVerified authenticates data produced by MarshalSigned or MarshalEncrypted using key,
+// decrypting it if necessary, and stores the filter in f.
+// If data fails authentication or is otherwise malformed, UnmarshalVerified returns an error without modifying the contents of f.
+func (f *Filter) UnmarshalVerified(data, key []byte) error {
+	if len(data) < signedHeader || string(data[:len(signedMagic)]) != signedMagic {
+		return newError(ErrCorrupt, "missing magic number")
+	}
+	if data[4] != signedVersion {
+		return newError(ErrUnsupported, "unsupported format version")
+	}
+	var payload []byte
+	switch data[5] {
+	case signedHMAC:
+		if len(data) < signedHeader+sha256.Size {
+			return newError(ErrTruncated, "data too short")
+		}
+		n := len(data) - sha256.Size
+		mac := hmac.New(sha256.New, key)
+		mac.Write(data[:n])
+		if !hmac.Equal(mac.Sum(nil), data[n:]) {
+			return newError(ErrCorrupt, "authentication failed")
+		}
+		payload = data[signedHeader:n]
+	case signedAESGCM:
+		aead, err := newGCM(key)
+		if err != nil {
+			return err
+		}
+		if len(data) < signedHeader+aead.NonceSize()+aead.Overhead() {
+			return newError(ErrTruncated, "data too short")
+		}
+		nonce := data[signedHeader : signedHeader+aead.NonceSize()]
+		payload, err = aead.Open(nil, nonce, data[signedHeader+aead.NonceSize():], data[:signedHeader])
+		if err != nil {
+			return newError(ErrCorrupt, "authentication failed")
+		}
+	default:
+		return newError(ErrUnsupported, "unsupported protection")
+	}
+	return f.UnmarshalBinary(payload)
+}
+
+// newGCM returns an AES-GCM AEAD using key.
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,70 @@
+package bloom
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestSigned(t *testing.T) {
+	key := []byte("0123456789abcdef")
+	f := New(16, 3)
+	f.Insert([]byte("a"))
+	plain, _ := f.MarshalBinary()
+
+	signed, err := f.MarshalSigned(key)
+	if err != nil {
+		t.Fatalf("TestSigned: %v", err)
+	}
+	if !bytes.Contains(signed, plain) {
+		t.Errorf("TestSigned: signed form does not contain binary form")
+	}
+	encrypted, err := f.MarshalEncrypted(key)
+	if err != nil {
+		t.Fatalf("TestSigned: %v", err)
+	}
+	if bytes.Contains(encrypted, plain[:8]) {
+		t.Errorf("TestSigned: encrypted form contains plaintext")
+	}
+	if again, _ := f.MarshalEncrypted(key); bytes.Equal(again, encrypted) {
+		t.Errorf("TestSigned: encryption reused a nonce")
+	}
+
+	for _, data := range [][]byte{signed, encrypted} {
+		g := new(Filter)
+		if err := g.UnmarshalVerified(data, key); err != nil {
+			t.Errorf("TestSigned: %v", err)
+		}
+		if !reflect.DeepEqual(g, f) {
+			t.Errorf("TestSigned: got %v, want %v", g, f)
+		}
+
+		for _, test := range []struct {
+			name string
+			data []byte
+			key  []byte
+		}{
+			{"wrong key", data, []byte("fedcba9876543210")},
+			{"truncated", data[:len(data)-1], key},
+			{"tampered", withByte(data, 30, data[30]^1), key},
+			{"tampered header", withByte(data, 5, data[5]^1), key},
+			{"tampered tag", withByte(data, len(data)-1, data[len(data)-1]^1), key},
+			{"unsupported version", withByte(data, 4, 2), key},
+			{"unsupported protection", withByte(data, 5, 2), key},
+			{"bad magic", withByte(data, 0, 'X'), key},
+			{"header only", data[:6], key},
+		} {
+			g := New(1, 1)
+			if err := g.UnmarshalVerified(test.data, test.key); err == nil {
+				t.Errorf("TestSigned(%v, %v): got nil error", data[5], test.name)
+			}
+			if !reflect.DeepEqual(g, New(1, 1)) {
+				t.Errorf("TestSigned(%v, %v): modified filter: %v", data[5], test.name, g)
+			}
+		}
+	}
+
+	if _, err := f.MarshalEncrypted([]byte("short")); err == nil {
+		t.Errorf("TestSigned: encrypted with an invalid key")
+	}
+}
@@ -0,0 +1,26 @@
+package bloom
+
+// Snapshot returns a Filter that holds f's current contents and is unaffected by later changes to f,
+// so that readers may query it while writers continue to insert into f.
+// Snapshot does not copy f's bits: f and the snapshot share them in pages of 32768 bits,
+// and whichever of them first modifies a shared page copies it, so that taking a snapshot of a large filter is cheap
+// and the cost of copying is proportional to the number of pages modified afterward.
+// Snapshot may be called concurrently with MaybeContains and the methods that marshal or export f,
+// but not with Insert, Union, ApplyDelta, or methods that replace f's contents;
+// a SyncFilter's Snapshot method provides the necessary exclusion.
+func (f *Filter) Snapshot() *Filter {
+	f.markShared()
+	g := *f
+	g.pages = make([]*page, len(f.pages))
+	for i := range f.pages {
+		g.pages[i] = f.page(i)
+	}
+	return &g
+}
+
+// markShared marks each of f's pages as shared, so that it is copied before it is next modified.
+func (f *Filter) markShared() {
+	for i := range f.pages {
+		f.page(i).shared.Store(true)
+	}
+}
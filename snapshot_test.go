@@ -0,0 +1,104 @@
+package bloom
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+// equal reports whether f and g have the same parameters and bits.
+func equal(f, g *Filter) bool {
+	return f.k == g.k && f.m == g.m && f.hash == g.hash && string(f.bytes()) == string(g.bytes())
+}
+
+func TestSnapshot(t *testing.T) {
+	for _, m := range []int{64, pageWords * 64, 3*pageWords*64 + 64} {
+		f := NewWithHash(m, 4, BitsAndBlooms)
+		for i := range 100 {
+			f.Insert([]byte(fmt.Sprint("before", i)))
+		}
+		want := NewWithHash(m, 4, BitsAndBlooms)
+		want.Union(f)
+
+		s := f.Snapshot()
+		for i := range 100 {
+			f.Insert([]byte(fmt.Sprint("after", i)))
+		}
+		if !equal(s, want) {
+			t.Errorf("TestSnapshot(%v): snapshot modified by insertion into filter", m)
+		}
+		for i := range 100 {
+			if !f.MaybeContains([]byte(fmt.Sprint("after", i))) {
+				t.Errorf("TestSnapshot(%v): inserted item %v not found", m, i)
+			}
+		}
+
+		// Modifying the snapshot does not affect the filter.
+		g := NewWithHash(m, 4, BitsAndBlooms)
+		g.Union(f)
+		s.Insert([]byte("snapshot"))
+		if !equal(f, g) {
+			t.Errorf("TestSnapshot(%v): filter modified by insertion into snapshot", m)
+		}
+		if !s.MaybeContains([]byte("snapshot")) {
+			t.Errorf("TestSnapshot(%v): item inserted into snapshot not found", m)
+		}
+	}
+}
+
+func TestSnapshotSharesPages(t *testing.T) {
+	f := NewWithHash(4*pageWords*64, 4, BitsAndBlooms)
+	s := f.Snapshot()
+	f.setBit(0)
+	if s.bit(0) != 0 {
+		t.Errorf("TestSnapshotSharesPages: snapshot modified")
+	}
+	for i := range f.pages {
+		if got, want := f.page(i) == s.page(i), i != 0; got != want {
+			t.Errorf("TestSnapshotSharesPages(%v): got sharing %v, want %v", i, got, want)
+		}
+	}
+}
+
+func TestSnapshotConcurrentInsert(t *testing.T) {
+	f := NewWithHash(2*pageWords*64, 4, BitsAndBlooms)
+	s := f.Snapshot()
+	var wg sync.WaitGroup
+	for g := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range 100 {
+				f.Insert([]byte(fmt.Sprint(g, i)))
+				s.MaybeContains([]byte(fmt.Sprint(g, i)))
+			}
+		}()
+	}
+	wg.Wait()
+
+	want := NewWithHash(2*pageWords*64, 4, BitsAndBlooms)
+	for g := range 8 {
+		for i := range 100 {
+			want.Insert([]byte(fmt.Sprint(g, i)))
+		}
+	}
+	if !equal(f, want) {
+		t.Errorf("TestSnapshotConcurrentInsert: insertions lost while copying pages")
+	}
+	if !equal(s, NewWithHash(2*pageWords*64, 4, BitsAndBlooms)) {
+		t.Errorf("TestSnapshotConcurrentInsert: snapshot modified")
+	}
+}
+
+func TestSnapshotRedundantInsert(t *testing.T) {
+	// Inserting an item already in the filter does not copy its pages.
+	f := NewWithHash(4*pageWords*64, 4, BitsAndBlooms)
+	f.Insert([]byte("item"))
+	s := f.Snapshot()
+	f.Insert([]byte("item"))
+	for i := range f.pages {
+		if f.page(i) != s.page(i) {
+			t.Errorf("TestSnapshotRedundantInsert(%v): page copied", i)
+		}
+	}
+}
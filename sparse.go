@@ -0,0 +1,69 @@
+package bloom
+
+import (
+	"encoding/binary"
+	"io"
+	"math/bits"
+)
+
+// appendSparse appends to dst the sparse encoding of the filter bits b:
+// the number of set bits, followed by the position of each set bit in increasing order,
+// each expressed as the difference from the position of the previous set bit (or from 0 for the first),
+// all encoded as unsigned varints.
+// If the encoding would be at least limit bytes long, appendSparse returns nil.
+func appendSparse(dst, b []byte, limit int) []byte {
+	var count int
+	for _, c := range b {
+		count += bits.OnesCount8(c)
+	}
+	start := len(dst)
+	dst = binary.AppendUvarint(dst, uint64(count))
+	prev := 0
+	for i, c := range b {
+		for ; c != 0; c &= c - 1 {
+			n := i*8 + bits.TrailingZeros8(c)
+			dst = binary.AppendUvarint(dst, uint64(n-prev))
+			prev = n
+			if len(dst)-start >= limit {
+				return nil
+			}
+		}
+	}
+	if len(dst)-start >= limit {
+		return nil
+	}
+	return dst
+}
+
+// readSparse reads the sparse encoding of the bits of a filter of m bits from r and appends to b[:0]
+// the bits up to the last byte that has a bit set. The result is shorter than the filter's bits if their final bytes
+// are zero: it grows only as positions are read, so that a filter's claimed size is not allocated for data that ends early.
+func readSparse(r io.ByteReader, b []byte, m uint64) ([]byte, error) {
+	b = b[:0]
+	count, err := binary.ReadUvarint(r)
+	if err != nil {
+		return nil, err
+	}
+	if count > m {
+		return nil, newError(ErrCorrupt, "sparse bit count out of range")
+	}
+	var n uint64
+	for i := uint64(0); i < count; i++ {
+		d, err := binary.ReadUvarint(r)
+		if err != nil {
+			return nil, err
+		}
+		if i > 0 && d == 0 {
+			return nil, newError(ErrCorrupt, "sparse bit positions not increasing")
+		}
+		if d >= m-n {
+			return nil, newError(ErrCorrupt, "sparse bit position out of range")
+		}
+		n += d
+		if i := int(n / 8); i >= len(b) {
+			b = append(b, make([]byte, i+1-len(b))...)
+		}
+		b[n/8] |= 1 << (n % 8)
+	}
+	return b, nil
+}
@@ -0,0 +1,32 @@
+package bloom
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+)
+
+// Value returns the binary form of f produced by MarshalBinary, for storage in a BLOB or bytea column,
+// or nil, which is stored as NULL, if f is nil. It satisfies the driver.Valuer interface.
+func (f *Filter) Value() (driver.Value, error) {
+	if f == nil {
+		return nil, nil
+	}
+	return f.MarshalBinary()
+}
+
+// Scan stores in f the filter in src, which must be a byte slice or string in a form accepted by UnmarshalBinary.
+// It returns an error without modifying the contents of f if src is NULL or is not a valid filter.
+// Scan satisfies the sql.Scanner interface.
+func (f *Filter) Scan(src any) error {
+	switch src := src.(type) {
+	case []byte:
+		return f.UnmarshalBinary(src)
+	case string:
+		return f.UnmarshalBinary([]byte(src))
+	case nil:
+		return errors.New("cannot scan NULL into Filter")
+	default:
+		return fmt.Errorf("cannot scan %T into Filter", src)
+	}
+}
@@ -0,0 +1,47 @@
+package bloom
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"reflect"
+	"testing"
+)
+
+var (
+	_ sql.Scanner   = (*Filter)(nil)
+	_ driver.Valuer = (*Filter)(nil)
+)
+
+func TestSQL(t *testing.T) {
+	f := New(8, 3)
+	f.Insert([]byte("a"))
+	v, err := f.Value()
+	if err != nil {
+		t.Fatalf("TestSQL: %v", err)
+	}
+	if !driver.IsValue(v) {
+		t.Errorf("TestSQL: %T is not a driver.Value", v)
+	}
+	for _, src := range []any{v, string(v.([]byte))} {
+		g := new(Filter)
+		if err := g.Scan(src); err != nil {
+			t.Errorf("TestSQL(%T): %v", src, err)
+		}
+		if !reflect.DeepEqual(g, f) {
+			t.Errorf("TestSQL(%T): got %v, want %v", src, g, f)
+		}
+	}
+
+	if v, err := (*Filter)(nil).Value(); v != nil || err != nil {
+		t.Errorf("TestSQL(nil): got %v, %v", v, err)
+	}
+	for _, src := range []any{nil, 42, []byte{}, []byte("BLMF")} {
+		g := New(1, 1)
+		if err := g.Scan(src); err == nil {
+			t.Errorf("TestSQL(%v): got nil error", src)
+		}
+		if !reflect.DeepEqual(g, New(1, 1)) {
+			t.Errorf("TestSQL(%v): modified filter: %v", src, g)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package bloom
+
+import (
+	"math"
+	"math/bits"
+	"sync/atomic"
+)
+
+// BitCount returns the number of bits of f that are set.
+// It counts a word at a time, and may be called concurrently with the same methods as MaybeContains.
+func (f *Filter) BitCount() int {
+	var n int
+	for i := range f.pages {
+		p := f.page(i)
+		for j := range p.w {
+			n += bits.OnesCount64(atomic.LoadUint64(&p.w[j]))
+		}
+	}
+	return n
+}
+
+// FillRatio returns the fraction of f's bits that are set, from 0 for an empty filter to 1 for a saturated one.
+// It returns 0 for a filter of size 0.
+func (f *Filter) FillRatio() float64 {
+	if f.m == 0 {
+		return 0
+	}
+	return float64(f.BitCount()) / float64(f.m)
+}
+
+// EstimatedItems returns an estimate of the number of distinct items inserted into f, derived from the number of
+// bits that are set by the method of Swamidass and Baldi. It returns +Inf if every bit is set, and 0 for a zero Filter.
+func (f *Filter) EstimatedItems() float64 {
+	if f.k == 0 {
+		return 0
+	}
+	return estimatedItems(f.m, f.k, f.BitCount())
+}
+
+// estimatedItems returns the estimated number of items in a filter of m bits using k hash values with x bits set.
+func estimatedItems(m, k, x int) float64 {
+	return -float64(m) / float64(k) * math.Log1p(-float64(x)/float64(m))
+}
+
+// EstimatedFalsePositiveRate returns the probability that MaybeContains reports an item that was not inserted
+// into f, estimated from the fraction of f's bits that are set as that fraction raised to the number of hash values.
+// It returns 0 for a zero Filter.
+func (f *Filter) EstimatedFalsePositiveRate() float64 {
+	if f.k == 0 {
+		return 0
+	}
+	return math.Pow(f.FillRatio(), float64(f.k))
+}
+
+// A Stats reports a Filter's parameters and estimates of its contents, as returned by Stats.
+type Stats struct {
+	Size              int  // the number of bits
+	HashValues        int  // the number of hash values derived for each item
+	HashAlgorithm     Hash // the hash algorithm
+	BitCount          int  // the number of bits that are set
+	FillRatio         float64
+	EstimatedItems    float64
+	FalsePositiveRate float64 // the estimated false positive rate
+}
+
+// Stats returns f's parameters and estimates of its contents, counting its set bits once.
+// It may be called concurrently with the same methods as MaybeContains.
+func (f *Filter) Stats() Stats {
+	st := Stats{Size: f.m, HashValues: f.k, HashAlgorithm: f.hash, BitCount: f.BitCount()}
+	if f.k != 0 {
+		st.FillRatio = float64(st.BitCount) / float64(f.m)
+		st.EstimatedItems = estimatedItems(f.m, f.k, st.BitCount)
+		st.FalsePositiveRate = math.Pow(st.FillRatio, float64(f.k))
+	}
+	return st
+}
+
+// Size returns the number of bits in f.
+func (f *Filter) Size() int { return f.m }
+
+// HashValues returns the number of hash values f derives for each item.
+func (f *Filter) HashValues() int { return f.k }
+
+// HashAlgorithm returns the hash algorithm f uses to derive hash values.
+func (f *Filter) HashAlgorithm() Hash { return f.hash }
@@ -0,0 +1,94 @@
+package bloom
+
+import (
+	"math"
+	"testing"
+)
+
+var statsTests = []struct {
+	f     *Filter
+	count int
+	ratio float64
+}{
+	{&Filter{}, 0, 0},
+	{filter(1, 0x00), 0, 0},
+	{filter(1, 0x01), 1, 0.125},
+	{filter(1, 0xff), 8, 1},
+	{filter(1, 0x01, 0x80, 0x00, 0xff), 10, 10.0 / 32},
+	{filter(1, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x0f), 68, 68.0 / 72},
+}
+
+func TestBitCount(t *testing.T) {
+	for _, test := range statsTests {
+		if got := test.f.BitCount(); got != test.count {
+			t.Errorf("TestBitCount(%v): got %v, want %v", test.f, got, test.count)
+		}
+	}
+
+	f := NewWithHash(3*pageWords*64, 3, BitsAndBlooms)
+	for _, n := range []int{0, 63, 64, pageWords * 64, 3*pageWords*64 - 1} {
+		f.setBit(n)
+	}
+	if got := f.BitCount(); got != 5 {
+		t.Errorf("TestBitCount(%v): got %v, want 5", f.m, got)
+	}
+}
+
+func TestFillRatio(t *testing.T) {
+	for _, test := range statsTests {
+		if got := test.f.FillRatio(); got != test.ratio {
+			t.Errorf("TestFillRatio(%v): got %v, want %v", test.f, got, test.ratio)
+		}
+	}
+}
+
+func TestParams(t *testing.T) {
+	f := NewWithHash(1024, 7, GuavaMitz64)
+	if got := f.Size(); got != 1024 {
+		t.Errorf("TestParams: Size: got %v, want 1024", got)
+	}
+	if got := f.HashValues(); got != 7 {
+		t.Errorf("TestParams: HashValues: got %v, want 7", got)
+	}
+	if got := f.HashAlgorithm(); got != GuavaMitz64 {
+		t.Errorf("TestParams: HashAlgorithm: got %v, want %v", got, GuavaMitz64)
+	}
+}
+
+func TestEstimates(t *testing.T) {
+	f := NewWithHash(1<<16, 5, BitsAndBlooms)
+	if n, p := f.EstimatedItems(), f.EstimatedFalsePositiveRate(); n != 0 || p != 0 {
+		t.Errorf("TestEstimates(empty): got %v items, rate %v, want 0, 0", n, p)
+	}
+	for i := range 2000 {
+		f.InsertUint64(uint64(i))
+	}
+	if n := f.EstimatedItems(); n < 1900 || n > 2100 {
+		t.Errorf("TestEstimates: got %v items, want about 2000", n)
+	}
+	if got, want := f.EstimatedFalsePositiveRate(), math.Pow(f.FillRatio(), 5); got != want {
+		t.Errorf("TestEstimates: got rate %v, want %v", got, want)
+	}
+	st := f.Stats()
+	want := Stats{
+		Size:              1 << 16,
+		HashValues:        5,
+		HashAlgorithm:     BitsAndBlooms,
+		BitCount:          f.BitCount(),
+		FillRatio:         f.FillRatio(),
+		EstimatedItems:    f.EstimatedItems(),
+		FalsePositiveRate: f.EstimatedFalsePositiveRate(),
+	}
+	if st != want {
+		t.Errorf("TestEstimates: Stats got %+v, want %+v", st, want)
+	}
+
+	full := filter(1, 0xff)
+	if n := full.EstimatedItems(); !math.IsInf(n, 1) {
+		t.Errorf("TestEstimates(full): got %v items, want +Inf", n)
+	}
+	var zero Filter
+	if st := zero.Stats(); st != (Stats{}) || zero.EstimatedItems() != 0 || zero.EstimatedFalsePositiveRate() != 0 {
+		t.Errorf("TestEstimates(zero): got %+v", st)
+	}
+}
@@ -0,0 +1,68 @@
+package bloom
+
+import (
+	"bytes"
+	"encoding/binary"
+)
+
+// Summary returns a summary of f's bits for synchronizing a replica of f with another by MarshalMissing:
+// the header of the binary form, with no flags, followed by blockSize as a uvarint and, for each successive
+// blockSize bytes of the bits in the order of the binary form, the big-endian 64-bit xxHash64 of those bytes.
+// A summary is 8/blockSize the size of the filter's bits, so a larger blockSize makes a smaller summary
+// but may cause the response to include more bits that the replica already has.
+// Summary panics if blockSize is not positive.
+func (f *Filter) Summary(blockSize int) []byte {
+	if blockSize <= 0 {
+		panic("bloom: summary block size must be positive")
+	}
+	h := f.header()
+	h.flags = 0
+	b := f.bytes()
+	s := appendHeader(make([]byte, 0, headerSize+binary.MaxVarintLen64+(len(b)+blockSize-1)/blockSize*8), h)
+	s = binary.AppendUvarint(s, uint64(blockSize))
+	for i := 0; i < len(b); i += blockSize {
+		s = binary.BigEndian.AppendUint64(s, xxhash64(b[i:min(i+blockSize, len(b))]))
+	}
+	return s
+}
+
+// MarshalMissing returns a delta, to be applied with ApplyDelta, of the bits set in f within each block
+// whose hash in summary, produced by another replica's Summary method, differs from that of f's bits.
+// The delta omits the blocks that the replicas hold in common, so two mostly identical filters are synchronized
+// by transferring their summary and the few differing blocks rather than either filter in its entirety.
+// In sparse encoding, the delta is no larger than the set bits of the differing blocks require.
+// MarshalMissing returns an error if summary is malformed or describes a filter
+// that differs from f in size, number of hash values, or hash algorithm.
+func (f *Filter) MarshalMissing(summary []byte) ([]byte, error) {
+	h, rest, err := readHeader(summary)
+	if err != nil {
+		return nil, err
+	}
+	if h.flags != 0 {
+		return nil, newError(ErrUnsupported, "unsupported format flags")
+	}
+	if Hash(h.hashAlgorithm) != f.hash || int(h.k) != f.k || h.m != uint64(f.m) {
+		return nil, newError(ErrIncompatible, "incompatible filters")
+	}
+	blockSize, n := binary.Uvarint(rest)
+	if n <= 0 || blockSize == 0 {
+		return nil, newError(ErrCorrupt, "invalid summary block size")
+	}
+	rest = rest[n:]
+	b := f.bytes()
+	bs := int(min(blockSize, uint64(len(b))))
+	if len(rest) != (len(b)+bs-1)/bs*8 {
+		return nil, newError(ErrCorrupt, "summary length does not match filter size")
+	}
+	for i := 0; i < len(b); i += bs {
+		block := b[i:min(i+bs, len(b))]
+		if binary.BigEndian.Uint64(rest[i/bs*8:]) == xxhash64(block) {
+			clear(block)
+		}
+	}
+	h = f.header()
+	h.flags |= flagDelta
+	var buf bytes.Buffer
+	encode(&buf, h, b)
+	return buf.Bytes(), nil
+}
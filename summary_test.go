@@ -0,0 +1,95 @@
+package bloom
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestMarshalMissing(t *testing.T) {
+	const m = 1 << 20
+	for _, blockSize := range []int{1, 100, 1024, m / 8, m} {
+		f, replica := NewWithHash(m, 4, BitsAndBlooms), NewWithHash(m, 4, BitsAndBlooms)
+		for i := range 100000 {
+			item := []byte(fmt.Sprint(i))
+			f.Insert(item)
+			replica.Insert(item)
+		}
+		// Each holds some items the other lacks.
+		for i := range 2 {
+			f.Insert([]byte(fmt.Sprint("f", i)))
+			replica.Insert([]byte(fmt.Sprint("r", i)))
+		}
+		want := f.Snapshot()
+		want.Union(replica)
+
+		summary := replica.Summary(blockSize)
+		data, err := f.MarshalMissing(summary)
+		if err != nil {
+			t.Fatalf("TestMarshalMissing(%v): %v", blockSize, err)
+		}
+		full, _ := f.MarshalBinary()
+		if blockSize == 1024 && len(summary)+len(data) >= len(full)/4 {
+			t.Errorf("TestMarshalMissing(%v): summary of %v bytes and delta of %v bytes, full filter of %v bytes",
+				blockSize, len(summary), len(data), len(full))
+		}
+		if err := replica.ApplyDelta(data); err != nil {
+			t.Fatalf("TestMarshalMissing(%v): %v", blockSize, err)
+		}
+		if !equal(replica, want) {
+			t.Errorf("TestMarshalMissing(%v): got %v, want %v", blockSize, replica, want)
+		}
+
+		// The replicas now differ only in bits f lacks, and a second exchange completes the synchronization.
+		data, err = replica.MarshalMissing(f.Summary(blockSize))
+		if err != nil {
+			t.Fatalf("TestMarshalMissing(%v): %v", blockSize, err)
+		}
+		if err := f.ApplyDelta(data); err != nil {
+			t.Fatalf("TestMarshalMissing(%v): %v", blockSize, err)
+		}
+		if !equal(f, want) {
+			t.Errorf("TestMarshalMissing(%v): got %v, want %v", blockSize, f, want)
+		}
+		if data, _ := f.MarshalMissing(replica.Summary(blockSize)); len(data) >= len(full)/8 {
+			t.Errorf("TestMarshalMissing(%v): delta of %v bytes between equal filters", blockSize, len(data))
+		}
+	}
+}
+
+func TestMarshalMissingErrors(t *testing.T) {
+	f := New(1024, 4)
+	summary := f.Summary(16)
+	full := mustMarshal(f)
+	for i, test := range []struct {
+		f    *Filter
+		data []byte
+	}{
+		{New(2048, 4), summary},
+		{New(1024, 3), summary},
+		{NewWithHash(1024*8, 4, BitsAndBlooms), summary},
+		{f, summary[:len(summary)-1]},
+		{f, append(summary, 0)},
+		{f, full},
+		{f, summary[:headerSize]},
+		{f, append(append([]byte{}, summary[:headerSize]...), 0)},
+		{f, nil},
+	} {
+		if _, err := test.f.MarshalMissing(test.data); err == nil {
+			t.Errorf("TestMarshalMissingErrors(%v): got nil error", i)
+		}
+	}
+	g := f.Snapshot()
+	if err := g.UnmarshalBinary(summary); err == nil || !reflect.DeepEqual(g.bytes(), f.bytes()) {
+		t.Errorf("TestMarshalMissingErrors: UnmarshalBinary accepted summary")
+	}
+}
+
+func TestSummaryPanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("TestSummaryPanics: did not panic")
+		}
+	}()
+	New(1024, 4).Summary(0)
+}
@@ -0,0 +1,311 @@
+package bloom
+
+import (
+	"io"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+)
+
+// A SyncFilter is a Filter that is safe for concurrent use by multiple goroutines, including UnmarshalBinary.
+// Because a Filter sets and reads its bits atomically, insertions and lookups proceed concurrently with each other;
+// only UnmarshalBinary, which replaces the Filter, excludes other insertions.
+// Lookups take no lock: each loads the current Filter once and makes all its probes in it,
+// so that they neither delay nor are delayed by writers, and each reflects the contents of a single Filter.
+// A SyncFilter must be created by NewSyncFilter or populated by UnmarshalBinary before use.
+type SyncFilter struct {
+	mu      sync.RWMutex
+	f       atomic.Pointer[Filter]
+	stripes [syncStripes]sync.Mutex // serialize TestAndInsert calls for the same item
+
+	opts    syncOptions
+	inserts atomic.Uint64 // counted if opts.counts
+	queries atomic.Uint64
+	bits    atomic.Int64 // the number of bits set in the Filter, tracked if opts.logger != nil
+}
+
+// A SyncOption configures NewSyncFilter.
+type SyncOption func(*syncOptions)
+
+type syncOptions struct {
+	counts   bool
+	onInsert func(item []byte, novel int)
+	onQuery  func(item []byte, result bool)
+	logger   *slog.Logger
+}
+
+// WithCounts enables the counts of insertions and lookups reported by Counts.
+// Counting adds an atomic increment of a shared word to each insertion and lookup,
+// which can limit their throughput when many goroutines call them at once.
+func WithCounts() SyncOption {
+	return func(o *syncOptions) { o.counts = true }
+}
+
+// WithInsertHook sets a function that Insert and TestAndInsert call after inserting an item, with the item
+// and the number of its bits that were not set beforehand, so that insertions can be audited, sampled,
+// or mirrored to another store. A novel count of 0 means that the item was probably in the set already.
+// The function is called on the inserting goroutine, possibly concurrently with other calls,
+// and must not retain item, whose memory belongs to the caller of Insert.
+func WithInsertHook(fn func(item []byte, novel int)) SyncOption {
+	return func(o *syncOptions) { o.onInsert = fn }
+}
+
+// WithQueryHook sets a function that MaybeContains calls with each item it looks up and its result,
+// on the calling goroutine, possibly concurrently with other calls. The function must not retain item.
+func WithQueryHook(fn func(item []byte, result bool)) SyncOption {
+	return func(o *syncOptions) { o.onQuery = fn }
+}
+
+// WithLogger sets a logger to which s reports operational events as structured records:
+// a warning when the fraction of its bits that are set first reaches each of 0.5, 0.75, and 0.9,
+// past which its false positive rate exceeds that for which an optimally sized filter is designed;
+// a warning when UnmarshalBinary, Union, or ApplyDelta fails; and a debug record when Union or ApplyDelta
+// merges bits into s and an informational record when UnmarshalBinary or Reset replaces its Filter.
+// Tracking the number of bits that are set counts the bits newly set by each insertion,
+// and a pass over the bits after each merge and replacement.
+func WithLogger(logger *slog.Logger) SyncOption {
+	return func(o *syncOptions) { o.logger = logger }
+}
+
+// saturationThresholds are the fill ratios whose crossing a SyncFilter with a logger reports.
+var saturationThresholds = []float64{0.5, 0.75, 0.9}
+
+// syncStripes is the number of locks among which a SyncFilter divides calls to TestAndInsert.
+const syncStripes = 64
+
+// NewSyncFilter returns a SyncFilter that takes ownership of f.
+// The caller must not use f after calling NewSyncFilter.
+func NewSyncFilter(f *Filter, opts ...SyncOption) *SyncFilter {
+	s := new(SyncFilter)
+	for _, opt := range opts {
+		opt(&s.opts)
+	}
+	s.f.Store(f)
+	if s.opts.logger != nil {
+		s.bits.Store(int64(f.BitCount()))
+	}
+	return s
+}
+
+// Insert inserts item into s's set.
+func (s *SyncFilter) Insert(item []byte) {
+	novel := s.insert(item)
+	if s.opts.counts {
+		s.inserts.Add(1)
+	}
+	if s.opts.onInsert != nil {
+		s.opts.onInsert(item, novel)
+	}
+}
+
+// insert inserts item into s's Filter. If s has an insert hook or a logger, it returns the number of bits
+// newly set; otherwise it does not count them, so as to leave bits that are already set unwritten, and returns 0.
+func (s *SyncFilter) insert(item []byte) int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	f := s.f.Load()
+	if s.opts.onInsert == nil && s.opts.logger == nil {
+		f.Insert(item)
+		return 0
+	}
+	d := f.hash.digest(item)
+	novel := f.insertNovel(&d)
+	s.addBits(f, novel)
+	return novel
+}
+
+// TestAndInsert inserts item into s's set and reports whether it was probably in the set beforehand,
+// in the manner of MaybeContains. Of concurrent calls with the same item that was not in the set,
+// exactly one returns false, so that callers deduplicating items do not process an item twice.
+// Calls with different items proceed concurrently with each other and with Insert and MaybeContains.
+func (s *SyncFilter) TestAndInsert(item []byte) bool {
+	novel := s.testAndInsert(item)
+	if s.opts.counts {
+		s.inserts.Add(1)
+	}
+	if s.opts.onInsert != nil {
+		s.opts.onInsert(item, novel)
+	}
+	return novel == 0
+}
+
+// testAndInsert inserts item into s's Filter, excluding concurrent calls with the same item,
+// and returns the number of bits newly set.
+func (s *SyncFilter) testAndInsert(item []byte) int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	f := s.f.Load()
+	d := f.hash.digest(item)
+	mu := &s.stripes[d[0]%syncStripes]
+	mu.Lock()
+	defer mu.Unlock()
+	novel := f.insertNovel(&d)
+	s.addBits(f, novel)
+	return novel
+}
+
+// addBits adds novel to the number of bits set in s's Filter f, if s tracks it, and logs any saturation threshold
+// that the number crosses. The caller must hold s.mu for reading.
+func (s *SyncFilter) addBits(f *Filter, novel int) {
+	if s.opts.logger == nil || novel == 0 {
+		return
+	}
+	n := s.bits.Add(int64(novel))
+	s.logSaturation(f, n-int64(novel), n)
+}
+
+// logSaturation logs each saturation threshold that the number of bits set in s's Filter f crossed
+// in increasing from before to after.
+func (s *SyncFilter) logSaturation(f *Filter, before, after int64) {
+	for _, r := range saturationThresholds {
+		if t := r * float64(f.m); float64(before) < t && t <= float64(after) {
+			s.opts.logger.Warn("bloom filter saturation threshold crossed",
+				"threshold", r,
+				"fillRatio", float64(after)/float64(f.m),
+				"size", f.m,
+				"hashValues", f.k,
+				"estimatedItems", estimatedItems(f.m, f.k, int(after)),
+			)
+		}
+	}
+}
+
+// MaybeContains reports whether item is probably in s's set, in the manner of Filter.MaybeContains.
+// Its k probes observe a single Filter, the one current when it is called, even if the Filter is replaced
+// while they are made. Insertions need not be excluded, because they only set bits: a lookup concurrent with them
+// reports the membership of item at the moment of its last probe.
+func (s *SyncFilter) MaybeContains(item []byte) bool {
+	ok := s.f.Load().MaybeContains(item)
+	if s.opts.counts {
+		s.queries.Add(1)
+	}
+	if s.opts.onQuery != nil {
+		s.opts.onQuery(item, ok)
+	}
+	return ok
+}
+
+// Counts returns the number of items that have been inserted into s by Insert and TestAndInsert
+// and the number of lookups by MaybeContains, if s was created with WithCounts, and reports whether it was.
+// The counts include repeated items and are not reset when s's Filter is replaced.
+func (s *SyncFilter) Counts() (inserts, queries uint64, ok bool) {
+	return s.inserts.Load(), s.queries.Load(), s.opts.counts
+}
+
+// Stats returns the parameters of s's Filter and estimates of its contents, in the manner of Filter.Stats.
+// It takes no lock, and so may be called at any time.
+func (s *SyncFilter) Stats() Stats {
+	return s.f.Load().Stats()
+}
+
+// Size returns the number of bits in s's Filter. It takes no lock.
+func (s *SyncFilter) Size() int {
+	return s.f.Load().Size()
+}
+
+// Union inserts into s every item in g's set, in the manner of Filter.Union.
+// To merge another SyncFilter, pass its Snapshot.
+func (s *SyncFilter) Union(g *Filter) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	f := s.f.Load()
+	err := f.Union(g)
+	s.logMerge(f, "union", err)
+	return err
+}
+
+// ApplyDelta sets the bits of s recorded in data, in the manner of Filter.ApplyDelta.
+func (s *SyncFilter) ApplyDelta(data []byte) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	f := s.f.Load()
+	err := f.ApplyDelta(data)
+	s.logMerge(f, "delta", err)
+	return err
+}
+
+// logMerge logs the result err of a merge of the given kind into s's Filter f, if s has a logger,
+// and recounts the bits that are set. The caller must hold s.mu for reading.
+func (s *SyncFilter) logMerge(f *Filter, kind string, err error) {
+	if s.opts.logger == nil {
+		return
+	}
+	if err != nil {
+		s.opts.logger.Warn("bloom filter merge failed", "kind", kind, "error", err)
+		return
+	}
+	// Insertions concurrent with the merge may be counted twice or not at all; the next merge or replacement corrects them.
+	after := int64(f.BitCount())
+	before := s.bits.Swap(after)
+	s.opts.logger.Debug("bloom filter merged", "kind", kind, "bitCount", after, "size", f.m)
+	s.logSaturation(f, before, after)
+}
+
+// Snapshot returns a copy of s's current Filter, in the manner of Filter.Snapshot.
+func (s *SyncFilter) Snapshot() *Filter {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.f.Load().Snapshot()
+}
+
+// MarshalBinary marshals s in the binary form produced by Filter.MarshalBinary.
+// It satisfies the encoding.BinaryMarshaler interface.
+func (s *SyncFilter) MarshalBinary() ([]byte, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.f.Load().MarshalBinary()
+}
+
+// WriteTo writes s's current Filter to w in the manner of Filter.WriteTo. It takes no lock and copies no page,
+// so that it neither delays nor is delayed by insertions, nor makes later insertions copy pages as Snapshot does;
+// the bits of items inserted while it writes may or may not be included.
+// It satisfies the io.WriterTo interface.
+func (s *SyncFilter) WriteTo(w io.Writer) (int64, error) {
+	return s.f.Load().WriteTo(w)
+}
+
+// UnmarshalBinary replaces s's Filter with the one in data, in the manner of Filter.UnmarshalBinary.
+// It satisfies the encoding.BinaryUnmarshaler interface.
+func (s *SyncFilter) UnmarshalBinary(data []byte) error {
+	g := new(Filter)
+	if err := g.UnmarshalBinary(data); err != nil {
+		if s.opts.logger != nil {
+			s.opts.logger.Warn("bloom filter unmarshal failed", "length", len(data), "error", err)
+		}
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.replace(g, "unmarshal")
+	return nil
+}
+
+// Reset empties s's set, by replacing its Filter with a new one of the same size, number of hash values,
+// and hash algorithm rather than clearing its bits, so that lookups are not delayed by a pass over the bits.
+// Lookups in progress complete against either the old Filter or the new one, and insertions in progress
+// complete before the replacement.
+func (s *SyncFilter) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f := s.f.Load()
+	s.replace(newFilter(f.m, f.k, f.hash), "reset")
+}
+
+// replace replaces s's Filter with g, logging the replacement for the given reason if s has a logger.
+// The caller must hold s.mu for writing.
+func (s *SyncFilter) replace(g *Filter, reason string) {
+	s.f.Store(g)
+	if s.opts.logger == nil {
+		return
+	}
+	n := int64(g.BitCount())
+	before := s.bits.Swap(n)
+	s.opts.logger.Info("bloom filter replaced",
+		"reason", reason,
+		"previousBitCount", before,
+		"size", g.m,
+		"hashValues", g.k,
+		"hashAlgorithm", g.hash.String(),
+		"bitCount", n,
+	)
+}
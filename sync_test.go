@@ -0,0 +1,360 @@
+package bloom
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestSyncFilter(t *testing.T) {
+	s := NewSyncFilter(New(1024, 4))
+	var wg sync.WaitGroup
+	for g := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range 100 {
+				item := []byte(fmt.Sprint(g, i))
+				s.Insert(item)
+				if !s.MaybeContains(item) {
+					t.Errorf("TestSyncFilter(%v, %v): inserted item not found", g, i)
+				}
+				s.MarshalBinary()
+			}
+		}()
+	}
+	wg.Wait()
+
+	// The result does not depend on the interleaving of insertions.
+	want := New(1024, 4)
+	for g := range 8 {
+		for i := range 100 {
+			want.Insert([]byte(fmt.Sprint(g, i)))
+		}
+	}
+	snap := s.Snapshot()
+	if !equal(snap, want) {
+		t.Errorf("TestSyncFilter: got %v, want %v", snap, want)
+	}
+	snap.Insert([]byte("not in s"))
+	if s.MaybeContains([]byte("not in s")) && !want.MaybeContains([]byte("not in s")) {
+		t.Errorf("TestSyncFilter: snapshot shares storage with s")
+	}
+
+	other := New(1024, 4)
+	other.Insert([]byte("other"))
+	if err := s.Union(other); err != nil {
+		t.Errorf("TestSyncFilter: %v", err)
+	}
+	if !s.MaybeContains([]byte("other")) {
+		t.Errorf("TestSyncFilter: union does not contain item")
+	}
+	if err := s.Union(New(1, 1)); err == nil {
+		t.Errorf("TestSyncFilter: union with incompatible filter: got nil error")
+	}
+
+	data, err := s.MarshalBinary()
+	if err != nil {
+		t.Errorf("TestSyncFilter: %v", err)
+	}
+	var u SyncFilter
+	if err := u.UnmarshalBinary(data); err != nil {
+		t.Errorf("TestSyncFilter: %v", err)
+	}
+	if !reflect.DeepEqual(u.Snapshot(), s.Snapshot()) {
+		t.Errorf("TestSyncFilter: got %v, want %v", u.Snapshot(), s.Snapshot())
+	}
+	if err := u.UnmarshalBinary(nil); err == nil {
+		t.Errorf("TestSyncFilter: unmarshaled nil data")
+	}
+}
+
+func TestSyncFilterTestAndInsert(t *testing.T) {
+	s := NewSyncFilter(NewWithHash(1<<16, 5, BitsAndBlooms))
+	const items = 200
+	var (
+		wg    sync.WaitGroup
+		mu    sync.Mutex
+		added = make(map[string]int)
+	)
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range items {
+				item := fmt.Sprint(i)
+				if !s.TestAndInsert([]byte(item)) {
+					mu.Lock()
+					added[item]++
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	// Barring false positives, which are improbable at this size, each item is reported absent exactly once.
+	for i := range items {
+		if n := added[fmt.Sprint(i)]; n != 1 {
+			t.Errorf("TestSyncFilterTestAndInsert(%v): reported absent %v times", i, n)
+		}
+		if !s.TestAndInsert([]byte(fmt.Sprint(i))) {
+			t.Errorf("TestSyncFilterTestAndInsert(%v): got false, want true", i)
+		}
+	}
+}
+
+func TestSyncFilterMaybeContainsDuringReplace(t *testing.T) {
+	// Each of filters a and b has one of the two bits of item x set,
+	// so that a lookup that probed both would find x.
+	x := []byte("x")
+	a, b := NewWithHash(1<<12, 2, BitsAndBlooms), NewWithHash(1<<12, 2, BitsAndBlooms)
+	d := a.hash.digest(x)
+	a.setBit(a.location(&d, 0))
+	b.setBit(b.location(&d, 1))
+	da, _ := a.MarshalBinary()
+	db, _ := b.MarshalBinary()
+	s := NewSyncFilter(a)
+
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; ; i++ {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			if i%2 == 0 {
+				s.UnmarshalBinary(db)
+			} else {
+				s.UnmarshalBinary(da)
+			}
+		}
+	}()
+	for range 10000 {
+		if s.MaybeContains(x) {
+			t.Errorf("TestSyncFilterMaybeContainsDuringReplace: got true, want false")
+			break
+		}
+	}
+	close(done)
+	wg.Wait()
+}
+
+func TestSyncFilterReset(t *testing.T) {
+	s := NewSyncFilter(NewWithHash(1<<12, 4, BitsAndBlooms))
+	snap := s.Snapshot()
+	for i := range 100 {
+		s.Insert([]byte(fmt.Sprint(i)))
+	}
+	s.Reset()
+	if got, want := s.Snapshot(), NewWithHash(1<<12, 4, BitsAndBlooms); !equal(got, want) {
+		t.Errorf("TestSyncFilterReset: got %v, want %v", got, want)
+	}
+	s.Insert([]byte("x"))
+	if !s.MaybeContains([]byte("x")) {
+		t.Errorf("TestSyncFilterReset: item inserted after Reset not found")
+	}
+	if snap.MaybeContains([]byte("x")) {
+		t.Errorf("TestSyncFilterReset: snapshot modified")
+	}
+}
+
+func TestSyncFilterApplyDelta(t *testing.T) {
+	f := NewWithHash(1<<12, 4, BitsAndBlooms)
+	since := f.Snapshot()
+	f.Insert([]byte("x"))
+	data, err := f.MarshalDelta(since)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := NewSyncFilter(NewWithHash(1<<12, 4, BitsAndBlooms))
+	if err := s.ApplyDelta(data); err != nil {
+		t.Errorf("TestSyncFilterApplyDelta: %v", err)
+	}
+	if !s.MaybeContains([]byte("x")) {
+		t.Errorf("TestSyncFilterApplyDelta: item not found")
+	}
+	if err := s.ApplyDelta(data[:len(data)-1]); err == nil {
+		t.Errorf("TestSyncFilterApplyDelta: truncated delta: got nil error")
+	}
+}
+
+func TestSyncFilterCounts(t *testing.T) {
+	s := NewSyncFilter(New(1024, 4), WithCounts())
+	var wg sync.WaitGroup
+	for g := range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range 50 {
+				item := []byte(fmt.Sprint(g, i))
+				s.Insert(item)
+				s.TestAndInsert(item)
+				s.MaybeContains(item)
+			}
+		}()
+	}
+	wg.Wait()
+	s.Reset()
+	if inserts, queries, ok := s.Counts(); inserts != 400 || queries != 200 || !ok {
+		t.Errorf("TestSyncFilterCounts: got %v, %v, %v, want 400, 200, true", inserts, queries, ok)
+	}
+	if st := s.Stats(); st.Size != 1024*8 || st.HashValues != 4 || st.BitCount != 0 {
+		t.Errorf("TestSyncFilterCounts: Stats got %+v after Reset", st)
+	}
+
+	s = NewSyncFilter(New(1024, 4))
+	s.Insert([]byte("a"))
+	s.MaybeContains([]byte("a"))
+	if inserts, queries, ok := s.Counts(); inserts != 0 || queries != 0 || ok {
+		t.Errorf("TestSyncFilterCounts(not counting): got %v, %v, %v, want 0, 0, false", inserts, queries, ok)
+	}
+	if st := s.Stats(); st.BitCount == 0 {
+		t.Errorf("TestSyncFilterCounts: Stats got %+v, want bits set", st)
+	}
+}
+
+func TestSyncFilterHooks(t *testing.T) {
+	type call struct {
+		item  string
+		novel int
+	}
+	var (
+		mu      sync.Mutex
+		inserts []call
+		queries = make(map[string]bool)
+	)
+	s := NewSyncFilter(NewWithHash(1024, 3, BitsAndBlooms),
+		WithInsertHook(func(item []byte, novel int) {
+			mu.Lock()
+			defer mu.Unlock()
+			inserts = append(inserts, call{string(item), novel})
+		}),
+		WithQueryHook(func(item []byte, result bool) {
+			mu.Lock()
+			defer mu.Unlock()
+			queries[string(item)] = result
+		}),
+	)
+	s.Insert([]byte("a"))
+	s.Insert([]byte("a"))
+	if s.TestAndInsert([]byte("b")) {
+		t.Errorf("TestSyncFilterHooks: TestAndInsert(b) got true, want false")
+	}
+	if !s.TestAndInsert([]byte("b")) {
+		t.Errorf("TestSyncFilterHooks: repeated TestAndInsert(b) got false, want true")
+	}
+	s.MaybeContains([]byte("a"))
+	s.MaybeContains([]byte("c"))
+
+	// An item's novel bits are those of its distinct positions that no earlier item set.
+	novel := func(item string, earlier ...string) int {
+		f := NewWithHash(1024, 3, BitsAndBlooms)
+		for _, e := range earlier {
+			f.InsertString(e)
+		}
+		d := f.hash.digest([]byte(item))
+		return f.insertNovel(&d)
+	}
+	want := []call{{"a", novel("a")}, {"a", 0}, {"b", novel("b", "a")}, {"b", 0}}
+	if !reflect.DeepEqual(inserts, want) || want[0].novel == 0 {
+		t.Errorf("TestSyncFilterHooks: got insertions %v, want %v", inserts, want)
+	}
+	if want := map[string]bool{"a": true, "c": s.Snapshot().MaybeContains([]byte("c"))}; !reflect.DeepEqual(queries, want) {
+		t.Errorf("TestSyncFilterHooks: got queries %v, want %v", queries, want)
+	}
+}
+
+func TestSyncFilterLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	s := NewSyncFilter(New(8, 3), WithLogger(logger))
+	// records returns the messages logged since the last call, with their thresholds or reasons.
+	records := func() []string {
+		var msgs []string
+		dec := json.NewDecoder(&buf)
+		for dec.More() {
+			var r map[string]any
+			if err := dec.Decode(&r); err != nil {
+				t.Fatalf("TestSyncFilterLogger: %v", err)
+			}
+			msg := r["msg"].(string)
+			for _, key := range []string{"threshold", "reason", "kind"} {
+				if v, ok := r[key]; ok {
+					msg += fmt.Sprintf(" %v", v)
+				}
+			}
+			msgs = append(msgs, msg)
+		}
+		buf.Reset()
+		return msgs
+	}
+
+	// Fill the filter until every threshold has been crossed.
+	for i := 0; s.Stats().FillRatio < 0.9; i++ {
+		if i%2 == 0 {
+			s.Insert([]byte(fmt.Sprint(i)))
+		} else {
+			s.TestAndInsert([]byte(fmt.Sprint(i)))
+		}
+	}
+	want := []string{
+		"bloom filter saturation threshold crossed 0.5",
+		"bloom filter saturation threshold crossed 0.75",
+		"bloom filter saturation threshold crossed 0.9",
+	}
+	if got := records(); !reflect.DeepEqual(got, want) {
+		t.Errorf("TestSyncFilterLogger(insert): got %q, want %q", got, want)
+	}
+
+	s.Reset()
+	if got, want := records(), []string{"bloom filter replaced reset"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("TestSyncFilterLogger(Reset): got %q, want %q", got, want)
+	}
+
+	// A merge that sets most bits crosses the first two thresholds at once.
+	if err := s.Union(filter(3, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0, 0)); err != nil {
+		t.Fatal(err)
+	}
+	s.Union(New(16, 3))
+	s.ApplyDelta(nil)
+	want = []string{
+		"bloom filter merged union",
+		"bloom filter saturation threshold crossed 0.5",
+		"bloom filter saturation threshold crossed 0.75",
+		"bloom filter merge failed union",
+		"bloom filter merge failed delta",
+	}
+	if got := records(); !reflect.DeepEqual(got, want) {
+		t.Errorf("TestSyncFilterLogger(merge): got %q, want %q", got, want)
+	}
+
+	s.UnmarshalBinary(nil)
+	s.UnmarshalBinary(mustMarshal(New(8, 3)))
+	want = []string{"bloom filter unmarshal failed", "bloom filter replaced unmarshal"}
+	if got := records(); !reflect.DeepEqual(got, want) {
+		t.Errorf("TestSyncFilterLogger(UnmarshalBinary): got %q, want %q", got, want)
+	}
+}
+
+func TestSyncFilterWriteTo(t *testing.T) {
+	s := NewSyncFilter(NewWithHash(1<<12, 4, BitsAndBlooms))
+	for i := range 100 {
+		s.Insert([]byte(fmt.Sprint(i)))
+	}
+	var b bytes.Buffer
+	if _, err := s.WriteTo(&b); err != nil {
+		t.Fatalf("TestSyncFilterWriteTo: %v", err)
+	}
+	if want, _ := s.MarshalBinary(); !bytes.Equal(b.Bytes(), want) {
+		t.Errorf("TestSyncFilterWriteTo: got %v, want %v", b.Bytes(), want)
+	}
+}
@@ -0,0 +1,150 @@
+package bloom
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// The text form of a filter consists of a header line of the form
+//
+//	bloom m=<size in bits> k=<number of hash values> hash=<name of hash algorithm>
+//
+// followed by rows of the filter's bytes in hexadecimal, in the order of the binary form produced by MarshalBinary.
+// Each row begins with the offset of its first byte in hexadecimal and a colon,
+// followed by up to textRowSize bytes in groups of 8 separated by spaces.
+// When parsing, blank lines and lines beginning with '#' are ignored.
+const (
+	textMagic   = "bloom"
+	textRowSize = 32
+)
+
+// MarshalText marshals f into a human-readable text form, suitable for small filters
+// that are checked into version control, reviewed as diffs, or edited by hand.
+// It satisfies the encoding.TextMarshaler interface.
+func (f *Filter) MarshalText() ([]byte, error) {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "%s m=%d k=%d hash=%v\n", textMagic, f.m, f.k, f.hash)
+	bits := f.bytes()
+	for off := 0; off < len(bits); off += textRowSize {
+		fmt.Fprintf(&buf, "%04x:", off)
+		row := bits[off:min(off+textRowSize, len(bits))]
+		for i := 0; i < len(row); i += 8 {
+			buf.WriteByte(' ')
+			buf.WriteString(hex.EncodeToString(row[i:min(i+8, len(row))]))
+		}
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes(), nil
+}
+
+// UnmarshalText unmarshals the text form produced by MarshalText and stores it in f.
+// If the data is malformed, its rows are out of order, or its header specifies parameters that its hash algorithm
+// does not support, UnmarshalText returns an error without modifying the contents of f.
+// UnmarshalText satisfies the encoding.TextUnmarshaler interface.
+func (f *Filter) UnmarshalText(text []byte) error {
+	var (
+		h          header
+		b          []byte
+		haveHeader bool
+	)
+	sc := bufio.NewScanner(bytes.NewReader(text))
+	for line := 1; sc.Scan(); line++ {
+		s := strings.TrimSpace(sc.Text())
+		if s == "" || strings.HasPrefix(s, "#") {
+			continue
+		}
+		var err error
+		if !haveHeader {
+			h, err = parseTextHeader(s)
+			haveHeader = true
+		} else {
+			b, err = parseTextRow(b, s, (h.m+7)/8)
+		}
+		if err != nil {
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return err
+	}
+	if !haveHeader {
+		return newError(ErrTruncated, "missing header")
+	}
+	if uint64(len(b)) != (h.m+7)/8 {
+		return newError(ErrCorrupt, "filter size does not match data length")
+	}
+	if err := checkPadding(b, h.m); err != nil {
+		return err
+	}
+	f.load(h, b)
+	return nil
+}
+
+// parseTextHeader parses the header line of the text form.
+func parseTextHeader(s string) (header, error) {
+	fields := strings.Fields(s)
+	if len(fields) != 4 || fields[0] != textMagic {
+		return header{}, newError(ErrCorrupt, "malformed header")
+	}
+	var (
+		h    header
+		k    uint64
+		seen = make(map[string]bool)
+	)
+	for _, field := range fields[1:] {
+		key, val, ok := strings.Cut(field, "=")
+		if !ok || seen[key] {
+			return header{}, newError(ErrCorrupt, "malformed header")
+		}
+		seen[key] = true
+		var err error
+		switch key {
+		case "m":
+			h.m, err = strconv.ParseUint(val, 10, 64)
+		case "k":
+			k, err = strconv.ParseUint(val, 10, 64)
+		case "hash":
+			i := slices.Index(hashNames[:], val)
+			if i < 0 {
+				return header{}, newError(ErrUnsupported, "unsupported hash algorithm")
+			}
+			h.hashAlgorithm = byte(i)
+		default:
+			return header{}, newError(ErrCorrupt, fmt.Sprintf("unknown field %q", key))
+		}
+		if err != nil {
+			return header{}, newError(ErrCorrupt, "malformed "+key)
+		}
+	}
+	if err := checkParams(Hash(h.hashAlgorithm), h.m, k); err != nil {
+		return header{}, err
+	}
+	h.k = byte(k)
+	return h, nil
+}
+
+// parseTextRow parses a row of the text form and appends its bytes to b,
+// which may hold no more than n bytes.
+func parseTextRow(b []byte, s string, n uint64) ([]byte, error) {
+	off, row, ok := strings.Cut(s, ":")
+	if !ok {
+		return nil, newError(ErrCorrupt, "missing offset")
+	}
+	if o, err := strconv.ParseUint(off, 16, 64); err != nil || o != uint64(len(b)) {
+		return nil, newError(ErrCorrupt, "unexpected offset")
+	}
+	row = strings.Join(strings.Fields(row), "")
+	if uint64(len(row)/2) > n-uint64(len(b)) {
+		return nil, newError(ErrCorrupt, "data beyond filter size")
+	}
+	p, err := hex.DecodeString(row)
+	if err != nil {
+		return nil, newError(ErrCorrupt, "malformed hexadecimal")
+	}
+	return append(b, p...), nil
+}
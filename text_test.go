@@ -0,0 +1,88 @@
+package bloom
+
+import (
+	"reflect"
+	"testing"
+)
+
+var textTests = []struct {
+	f    *Filter
+	text string
+}{
+	{New(1, 1), "bloom m=8 k=1 hash=sha256\n0000: 00\n"},
+	{filter(4, 15, 23), "bloom m=16 k=4 hash=sha256\n0000: 0f17\n"},
+	{
+		filter(2, bitsAt(64, 3, 300)...),
+		"bloom m=512 k=2 hash=sha256\n" +
+			"0000: 0800000000000000 0000000000000000 0000000000000000 0000000000000000\n" +
+			"0020: 0000000000100000 0000000000000000 0000000000000000 0000000000000000\n",
+	},
+	{NewWithHash(12, 3, BitsAndBlooms), "bloom m=12 k=3 hash=bits-and-blooms\n0000: 0000\n"},
+}
+
+func TestMarshalText(t *testing.T) {
+	for _, test := range textTests {
+		text, err := test.f.MarshalText()
+		if err != nil {
+			t.Errorf("TestMarshalText: %v", err)
+		}
+		if string(text) != test.text {
+			t.Errorf("TestMarshalText: got %q, want %q", text, test.text)
+		}
+	}
+}
+
+func TestUnmarshalText(t *testing.T) {
+	for _, test := range textTests {
+		f := new(Filter)
+		if err := f.UnmarshalText([]byte(test.text)); err != nil {
+			t.Errorf("TestUnmarshalText(%q): %v", test.text, err)
+		}
+		if !reflect.DeepEqual(f, test.f) {
+			t.Errorf("TestUnmarshalText(%q): got %v, want %v", test.text, f, test.f)
+		}
+	}
+
+	// Comments, blank lines, surrounding space, and regrouped hexadecimal are accepted.
+	f := new(Filter)
+	text := "# allowed tenants\n\n  bloom k=4 hash=sha256 m=16  \n# edited by hand\n0: 0F 17\n"
+	if err := f.UnmarshalText([]byte(text)); err != nil {
+		t.Errorf("TestUnmarshalText(%q): %v", text, err)
+	}
+	if want := filter(4, 15, 23); !reflect.DeepEqual(f, want) {
+		t.Errorf("TestUnmarshalText(%q): got %v, want %v", text, f, want)
+	}
+
+	for _, text := range []string{
+		"",
+		"# only a comment\n",
+		"bloom m=16 k=4\n0000: 0f17\n",
+		"bloom m=16 k=4 hash=sha256 seed=0\n0000: 0f17\n",
+		"filter m=16 k=4 hash=sha256\n0000: 0f17\n",
+		"bloom m=16 m=16 hash=sha256\n0000: 0f17\n",
+		"bloom m=16 k=4 n=1\n0000: 0f17\n",
+		"bloom m=x k=4 hash=sha256\n0000: 0f17\n",
+		"bloom m=16 k=-1 hash=sha256\n0000: 0f17\n",
+		"bloom m=16 k=4 hash=md5\n0000: 0f17\n",
+		"bloom m=12 k=4 hash=sha256\n0000: 0f17\n",  // size not supported by hash algorithm
+		"bloom m=16 k=17 hash=sha256\n0000: 0f17\n", // too many hash values
+		"bloom m=16 k=4 hash=sha256\n",
+		"bloom m=16 k=4 hash=sha256\n0f17\n",
+		"bloom m=16 k=4 hash=sha256\n0001: 0f17\n",
+		"bloom m=16 k=4 hash=sha256\n0000: 0f\n",
+		"bloom m=16 k=4 hash=sha256\n0000: 0f\n0000: 17\n",
+		"bloom m=16 k=4 hash=sha256\n0000: 0f1\n",
+		"bloom m=16 k=4 hash=sha256\n0000: 0g17\n",
+		"bloom m=16 k=4 hash=sha256\n0000: 0f1700\n",
+		"bloom m=16 k=4 hash=sha256\n0000: 0f17\n0002: 00\n",
+		"bloom m=12 k=3 hash=bits-and-blooms\n0000: 00f0\n", // bits set beyond filter size
+	} {
+		f := New(1, 1)
+		if err := f.UnmarshalText([]byte(text)); err == nil {
+			t.Errorf("TestUnmarshalText(%q): got nil error", text)
+		}
+		if !reflect.DeepEqual(f, New(1, 1)) {
+			t.Errorf("TestUnmarshalText(%q): modified filter to %v", text, f)
+		}
+	}
+}
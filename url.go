@@ -0,0 +1,109 @@
+package bloom
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// A URLFilter is a Filter fetched from a URL, such as a blocklist built centrally and served to many consumers,
+// that can be refreshed when the served filter changes.
+// Refreshes are conditional requests: the server's ETag and Last-Modified headers are sent back to it,
+// so that a filter that has not changed is not transferred again.
+// A URLFilter is safe for concurrent use by multiple goroutines; lookups are not delayed by refreshes.
+type URLFilter struct {
+	url    string
+	client *http.Client
+	f      atomic.Pointer[Filter]
+
+	mu           sync.Mutex // serializes refreshes
+	etag         string
+	lastModified string
+}
+
+// NewURLFilter fetches the Filter at url with client, or http.DefaultClient if client is nil,
+// and returns a URLFilter holding it. The response body must hold a Filter in the binary form produced by MarshalBinary.
+func NewURLFilter(ctx context.Context, client *http.Client, url string) (*URLFilter, error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	u := &URLFilter{url: url, client: client}
+	if _, err := u.Refresh(ctx); err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+// Refresh fetches u's Filter again if it has changed, and reports whether it did.
+// If it returns an error, u's Filter is unchanged.
+func (u *URLFilter) Refresh(ctx context.Context) (bool, error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.url, nil)
+	if err != nil {
+		return false, err
+	}
+	if u.f.Load() != nil {
+		if u.etag != "" {
+			req.Header.Set("If-None-Match", u.etag)
+		}
+		if u.lastModified != "" {
+			req.Header.Set("If-Modified-Since", u.lastModified)
+		}
+	}
+	resp, err := u.client.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	switch {
+	case resp.StatusCode == http.StatusNotModified && u.f.Load() != nil:
+		return false, nil
+	case resp.StatusCode != http.StatusOK:
+		return false, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	g := new(Filter)
+	if _, err := g.ReadFrom(resp.Body); err != nil {
+		return false, err
+	}
+	if n, err := io.Copy(io.Discard, resp.Body); err != nil {
+		return false, err
+	} else if n != 0 {
+		return false, newError(ErrCorrupt, "filter size does not match data length")
+	}
+	u.f.Store(g)
+	u.etag = resp.Header.Get("ETag")
+	u.lastModified = resp.Header.Get("Last-Modified")
+	return true, nil
+}
+
+// Poll refreshes u every interval until ctx is done, passing errors from Refresh to onError if it is not nil.
+// It is typically called in its own goroutine.
+func (u *URLFilter) Poll(ctx context.Context, interval time.Duration, onError func(error)) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+		if _, err := u.Refresh(ctx); err != nil && onError != nil && ctx.Err() == nil {
+			onError(err)
+		}
+	}
+}
+
+// MaybeContains reports whether item is probably in the set of u's current Filter, in the manner of Filter.MaybeContains.
+func (u *URLFilter) MaybeContains(item []byte) bool {
+	return u.f.Load().MaybeContains(item)
+}
+
+// Filter returns a snapshot of u's current Filter, which later refreshes do not affect.
+func (u *URLFilter) Filter() *Filter {
+	return u.f.Load().Snapshot()
+}
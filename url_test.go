@@ -0,0 +1,106 @@
+package bloom
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestURLFilter(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		data     = mustMarshal(NewWithHash(1<<12, 4, BitsAndBlooms))
+		etag     = `"1"`
+		fetches  int
+		notFound bool
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if notFound {
+			http.NotFound(w, r)
+			return
+		}
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		fetches++
+		w.Header().Set("ETag", etag)
+		w.Write(data)
+	}))
+	defer srv.Close()
+	ctx := context.Background()
+
+	u, err := NewURLFilter(ctx, nil, srv.URL)
+	if err != nil {
+		t.Fatalf("TestURLFilter: %v", err)
+	}
+	if u.MaybeContains([]byte("x")) {
+		t.Errorf("TestURLFilter: empty filter contains item")
+	}
+	if ok, err := u.Refresh(ctx); ok || err != nil {
+		t.Errorf("TestURLFilter: unchanged: got %v, %v, want false, nil", ok, err)
+	}
+
+	f := NewWithHash(1<<12, 4, BitsAndBlooms)
+	f.Insert([]byte("x"))
+	mu.Lock()
+	data, etag = mustMarshal(f), `"2"`
+	mu.Unlock()
+	if ok, err := u.Refresh(ctx); !ok || err != nil {
+		t.Errorf("TestURLFilter: changed: got %v, %v, want true, nil", ok, err)
+	}
+	if !u.MaybeContains([]byte("x")) {
+		t.Errorf("TestURLFilter: refreshed filter does not contain item")
+	}
+	if g := u.Filter(); !equal(g, f) {
+		t.Errorf("TestURLFilter: got %v, want %v", g, f)
+	}
+
+	// A failed refresh keeps the current filter.
+	mu.Lock()
+	notFound = true
+	mu.Unlock()
+	if _, err := u.Refresh(ctx); err == nil {
+		t.Errorf("TestURLFilter: not found: got nil error")
+	}
+	mu.Lock()
+	notFound, data, etag = false, data[:len(data)-1], `"3"`
+	mu.Unlock()
+	if _, err := u.Refresh(ctx); err == nil {
+		t.Errorf("TestURLFilter: truncated: got nil error")
+	}
+	if !u.MaybeContains([]byte("x")) {
+		t.Errorf("TestURLFilter: failed refresh replaced filter")
+	}
+
+	// Poll refreshes until its context is done, and reports errors.
+	ctx, cancel := context.WithCancel(ctx)
+	errs := make(chan error, 1)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		u.Poll(ctx, time.Millisecond, func(err error) {
+			select {
+			case errs <- err:
+			default:
+			}
+		})
+	}()
+	<-errs
+	cancel()
+	<-done
+	mu.Lock()
+	defer mu.Unlock()
+	if fetches < 4 {
+		t.Errorf("TestURLFilter: fetched %v times, want at least 4", fetches)
+	}
+
+	if _, err := NewURLFilter(context.Background(), nil, srv.URL+"/\x7f"); err == nil {
+		t.Errorf("TestURLFilter: invalid URL: got nil error")
+	}
+}
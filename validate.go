@@ -0,0 +1,109 @@
+package bloom
+
+import (
+	"bytes"
+	"math"
+	"sync/atomic"
+)
+
+// Validate checks f's internal invariants and returns an error describing the first that does not hold:
+// that its size and number of hash values are supported by its hash algorithm, that its bits are held
+// in pages of the expected number and lengths, and that no bit beyond its size is set.
+// A Filter created by this package always satisfies them, so an error indicates that f's memory
+// was corrupted, for instance through a memory-mapped file modified by another process.
+// The zero Filter is valid.
+// Validate may be called concurrently with the same methods as MaybeContains.
+func (f *Filter) Validate() error {
+	if f.m == 0 && f.k == 0 && len(f.pages) == 0 {
+		return nil
+	}
+	if err := checkParams(f.hash, uint64(f.m), uint64(f.k)); err != nil {
+		return err
+	}
+	n := (f.m + 63) / 64
+	if len(f.pages) != (n+pageWords-1)/pageWords {
+		return newError(ErrCorrupt, "number of pages does not match filter size")
+	}
+	for i := range f.pages {
+		p := f.page(i)
+		if p == nil || len(p.w) != min(pageWords, n-i*pageWords) {
+			return newError(ErrCorrupt, "page length does not match filter size")
+		}
+	}
+	if f.m%64 != 0 {
+		p := f.page(len(f.pages) - 1)
+		if atomic.LoadUint64(&p.w[len(p.w)-1])>>(f.m%64) != 0 {
+			return newError(ErrCorrupt, "bits set beyond filter size")
+		}
+	}
+	return nil
+}
+
+// Validate checks c's internal invariants, returning an error if its number of counters and of hash values
+// are not supported by its hash algorithm or if, while no counter has reached its maximum value,
+// the counters do not sum to a multiple of the number of hash values, as k increments for each insertion
+// and k decrements for each removal leave them. It may be called concurrently with MaybeContains,
+// but an insertion or removal in progress may cause it to report an error spuriously.
+func (c *CountingFilter) Validate() error {
+	if err := checkParams(c.hash, uint64(len(c.c)), uint64(c.k)); err != nil {
+		return err
+	}
+	var sum uint64
+	for i := range c.c {
+		n := atomic.LoadUint32(&c.c[i])
+		if n == math.MaxUint32 {
+			return nil // a saturated counter absorbs increments that its siblings received
+		}
+		sum += uint64(n)
+	}
+	if sum%uint64(c.k) != 0 {
+		return newError(ErrCorrupt, "counters do not sum to a multiple of the number of hash values")
+	}
+	return nil
+}
+
+// Validate checks m's Filter in the manner of Filter.Validate, and checks that the header of the mapped file,
+// if it is not in the legacy form, still matches the Filter's parameters.
+func (m *MappedFilter) Validate() error {
+	if err := m.Filter.Validate(); err != nil {
+		return err
+	}
+	if m.data == nil || !bytes.HasPrefix(m.data, []byte(magic)) {
+		return nil
+	}
+	h, _, err := readHeader(m.data)
+	if err != nil {
+		return err
+	}
+	if h.hashAlgorithm != byte(m.hash) || int(h.k) != m.k || h.m != uint64(m.m) {
+		return newError(ErrCorrupt, "mapped header does not match filter")
+	}
+	return nil
+}
+
+// Validate checks s's Filter in the manner of Filter.Validate, and checks that s's file
+// holds a header matching the Filter's parameters and is of the length the Filter requires.
+func (s *FileFilter) Validate() error {
+	if err := s.f.Validate(); err != nil {
+		return err
+	}
+	var hb [headerSize]byte
+	if _, err := s.file.ReadAt(hb[:], 0); err != nil {
+		return eofError(err)
+	}
+	h, _, err := readHeader(hb[:])
+	if err != nil {
+		return err
+	}
+	if h.hashAlgorithm != byte(s.f.hash) || int(h.k) != s.f.k || h.m != uint64(s.f.m) || h.flags != 0 {
+		return newError(ErrCorrupt, "file header does not match filter")
+	}
+	fi, err := s.file.Stat()
+	if err != nil {
+		return err
+	}
+	if fi.Size() != int64(headerSize+(s.f.m+7)/8) {
+		return newError(ErrCorrupt, "filter size does not match file length")
+	}
+	return nil
+}
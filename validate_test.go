@@ -0,0 +1,86 @@
+package bloom
+
+import (
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	for _, test := range marshalTests {
+		if err := test.f.Validate(); err != nil {
+			t.Errorf("TestValidate(%v): %v", test.f, err)
+		}
+	}
+	for _, f := range []*Filter{new(Filter), New(8, 3), NewWithHash(100, 3, BitsAndBlooms), NewWithHash(64*pageWords*2+1, 7, BitsAndBlooms)} {
+		if err := f.Validate(); err != nil {
+			t.Errorf("TestValidate(%v): %v", f, err)
+		}
+	}
+
+	for _, test := range []struct {
+		name    string
+		corrupt func(f *Filter)
+		want    error
+	}{
+		{"size", func(f *Filter) { f.m = 0 }, ErrBadSize},
+		{"size mismatch", func(f *Filter) { f.m = 64 }, ErrCorrupt},
+		{"hash values", func(f *Filter) { f.k = 0 }, ErrBadHashCount},
+		{"hash", func(f *Filter) { f.hash = 255 }, ErrUnsupported},
+		{"pages", func(f *Filter) { f.pages = append(f.pages, f.pages[0]) }, ErrCorrupt},
+		{"page length", func(f *Filter) { f.pages[1].w = f.pages[1].w[:1] }, ErrCorrupt},
+		{"padding", func(f *Filter) { f.pages[2].w[0] |= 1 << 63 }, ErrCorrupt},
+	} {
+		f := NewWithHash(64*pageWords*2+1, 7, BitsAndBlooms)
+		test.corrupt(f)
+		if err := f.Validate(); !errors.Is(err, test.want) {
+			t.Errorf("TestValidate(%v): got %v, want %v", test.name, err, test.want)
+		}
+	}
+}
+
+func TestCountingFilterValidate(t *testing.T) {
+	c := NewCountingFilter(64, 3, BitsAndBlooms)
+	for _, s := range []string{"a", "b", "a", "c"} {
+		c.Insert([]byte(s))
+	}
+	c.Remove([]byte("b"))
+	if err := c.Validate(); err != nil {
+		t.Errorf("TestCountingFilterValidate: %v", err)
+	}
+	c.c[0]++
+	if err := c.Validate(); !errors.Is(err, ErrCorrupt) {
+		t.Errorf("TestCountingFilterValidate(corrupt): got %v, want %v", err, ErrCorrupt)
+	}
+	c.c[1] = math.MaxUint32
+	if err := c.Validate(); err != nil {
+		t.Errorf("TestCountingFilterValidate(saturated): %v", err)
+	}
+}
+
+func TestFileFilterValidate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "filter")
+	s, err := CreateFile(path, New(64, 3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	s.Insert([]byte("a"))
+	if err := s.Validate(); err != nil {
+		t.Errorf("TestFileFilterValidate: %v", err)
+	}
+	if err := os.Truncate(path, headerSize+32); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Validate(); !errors.Is(err, ErrCorrupt) {
+		t.Errorf("TestFileFilterValidate(truncated): got %v, want %v", err, ErrCorrupt)
+	}
+	if err := os.WriteFile(path, mustMarshal(New(32, 3)), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Validate(); !errors.Is(err, ErrCorrupt) {
+		t.Errorf("TestFileFilterValidate(replaced): got %v, want %v", err, ErrCorrupt)
+	}
+}
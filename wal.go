@@ -0,0 +1,96 @@
+package bloom
+
+import (
+	"encoding/binary"
+	"hash/crc32"
+	"io"
+	"os"
+)
+
+// Each record of a FileFilter's write-ahead log consists of the digest of an inserted item,
+// as four big-endian 64-bit integers, followed by the CRC-32C checksum of the digest.
+// Records are written at offsets reserved in advance, so that concurrent insertions can append them in parallel.
+// Because setting bits is idempotent, replaying a record whose bits are already set is harmless;
+// a record that is incomplete or fails its checksum, as may be left by a crash, is skipped.
+const walRecordSize = 4*8 + crc32.Size
+
+// openWAL opens or, if create is true, creates the write-ahead log of the filter at path if the options enable it.
+// It replays the records of an existing log into s, stores their bits in s's file, and empties the log.
+func (s *FileFilter) openWAL(path string, o *fileOptions, create bool) error {
+	if !o.wal {
+		return nil
+	}
+	flag := os.O_RDWR | os.O_CREATE
+	if create {
+		flag |= os.O_TRUNC
+	}
+	wal, err := os.OpenFile(path+".wal", flag, 0o666)
+	if err != nil {
+		return err
+	}
+	data, err := io.ReadAll(wal)
+	if err != nil {
+		wal.Close()
+		return err
+	}
+	s.wal = wal
+	s.walOff.Store(int64(len(data)))
+	for ; len(data) >= walRecordSize; data = data[walRecordSize:] {
+		if d, ok := readWALRecord(data[:walRecordSize]); ok {
+			s.f.insertDigest(&d)
+		}
+	}
+	if err := s.Sync(); err != nil {
+		wal.Close()
+		return err
+	}
+	return nil
+}
+
+// appendWAL appends to s's write-ahead log a record of an item with digest d.
+func (s *FileFilter) appendWAL(d *digest) {
+	var b [walRecordSize]byte
+	for i, v := range d {
+		binary.BigEndian.PutUint64(b[8*i:], v)
+	}
+	binary.BigEndian.PutUint32(b[len(d)*8:], crc32.Checksum(b[:len(d)*8], castagnoli))
+	off := s.walOff.Add(walRecordSize) - walRecordSize
+	if _, err := s.wal.WriteAt(b[:], off); err != nil {
+		s.errMu.Lock()
+		if s.walErr == nil {
+			s.walErr = err
+		}
+		s.errMu.Unlock()
+	}
+}
+
+// readWALRecord returns the digest in the write-ahead log record b and reports whether the record is valid.
+func readWALRecord(b []byte) (digest, bool) {
+	var d digest
+	if binary.BigEndian.Uint32(b[len(d)*8:]) != crc32.Checksum(b[:len(d)*8], castagnoli) {
+		return d, false
+	}
+	for i := range d {
+		d[i] = binary.BigEndian.Uint64(b[8*i:])
+	}
+	return d, true
+}
+
+// trimWAL removes from s's write-ahead log the records before offset off, whose bits have been stored in s's file.
+// Records appended since are moved to the start of the log.
+func (s *FileFilter) trimWAL(off int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	tail := make([]byte, s.walOff.Load()-off)
+	if _, err := s.wal.ReadAt(tail, off); err != nil {
+		return eofError(err)
+	}
+	if _, err := s.wal.WriteAt(tail, 0); err != nil {
+		return err
+	}
+	if err := s.wal.Truncate(int64(len(tail))); err != nil {
+		return err
+	}
+	s.walOff.Store(int64(len(tail)))
+	return nil
+}
@@ -0,0 +1,82 @@
+package bloom
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWAL(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "filter")
+	const m = 2*pageWords*64 + 100
+	s, err := CreateFile(path, NewWithHash(m, 4, BitsAndBlooms), WithWAL())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := NewWithHash(m, 4, BitsAndBlooms)
+	for i := range 100 {
+		item := []byte(fmt.Sprint(i))
+		s.Insert(item)
+		want.Insert(item)
+	}
+	if fi, err := os.Stat(path + ".wal"); err != nil || fi.Size() != 100*walRecordSize {
+		t.Errorf("TestWAL: got log %v, %v, want %v records", fi, err, 100)
+	}
+	if err := s.Sync(); err != nil {
+		t.Errorf("TestWAL: %v", err)
+	}
+	if fi, err := os.Stat(path + ".wal"); err != nil || fi.Size() != 0 {
+		t.Errorf("TestWAL: got log %v, %v after Sync, want empty", fi, err)
+	}
+
+	// Simulate a crash by abandoning s after insertions that have not been synchronized.
+	for i := 100; i < 200; i++ {
+		item := []byte(fmt.Sprint(i))
+		s.Insert(item)
+		want.Insert(item)
+	}
+	if got := fileContents(t, path); equal(got, want) {
+		t.Errorf("TestWAL: file modified before Sync")
+	}
+	// A torn record at the end of the log is ignored.
+	if _, err := s.wal.WriteAt([]byte{1, 2, 3}, s.walOff.Load()); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := OpenFile(path, WithWAL())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !equal(r.f, want) {
+		t.Errorf("TestWAL: got %v after replay, want %v", r.f, want)
+	}
+	if got := fileContents(t, path); !equal(got, want) {
+		t.Errorf("TestWAL: got file %v after replay, want %v", got, want)
+	}
+	if err := r.Close(); err != nil {
+		t.Errorf("TestWAL: %v", err)
+	}
+	s.file.Close()
+	s.wal.Close()
+}
+
+func TestReadWALRecord(t *testing.T) {
+	s := &FileFilter{}
+	path := filepath.Join(t.TempDir(), "wal")
+	var err error
+	if s.wal, err = os.Create(path); err != nil {
+		t.Fatal(err)
+	}
+	defer s.wal.Close()
+	want := digest{1, 2, 3, 4}
+	s.appendWAL(&want)
+	b, _ := os.ReadFile(path)
+	if d, ok := readWALRecord(b); !ok || d != want {
+		t.Errorf("TestReadWALRecord: got %v, %v, want %v, true", d, ok, want)
+	}
+	b[0] ^= 1
+	if d, ok := readWALRecord(b); ok {
+		t.Errorf("TestReadWALRecord: got %v, true for corrupt record", d)
+	}
+}
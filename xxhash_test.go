@@ -0,0 +1,23 @@
+package bloom
+
+import "testing"
+
+func TestXXHash64(t *testing.T) {
+	for _, test := range []struct {
+		s string
+		h uint64
+	}{
+		{"", 0xef46db3751d8e999},
+		{"a", 0xd24ec4f1a98c6e5b},
+		{"abc", 0x44bc2cf5ad770999},
+		{"hello", 0x26c7827d889f6da3},
+		{"The quick brown fox jumps over the lazy dog", 0x0b242d361fda71bc},
+		{"0123456789abcdef0123456789abcde", 0x1fdfc63febacfde7},
+		{"0123456789abcdef0123456789abcdef", 0x642a94958e71e6c5},
+		{"0123456789abcdef0123456789abcdef0123456789abc", 0xfc9bc401c0e4cff3},
+	} {
+		if h := xxhash64([]byte(test.s)); h != test.h {
+			t.Errorf("TestXXHash64(%q): got %#x, want %#x", test.s, h, test.h)
+		}
+	}
+}